@@ -0,0 +1,205 @@
+package commands
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/jedib0t/go-pretty/v6/table"
+	"github.com/pkg/errors"
+	"gopkg.in/urfave/cli.v1"
+
+	"github.com/Southclaws/sampctl/src/pkg/build"
+	"github.com/Southclaws/sampctl/src/pkg/build/amx"
+)
+
+func amxInspectFlags() []cli.Flag {
+	return []cli.Flag{
+		cli.StringFlag{
+			Name:  "format",
+			Value: "text",
+			Usage: "output format, one of `text` or `json`",
+		},
+	}
+}
+
+func amxInspect(c *cli.Context) error {
+	applyVerboseFlag(c)
+
+	format := c.String("format")
+	if format != "text" && format != "json" {
+		return errors.Errorf("unsupported format %q, must be one of text or json", format)
+	}
+	if len(c.Args()) != 1 {
+		return cli.NewExitError("inspect requires exactly one AMX file argument", 1)
+	}
+
+	return runAMXInspect(os.Stdout, c.Args().First(), format)
+}
+
+// amxInspection is the JSON document written by `amx inspect`
+type amxInspection struct {
+	Path string `json:"path"`
+	amx.File
+	Sizes build.Result `json:"sizes"`
+}
+
+func runAMXInspect(w io.Writer, path, format string) error {
+	f, err := amx.Open(path)
+	if err != nil {
+		return errors.Wrapf(err, "failed to inspect %s", path)
+	}
+
+	if format == "json" {
+		encoder := json.NewEncoder(w)
+		encoder.SetIndent("", "\t")
+		return encoder.Encode(amxInspection{Path: path, File: f, Sizes: f.Result()})
+	}
+	return writeAMXInspection(w, path, f)
+}
+
+func writeAMXInspection(w io.Writer, path string, f amx.File) error {
+	h := f.Header
+	sizes := f.Result()
+
+	flags := strings.Join(h.Flags.Names(), ", ")
+	if flags == "" {
+		flags = "-"
+	}
+	entry := "no"
+	if f.HasMain() {
+		entry = fmt.Sprintf("yes (0x%X)", h.CIP)
+	}
+
+	if _, err := fmt.Fprintln(w, path); err != nil {
+		return err
+	}
+
+	t := table.NewWriter()
+	t.SetOutputMirror(w)
+	t.AppendRows([]table.Row{
+		{"Magic", fmt.Sprintf("0x%04X", h.Magic)},
+		{"File version", h.FileVersion},
+		{"AMX version", h.AMXVersion},
+		{"Cell size", fmt.Sprintf("%d bits", f.CellSize)},
+		{"Flags", fmt.Sprintf("0x%04X %s", uint16(h.Flags), flags)},
+		{"File size", h.Size},
+		{"Main", entry},
+	})
+	t.AppendSeparator()
+	t.AppendRows([]table.Row{
+		{"COD", fmt.Sprintf("0x%X", h.COD)},
+		{"DAT", fmt.Sprintf("0x%X", h.DAT)},
+		{"HEA", fmt.Sprintf("0x%X", h.HEA)},
+		{"STP", fmt.Sprintf("0x%X", h.STP)},
+	})
+	t.AppendSeparator()
+	t.AppendRows([]table.Row{
+		{"Header size", sizes.Header},
+		{"Code size", sizes.Code},
+		{"Data size", sizes.Data},
+		{"Stack/heap size", sizes.StackHeap},
+		{"Total requirements", sizes.Total},
+	})
+	t.Render()
+
+	for _, section := range []struct {
+		title   string
+		value   string
+		symbols []amx.Symbol
+	}{
+		{"Publics", "Address", f.Publics},
+		{"Natives", "", f.Natives},
+		{"Libraries", "", f.Libraries},
+		{"Public variables", "Address", f.PubVars},
+		{"Tags", "ID", f.Tags},
+	} {
+		if err := writeAMXSymbols(w, section.title, section.value, section.symbols); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func writeAMXSymbols(w io.Writer, title, value string, symbols []amx.Symbol) error {
+	if _, err := fmt.Fprintf(w, "\n%s (%d)\n", title, len(symbols)); err != nil {
+		return err
+	}
+	if len(symbols) == 0 {
+		return nil
+	}
+
+	t := table.NewWriter()
+	t.SetOutputMirror(w)
+	if value == "" {
+		t.AppendHeader(table.Row{"Index", "Name"})
+	} else {
+		t.AppendHeader(table.Row{"Index", "Name", value})
+	}
+	for i, symbol := range symbols {
+		if value == "" {
+			t.AppendRow(table.Row{i, symbol.Name})
+		} else {
+			t.AppendRow(table.Row{i, symbol.Name, fmt.Sprintf("0x%X", symbol.Value)})
+		}
+	}
+	t.Render()
+	return nil
+}
+
+func amxDiffFlags() []cli.Flag {
+	return amxInspectFlags()
+}
+
+func amxDiff(c *cli.Context) error {
+	applyVerboseFlag(c)
+
+	format := c.String("format")
+	if format != "text" && format != "json" {
+		return errors.Errorf("unsupported format %q, must be one of text or json", format)
+	}
+	if len(c.Args()) != 2 {
+		return cli.NewExitError("diff requires an old and a new AMX file argument", 1)
+	}
+
+	return runAMXDiff(os.Stdout, c.Args().Get(0), c.Args().Get(1), format)
+}
+
+// amxDiffReport is the JSON document written by `amx diff`
+type amxDiffReport struct {
+	Old string `json:"old"`
+	New string `json:"new"`
+	amx.Diff
+}
+
+// runAMXDiff writes the differences between the exported symbols of two files and returns an
+// error after writing them when any symbol was removed
+func runAMXDiff(w io.Writer, oldPath, newPath, format string) error {
+	previous, err := amx.Open(oldPath)
+	if err != nil {
+		return errors.Wrapf(err, "failed to inspect %s", oldPath)
+	}
+	current, err := amx.Open(newPath)
+	if err != nil {
+		return errors.Wrapf(err, "failed to inspect %s", newPath)
+	}
+
+	diff := amx.Compare(previous, current)
+	if format == "json" {
+		encoder := json.NewEncoder(w)
+		encoder.SetIndent("", "\t")
+		err = encoder.Encode(amxDiffReport{Old: oldPath, New: newPath, Diff: diff})
+	} else {
+		err = diff.WriteText(w)
+	}
+	if err != nil {
+		return err
+	}
+
+	if removed := diff.Removed(); removed > 0 {
+		return errors.Errorf("%d exported symbols were removed from %s", removed, oldPath)
+	}
+	return nil
+}
@@ -0,0 +1,119 @@
+package commands
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/Southclaws/sampctl/src/pkg/build/amx"
+)
+
+// writeInspectAMX writes a script with one public, one native and a name table
+func writeInspectAMX(t *testing.T) string {
+	t.Helper()
+
+	const (
+		publics   = amx.HeaderSize
+		natives   = publics + 8
+		nameTable = natives + 8
+	)
+	names := []byte("\x1f\x00OnGameModeInit\x00print\x00")
+	cod := int32(nameTable + len(names))
+	header := amx.Header{
+		Magic: amx.Magic32, FileVersion: 11, AMXVersion: 11, Flags: amx.FlagDebug, DefSize: 8,
+		COD: cod, DAT: cod + 64, HEA: cod + 80, STP: cod + 80 + 16384, CIP: -1,
+		Publics: publics, Natives: natives, Libraries: nameTable, PubVars: nameTable, Tags: nameTable, NameTable: nameTable,
+	}
+	header.Size = header.HEA
+
+	var file bytes.Buffer
+	require.NoError(t, binary.Write(&file, binary.LittleEndian, header))
+	require.NoError(t, binary.Write(&file, binary.LittleEndian, []uint32{0x10, nameTable + 2, 0, nameTable + 17}))
+	file.Write(names)
+	file.Write(make([]byte, 80))
+
+	path := filepath.Join(t.TempDir(), "script.amx")
+	require.NoError(t, os.WriteFile(path, file.Bytes(), 0o644))
+	return path
+}
+
+func TestRunAMXInspectText(t *testing.T) {
+	t.Parallel()
+
+	path := writeInspectAMX(t)
+	var out bytes.Buffer
+	require.NoError(t, runAMXInspect(&out, path, "text"))
+
+	text := out.String()
+	assert.Regexp(t, `Cell size\s+\|\s+32 bits`, text)
+	assert.Regexp(t, `Flags\s+\|\s+0x0002 debug`, text)
+	assert.Regexp(t, `Code size\s+\|\s+64`, text)
+	assert.Regexp(t, `Stack/heap size\s+\|\s+16384`, text)
+	assert.Contains(t, text, "\nPublics (1)\n")
+	assert.Regexp(t, `0\s+\|\s+OnGameModeInit\s+\|\s+0x10`, text)
+	assert.Contains(t, text, "\nNatives (1)\n")
+	assert.Regexp(t, `0\s+\|\s+print\s+\|`, text)
+	assert.Contains(t, text, "\nTags (0)\n")
+}
+
+func TestRunAMXInspectJSON(t *testing.T) {
+	t.Parallel()
+
+	path := writeInspectAMX(t)
+	var out bytes.Buffer
+	require.NoError(t, runAMXInspect(&out, path, "json"))
+
+	var decoded amxInspection
+	require.NoError(t, json.Unmarshal(out.Bytes(), &decoded))
+	assert.Equal(t, path, decoded.Path)
+	assert.Equal(t, 32, decoded.CellSize)
+	assert.Equal(t, []amx.Symbol{{Name: "OnGameModeInit", Value: 0x10}}, decoded.Publics)
+	assert.Equal(t, []amx.Symbol{{Name: "print"}}, decoded.Natives)
+	assert.Equal(t, 64, decoded.Sizes.Code)
+	assert.Equal(t, 16, decoded.Sizes.Data)
+}
+
+func TestRunAMXInspectInvalidFile(t *testing.T) {
+	t.Parallel()
+
+	path := filepath.Join(t.TempDir(), "broken.amx")
+	require.NoError(t, os.WriteFile(path, []byte("not an amx"), 0o644))
+	err := runAMXInspect(&bytes.Buffer{}, path, "text")
+	assert.ErrorContains(t, err, "too small for an AMX header")
+}
+
+func TestRunAMXDiff(t *testing.T) {
+	t.Parallel()
+
+	previous := writeInspectAMX(t)
+	var out bytes.Buffer
+	require.NoError(t, runAMXDiff(&out, previous, previous, "text"))
+	assert.Equal(t, "no exported symbols changed\n", out.String())
+
+	// a script without any symbols removes the public and the native
+	data, err := os.ReadFile(previous)
+	require.NoError(t, err)
+	header := data[:amx.HeaderSize]
+	for _, offset := range []int{32, 36} { // publics and natives start where the libraries table does
+		copy(header[offset:offset+4], header[40:44])
+	}
+	current := filepath.Join(t.TempDir(), "current.amx")
+	require.NoError(t, os.WriteFile(current, data, 0o644))
+
+	out.Reset()
+	err = runAMXDiff(&out, previous, current, "json")
+	assert.EqualError(t, err, "2 exported symbols were removed from "+previous)
+
+	var decoded amxDiffReport
+	require.NoError(t, json.Unmarshal(out.Bytes(), &decoded))
+	assert.Equal(t, current, decoded.New)
+	assert.Equal(t, []amx.Symbol{{Name: "OnGameModeInit", Value: 0x10}}, decoded.Publics.Removed)
+	assert.Equal(t, []amx.Symbol{{Name: "print"}}, decoded.Natives.Removed)
+	assert.Empty(t, decoded.Publics.Added)
+}
@@ -88,16 +88,23 @@ func appCommands(global []cli.Flag) []cli.Command {
 		newEnsureCommand(global),
 		newInstallCommand(global),
 		newUninstallCommand(global),
+		newTreeCommand(global),
+		newWhyCommand(global),
+		newOutdatedCommand(global),
+		newUpdateCommand(global),
 		newReleaseCommand(global),
 		newConfigCommand(global),
 		newGetCommand(global),
 		newBuildCommand(global),
 		newRunCommand(global),
 		newCompilerCommand(global),
+		newAMXCommand(global),
+		newQueryCommand(global),
+		newRCONCommand(global),
 		newTemplateCommand(global),
 		newVersionCommand(),
 		newCompletionCommand(),
-		newDocsCommand(),
+		newDocsCommand(global),
 	}
 }
 
@@ -149,6 +156,46 @@ func newUninstallCommand(global []cli.Flag) cli.Command {
 	}
 }
 
+func newTreeCommand(global []cli.Flag) cli.Command {
+	return cli.Command{
+		Name:        "tree",
+		Usage:       "sampctl tree [--format text|json|dot]",
+		Description: "Prints the resolved dependency graph from `pawn.lock` and the package's dependency tree.",
+		Action:      packageTree,
+		Flags:       withGlobalFlags(global, packageTreeFlags()),
+	}
+}
+
+func newWhyCommand(global []cli.Flag) cli.Command {
+	return cli.Command{
+		Name:        "why",
+		Usage:       "sampctl why <dependency>",
+		Description: "Explains which direct dependencies pulled in a package and at what constraint.",
+		Action:      packageWhy,
+		Flags:       withGlobalFlags(global, packageWhyFlags()),
+	}
+}
+
+func newOutdatedCommand(global []cli.Flag) cli.Command {
+	return cli.Command{
+		Name:        "outdated",
+		Usage:       "sampctl outdated [--json] [--exit-code]",
+		Description: "Lists locked dependencies that have newer tags, both within their version constraint and overall.",
+		Action:      packageOutdated,
+		Flags:       withGlobalFlags(global, packageOutdatedFlags()),
+	}
+}
+
+func newUpdateCommand(global []cli.Flag) cli.Command {
+	return cli.Command{
+		Name:        "update",
+		Usage:       "sampctl update <dependency...>",
+		Description: "Re-resolves the named dependencies to the newest version allowed by their constraints and updates `pawn.lock` without touching the package definition.",
+		Action:      packageUpdate,
+		Flags:       withGlobalFlags(global, packageUpdateFlags()),
+	}
+}
+
 func newReleaseCommand(global []cli.Flag) cli.Command {
 	return cli.Command{
 		Name:        "release",
@@ -218,6 +265,50 @@ func newCompilerCommand(global []cli.Flag) cli.Command {
 	}
 }
 
+func newAMXCommand(global []cli.Flag) cli.Command {
+	return cli.Command{
+		Name:        "amx",
+		Usage:       "sampctl amx <subcommand>",
+		Description: "Provides commands for compiled AMX files",
+		Subcommands: []cli.Command{
+			{
+				Name:        "inspect",
+				Usage:       "sampctl amx inspect <file>",
+				Description: "Lists the header, sizes, publics, natives, public variables and tags of a compiled AMX file.",
+				Action:      amxInspect,
+				Flags:       withGlobalFlags(global, amxInspectFlags()),
+			},
+			{
+				Name:        "diff",
+				Usage:       "sampctl amx diff <old> <new>",
+				Description: "Lists the publics, natives and public variables added, removed or moved between two compiled AMX files and fails when any were removed.",
+				Action:      amxDiff,
+				Flags:       withGlobalFlags(global, amxDiffFlags()),
+			},
+		},
+	}
+}
+
+func newQueryCommand(global []cli.Flag) cli.Command {
+	return cli.Command{
+		Name:        "query",
+		Usage:       "sampctl query [host:port]",
+		Description: "Queries a running SA:MP or open.mp server for its info, rules and players, by default the server of the package's runtime.",
+		Action:      queryServer,
+		Flags:       withGlobalFlags(global, queryFlags()),
+	}
+}
+
+func newRCONCommand(global []cli.Flag) cli.Command {
+	return cli.Command{
+		Name:        "rcon",
+		Usage:       "sampctl rcon [command]",
+		Description: "Sends a remote console command to a running SA:MP or open.mp server and prints its output, without a command it reads commands from standard input. Uses the address and RCON password of the package's runtime by default.",
+		Action:      rconCommand,
+		Flags:       withGlobalFlags(global, rconFlags()),
+	}
+}
+
 func newTemplateCommand(global []cli.Flag) cli.Command {
 	return cli.Command{
 		Name:        "template",
@@ -265,15 +356,30 @@ func newCompletionCommand() cli.Command {
 	}
 }
 
-func newDocsCommand() cli.Command {
+func newDocsCommand(global []cli.Flag) cli.Command {
 	return cli.Command{
 		Name:        "docs",
 		Usage:       "sampctl docs > documentation.md",
 		Description: "Generate documentation in markdown format and print to standard out.",
 		Action: func(c *cli.Context) error {
-			fmt.Print(GenerateDocs(c.App))
+			// with subcommands the action runs in a sub-application, the commands to document
+			// belong to the application that ran it
+			app := c.App
+			if parent := c.Parent(); parent != nil && parent.App != nil {
+				app = parent.App
+			}
+			fmt.Print(GenerateDocs(app))
 			return nil
 		},
+		Subcommands: []cli.Command{
+			{
+				Name:        "package",
+				Usage:       "sampctl docs package [--format markdown|html] [--output docs/api]",
+				Description: "Compiles a harness that includes the package with the compiler's XML report enabled and writes a page of functions, parameters, return values and remarks for each include file.",
+				Action:      packageDocs,
+				Flags:       withGlobalFlags(global, packageDocsFlags()),
+			},
+		},
 	}
 }
 
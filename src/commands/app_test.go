@@ -41,12 +41,19 @@ func TestNewCLIAppConfiguresMetadataAndCommands(t *testing.T) {
 		"ensure",
 		"install",
 		"uninstall",
+		"tree",
+		"why",
+		"outdated",
+		"update",
 		"release",
 		"config",
 		"get",
 		"build",
 		"run",
 		"compiler",
+		"amx",
+		"query",
+		"rcon",
 		"template",
 		"version",
 		"completion",
@@ -48,14 +48,3 @@ func lockfileDependencyCount(lockfiles pkgcontext.LockfileController) int {
 	}
 	return lf.DependencyCount()
 }
-
-func persistBuildLockfile(
-	lockfiles pkgcontext.BuildLockfileController,
-	compilerVersion string,
-	compilerPreset string,
-	entry string,
-	output string,
-) error {
-	lockfiles.RecordBuildToLockfile(compilerVersion, compilerPreset, entry, output)
-	return lockfiles.SaveLockfile()
-}
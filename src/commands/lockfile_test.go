@@ -21,12 +21,6 @@ type fakeCommandLockfile struct {
 	hasLockfile       bool
 	hasResolver       bool
 	forceUpdateCalled bool
-	buildRecord       struct {
-		compilerVersion string
-		compilerPreset  string
-		entry           string
-		output          string
-	}
 }
 
 func (f *fakeCommandLockfile) InitLockfileResolver(sampctlVersion string) error {
@@ -55,13 +49,6 @@ func (f *fakeCommandLockfile) GetLockfile() *lockfile.Lockfile {
 	return f.lockfile
 }
 
-func (f *fakeCommandLockfile) RecordBuildToLockfile(compilerVersion, compilerPreset, entry, output string) {
-	f.buildRecord.compilerVersion = compilerVersion
-	f.buildRecord.compilerPreset = compilerPreset
-	f.buildRecord.entry = entry
-	f.buildRecord.output = output
-}
-
 func TestInitLockfileResolver(t *testing.T) {
 	t.Parallel()
 
@@ -120,17 +107,6 @@ func TestSaveCommandLockfile(t *testing.T) {
 	assert.EqualError(t, err, "save failed")
 }
 
-func TestPersistBuildLockfile(t *testing.T) {
-	t.Parallel()
-
-	target := &fakeCommandLockfile{}
-	require.NoError(t, persistBuildLockfile(target, "1.0.0", "default", "src/main.pwn", "gamemodes/test.amx"))
-	assert.Equal(t, "1.0.0", target.buildRecord.compilerVersion)
-	assert.Equal(t, "default", target.buildRecord.compilerPreset)
-	assert.Equal(t, "src/main.pwn", target.buildRecord.entry)
-	assert.Equal(t, "gamemodes/test.amx", target.buildRecord.output)
-}
-
 func newTestCLIContext(version string) *cli.Context {
 	app := cli.NewApp()
 	app.Metadata = map[string]interface{}{
@@ -2,10 +2,13 @@ package commands
 
 import (
 	"fmt"
+	"io"
+	"os"
 
 	"github.com/pkg/errors"
 	"gopkg.in/urfave/cli.v1"
 
+	"github.com/Southclaws/sampctl/src/pkg/build"
 	"github.com/Southclaws/sampctl/src/pkg/infrastructure/fs"
 	"github.com/Southclaws/sampctl/src/pkg/infrastructure/print"
 	"github.com/Southclaws/sampctl/src/pkg/package/pkgcontext"
@@ -43,6 +46,56 @@ func packageBuildFlags() []cli.Flag {
 			Name:  "no-lock",
 			Usage: "disable lockfile support",
 		},
+		cli.BoolFlag{
+			Name:  "all",
+			Usage: "compile every build config in the package",
+		},
+		cli.StringFlag{
+			Name:  "build",
+			Usage: "compile every build config whose name matches a glob pattern, such as `test-*`",
+		},
+		cli.IntFlag{
+			Name:  "jobs",
+			Value: defaultBuildJobs,
+			Usage: "maximum number of builds to compile at the same time with --all or --build",
+		},
+		cli.StringFlag{
+			Name:  "dependency-warnings",
+			Usage: "how warnings from files inside dependencies are reported, one of `show`, `summary` or `hide` - overrides dependency_warnings in the build config",
+		},
+		cli.BoolFlag{
+			Name:  "depfile",
+			Usage: "writes a Makefile-style depfile (output.amx.d) and a JSON include manifest (output.amx.includes.json) after each successful build",
+		},
+		cli.BoolFlag{
+			Name:  "check-natives",
+			Usage: "fails the build when the output calls natives that no plugin or component dependency provides",
+		},
+		cli.BoolFlag{
+			Name:  "diff-previous",
+			Usage: "lists the publics, natives and public variables added or removed since the last locked build and fails if any were removed",
+		},
+		cli.BoolFlag{
+			Name:  "explain-includes",
+			Usage: "lists the file and dependency that satisfies each include instead of compiling and warns about shadowed files",
+		},
+		cli.BoolFlag{
+			Name:  "preprocess",
+			Usage: "runs only the preprocessor and writes the expanded source to a listing next to the output instead of compiling",
+		},
+		cli.StringFlag{
+			Name:  "grep",
+			Usage: "with --preprocess, shows only the expanded regions that contain this symbol",
+		},
+		cli.BoolFlag{
+			Name:  "force",
+			Usage: "compile even when nothing changed since the build recorded in the lockfile",
+		},
+		cli.StringFlag{
+			Name:  "format",
+			Value: string(build.ReportText),
+			Usage: "problem output format, one of `text`, `json`, `sarif` or `github-annotations`",
+		},
 	}
 }
 
@@ -55,8 +108,78 @@ func packageBuild(c *cli.Context) error {
 	relativePaths := c.Bool("relativePaths")
 	noLock := c.Bool("no-lock")
 	useLockfile := !noLock
+	format, err := build.ParseReportFormat(c.String("format"))
+	if err != nil {
+		return err
+	}
+	if watch && format != build.ReportText {
+		return errors.New("--format cannot be used with --watch")
+	}
+	var dependencyWarnings build.DependencyWarnings
+	if c.IsSet("dependency-warnings") {
+		if dependencyWarnings, err = build.ParseDependencyWarnings(c.String("dependency-warnings")); err != nil {
+			return err
+		}
+	}
+
+	buildName := c.Args().Get(0)
+	all := c.Bool("all")
+	pattern := c.String("build")
+	matrix := all || pattern != ""
+	if matrix {
+		switch {
+		case all && pattern != "":
+			return errors.New("--all and --build cannot be used together")
+		case buildName != "":
+			return errors.New("a build name cannot be used with --all or --build")
+		case watch:
+			return errors.New("--watch cannot be used with --all or --build")
+		case buildFile != "":
+			return errors.New("--buildFile cannot be used with --all or --build")
+		case c.Int("jobs") < 1:
+			return errors.New("--jobs must be at least 1")
+		}
+	}
+
+	if watch && c.Bool("diff-previous") {
+		return errors.New("--diff-previous cannot be used with --watch")
+	}
+
+	explainIncludes := c.Bool("explain-includes")
+	if explainIncludes {
+		switch {
+		case matrix:
+			return errors.New("--explain-includes cannot be used with --all or --build")
+		case watch:
+			return errors.New("--explain-includes cannot be used with --watch")
+		case dryRun:
+			return errors.New("--explain-includes cannot be used with --dryRun")
+		}
+	}
 
-	build := c.Args().Get(0)
+	preprocess := c.Bool("preprocess")
+	if preprocess {
+		switch {
+		case matrix:
+			return errors.New("--preprocess cannot be used with --all or --build")
+		case watch:
+			return errors.New("--preprocess cannot be used with --watch")
+		case dryRun:
+			return errors.New("--preprocess cannot be used with --dryRun")
+		case explainIncludes:
+			return errors.New("--preprocess cannot be used with --explain-includes")
+		}
+	} else if c.String("grep") != "" {
+		return errors.New("--grep can only be used with --preprocess")
+	}
+
+	// structured reports own standard output, so logs and compiler output go to standard error
+	var buildOutput *os.File
+	if format.Structured() {
+		buildOutput = os.Stderr
+		print.SetOutput(os.Stderr)
+		defer print.SetOutput(nil)
+	}
 
 	pcx, _, err := loadPackageContext(c, dir, false)
 	if err != nil {
@@ -72,30 +195,114 @@ func packageBuild(c *cli.Context) error {
 	ctx, cancel := newCommandContext()
 	defer cancel()
 
+	if explainIncludes {
+		err := runPackageBuildExplainIncludes(ctx, pcx, os.Stdout, explainIncludesCommandOptions{
+			name:    buildName,
+			ensure:  forceEnsure,
+			format:  format,
+			baseDir: pcx.Package.LocalPath,
+		})
+		if err != nil {
+			return cli.NewExitError(err.Error(), 1)
+		}
+		return nil
+	}
+
+	if preprocess {
+		compilerOutput := io.Writer(os.Stdout)
+		if buildOutput != nil {
+			compilerOutput = buildOutput
+		}
+		err := runPackageBuildPreprocess(ctx, pcx, os.Stdout, preprocessCommandOptions{
+			name:    buildName,
+			ensure:  forceEnsure,
+			grep:    c.String("grep"),
+			format:  format,
+			baseDir: pcx.Package.LocalPath,
+			output:  compilerOutput,
+		})
+		if err != nil {
+			return cli.NewExitError(err.Error(), 1)
+		}
+		return nil
+	}
+
+	if matrix {
+		summary := io.Writer(os.Stdout)
+		if buildOutput != nil {
+			summary = buildOutput
+		}
+		err := runPackageBuildMatrix(ctx, pcx, summary, os.Stdout, buildMatrixCommandOptions{
+			all:     all,
+			pattern: pattern,
+			jobs:    c.Int("jobs"),
+			format:  format,
+			baseDir: pcx.Package.LocalPath,
+			build: pkgcontext.BuildMatrixOptions{
+				Ensure:   forceEnsure,
+				DryRun:   dryRun,
+				Relative: relativePaths,
+				Force:    c.Bool("force"),
+				Output:   summary,
+
+				DependencyWarnings: dependencyWarnings,
+				Depfile:            c.Bool("depfile"),
+				CheckNatives:       c.Bool("check-natives"),
+				DiffPrevious:       c.Bool("diff-previous"),
+			},
+		})
+		if useLockfile && !dryRun {
+			if saveErr := saveCommandLockfile(pcx); saveErr != nil {
+				print.Warn("failed to save lockfile:", saveErr)
+			}
+		}
+		return err
+	}
+
 	if watch {
 		err := pcx.BuildWatch(ctx, pkgcontext.BuildOptions{
-			Name:      build,
+			Name:      buildName,
 			Ensure:    forceEnsure,
 			BuildFile: buildFile,
 			Relative:  relativePaths,
+
+			DependencyWarnings: dependencyWarnings,
+			Depfile:            c.Bool("depfile"),
+			CheckNatives:       c.Bool("check-natives"),
 		})
 		if err != nil {
 			return cli.NewExitError(err.Error(), 1)
 		}
 	} else {
-		problems, result, err := pcx.Build(ctx, pkgcontext.BuildOptions{
-			Name:      build,
+		options := pkgcontext.BuildOptions{
+			Name:      buildName,
 			Ensure:    forceEnsure,
 			DryRun:    dryRun,
 			Relative:  relativePaths,
 			BuildFile: buildFile,
-		})
+			Force:     c.Bool("force"),
+
+			DependencyWarnings: dependencyWarnings,
+			Depfile:            c.Bool("depfile"),
+			CheckNatives:       c.Bool("check-natives"),
+			DiffPrevious:       c.Bool("diff-previous"),
+		}
+		if buildOutput != nil {
+			options.Output = buildOutput
+		}
+		problems, result, err := pcx.Build(ctx, options)
 		if err != nil {
 			return cli.NewExitError(err.Error(), 1)
 		}
 
-		if build == "" {
-			build = "default"
+		if buildName == "" {
+			buildName = "default"
+		}
+
+		if !dryRun {
+			if err := writeBuildReport(pcx, buildName, format, problems, result); err != nil {
+				return errors.Wrap(err, "failed to write build report")
+			}
 		}
 
 		if problems.Fatal() {
@@ -103,9 +310,9 @@ func packageBuild(c *cli.Context) error {
 		} else if len(problems.Errors()) > 0 {
 			return cli.NewExitError(errors.Errorf("Build failed with %d problems", len(problems)), 1)
 		} else if len(problems.Warnings()) > 0 {
-			print.Warn("Build", build, "complete with", len(problems), "problems")
+			print.Warn("Build", buildName, "complete with", len(problems), "problems")
 		} else {
-			print.Info("Build", build, "successful with", len(problems), "problems")
+			print.Info("Build", buildName, "successful with", len(problems), "problems")
 		}
 
 		print.Verb(fmt.Sprintf(
@@ -119,17 +326,8 @@ func packageBuild(c *cli.Context) error {
 		))
 
 		if useLockfile && !problems.Fatal() && len(problems.Errors()) == 0 {
-			config := pcx.Package.GetBuildConfig(build)
-			if config != nil {
-				if saveErr := persistBuildLockfile(
-					pcx,
-					config.Compiler.Version,
-					config.Compiler.Preset,
-					pcx.Package.Entry,
-					pcx.Package.Output,
-				); saveErr != nil {
-					print.Warn("failed to save lockfile:", saveErr)
-				}
+			if saveErr := saveCommandLockfile(pcx); saveErr != nil {
+				print.Warn("failed to save lockfile:", saveErr)
 			}
 		}
 	}
@@ -137,6 +335,25 @@ func packageBuild(c *cli.Context) error {
 	return nil
 }
 
+func writeBuildReport(
+	pcx *pkgcontext.PackageContext,
+	buildName string,
+	format build.ReportFormat,
+	problems build.Problems,
+	result build.Result,
+) error {
+	report := build.Report{
+		Build:    buildName,
+		BaseDir:  pcx.Package.LocalPath,
+		Problems: problems,
+		Result:   result,
+	}
+	if config := pcx.Package.GetBuildConfig(buildName); config != nil {
+		report.Compiler = config.Compiler.ResolveCompilerConfig().Version
+	}
+	return report.Write(os.Stdout, format)
+}
+
 func packageBuildBash(c *cli.Context) {
 	dir := fs.MustAbs(c.String("dir"))
 	pcx, _, err := loadPackageContext(c, dir, false)
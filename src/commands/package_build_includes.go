@@ -0,0 +1,134 @@
+package commands
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"path/filepath"
+	"strings"
+
+	"github.com/jedib0t/go-pretty/v6/table"
+	"github.com/pkg/errors"
+
+	"github.com/Southclaws/sampctl/src/pkg/build"
+	"github.com/Southclaws/sampctl/src/pkg/infrastructure/print"
+	"github.com/Southclaws/sampctl/src/pkg/package/pkgcontext"
+)
+
+type explainIncludesCommandTarget interface {
+	ExplainIncludes(ctx context.Context, name string, ensure bool) (pkgcontext.IncludeExplanation, error)
+}
+
+type explainIncludesCommandOptions struct {
+	name    string
+	ensure  bool
+	format  build.ReportFormat
+	baseDir string
+}
+
+// runPackageBuildExplainIncludes writes which file satisfied every include directive of a build
+// and warns about files that shadow others with the same name
+func runPackageBuildExplainIncludes(
+	ctx context.Context,
+	target explainIncludesCommandTarget,
+	w io.Writer,
+	options explainIncludesCommandOptions,
+) error {
+	if options.format != build.ReportText && options.format != build.ReportJSON {
+		return errors.Errorf("--explain-includes only supports the %s and %s formats", build.ReportText, build.ReportJSON)
+	}
+
+	explanation, err := target.ExplainIncludes(ctx, options.name, options.ensure)
+	if err != nil {
+		return errors.Wrap(err, "failed to explain includes")
+	}
+
+	if options.format == build.ReportJSON {
+		encoder := json.NewEncoder(w)
+		encoder.SetIndent("", "\t")
+		if err := encoder.Encode(explanation); err != nil {
+			return err
+		}
+	} else {
+		writeIncludeExplanation(w, explanation, options.baseDir)
+	}
+
+	warnIncludeShadowing(explanation, options.baseDir)
+	for _, include := range explanation.Missing() {
+		print.Warn(fmt.Sprintf("%s:%d: no file satisfies %s", relativeIncludePath(options.baseDir, include.File), include.Line, includeDirective(include)))
+	}
+	return nil
+}
+
+func writeIncludeExplanation(w io.Writer, explanation pkgcontext.IncludeExplanation, baseDir string) {
+	t := table.NewWriter()
+	t.SetOutputMirror(w)
+	t.AppendHeader(table.Row{"Directive", "Include", "Resolved", "Dependency"})
+
+	for _, include := range explanation.Includes {
+		resolved, owner := "(not found)", "-"
+		if include.Resolved != nil {
+			resolved = relativeIncludePath(baseDir, include.Resolved.Path)
+			owner = includeOwner(include.Resolved.Owner)
+		}
+		t.AppendRow(table.Row{
+			fmt.Sprintf("%s:%d", relativeIncludePath(baseDir, include.File), include.Line),
+			includeDirective(include),
+			resolved,
+			owner,
+		})
+	}
+
+	t.Render()
+}
+
+// warnIncludeShadowing warns once for every file that hides another, a file is usually included
+// from several places so warning for every directive would repeat the same message
+func warnIncludeShadowing(explanation pkgcontext.IncludeExplanation, baseDir string) {
+	warned := make(map[string]bool)
+	for _, include := range explanation.Shadowing() {
+		if warned[include.Resolved.Path] {
+			continue
+		}
+		warned[include.Resolved.Path] = true
+
+		shadowed := make([]string, 0, len(include.Shadowed))
+		for _, file := range include.Shadowed {
+			shadowed = append(shadowed, fmt.Sprintf("%s (%s)", relativeIncludePath(baseDir, file.Path), includeOwner(file.Owner)))
+		}
+		print.Warn(fmt.Sprintf("%s resolved to %s (%s) which shadows %s",
+			includeDirective(include),
+			relativeIncludePath(baseDir, include.Resolved.Path),
+			includeOwner(include.Resolved.Owner),
+			strings.Join(shadowed, ", "),
+		))
+	}
+}
+
+func includeDirective(include pkgcontext.ExplainedInclude) string {
+	directive := "#include"
+	if include.Try {
+		directive = "#tryinclude"
+	}
+	if include.Quoted {
+		return fmt.Sprintf("%s \"%s\"", directive, include.Name)
+	}
+	return fmt.Sprintf("%s <%s>", directive, include.Name)
+}
+
+func includeOwner(owner string) string {
+	if owner == "" {
+		return "-"
+	}
+	return owner
+}
+
+func relativeIncludePath(baseDir, path string) string {
+	if baseDir != "" {
+		if rel, err := filepath.Rel(baseDir, path); err == nil && !strings.HasPrefix(rel, "..") {
+			return filepath.ToSlash(rel)
+		}
+	}
+	return filepath.ToSlash(path)
+}
@@ -0,0 +1,101 @@
+package commands
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/Southclaws/sampctl/src/pkg/build"
+	"github.com/Southclaws/sampctl/src/pkg/build/includes"
+	"github.com/Southclaws/sampctl/src/pkg/infrastructure/print"
+	"github.com/Southclaws/sampctl/src/pkg/package/pkgcontext"
+)
+
+type fakeExplainIncludesTarget struct {
+	name        string
+	ensure      bool
+	explanation pkgcontext.IncludeExplanation
+}
+
+func (f *fakeExplainIncludesTarget) ExplainIncludes(_ context.Context, name string, ensure bool) (pkgcontext.IncludeExplanation, error) {
+	f.name = name
+	f.ensure = ensure
+	return f.explanation, nil
+}
+
+func testIncludeExplanation() pkgcontext.IncludeExplanation {
+	mysql := pkgcontext.ExplainedInclude{
+		Directive: includes.Directive{File: "/project/gamemodes/main.pwn", Line: 1, Name: "a_mysql"},
+		Resolved:  &pkgcontext.IncludeFile{Path: "/project/dependencies/lib-a/a_mysql.inc", Owner: "user/lib-a"},
+		Shadowed:  []pkgcontext.IncludeFile{{Path: "/project/dependencies/lib-b/a_mysql.inc", Owner: "user/lib-b"}},
+	}
+	mysqlAgain := mysql
+	mysqlAgain.Directive.File = "/project/gamemodes/util.inc"
+
+	return pkgcontext.IncludeExplanation{
+		Build: "default",
+		Entry: "/project/gamemodes/main.pwn",
+		Includes: []pkgcontext.ExplainedInclude{
+			mysql,
+			{
+				Directive: includes.Directive{File: "/project/gamemodes/main.pwn", Line: 2, Name: "util", Quoted: true},
+				Resolved:  &pkgcontext.IncludeFile{Path: "/project/gamemodes/util.inc", Owner: pkgcontext.IncludeOwnerPackage},
+			},
+			mysqlAgain,
+			{Directive: includes.Directive{File: "/project/gamemodes/main.pwn", Line: 3, Name: "optional", Try: true}},
+			{Directive: includes.Directive{File: "/project/gamemodes/main.pwn", Line: 4, Name: "missing"}},
+		},
+	}
+}
+
+func TestRunPackageBuildExplainIncludesWritesTable(t *testing.T) {
+	var logs bytes.Buffer
+	print.SetOutput(&logs)
+	defer print.SetOutput(nil)
+
+	target := &fakeExplainIncludesTarget{explanation: testIncludeExplanation()}
+	var out bytes.Buffer
+	err := runPackageBuildExplainIncludes(context.Background(), target, &out, explainIncludesCommandOptions{
+		name:    "main",
+		ensure:  true,
+		format:  build.ReportText,
+		baseDir: "/project",
+	})
+	require.NoError(t, err)
+	assert.Equal(t, "main", target.name)
+	assert.True(t, target.ensure)
+
+	table := out.String()
+	assert.Regexp(t, `gamemodes/main.pwn:1\s+\|\s+#include <a_mysql>\s+\|\s+dependencies/lib-a/a_mysql.inc\s+\|\s+user/lib-a`, table)
+	assert.Regexp(t, `gamemodes/main.pwn:2\s+\|\s+#include "util"\s+\|\s+gamemodes/util.inc\s+\|\s+package`, table)
+	assert.Regexp(t, `#tryinclude <optional>\s+\|\s+\(not found\)\s+\|\s+-`, table)
+
+	assert.Equal(t, 1, bytes.Count(logs.Bytes(), []byte("shadows")), "shadowing is reported once per file")
+	assert.Contains(t, logs.String(), "#include <a_mysql> resolved to dependencies/lib-a/a_mysql.inc (user/lib-a) which shadows dependencies/lib-b/a_mysql.inc (user/lib-b)")
+	assert.Contains(t, logs.String(), "gamemodes/main.pwn:4: no file satisfies #include <missing>")
+	assert.NotContains(t, logs.String(), "optional")
+}
+
+func TestRunPackageBuildExplainIncludesWritesJSON(t *testing.T) {
+	target := &fakeExplainIncludesTarget{explanation: testIncludeExplanation()}
+	var out bytes.Buffer
+	err := runPackageBuildExplainIncludes(context.Background(), target, &out, explainIncludesCommandOptions{format: build.ReportJSON})
+	require.NoError(t, err)
+
+	var decoded pkgcontext.IncludeExplanation
+	require.NoError(t, json.Unmarshal(out.Bytes(), &decoded))
+	assert.Equal(t, testIncludeExplanation(), decoded)
+	assert.Contains(t, out.String(), `"owner": "user/lib-b"`)
+}
+
+func TestRunPackageBuildExplainIncludesRejectsReportFormats(t *testing.T) {
+	t.Parallel()
+
+	target := &fakeExplainIncludesTarget{}
+	err := runPackageBuildExplainIncludes(context.Background(), target, &bytes.Buffer{}, explainIncludesCommandOptions{format: build.ReportSARIF})
+	assert.EqualError(t, err, "--explain-includes only supports the text and json formats")
+}
@@ -0,0 +1,119 @@
+package commands
+
+import (
+	"context"
+	"fmt"
+	"io"
+
+	"github.com/jedib0t/go-pretty/v6/table"
+	"github.com/pkg/errors"
+	"gopkg.in/urfave/cli.v1"
+
+	"github.com/Southclaws/sampctl/src/pkg/build"
+	"github.com/Southclaws/sampctl/src/pkg/infrastructure/print"
+	"github.com/Southclaws/sampctl/src/pkg/package/pkgcontext"
+)
+
+const defaultBuildJobs = 4
+
+type buildMatrixCommandTarget interface {
+	AllBuilds() []string
+	MatchBuilds(pattern string) ([]string, error)
+	BuildMatrix(ctx context.Context, options pkgcontext.BuildMatrixOptions) ([]pkgcontext.BuildMatrixResult, error)
+}
+
+type buildMatrixCommandOptions struct {
+	all     bool
+	pattern string
+	jobs    int
+	format  build.ReportFormat
+	baseDir string
+	build   pkgcontext.BuildMatrixOptions
+}
+
+// runPackageBuildMatrix compiles every selected build, the summary table is written to summary
+// and any structured report to report.
+func runPackageBuildMatrix(
+	ctx context.Context,
+	target buildMatrixCommandTarget,
+	summary io.Writer,
+	report io.Writer,
+	options buildMatrixCommandOptions,
+) error {
+	names := target.AllBuilds()
+	if !options.all {
+		var err error
+		names, err = target.MatchBuilds(options.pattern)
+		if err != nil {
+			return err
+		}
+	}
+
+	buildOptions := options.build
+	buildOptions.Names = names
+	buildOptions.Jobs = options.jobs
+	results, err := target.BuildMatrix(ctx, buildOptions)
+	if err != nil {
+		return err
+	}
+	if buildOptions.DryRun {
+		return nil
+	}
+
+	reports := make(build.Reports, 0, len(results))
+	for _, result := range results {
+		reports = append(reports, build.Report{
+			Build:    result.Name,
+			Compiler: result.Compiler,
+			BaseDir:  options.baseDir,
+			Problems: result.Problems,
+			Result:   result.Result,
+		})
+	}
+	if err := reports.Write(report, options.format); err != nil {
+		return errors.Wrap(err, "failed to write build report")
+	}
+
+	writeBuildMatrixSummary(summary, results)
+
+	failed := 0
+	for _, result := range results {
+		if result.Err != nil {
+			print.Erro("build", result.Name, "failed:", result.Err)
+		}
+		if result.Failed() {
+			failed++
+		}
+	}
+	if failed > 0 {
+		return cli.NewExitError(fmt.Sprintf("%d of %d builds failed", failed, len(results)), 1)
+	}
+	return nil
+}
+
+func writeBuildMatrixSummary(w io.Writer, results []pkgcontext.BuildMatrixResult) {
+	t := table.NewWriter()
+	t.SetOutputMirror(w)
+	t.AppendHeader(table.Row{"Build", "Status", "Errors", "Warnings", "Code", "Data", "Total"})
+
+	for _, result := range results {
+		status := "ok"
+		switch {
+		case result.Failed():
+			status = "failed"
+		case len(result.Problems.Warnings()) > 0:
+			status = "warnings"
+		}
+		t.AppendRow(table.Row{
+			result.Name,
+			status,
+			len(result.Problems.Errors()),
+			len(result.Problems.Warnings()),
+			result.Result.Code,
+			result.Result.Data,
+			result.Result.Total,
+		})
+	}
+
+	t.Render()
+}
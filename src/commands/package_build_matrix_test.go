@@ -0,0 +1,123 @@
+package commands
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/Southclaws/sampctl/src/pkg/build"
+	"github.com/Southclaws/sampctl/src/pkg/package/pkgcontext"
+)
+
+type fakeBuildMatrixTarget struct {
+	builds  []string
+	options pkgcontext.BuildMatrixOptions
+	results []pkgcontext.BuildMatrixResult
+	err     error
+}
+
+func (f *fakeBuildMatrixTarget) AllBuilds() []string {
+	return f.builds
+}
+
+func (f *fakeBuildMatrixTarget) MatchBuilds(pattern string) ([]string, error) {
+	if pattern == "none" {
+		return nil, errors.New("no build configs match 'none'")
+	}
+	return f.builds[1:], nil
+}
+
+func (f *fakeBuildMatrixTarget) BuildMatrix(_ context.Context, options pkgcontext.BuildMatrixOptions) ([]pkgcontext.BuildMatrixResult, error) {
+	f.options = options
+	return f.results, f.err
+}
+
+func TestRunPackageBuildMatrixWritesSummary(t *testing.T) {
+	t.Parallel()
+
+	target := &fakeBuildMatrixTarget{
+		builds: []string{"main", "test-a"},
+		results: []pkgcontext.BuildMatrixResult{
+			{Name: "main", Result: build.Result{Code: 276, Data: 12, Total: 16720}},
+			{
+				Name:     "test-a",
+				Problems: build.Problems{{File: "test.pwn", Line: 1, Severity: build.ProblemWarning, Code: 203}},
+				Result:   build.Result{Code: 300, Total: 16800},
+			},
+		},
+	}
+
+	var summary, report bytes.Buffer
+	err := runPackageBuildMatrix(context.Background(), target, &summary, &report, buildMatrixCommandOptions{
+		all:    true,
+		jobs:   2,
+		format: build.ReportText,
+	})
+	require.NoError(t, err)
+
+	assert.Equal(t, []string{"main", "test-a"}, target.options.Names)
+	assert.Equal(t, 2, target.options.Jobs)
+	assert.Empty(t, report.String())
+
+	table := summary.String()
+	assert.Contains(t, table, "BUILD")
+	assert.Regexp(t, `main\s+\|\s+ok\s+\|\s+0\s+\|\s+0\s+\|\s+276\s+\|\s+12\s+\|\s+16720`, table)
+	assert.Regexp(t, `test-a\s+\|\s+warnings\s+\|\s+0\s+\|\s+1\s+\|\s+300`, table)
+}
+
+func TestRunPackageBuildMatrixFailsWhenAnyBuildFails(t *testing.T) {
+	t.Parallel()
+
+	target := &fakeBuildMatrixTarget{
+		builds: []string{"main", "test-a", "test-b"},
+		results: []pkgcontext.BuildMatrixResult{
+			{Name: "test-a", Problems: build.Problems{{Severity: build.ProblemError, Code: 17}}},
+			{Name: "test-b", Err: errors.New("compiler crashed")},
+		},
+	}
+
+	var summary, report bytes.Buffer
+	err := runPackageBuildMatrix(context.Background(), target, &summary, &report, buildMatrixCommandOptions{
+		pattern: "test-*",
+		format:  build.ReportJSON,
+	})
+	require.EqualError(t, err, "2 of 2 builds failed")
+	assert.Equal(t, []string{"test-a", "test-b"}, target.options.Names)
+	assert.Contains(t, summary.String(), "failed")
+
+	var decoded []map[string]interface{}
+	require.NoError(t, json.Unmarshal(report.Bytes(), &decoded))
+	require.Len(t, decoded, 2)
+	assert.Equal(t, "test-a", decoded[0]["build"])
+}
+
+func TestRunPackageBuildMatrixSelectionErrors(t *testing.T) {
+	t.Parallel()
+
+	target := &fakeBuildMatrixTarget{builds: []string{"main"}}
+	err := runPackageBuildMatrix(context.Background(), target, &bytes.Buffer{}, &bytes.Buffer{}, buildMatrixCommandOptions{pattern: "none"})
+	assert.EqualError(t, err, "no build configs match 'none'")
+
+	target.err = errors.New("failed to prepare build main")
+	err = runPackageBuildMatrix(context.Background(), target, &bytes.Buffer{}, &bytes.Buffer{}, buildMatrixCommandOptions{all: true})
+	assert.EqualError(t, err, "failed to prepare build main")
+}
+
+func TestRunPackageBuildMatrixDryRunWritesNothing(t *testing.T) {
+	t.Parallel()
+
+	target := &fakeBuildMatrixTarget{builds: []string{"main"}}
+	var summary bytes.Buffer
+	err := runPackageBuildMatrix(context.Background(), target, &summary, &summary, buildMatrixCommandOptions{
+		all:   true,
+		build: pkgcontext.BuildMatrixOptions{DryRun: true},
+	})
+	require.NoError(t, err)
+	assert.True(t, target.options.DryRun)
+	assert.Empty(t, summary.String())
+}
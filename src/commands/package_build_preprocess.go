@@ -0,0 +1,92 @@
+package commands
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+
+	"github.com/jedib0t/go-pretty/v6/table"
+	"github.com/pkg/errors"
+
+	"github.com/Southclaws/sampctl/src/pkg/build"
+	"github.com/Southclaws/sampctl/src/pkg/build/listing"
+	"github.com/Southclaws/sampctl/src/pkg/package/pkgcontext"
+)
+
+type preprocessCommandTarget interface {
+	Preprocess(ctx context.Context, name string, ensure bool, output io.Writer) (pkgcontext.PreprocessedBuild, error)
+}
+
+type preprocessCommandOptions struct {
+	name    string
+	ensure  bool
+	grep    string
+	format  build.ReportFormat
+	baseDir string
+	output  io.Writer // compiler output
+}
+
+// runPackageBuildPreprocess writes the listing of a build and summarises which files the
+// expanded source came from, or shows the expanded regions that contain a symbol
+func runPackageBuildPreprocess(
+	ctx context.Context,
+	target preprocessCommandTarget,
+	w io.Writer,
+	options preprocessCommandOptions,
+) error {
+	if options.format != build.ReportText && options.format != build.ReportJSON {
+		return errors.Errorf("--preprocess only supports the %s and %s formats", build.ReportText, build.ReportJSON)
+	}
+
+	preprocessed, err := target.Preprocess(ctx, options.name, options.ensure, options.output)
+	if err != nil {
+		return errors.Wrap(err, "failed to preprocess build")
+	}
+	if options.grep != "" {
+		preprocessed.Regions = preprocessed.Grep(options.grep)
+	}
+
+	if options.format == build.ReportJSON {
+		encoder := json.NewEncoder(w)
+		encoder.SetIndent("", "\t")
+		return encoder.Encode(preprocessed)
+	}
+
+	if _, err = fmt.Fprintf(w, "Listing written to %s\n", relativeIncludePath(options.baseDir, preprocessed.File)); err != nil {
+		return err
+	}
+	if options.grep != "" {
+		return writeListingRegions(w, preprocessed.Regions, options.grep, options.baseDir)
+	}
+	writeListingSummary(w, preprocessed.Files(), options.baseDir)
+	return nil
+}
+
+func writeListingSummary(w io.Writer, files []listing.FileSummary, baseDir string) {
+	t := table.NewWriter()
+	t.SetOutputMirror(w)
+	t.AppendHeader(table.Row{"File", "Regions", "Lines"})
+	for _, file := range files {
+		t.AppendRow(table.Row{relativeIncludePath(baseDir, file.File), file.Regions, file.Lines})
+	}
+	t.Render()
+}
+
+func writeListingRegions(w io.Writer, regions []listing.Region, symbol, baseDir string) error {
+	if len(regions) == 0 {
+		_, err := fmt.Fprintf(w, "no expanded regions contain %s\n", symbol)
+		return err
+	}
+	for _, region := range regions {
+		if _, err := fmt.Fprintf(w, "\n%s:%d-%d\n", relativeIncludePath(baseDir, region.File), region.Start, region.End); err != nil {
+			return err
+		}
+		for _, line := range region.Lines {
+			if _, err := fmt.Fprintf(w, "\t%s\n", line); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
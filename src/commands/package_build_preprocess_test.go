@@ -0,0 +1,106 @@
+package commands
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/Southclaws/sampctl/src/pkg/build"
+	"github.com/Southclaws/sampctl/src/pkg/build/listing"
+	"github.com/Southclaws/sampctl/src/pkg/package/pkgcontext"
+)
+
+type fakePreprocessTarget struct {
+	name         string
+	ensure       bool
+	preprocessed pkgcontext.PreprocessedBuild
+}
+
+func (f *fakePreprocessTarget) Preprocess(_ context.Context, name string, ensure bool, _ io.Writer) (pkgcontext.PreprocessedBuild, error) {
+	f.name = name
+	f.ensure = ensure
+	return f.preprocessed, nil
+}
+
+func testPreprocessedBuild() pkgcontext.PreprocessedBuild {
+	return pkgcontext.PreprocessedBuild{
+		Build: "default",
+		File:  "/project/gamemodes/main.lst",
+		Listing: listing.Listing{Regions: []listing.Region{
+			{File: "/project/dependencies/YSI/y_iterate.inc", Start: 10, End: 10, Lines: []string{"new Iterator@Player[501];"}},
+			{File: "/project/gamemodes/main.pwn", Start: 3, End: 5, Lines: []string{"main()", "{", "}"}},
+			{File: "/project/gamemodes/main.pwn", Start: 7, End: 7, Lines: []string{"new count = Iterator@Player[500];"}},
+		}},
+	}
+}
+
+func TestRunPackageBuildPreprocessSummarisesFiles(t *testing.T) {
+	target := &fakePreprocessTarget{preprocessed: testPreprocessedBuild()}
+	var out bytes.Buffer
+
+	err := runPackageBuildPreprocess(context.Background(), target, &out, preprocessCommandOptions{
+		name:    "server",
+		ensure:  true,
+		format:  build.ReportText,
+		baseDir: "/project",
+	})
+	require.NoError(t, err)
+	assert.Equal(t, "server", target.name)
+	assert.True(t, target.ensure)
+
+	assert.Contains(t, out.String(), "Listing written to gamemodes/main.lst\n")
+	assert.Contains(t, out.String(), "| dependencies/YSI/y_iterate.inc |       1 |     1 |")
+	assert.Contains(t, out.String(), "| gamemodes/main.pwn             |       2 |     4 |")
+}
+
+func TestRunPackageBuildPreprocessGrep(t *testing.T) {
+	target := &fakePreprocessTarget{preprocessed: testPreprocessedBuild()}
+	var out bytes.Buffer
+
+	err := runPackageBuildPreprocess(context.Background(), target, &out, preprocessCommandOptions{
+		grep:    "Iterator@Player",
+		format:  build.ReportText,
+		baseDir: "/project",
+	})
+	require.NoError(t, err)
+	assert.Equal(t, "Listing written to gamemodes/main.lst\n"+
+		"\ndependencies/YSI/y_iterate.inc:10-10\n\tnew Iterator@Player[501];\n"+
+		"\ngamemodes/main.pwn:7-7\n\tnew count = Iterator@Player[500];\n", out.String())
+
+	out.Reset()
+	err = runPackageBuildPreprocess(context.Background(), target, &out, preprocessCommandOptions{
+		grep:   "Iter_Add",
+		format: build.ReportText,
+	})
+	require.NoError(t, err)
+	assert.Contains(t, out.String(), "no expanded regions contain Iter_Add\n")
+}
+
+func TestRunPackageBuildPreprocessJSON(t *testing.T) {
+	target := &fakePreprocessTarget{preprocessed: testPreprocessedBuild()}
+	var out bytes.Buffer
+
+	err := runPackageBuildPreprocess(context.Background(), target, &out, preprocessCommandOptions{
+		grep:   "main",
+		format: build.ReportJSON,
+	})
+	require.NoError(t, err)
+
+	var decoded pkgcontext.PreprocessedBuild
+	require.NoError(t, json.Unmarshal(out.Bytes(), &decoded))
+	assert.Equal(t, "default", decoded.Build)
+	require.Len(t, decoded.Regions, 1)
+	assert.Equal(t, 3, decoded.Regions[0].Start)
+}
+
+func TestRunPackageBuildPreprocessRejectsReportFormats(t *testing.T) {
+	err := runPackageBuildPreprocess(context.Background(), &fakePreprocessTarget{}, &bytes.Buffer{}, preprocessCommandOptions{
+		format: build.ReportSARIF,
+	})
+	assert.EqualError(t, err, "--preprocess only supports the text and json formats")
+}
@@ -0,0 +1,96 @@
+package commands
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+
+	"github.com/pkg/errors"
+	"gopkg.in/urfave/cli.v1"
+
+	"github.com/Southclaws/sampctl/src/pkg/infrastructure/fs"
+	"github.com/Southclaws/sampctl/src/pkg/infrastructure/print"
+	"github.com/Southclaws/sampctl/src/pkg/package/pawndoc"
+	"github.com/Southclaws/sampctl/src/pkg/package/pkgcontext"
+)
+
+func packageDocsFlags() []cli.Flag {
+	return []cli.Flag{
+		cli.StringFlag{
+			Name:  "dir",
+			Value: ".",
+			Usage: "working directory for the project - by default, uses the current directory",
+		},
+		cli.StringFlag{
+			Name:  "build",
+			Usage: "build config whose compiler, options and include paths compile the documentation harness",
+		},
+		cli.StringFlag{
+			Name:  "harness",
+			Usage: "script that includes the package, by default a harness that includes every include file of the package is generated",
+		},
+		cli.StringFlag{
+			Name:  "output",
+			Value: filepath.Join("docs", "api"),
+			Usage: "directory the pages are written to, relative to the package",
+		},
+		cli.StringFlag{
+			Name:  "format",
+			Value: string(pawndoc.FormatMarkdown),
+			Usage: "page format, one of `markdown` or `html`",
+		},
+		cli.BoolFlag{
+			Name:  "forceEnsure",
+			Usage: "forces dependency ensure before compiling the harness",
+		},
+	}
+}
+
+type docsCommandTarget interface {
+	Docs(ctx context.Context, options pkgcontext.DocsOptions) ([]string, error)
+}
+
+// runPackageDocs generates the documentation pages and lists the files that were written
+func runPackageDocs(ctx context.Context, target docsCommandTarget, w io.Writer, baseDir string, options pkgcontext.DocsOptions) error {
+	written, err := target.Docs(ctx, options)
+	if err != nil {
+		return errors.Wrap(err, "failed to generate documentation")
+	}
+	for _, file := range written {
+		if _, err = fmt.Fprintln(w, relativeIncludePath(baseDir, file)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func packageDocs(c *cli.Context) error {
+	dir := fs.MustAbs(c.String("dir"))
+	format, err := pawndoc.ParseFormat(c.String("format"))
+	if err != nil {
+		return err
+	}
+
+	pcx, _, err := loadPackageContext(c, dir, false)
+	if err != nil {
+		return errors.Wrap(err, "failed to interpret directory as Pawn package")
+	}
+
+	ctx, cancel := newCommandContext()
+	defer cancel()
+
+	print.Info("Generating documentation for", pcx.Package)
+	err = runPackageDocs(ctx, pcx, os.Stdout, pcx.Package.LocalPath, pkgcontext.DocsOptions{
+		Build:     c.String("build"),
+		Ensure:    c.Bool("forceEnsure"),
+		Harness:   c.String("harness"),
+		OutputDir: c.String("output"),
+		Format:    format,
+	})
+	if err != nil {
+		return cli.NewExitError(err.Error(), 1)
+	}
+	return nil
+}
@@ -0,0 +1,45 @@
+package commands
+
+import (
+	"bytes"
+	"context"
+	"testing"
+
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/Southclaws/sampctl/src/pkg/package/pawndoc"
+	"github.com/Southclaws/sampctl/src/pkg/package/pkgcontext"
+)
+
+type fakeDocsTarget struct {
+	options pkgcontext.DocsOptions
+	written []string
+	err     error
+}
+
+func (f *fakeDocsTarget) Docs(_ context.Context, options pkgcontext.DocsOptions) ([]string, error) {
+	f.options = options
+	return f.written, f.err
+}
+
+func TestRunPackageDocsListsWrittenPages(t *testing.T) {
+	t.Parallel()
+
+	target := &fakeDocsTarget{written: []string{"/project/docs/api/things.html", "/project/docs/api/index.html"}}
+	var out bytes.Buffer
+	options := pkgcontext.DocsOptions{OutputDir: "docs/api", Format: pawndoc.FormatHTML, Harness: "test.pwn"}
+
+	require.NoError(t, runPackageDocs(context.Background(), target, &out, "/project", options))
+	assert.Equal(t, options, target.options)
+	assert.Equal(t, "docs/api/things.html\ndocs/api/index.html\n", out.String())
+}
+
+func TestRunPackageDocsWrapsErrors(t *testing.T) {
+	t.Parallel()
+
+	target := &fakeDocsTarget{err: errors.New("package has no include files to document")}
+	err := runPackageDocs(context.Background(), target, &bytes.Buffer{}, "/project", pkgcontext.DocsOptions{})
+	assert.EqualError(t, err, "failed to generate documentation: package has no include files to document")
+}
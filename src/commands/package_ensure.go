@@ -21,6 +21,8 @@ type ensureCommandTarget interface {
 	EnsureProject(ctx context.Context, request pkgcontext.DependencyUpdateRequest) (bool, error)
 }
 
+const defaultEnsureJobs = 4
+
 type ensureCommandOptions struct {
 	version     string
 	useLockfile bool
@@ -51,6 +53,11 @@ func packageEnsureFlags() []cli.Flag {
 			Name:  "lock-only",
 			Usage: "only update the lockfile without modifying dependencies",
 		},
+		cli.IntFlag{
+			Name:  "jobs",
+			Value: defaultEnsureJobs,
+			Usage: "number of dependencies to fetch and install concurrently",
+		},
 	}
 }
 
@@ -67,12 +74,17 @@ func packageEnsure(c *cli.Context) error {
 	noLock := c.Bool("no-lock")
 	lockOnly := c.Bool("lock-only")
 	useLockfile := !noLock
+	jobs := c.Int("jobs")
+	if jobs < 1 {
+		return errors.New("--jobs must be at least 1")
+	}
 
 	// Create package context
 	pcx, _, err := loadPackageContext(c, dir, false)
 	if err != nil {
 		return errors.Wrap(err, "failed to create package context")
 	}
+	pcx.Jobs = jobs
 
 	state, err := getCommandState(c)
 	if err != nil {
@@ -0,0 +1,140 @@
+package commands
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"time"
+
+	"github.com/jedib0t/go-pretty/v6/table"
+	"github.com/pkg/errors"
+	"gopkg.in/urfave/cli.v1"
+
+	"github.com/Southclaws/sampctl/src/pkg/infrastructure/fs"
+	"github.com/Southclaws/sampctl/src/pkg/infrastructure/print"
+	"github.com/Southclaws/sampctl/src/pkg/package/pkgcontext"
+)
+
+type outdatedCommandTarget interface {
+	pkgcontext.LockfileInitializer
+	OutdatedDependencies(ctx context.Context) ([]pkgcontext.OutdatedDependency, error)
+}
+
+type outdatedCommandOptions struct {
+	json     bool
+	exitCode bool
+}
+
+func packageOutdatedFlags() []cli.Flag {
+	return []cli.Flag{
+		cli.StringFlag{
+			Name:  "dir",
+			Value: ".",
+			Usage: "working directory for the project - by default, uses the current directory",
+		},
+		cli.BoolFlag{
+			Name:  "json",
+			Usage: "print the report as JSON",
+		},
+		cli.BoolFlag{
+			Name:  "exit-code",
+			Usage: "exit with a non-zero status when any dependency is outdated, for use in CI",
+		},
+	}
+}
+
+func packageOutdated(c *cli.Context) error {
+	dir := fs.MustAbs(c.String("dir"))
+
+	pcx, _, err := loadPackageContext(c, dir, false)
+	if err != nil {
+		return errors.Wrap(err, "failed to interpret directory as Pawn package")
+	}
+
+	if err := initLockfileResolver(c, pcx); err != nil {
+		return errors.Wrap(err, "failed to initialize lockfile resolver")
+	}
+
+	ctx, cancel := newCommandTimeoutContext(5 * time.Minute)
+	defer cancel()
+
+	return runPackageOutdated(ctx, pcx, os.Stdout, outdatedCommandOptions{
+		json:     c.Bool("json"),
+		exitCode: c.Bool("exit-code"),
+	})
+}
+
+func runPackageOutdated(ctx context.Context, target outdatedCommandTarget, w io.Writer, opts outdatedCommandOptions) error {
+	outdated, err := target.OutdatedDependencies(ctx)
+	if err != nil {
+		return errors.Wrap(err, "failed to check dependencies for newer versions")
+	}
+
+	if opts.json {
+		err = writeOutdatedJSON(w, outdated)
+	} else {
+		err = writeOutdatedTable(w, outdated)
+	}
+	if err != nil {
+		return err
+	}
+
+	count := 0
+	for _, dep := range outdated {
+		if dep.Error != "" {
+			print.Warn(dep.Key, "could not be checked:", dep.Error)
+		}
+		if dep.Outdated {
+			count++
+		}
+	}
+
+	if opts.exitCode && count > 0 {
+		return cli.NewExitError(fmt.Sprintf("%d of %d dependencies are outdated", count, len(outdated)), 1)
+	}
+	return nil
+}
+
+func writeOutdatedJSON(w io.Writer, outdated []pkgcontext.OutdatedDependency) error {
+	if outdated == nil {
+		outdated = []pkgcontext.OutdatedDependency{}
+	}
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "\t")
+	return encoder.Encode(outdated)
+}
+
+func writeOutdatedTable(w io.Writer, outdated []pkgcontext.OutdatedDependency) error {
+	if len(outdated) == 0 {
+		_, err := fmt.Fprintln(w, "no locked dependencies")
+		return err
+	}
+
+	t := table.NewWriter()
+	t.SetOutputMirror(w)
+	t.AppendHeader(table.Row{"Dependency", "Constraint", "Current", "Wanted", "Latest"})
+
+	for _, dep := range outdated {
+		name := dep.Key
+		if dep.Outdated {
+			name += " *"
+		}
+		t.AppendRow(table.Row{name, dep.Constraint, dep.Current, outdatedColumn(dep, dep.Wanted), outdatedColumn(dep, dep.Latest)})
+	}
+
+	t.Render()
+	return nil
+}
+
+func outdatedColumn(dep pkgcontext.OutdatedDependency, value string) string {
+	switch {
+	case dep.Error != "":
+		return "?"
+	case value == "":
+		return "-"
+	default:
+		return value
+	}
+}
@@ -0,0 +1,78 @@
+package commands
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gopkg.in/urfave/cli.v1"
+
+	"github.com/Southclaws/sampctl/src/pkg/package/pkgcontext"
+)
+
+type fakeOutdatedTarget struct {
+	outdated []pkgcontext.OutdatedDependency
+	err      error
+}
+
+func (f *fakeOutdatedTarget) InitLockfileResolver(string) error { return nil }
+
+func (f *fakeOutdatedTarget) OutdatedDependencies(context.Context) ([]pkgcontext.OutdatedDependency, error) {
+	return f.outdated, f.err
+}
+
+func testOutdatedDependencies() []pkgcontext.OutdatedDependency {
+	return []pkgcontext.OutdatedDependency{
+		{Key: "github.com/user/lib-a", Constraint: ":1.x", Current: "1.0.0", Wanted: "1.2.0", Latest: "2.0.0", Source: "cache", Outdated: true},
+		{Key: "github.com/user/lib-b", Constraint: ":2.0.0", Current: "2.0.0", Wanted: "2.0.0", Latest: "2.0.0", Source: "cache"},
+		{Key: "github.com/user/lib-c", Constraint: "@main", Current: "abcdef01", Error: "dependency is not cached"},
+	}
+}
+
+func TestWriteOutdatedTable(t *testing.T) {
+	t.Parallel()
+
+	var out bytes.Buffer
+	require.NoError(t, writeOutdatedTable(&out, testOutdatedDependencies()))
+
+	table := out.String()
+	assert.Contains(t, table, "DEPENDENCY")
+	assert.Contains(t, table, "WANTED")
+	assert.Contains(t, table, "github.com/user/lib-a *")
+	assert.Contains(t, table, "1.2.0")
+	assert.NotContains(t, table, "github.com/user/lib-b *")
+	assert.Contains(t, table, "?")
+}
+
+func TestRunPackageOutdatedJSON(t *testing.T) {
+	t.Parallel()
+
+	var out bytes.Buffer
+	target := &fakeOutdatedTarget{outdated: testOutdatedDependencies()}
+	require.NoError(t, runPackageOutdated(context.Background(), target, &out, outdatedCommandOptions{json: true}))
+
+	var decoded []pkgcontext.OutdatedDependency
+	require.NoError(t, json.Unmarshal(out.Bytes(), &decoded))
+	assert.Equal(t, testOutdatedDependencies(), decoded)
+}
+
+func TestRunPackageOutdatedExitCode(t *testing.T) {
+	t.Parallel()
+
+	var out bytes.Buffer
+	target := &fakeOutdatedTarget{outdated: testOutdatedDependencies()}
+	err := runPackageOutdated(context.Background(), target, &out, outdatedCommandOptions{exitCode: true})
+	require.Error(t, err)
+
+	exitErr, ok := err.(*cli.ExitError)
+	require.True(t, ok)
+	assert.Equal(t, 1, exitErr.ExitCode())
+	assert.Equal(t, "1 of 3 dependencies are outdated", exitErr.Error())
+
+	target.outdated = testOutdatedDependencies()[1:]
+	out.Reset()
+	assert.NoError(t, runPackageOutdated(context.Background(), target, &out, outdatedCommandOptions{exitCode: true}))
+}
@@ -50,6 +50,22 @@ func packageRunFlags() []cli.Flag {
 			Name:  "relativePaths",
 			Usage: "force compiler output to use relative paths instead of absolute",
 		},
+		cli.BoolFlag{
+			Name:  "skip-native-check",
+			Usage: "starts the server without checking that every native the script calls is declared outside of the package",
+		},
+		cli.StringFlag{
+			Name:  "ready-file",
+			Usage: "file to write once the server accepts players, it is removed when the server stops",
+		},
+		cli.StringFlag{
+			Name:  "on-ready",
+			Usage: "shell command to run once the server accepts players, with SAMPCTL_READY_ADDRESS set to its address",
+		},
+		cli.StringFlag{
+			Name:  "restart-log",
+			Usage: "file to append a line of JSON to whenever the runtime's restart policy restarts the server or gives up",
+		},
 	}
 }
 
@@ -81,6 +97,14 @@ func packageRun(c *cli.Context) error {
 	pcx.NoCache = noCache
 	pcx.BuildFile = buildFile
 	pcx.Relative = relativePaths
+	pcx.SkipNativeCheck = c.Bool("skip-native-check")
+	if readyFile := c.String("ready-file"); readyFile != "" {
+		pcx.ReadyFile = fs.MustAbs(readyFile)
+	}
+	pcx.OnReady = c.String("on-ready")
+	if restartLog := c.String("restart-log"); restartLog != "" {
+		pcx.RestartLog = fs.MustAbs(restartLog)
+	}
 
 	ctx, cancel := newCommandContext()
 	defer cancel()
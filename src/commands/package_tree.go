@@ -0,0 +1,295 @@
+package commands
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/pkg/errors"
+	"gopkg.in/urfave/cli.v1"
+
+	"github.com/Southclaws/sampctl/src/pkg/infrastructure/fs"
+	"github.com/Southclaws/sampctl/src/pkg/infrastructure/versioning"
+	"github.com/Southclaws/sampctl/src/pkg/package/pkgcontext"
+)
+
+type dependencyGraphSource interface {
+	pkgcontext.LockfileInitializer
+	DependencyGraph() (pkgcontext.DependencyGraph, error)
+}
+
+func packageTreeFlags() []cli.Flag {
+	return []cli.Flag{
+		cli.StringFlag{
+			Name:  "dir",
+			Value: ".",
+			Usage: "working directory for the project - by default, uses the current directory",
+		},
+		cli.StringFlag{
+			Name:  "format",
+			Value: "text",
+			Usage: "output format, one of `text`, `json` or `dot`",
+		},
+	}
+}
+
+func packageWhyFlags() []cli.Flag {
+	return []cli.Flag{
+		cli.StringFlag{
+			Name:  "dir",
+			Value: ".",
+			Usage: "working directory for the project - by default, uses the current directory",
+		},
+		cli.StringFlag{
+			Name:  "format",
+			Value: "text",
+			Usage: "output format, one of `text` or `json`",
+		},
+	}
+}
+
+func packageTree(c *cli.Context) error {
+	format := c.String("format")
+	if format != "text" && format != "json" && format != "dot" {
+		return errors.Errorf("unsupported format %q, must be one of text, json or dot", format)
+	}
+
+	graph, err := loadDependencyGraph(c)
+	if err != nil {
+		return err
+	}
+
+	switch format {
+	case "json":
+		return writeDependencyJSON(os.Stdout, graph)
+	case "dot":
+		return writeDependencyDOT(os.Stdout, graph)
+	default:
+		return writeDependencyTree(os.Stdout, graph)
+	}
+}
+
+func packageWhy(c *cli.Context) error {
+	format := c.String("format")
+	if format != "text" && format != "json" {
+		return errors.Errorf("unsupported format %q, must be one of text or json", format)
+	}
+	if len(c.Args()) != 1 {
+		return cli.NewExitError("why requires exactly one dependency argument", 1)
+	}
+
+	graph, err := loadDependencyGraph(c)
+	if err != nil {
+		return err
+	}
+
+	query := c.Args().First()
+	keys := graph.Find(query)
+	switch len(keys) {
+	case 0:
+		return cli.NewExitError(fmt.Sprintf("%s is not a dependency of %s", query, graph.Root), 1)
+	case 1:
+	default:
+		return cli.NewExitError(fmt.Sprintf("%s is ambiguous, matches: %s", query, strings.Join(keys, ", ")), 1)
+	}
+
+	if format == "json" {
+		return writeDependencyWhyJSON(os.Stdout, graph, keys[0])
+	}
+	return writeDependencyWhy(os.Stdout, graph, keys[0])
+}
+
+func loadDependencyGraph(c *cli.Context) (pkgcontext.DependencyGraph, error) {
+	dir := fs.MustAbs(c.String("dir"))
+
+	pcx, _, err := loadPackageContext(c, dir, false)
+	if err != nil {
+		return pkgcontext.DependencyGraph{}, errors.Wrap(err, "failed to interpret directory as Pawn package")
+	}
+
+	return dependencyGraphFor(c, pcx)
+}
+
+func dependencyGraphFor(c *cli.Context, source dependencyGraphSource) (pkgcontext.DependencyGraph, error) {
+	if err := initLockfileResolver(c, source); err != nil {
+		return pkgcontext.DependencyGraph{}, errors.Wrap(err, "failed to initialize lockfile resolver")
+	}
+
+	graph, err := source.DependencyGraph()
+	if err != nil {
+		return pkgcontext.DependencyGraph{}, errors.Wrap(err, "failed to build dependency graph")
+	}
+	return graph, nil
+}
+
+func writeDependencyJSON(w io.Writer, graph pkgcontext.DependencyGraph) error {
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "\t")
+	return encoder.Encode(graph)
+}
+
+func writeDependencyTree(w io.Writer, graph pkgcontext.DependencyGraph) error {
+	if _, err := fmt.Fprintln(w, graph.Root); err != nil {
+		return err
+	}
+
+	expanded := make(map[string]bool)
+	var walk func(keys []string, parent string, prefix string, path map[string]bool) error
+	walk = func(keys []string, parent string, prefix string, path map[string]bool) error {
+		for i, key := range keys {
+			node, ok := graph.Node(key)
+			if !ok {
+				continue
+			}
+
+			branch, indent := "├── ", "│   "
+			if i == len(keys)-1 {
+				branch, indent = "└── ", "    "
+			}
+
+			line := prefix + branch + describeDependencyNode(node, parent)
+			repeated := expanded[key] && len(node.Requires) > 0
+			if path[key] {
+				line += " (cycle)"
+			} else if repeated {
+				line += " (*)"
+			}
+			if _, err := fmt.Fprintln(w, line); err != nil {
+				return err
+			}
+			if path[key] || repeated {
+				continue
+			}
+
+			expanded[key] = true
+			path[key] = true
+			if err := walk(node.Requires, key, prefix+indent, path); err != nil {
+				return err
+			}
+			delete(path, key)
+		}
+		return nil
+	}
+
+	direct := graph.Direct()
+	keys := make([]string, 0, len(direct))
+	for _, node := range direct {
+		keys = append(keys, node.Key)
+	}
+
+	return walk(keys, graph.Root, "", make(map[string]bool))
+}
+
+func describeDependencyNode(node pkgcontext.DependencyGraphNode, parent string) string {
+	var b strings.Builder
+	b.WriteString(node.Key)
+
+	constraint := node.Constraint
+	if declared, ok := node.Constraints[parent]; ok {
+		constraint = declared
+	}
+	if constraint != "" {
+		b.WriteString(constraint)
+	}
+
+	if node.Resolved != "" || node.Commit != "" {
+		b.WriteString(" (")
+		b.WriteString(node.Resolved)
+		if node.Commit != "" {
+			if node.Resolved != "" {
+				b.WriteString(" ")
+			}
+			b.WriteString(versioning.ShortCommit(node.Commit))
+		}
+		b.WriteString(")")
+	}
+
+	switch {
+	case node.Locked && !node.Active:
+		b.WriteString(" [stale]")
+	case node.Active && !node.Locked:
+		b.WriteString(" [unlocked]")
+	}
+
+	return b.String()
+}
+
+func writeDependencyDOT(w io.Writer, graph pkgcontext.DependencyGraph) error {
+	var b strings.Builder
+	b.WriteString("digraph dependencies {\n")
+	fmt.Fprintf(&b, "\t%q [shape=box];\n", graph.Root)
+	for _, node := range graph.Nodes {
+		label := node.Key
+		if node.Resolved != "" {
+			label += "\\n" + node.Resolved
+		}
+		fmt.Fprintf(&b, "\t%q [label=\"%s\"];\n", node.Key, strings.ReplaceAll(label, `"`, `\"`))
+	}
+	for _, node := range graph.Direct() {
+		fmt.Fprintf(&b, "\t%q -> %q%s;\n", graph.Root, node.Key, dotEdgeLabel(node, graph.Root))
+	}
+	for _, node := range graph.Nodes {
+		for _, parent := range node.RequiredBy {
+			fmt.Fprintf(&b, "\t%q -> %q%s;\n", parent, node.Key, dotEdgeLabel(node, parent))
+		}
+	}
+	b.WriteString("}\n")
+
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+func dotEdgeLabel(node pkgcontext.DependencyGraphNode, parent string) string {
+	constraint := node.Constraint
+	if declared, ok := node.Constraints[parent]; ok {
+		constraint = declared
+	}
+	if constraint == "" {
+		return ""
+	}
+	return fmt.Sprintf(" [label=%q]", constraint)
+}
+
+func writeDependencyWhy(w io.Writer, graph pkgcontext.DependencyGraph, key string) error {
+	paths := graph.Why(key)
+	if len(paths) == 0 {
+		_, err := fmt.Fprintf(w, "%s is not reachable from any direct dependency of %s\n", key, graph.Root)
+		return err
+	}
+
+	if _, err := fmt.Fprintf(w, "%s is required by:\n", key); err != nil {
+		return err
+	}
+	for _, path := range paths {
+		steps := make([]string, 0, len(path)+1)
+		steps = append(steps, graph.Root)
+		for _, step := range path {
+			steps = append(steps, step.Key+step.Constraint)
+		}
+		if _, err := fmt.Fprintf(w, "  %s\n", strings.Join(steps, " -> ")); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func writeDependencyWhyJSON(w io.Writer, graph pkgcontext.DependencyGraph, key string) error {
+	paths := graph.Why(key)
+	if paths == nil {
+		paths = [][]pkgcontext.DependencyGraphStep{}
+	}
+
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "\t")
+	return encoder.Encode(struct {
+		Root   string                             `json:"root"`
+		Target string                             `json:"target"`
+		Paths  [][]pkgcontext.DependencyGraphStep `json:"paths"`
+	}{
+		Root:   graph.Root,
+		Target: key,
+		Paths:  paths,
+	})
+}
@@ -0,0 +1,91 @@
+package commands
+
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/Southclaws/sampctl/src/pkg/package/pkgcontext"
+)
+
+func testCommandDependencyGraph() pkgcontext.DependencyGraph {
+	return pkgcontext.DependencyGraph{
+		Root: "user/root",
+		Nodes: []pkgcontext.DependencyGraphNode{
+			{
+				Key: "github.com/user/lib-a", Constraint: ":1.0.0", Resolved: "1.0.0", Commit: "aaaaaaaaaaaa",
+				Direct: true, Locked: true, Active: true,
+				Requires:    []string{"github.com/user/lib-b"},
+				Constraints: map[string]string{"user/root": ":1.0.0"},
+			},
+			{
+				Key: "github.com/user/lib-b", Constraint: ":2.0.0", Resolved: "2.0.0", Commit: "bbbbbbbbbbbb",
+				Locked: true, Active: true,
+				RequiredBy:  []string{"github.com/user/lib-a", "github.com/user/lib-c"},
+				Constraints: map[string]string{"github.com/user/lib-a": ":2.0.0", "github.com/user/lib-c": ":2.1.0"},
+			},
+			{
+				Key: "github.com/user/lib-c", Direct: true, Active: true,
+				Requires: []string{"github.com/user/lib-b"},
+			},
+		},
+	}
+}
+
+func TestWriteDependencyTree(t *testing.T) {
+	t.Parallel()
+
+	var out bytes.Buffer
+	require.NoError(t, writeDependencyTree(&out, testCommandDependencyGraph()))
+
+	assert.Equal(t, "user/root\n"+
+		"├── github.com/user/lib-a:1.0.0 (1.0.0 aaaaaaaa)\n"+
+		"│   └── github.com/user/lib-b:2.0.0 (2.0.0 bbbbbbbb)\n"+
+		"└── github.com/user/lib-c [unlocked]\n"+
+		"    └── github.com/user/lib-b:2.1.0 (2.0.0 bbbbbbbb)\n", out.String())
+}
+
+func TestWriteDependencyDOT(t *testing.T) {
+	t.Parallel()
+
+	var out bytes.Buffer
+	require.NoError(t, writeDependencyDOT(&out, testCommandDependencyGraph()))
+
+	dot := out.String()
+	assert.Contains(t, dot, "digraph dependencies {\n")
+	assert.Contains(t, dot, "\t\"user/root\" -> \"github.com/user/lib-a\" [label=\":1.0.0\"];\n")
+	assert.Contains(t, dot, "\t\"github.com/user/lib-c\" -> \"github.com/user/lib-b\" [label=\":2.1.0\"];\n")
+	assert.Contains(t, dot, "\t\"user/root\" -> \"github.com/user/lib-c\";\n")
+}
+
+func TestWriteDependencyWhy(t *testing.T) {
+	t.Parallel()
+
+	var out bytes.Buffer
+	require.NoError(t, writeDependencyWhy(&out, testCommandDependencyGraph(), "github.com/user/lib-b"))
+
+	assert.Equal(t, "github.com/user/lib-b is required by:\n"+
+		"  user/root -> github.com/user/lib-a:1.0.0 -> github.com/user/lib-b:2.0.0\n"+
+		"  user/root -> github.com/user/lib-c -> github.com/user/lib-b:2.1.0\n", out.String())
+}
+
+func TestWriteDependencyWhyJSON(t *testing.T) {
+	t.Parallel()
+
+	var out bytes.Buffer
+	require.NoError(t, writeDependencyWhyJSON(&out, testCommandDependencyGraph(), "github.com/user/lib-b"))
+
+	var decoded struct {
+		Root   string                             `json:"root"`
+		Target string                             `json:"target"`
+		Paths  [][]pkgcontext.DependencyGraphStep `json:"paths"`
+	}
+	require.NoError(t, json.Unmarshal(out.Bytes(), &decoded))
+	assert.Equal(t, "user/root", decoded.Root)
+	assert.Equal(t, "github.com/user/lib-b", decoded.Target)
+	require.Len(t, decoded.Paths, 2)
+	assert.Equal(t, "github.com/user/lib-a", decoded.Paths[0][0].Key)
+}
@@ -0,0 +1,130 @@
+package commands
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"time"
+
+	"github.com/jedib0t/go-pretty/v6/table"
+	"github.com/pkg/errors"
+	"gopkg.in/urfave/cli.v1"
+
+	"github.com/Southclaws/sampctl/src/pkg/infrastructure/fs"
+	"github.com/Southclaws/sampctl/src/pkg/infrastructure/print"
+	"github.com/Southclaws/sampctl/src/pkg/infrastructure/sys/gitcheck"
+	"github.com/Southclaws/sampctl/src/pkg/infrastructure/versioning"
+	"github.com/Southclaws/sampctl/src/pkg/package/pkgcontext"
+)
+
+type updateCommandTarget interface {
+	pkgcontext.LockfileInitializer
+	pkgcontext.LockfileController
+	UpdateLockedDependencies(ctx context.Context, targets []versioning.DependencyMeta) ([]pkgcontext.LockedDependencyUpdate, error)
+}
+
+func packageUpdateFlags() []cli.Flag {
+	return []cli.Flag{
+		cli.StringFlag{
+			Name:  "dir",
+			Value: ".",
+			Usage: "working directory for the project - by default, uses the current directory",
+		},
+	}
+}
+
+func packageUpdate(c *cli.Context) error {
+	if err := gitcheck.RequireInstalled(); err != nil {
+		return err
+	}
+
+	if len(c.Args()) == 0 {
+		return cli.NewExitError("update requires at least one dependency argument", 1)
+	}
+
+	targets := make([]versioning.DependencyMeta, 0, len(c.Args()))
+	for _, arg := range c.Args() {
+		meta, err := versioning.DependencyString(arg).Explode()
+		if err != nil {
+			return errors.Wrapf(err, "failed to parse dependency selector %s", arg)
+		}
+		targets = append(targets, meta)
+	}
+
+	dir := fs.MustAbs(c.String("dir"))
+	pcx, _, err := loadPackageContext(c, dir, false)
+	if err != nil {
+		return errors.Wrap(err, "failed to interpret directory as Pawn package")
+	}
+
+	if err := initLockfileResolver(c, pcx); err != nil {
+		return errors.Wrap(err, "failed to initialize lockfile resolver")
+	}
+
+	ctx, cancel := newCommandTimeoutContext(time.Hour)
+	defer cancel()
+
+	return runPackageUpdate(ctx, pcx, os.Stdout, targets)
+}
+
+func runPackageUpdate(ctx context.Context, target updateCommandTarget, w io.Writer, targets []versioning.DependencyMeta) error {
+	updates, err := target.UpdateLockedDependencies(ctx, targets)
+	if err != nil {
+		return errors.Wrap(err, "failed to update dependencies")
+	}
+
+	if err := saveCommandLockfile(target); err != nil {
+		return errors.Wrap(err, "failed to save lockfile")
+	}
+
+	if err := writeDependencyUpdates(w, updates); err != nil {
+		return err
+	}
+
+	for _, update := range updates {
+		if update.Changed() {
+			print.Info("lockfile updated, run `sampctl ensure` to install the new versions")
+			break
+		}
+	}
+	return nil
+}
+
+func writeDependencyUpdates(w io.Writer, updates []pkgcontext.LockedDependencyUpdate) error {
+	if len(updates) == 0 {
+		_, err := fmt.Fprintln(w, "no dependencies updated")
+		return err
+	}
+
+	t := table.NewWriter()
+	t.SetOutputMirror(w)
+	t.AppendHeader(table.Row{"Dependency", "Constraint", "Before", "After"})
+
+	for _, update := range updates {
+		after := describeLockedVersion(update.AfterTag, update.AfterCommit)
+		if !update.Changed() {
+			after = "(unchanged)"
+		}
+		t.AppendRow(table.Row{
+			update.Key,
+			update.Constraint,
+			describeLockedVersion(update.BeforeTag, update.BeforeCommit),
+			after,
+		})
+	}
+
+	t.Render()
+	return nil
+}
+
+func describeLockedVersion(tag, commit string) string {
+	switch {
+	case commit == "":
+		return "-"
+	case tag == "":
+		return versioning.ShortCommit(commit)
+	default:
+		return tag + " (" + versioning.ShortCommit(commit) + ")"
+	}
+}
@@ -0,0 +1,77 @@
+package commands
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/Southclaws/sampctl/src/pkg/infrastructure/versioning"
+	"github.com/Southclaws/sampctl/src/pkg/package/pkgcontext"
+)
+
+type fakeUpdateCommandTarget struct {
+	fakeCommandLockfile
+	targets []versioning.DependencyMeta
+	updates []pkgcontext.LockedDependencyUpdate
+	err     error
+}
+
+func (f *fakeUpdateCommandTarget) UpdateLockedDependencies(_ context.Context, targets []versioning.DependencyMeta) ([]pkgcontext.LockedDependencyUpdate, error) {
+	f.targets = targets
+	return f.updates, f.err
+}
+
+func TestRunPackageUpdateWritesBeforeAfterTable(t *testing.T) {
+	t.Parallel()
+
+	target := &fakeUpdateCommandTarget{
+		updates: []pkgcontext.LockedDependencyUpdate{
+			{
+				Key: "github.com/user/lib-a", Constraint: ":1.x",
+				BeforeTag: "1.0.0", BeforeCommit: "aaaaaaaaaaaaaaaa",
+				AfterTag: "1.2.0", AfterCommit: "bbbbbbbbbbbbbbbb",
+			},
+			{
+				Key: "github.com/user/lib-b", Constraint: ":2.0.0",
+				BeforeTag: "2.0.0", BeforeCommit: "cccccccccccccccc",
+				AfterTag: "2.0.0", AfterCommit: "cccccccccccccccc",
+			},
+		},
+	}
+
+	var out bytes.Buffer
+	targets := []versioning.DependencyMeta{{User: "user", Repo: "lib-a"}, {User: "user", Repo: "lib-b"}}
+	require.NoError(t, runPackageUpdate(context.Background(), target, &out, targets))
+
+	assert.Equal(t, targets, target.targets)
+	assert.True(t, target.saved)
+
+	table := out.String()
+	assert.Contains(t, table, "1.0.0 (aaaaaaaa)")
+	assert.Contains(t, table, "1.2.0 (bbbbbbbb)")
+	assert.Contains(t, table, "(unchanged)")
+}
+
+func TestRunPackageUpdateDoesNotSaveOnFailure(t *testing.T) {
+	t.Parallel()
+
+	target := &fakeUpdateCommandTarget{err: errors.New("boom")}
+
+	var out bytes.Buffer
+	err := runPackageUpdate(context.Background(), target, &out, []versioning.DependencyMeta{{User: "user", Repo: "lib-a"}})
+	require.Error(t, err)
+	assert.False(t, target.saved)
+	assert.Empty(t, out.String())
+}
+
+func TestDescribeLockedVersion(t *testing.T) {
+	t.Parallel()
+
+	assert.Equal(t, "-", describeLockedVersion("", ""))
+	assert.Equal(t, "abcdef01", describeLockedVersion("", "abcdef0123"))
+	assert.Equal(t, "1.0.0 (abcdef01)", describeLockedVersion("1.0.0", "abcdef0123"))
+}
@@ -0,0 +1,190 @@
+package commands
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"time"
+
+	"github.com/jedib0t/go-pretty/v6/table"
+	"github.com/pkg/errors"
+	"gopkg.in/urfave/cli.v1"
+
+	"github.com/Southclaws/sampctl/src/pkg/infrastructure/fs"
+	"github.com/Southclaws/sampctl/src/pkg/infrastructure/print"
+	"github.com/Southclaws/sampctl/src/pkg/package/pawnpackage"
+	run "github.com/Southclaws/sampctl/src/pkg/runtime/config"
+	"github.com/Southclaws/sampctl/src/pkg/runtime/query"
+)
+
+func queryFlags() []cli.Flag {
+	return []cli.Flag{
+		cli.StringFlag{
+			Name:  "dir",
+			Value: ".",
+			Usage: "package directory whose runtime port and bind address are queried when no address is given",
+		},
+		cli.StringFlag{
+			Name:  "runtime",
+			Usage: "runtime configuration whose port and bind address are queried when no address is given",
+		},
+		cli.DurationFlag{
+			Name:  "timeout",
+			Value: query.DefaultTimeout,
+			Usage: "how long to wait for each response before sending the query again",
+		},
+		cli.StringFlag{
+			Name:  "format",
+			Value: "text",
+			Usage: "output format, one of `text` or `json`",
+		},
+	}
+}
+
+func queryServer(c *cli.Context) error {
+	applyVerboseFlag(c)
+
+	format := c.String("format")
+	if format != "text" && format != "json" {
+		return errors.Errorf("unsupported format %q, must be one of text or json", format)
+	}
+	if len(c.Args()) > 1 {
+		return cli.NewExitError("query accepts at most one address argument", 1)
+	}
+
+	address := c.Args().First()
+	if address == "" {
+		cfg, err := packageRuntime(fs.MustAbs(c.String("dir")), c.String("runtime"))
+		if err != nil {
+			return cli.NewExitError(err.Error(), 1)
+		}
+		address = cfg.LocalAddress()
+	}
+
+	client, err := query.Dial(address)
+	if err != nil {
+		return cli.NewExitError(err.Error(), 1)
+	}
+	defer client.Close() // nolint
+	client.Timeout = c.Duration("timeout")
+
+	ctx, cancel := newCommandContext()
+	defer cancel()
+
+	if err = runQuery(ctx, client, os.Stdout, address, format); err != nil {
+		return cli.NewExitError(err.Error(), 1)
+	}
+	return nil
+}
+
+// packageRuntime returns the runtime configuration a server is started from by `run` in a package
+// directory, directories without a package use the default runtime
+func packageRuntime(dir, runtimeName string) (run.Runtime, error) {
+	pkg, err := pawnpackage.PackageFromDir(dir)
+	if err != nil {
+		return run.Runtime{}, errors.Wrap(err, "failed to read package")
+	}
+	return pkg.GetRuntimeConfig(runtimeName)
+}
+
+type queryTarget interface {
+	Info(ctx context.Context) (query.Info, error)
+	Rules(ctx context.Context) ([]query.Rule, error)
+	Clients(ctx context.Context) ([]query.Player, error)
+	Players(ctx context.Context) ([]query.Player, error)
+	Ping(ctx context.Context) (time.Duration, error)
+}
+
+// queryResult is the JSON document written by `query`
+type queryResult struct {
+	Address string         `json:"address"`
+	PingMS  float64        `json:"ping_ms"`
+	Info    query.Info     `json:"info"`
+	Rules   []query.Rule   `json:"rules"`
+	Players []query.Player `json:"players"`
+}
+
+// runQuery queries a server for everything it reports to the server browser. The command fails
+// when the server does not answer, rules and players are optional since servers may not answer
+// those queries.
+func runQuery(ctx context.Context, target queryTarget, w io.Writer, address, format string) error {
+	result := queryResult{Address: address}
+
+	ping, err := target.Ping(ctx)
+	if err != nil {
+		return errors.Wrap(err, "server did not answer")
+	}
+	result.PingMS = float64(ping.Microseconds()) / 1000
+
+	if result.Info, err = target.Info(ctx); err != nil {
+		return errors.Wrap(err, "failed to query server info")
+	}
+	if result.Rules, err = target.Rules(ctx); err != nil {
+		print.Warn("failed to query server rules:", err)
+	}
+	if result.Info.Players > 0 {
+		if result.Players, err = target.Players(ctx); err != nil {
+			// servers with more than 100 players or with detailed queries disabled
+			print.Verb("detailed player query failed:", err)
+			if result.Players, err = target.Clients(ctx); err != nil {
+				print.Warn("failed to query players, servers with more than 100 players do not list them:", err)
+			}
+		}
+	}
+
+	if format == "json" {
+		encoder := json.NewEncoder(w)
+		encoder.SetIndent("", "\t")
+		return encoder.Encode(result)
+	}
+	return writeQueryResult(w, result)
+}
+
+func writeQueryResult(w io.Writer, result queryResult) error {
+	if _, err := fmt.Fprintf(w, "%s answered in %.1fms\n", result.Address, result.PingMS); err != nil {
+		return err
+	}
+
+	password := "no"
+	if result.Info.Password {
+		password = "yes"
+	}
+	t := table.NewWriter()
+	t.SetOutputMirror(w)
+	t.AppendRows([]table.Row{
+		{"Hostname", result.Info.Hostname},
+		{"Gamemode", result.Info.Gamemode},
+		{"Language", result.Info.Language},
+		{"Players", fmt.Sprintf("%d/%d", result.Info.Players, result.Info.MaxPlayers)},
+		{"Password", password},
+	})
+	t.Render()
+
+	if len(result.Rules) > 0 {
+		t = table.NewWriter()
+		t.SetOutputMirror(w)
+		t.AppendHeader(table.Row{"Rule", "Value"})
+		for _, rule := range result.Rules {
+			t.AppendRow(table.Row{rule.Name, rule.Value})
+		}
+		t.Render()
+	}
+
+	if len(result.Players) > 0 {
+		t = table.NewWriter()
+		t.SetOutputMirror(w)
+		t.AppendHeader(table.Row{"ID", "Name", "Score", "Ping"})
+		for _, player := range result.Players {
+			id, ping := "-", "-"
+			if player.ID >= 0 {
+				id = fmt.Sprint(player.ID)
+				ping = fmt.Sprint(player.Ping)
+			}
+			t.AppendRow(table.Row{id, player.Name, player.Score, ping})
+		}
+		t.Render()
+	}
+	return nil
+}
@@ -0,0 +1,116 @@
+package commands
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/Southclaws/sampctl/src/pkg/infrastructure/print"
+	"github.com/Southclaws/sampctl/src/pkg/runtime/query"
+)
+
+type fakeQueryTarget struct {
+	info       query.Info
+	infoErr    error
+	rules      []query.Rule
+	players    []query.Player
+	playersErr error
+	clients    []query.Player
+}
+
+func (f *fakeQueryTarget) Info(context.Context) (query.Info, error) { return f.info, f.infoErr }
+
+func (f *fakeQueryTarget) Rules(context.Context) ([]query.Rule, error) { return f.rules, nil }
+
+func (f *fakeQueryTarget) Clients(context.Context) ([]query.Player, error) { return f.clients, nil }
+
+func (f *fakeQueryTarget) Players(context.Context) ([]query.Player, error) {
+	return f.players, f.playersErr
+}
+
+func (f *fakeQueryTarget) Ping(context.Context) (time.Duration, error) {
+	return 1500 * time.Microsecond, nil
+}
+
+func TestRunQueryWritesTables(t *testing.T) {
+	target := &fakeQueryTarget{
+		info:    query.Info{Players: 1, MaxPlayers: 50, Hostname: "Test Server", Gamemode: "Freeroam", Language: "English"},
+		rules:   []query.Rule{{Name: "version", Value: "omp 1.4.0"}},
+		players: []query.Player{{ID: 3, Name: "Southclaws", Score: 10, Ping: 35}},
+	}
+	var out bytes.Buffer
+
+	require.NoError(t, runQuery(context.Background(), target, &out, "127.0.0.1:7777", "text"))
+	assert.Contains(t, out.String(), "127.0.0.1:7777 answered in 1.5ms\n")
+	assert.Contains(t, out.String(), "| Hostname | Test Server |")
+	assert.Contains(t, out.String(), "| Players  | 1/50        |")
+	assert.Contains(t, out.String(), "| version | omp 1.4.0 |")
+	assert.Contains(t, out.String(), "| 3  | Southclaws |    10 | 35   |")
+}
+
+func TestRunQueryFallsBackToClients(t *testing.T) {
+	var logs bytes.Buffer
+	print.SetOutput(&logs)
+	defer print.SetOutput(nil)
+
+	target := &fakeQueryTarget{
+		info:       query.Info{Players: 1, MaxPlayers: 50},
+		playersErr: errors.New("no response"),
+		clients:    []query.Player{{ID: -1, Name: "Southclaws", Score: 10}},
+	}
+	var out bytes.Buffer
+
+	require.NoError(t, runQuery(context.Background(), target, &out, "127.0.0.1:7777", "json"))
+
+	var result queryResult
+	require.NoError(t, json.Unmarshal(out.Bytes(), &result))
+	assert.Equal(t, 1.5, result.PingMS)
+	assert.Equal(t, target.clients, result.Players)
+}
+
+func TestRunQueryFailsWhenInfoFails(t *testing.T) {
+	target := &fakeQueryTarget{infoErr: errors.New("no response")}
+
+	err := runQuery(context.Background(), target, &bytes.Buffer{}, "127.0.0.1:7777", "text")
+	assert.EqualError(t, err, "failed to query server info: no response")
+}
+
+func TestPackageRuntime(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "pawn.json"), []byte(`{
+		"entry": "gamemodes/main.pwn",
+		"output": "gamemodes/main.amx",
+		"runtimes": [
+			{"name": "main", "port": 7778, "rcon_password": "secret", "rcon": true},
+			{"name": "test", "port": 7790, "bind": "10.0.0.5"}
+		]
+	}`), 0o644))
+
+	cfg, err := packageRuntime(dir, "")
+	require.NoError(t, err)
+	assert.Equal(t, "127.0.0.1:7778", cfg.LocalAddress())
+	password, enabled := cfg.RemoteConsole()
+	assert.Equal(t, "secret", password)
+	assert.True(t, enabled)
+
+	cfg, err = packageRuntime(dir, "test")
+	require.NoError(t, err)
+	assert.Equal(t, "10.0.0.5:7790", cfg.LocalAddress())
+
+	cfg, err = packageRuntime(t.TempDir(), "")
+	require.NoError(t, err)
+	assert.Equal(t, "127.0.0.1:7777", cfg.LocalAddress(), "directories without a package use the default port")
+	password, enabled = cfg.RemoteConsole()
+	assert.Equal(t, "password", password)
+	assert.False(t, enabled)
+}
@@ -0,0 +1,159 @@
+package commands
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/pkg/errors"
+	"gopkg.in/urfave/cli.v1"
+
+	"github.com/Southclaws/sampctl/src/pkg/infrastructure/fs"
+	"github.com/Southclaws/sampctl/src/pkg/infrastructure/print"
+	"github.com/Southclaws/sampctl/src/pkg/runtime/query"
+)
+
+func rconFlags() []cli.Flag {
+	return []cli.Flag{
+		cli.StringFlag{
+			Name:  "dir",
+			Value: ".",
+			Usage: "package directory whose runtime address and RCON password are used",
+		},
+		cli.StringFlag{
+			Name:  "runtime",
+			Usage: "runtime configuration whose address and RCON password are used",
+		},
+		cli.StringFlag{
+			Name:  "address",
+			Usage: "host:port of the server instead of the runtime's port and bind address",
+		},
+		cli.StringFlag{
+			Name:   "password",
+			EnvVar: "SAMPCTL_RCON_PASSWORD",
+			Usage:  "RCON password instead of the runtime's rcon_password",
+		},
+		cli.DurationFlag{
+			Name:  "timeout",
+			Value: query.DefaultTimeout,
+			Usage: "how long to wait for the server to answer a command",
+		},
+	}
+}
+
+func rconCommand(c *cli.Context) error {
+	applyVerboseFlag(c)
+
+	cfg, err := packageRuntime(fs.MustAbs(c.String("dir")), c.String("runtime"))
+	if err != nil {
+		return cli.NewExitError(err.Error(), 1)
+	}
+	password, enabled := cfg.RemoteConsole()
+	if c.String("password") != "" {
+		password = c.String("password")
+	} else if !enabled {
+		print.Warn("remote RCON is not enabled in the runtime configuration, set \"rcon\": true (or \"rcon_config\": {\"enable\": true} for open.mp) or the server will ignore commands")
+	}
+	address := c.String("address")
+	if address == "" {
+		address = cfg.LocalAddress()
+	}
+
+	client, err := query.Dial(address)
+	if err != nil {
+		return cli.NewExitError(err.Error(), 1)
+	}
+	defer client.Close() // nolint
+	client.Timeout = c.Duration("timeout")
+
+	ctx, cancel := newCommandContext()
+	defer cancel()
+
+	// a server that is down and a command without output look the same, so check the server answers
+	if _, err = client.Ping(ctx); err != nil {
+		return cli.NewExitError(errors.Wrapf(err, "server at %s did not answer", address).Error(), 1)
+	}
+
+	command := strings.Join(c.Args(), " ")
+	if err = runRCON(ctx, client, os.Stdin, os.Stdout, password, command, isTerminal(os.Stdin)); err != nil {
+		return cli.NewExitError(err.Error(), 1)
+	}
+	return nil
+}
+
+type rconTarget interface {
+	RCON(ctx context.Context, password, command string) ([]string, error)
+}
+
+// runRCON sends a single command and prints its output, without a command it reads commands from in
+// until it is closed. The prompt is only written when in is a terminal.
+func runRCON(ctx context.Context, target rconTarget, in io.Reader, w io.Writer, password, command string, prompt bool) error {
+	if command != "" {
+		return sendRCON(ctx, target, w, password, command)
+	}
+
+	// read in the background so an interrupt does not wait for the next line
+	lines := make(chan string)
+	done := make(chan struct{})
+	defer close(done)
+	go func() {
+		defer close(lines)
+		scanner := bufio.NewScanner(in)
+		for scanner.Scan() {
+			select {
+			case lines <- scanner.Text():
+			case <-done:
+				return
+			}
+		}
+	}()
+
+	for {
+		if prompt {
+			fmt.Fprint(w, "> ") // nolint
+		}
+		select {
+		case <-ctx.Done():
+			return nil
+		case line, ok := <-lines:
+			if !ok {
+				return nil
+			}
+			line = strings.TrimSpace(line)
+			if line == "" {
+				continue
+			}
+			if err := sendRCON(ctx, target, w, password, line); err != nil {
+				if errors.Is(err, query.ErrInvalidPassword) {
+					return err
+				}
+				print.Erro(err)
+			}
+		}
+	}
+}
+
+func sendRCON(ctx context.Context, target rconTarget, w io.Writer, password, command string) error {
+	output, err := target.RCON(ctx, password, command)
+	for _, line := range output {
+		if _, writeErr := fmt.Fprintln(w, line); writeErr != nil {
+			return writeErr
+		}
+	}
+	if err != nil {
+		return errors.Wrapf(err, "failed to run %q", command)
+	}
+	return nil
+}
+
+// isTerminal reports whether f is an interactive terminal rather than a pipe or a file
+func isTerminal(f *os.File) bool {
+	info, err := f.Stat()
+	if err != nil {
+		return false
+	}
+	return info.Mode()&os.ModeCharDevice != 0
+}
@@ -0,0 +1,68 @@
+package commands
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/Southclaws/sampctl/src/pkg/runtime/query"
+)
+
+type fakeRCONTarget struct {
+	password string
+	output   map[string][]string
+	commands []string
+}
+
+func (f *fakeRCONTarget) RCON(_ context.Context, password, command string) ([]string, error) {
+	if password != f.password {
+		return nil, query.ErrInvalidPassword
+	}
+	f.commands = append(f.commands, command)
+	if command == "unknown" {
+		return []string{"Unknown command or variable:", "  unknown"}, errors.New("read failed")
+	}
+	return f.output[command], nil
+}
+
+func TestRunRCONSendsOneCommand(t *testing.T) {
+	t.Parallel()
+
+	target := &fakeRCONTarget{password: "secret", output: map[string][]string{
+		"echo hello": {"hello"},
+	}}
+	var out bytes.Buffer
+	require.NoError(t, runRCON(context.Background(), target, strings.NewReader("gmx\n"), &out, "secret", "echo hello", false))
+	assert.Equal(t, []string{"echo hello"}, target.commands, "standard input is not read in one-shot mode")
+	assert.Equal(t, "hello\n", out.String())
+}
+
+func TestRunRCONReadsCommandsUntilEOF(t *testing.T) {
+	t.Parallel()
+
+	target := &fakeRCONTarget{password: "secret", output: map[string][]string{
+		"players": {"ID\tName\tPing\tIP"},
+	}}
+	var out bytes.Buffer
+	require.NoError(t, runRCON(context.Background(), target, strings.NewReader("players\n\n  reloadfs admin  \nunknown\ngmx\n"), &out, "secret", "", true))
+	assert.Equal(t, []string{"players", "reloadfs admin", "unknown", "gmx"}, target.commands, "failed commands do not stop the console")
+	assert.Equal(t, "> ID\tName\tPing\tIP\n> > > Unknown command or variable:\n  unknown\n> > ", out.String())
+}
+
+func TestRunRCONStopsOnInvalidPassword(t *testing.T) {
+	t.Parallel()
+
+	target := &fakeRCONTarget{password: "secret"}
+	var out bytes.Buffer
+	err := runRCON(context.Background(), target, strings.NewReader("gmx\nexit\n"), &out, "wrong", "", false)
+	assert.ErrorIs(t, err, query.ErrInvalidPassword)
+	assert.Empty(t, out.String())
+
+	err = runRCON(context.Background(), target, nil, &out, "wrong", "gmx", false)
+	assert.ErrorIs(t, err, query.ErrInvalidPassword)
+}
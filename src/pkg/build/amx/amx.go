@@ -0,0 +1,268 @@
+// Package amx reads the header and symbol tables of compiled Pawn scripts so artifacts can be
+// checked without loading them into a server.
+package amx
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"os"
+
+	"github.com/pkg/errors"
+
+	"github.com/Southclaws/sampctl/src/pkg/build"
+)
+
+// Magic numbers identify an AMX file and its cell size
+const (
+	Magic16 uint16 = 0xF1E2
+	Magic32 uint16 = 0xF1E0
+	Magic64 uint16 = 0xF1E1
+)
+
+// HeaderSize is the size of the fixed part of the header, the symbol tables follow it
+const HeaderSize = 56
+
+// nameTableVersion is the first file version that stores symbol names in a name table instead of
+// inside each table record
+const nameTableVersion = 7
+
+// Flags are the options a script was compiled with
+type Flags uint16
+
+// Flags set in the header by the compiler
+const (
+	FlagOverlay  Flags = 0x01
+	FlagDebug    Flags = 0x02
+	FlagCompact  Flags = 0x04
+	FlagSleep    Flags = 0x08
+	FlagNoChecks Flags = 0x10
+	FlagSysreqN  Flags = 0x800
+	FlagNtvReg   Flags = 0x1000
+	FlagJITC     Flags = 0x2000
+	FlagBrowse   Flags = 0x4000
+	FlagReloc    Flags = 0x8000
+)
+
+var flagNames = []struct {
+	flag Flags
+	name string
+}{
+	{FlagOverlay, "overlay"},
+	{FlagDebug, "debug"},
+	{FlagCompact, "compact"},
+	{FlagSleep, "sleep"},
+	{FlagNoChecks, "nochecks"},
+	{FlagSysreqN, "sysreq.n"},
+	{FlagNtvReg, "ntvreg"},
+	{FlagJITC, "jitc"},
+	{FlagBrowse, "browse"},
+	{FlagReloc, "reloc"},
+}
+
+// Names returns the names of the flags that are set
+func (f Flags) Names() []string {
+	names := []string{}
+	for _, known := range flagNames {
+		if f&known.flag != 0 {
+			names = append(names, known.name)
+		}
+	}
+	return names
+}
+
+// Header is the fixed header at the start of every AMX file, offsets are from the start of the
+// file
+type Header struct {
+	Size        int32  `json:"size"` // size of the file, which is smaller than the memory image when compact encoding is used
+	Magic       uint16 `json:"magic"`
+	FileVersion uint8  `json:"file_version"`
+	AMXVersion  uint8  `json:"amx_version"` // the minimum abstract machine version required
+	Flags       Flags  `json:"flags"`
+	DefSize     int16  `json:"defsize"` // the size of a single symbol table record
+	COD         int32  `json:"cod"`     // start of the code section
+	DAT         int32  `json:"dat"`     // start of the data section
+	HEA         int32  `json:"hea"`     // initial top of the heap
+	STP         int32  `json:"stp"`     // top of the stack
+	CIP         int32  `json:"cip"`     // address of main, -1 when there is none
+	Publics     int32  `json:"publics"`
+	Natives     int32  `json:"natives"`
+	Libraries   int32  `json:"libraries"`
+	PubVars     int32  `json:"pubvars"`
+	Tags        int32  `json:"tags"`
+	NameTable   int32  `json:"nametable"` // zero before file version 7
+}
+
+// Symbol is a single record of one of the symbol tables
+type Symbol struct {
+	Name string `json:"name"`
+	// Value is the code address of a public function, the data address of a public variable or
+	// the identifier of a tag, including its flag bits. Natives and libraries are resolved when
+	// the script is loaded so their value is usually zero.
+	Value uint32 `json:"value"`
+}
+
+// File is a parsed AMX header and its symbol tables
+type File struct {
+	Header    Header   `json:"header"`
+	CellSize  int      `json:"cell_size"` // in bits
+	Publics   []Symbol `json:"publics"`
+	Natives   []Symbol `json:"natives"`
+	Libraries []Symbol `json:"libraries"`
+	PubVars   []Symbol `json:"pubvars"`
+	Tags      []Symbol `json:"tags"`
+}
+
+// Result returns the sizes of the script in the same form the compiler reports them after a
+// build, the estimated stack usage is not stored in the file so it is always zero
+func (f File) Result() build.Result {
+	h := f.Header
+	return build.Result{
+		Header:    int(h.COD),
+		Code:      int(h.DAT - h.COD),
+		Data:      int(h.HEA - h.DAT),
+		StackHeap: int(h.STP - h.HEA),
+		Total:     int(h.STP),
+	}
+}
+
+// HasMain returns true if the script has a main function
+func (f File) HasMain() bool {
+	return f.Header.CIP >= 0
+}
+
+// Open reads and parses an AMX file
+func Open(path string) (File, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return File{}, errors.Wrap(err, "failed to read AMX file")
+	}
+	return Parse(data)
+}
+
+// Parse reads the header and symbol tables from the contents of an AMX file
+func Parse(data []byte) (File, error) {
+	if len(data) < HeaderSize {
+		return File{}, errors.Errorf("file is %d bytes, too small for an AMX header", len(data))
+	}
+
+	var h Header
+	if err := binary.Read(bytes.NewReader(data[:HeaderSize]), binary.LittleEndian, &h); err != nil {
+		return File{}, errors.Wrap(err, "failed to read AMX header")
+	}
+
+	// before file version 7 the field holds no offset, names are stored in the symbol records
+	if h.FileVersion < nameTableVersion {
+		h.NameTable = 0
+	}
+
+	f := File{Header: h}
+	switch h.Magic {
+	case Magic16:
+		f.CellSize = 16
+	case Magic32:
+		f.CellSize = 32
+	case Magic64:
+		f.CellSize = 64
+	default:
+		return File{}, errors.Errorf("invalid AMX magic 0x%04X", h.Magic)
+	}
+
+	if h.DefSize <= 0 {
+		return File{}, errors.Errorf("invalid symbol record size %d", h.DefSize)
+	}
+	tagsEnd := h.COD
+	if h.NameTable != 0 {
+		tagsEnd = h.NameTable
+	}
+
+	r := tableReader{data: data, header: h, cellSize: f.CellSize}
+	tables := []struct {
+		name       string
+		start, end int32
+		symbols    *[]Symbol
+	}{
+		{"publics", h.Publics, h.Natives, &f.Publics},
+		{"natives", h.Natives, h.Libraries, &f.Natives},
+		{"libraries", h.Libraries, h.PubVars, &f.Libraries},
+		{"pubvars", h.PubVars, h.Tags, &f.PubVars},
+		{"tags", h.Tags, tagsEnd, &f.Tags},
+	}
+	for _, table := range tables {
+		symbols, err := r.read(table.start, table.end)
+		if err != nil {
+			return File{}, errors.Wrapf(err, "failed to read %s table", table.name)
+		}
+		*table.symbols = symbols
+	}
+
+	return f, nil
+}
+
+type tableReader struct {
+	data     []byte
+	header   Header
+	cellSize int
+}
+
+func (r tableReader) read(start, end int32) ([]Symbol, error) {
+	if start < HeaderSize || end < start || int(end) > len(r.data) {
+		return nil, fmt.Errorf("table from %d to %d is outside of the file", start, end)
+	}
+
+	defSize := int32(r.header.DefSize)
+	symbols := make([]Symbol, 0, (end-start)/defSize)
+	for offset := start; offset+defSize <= end; offset += defSize {
+		record := r.data[offset : offset+defSize]
+		symbol, err := r.symbol(record)
+		if err != nil {
+			return nil, err
+		}
+		symbols = append(symbols, symbol)
+	}
+	return symbols, nil
+}
+
+// symbol decodes a record, with a name table each record is a 32 bit address and a 32 bit name
+// offset, older files store a cell sized address followed by the name
+func (r tableReader) symbol(record []byte) (Symbol, error) {
+	if r.header.NameTable != 0 {
+		if len(record) < 8 {
+			return Symbol{}, errors.Errorf("record of %d bytes is too small", len(record))
+		}
+		name, err := r.name(binary.LittleEndian.Uint32(record[4:8]))
+		if err != nil {
+			return Symbol{}, err
+		}
+		return Symbol{Name: name, Value: binary.LittleEndian.Uint32(record[:4])}, nil
+	}
+
+	addressSize := r.cellSize / 8
+	if len(record) <= addressSize {
+		return Symbol{}, errors.Errorf("record of %d bytes is too small", len(record))
+	}
+	var value uint32
+	switch addressSize {
+	case 2:
+		value = uint32(binary.LittleEndian.Uint16(record))
+	case 4:
+		value = binary.LittleEndian.Uint32(record)
+	default:
+		value = uint32(binary.LittleEndian.Uint64(record))
+	}
+	return Symbol{Name: cString(record[addressSize:]), Value: value}, nil
+}
+
+func (r tableReader) name(offset uint32) (string, error) {
+	if offset < HeaderSize || int64(offset) >= int64(len(r.data)) {
+		return "", errors.Errorf("name offset %d is outside of the file", offset)
+	}
+	return cString(r.data[offset:]), nil
+}
+
+func cString(b []byte) string {
+	if end := bytes.IndexByte(b, 0); end >= 0 {
+		b = b[:end]
+	}
+	return string(b)
+}
@@ -0,0 +1,220 @@
+package amx
+
+import (
+	"bytes"
+	"encoding/binary"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/Southclaws/sampctl/src/pkg/build"
+)
+
+type testSymbol struct {
+	name  string
+	value uint32
+}
+
+// writeTestAMX lays out an AMX file with a name table the same way pawncc does: the header, the
+// publics, natives, libraries, pubvars and tags tables, the name table and then the code and data
+func writeTestAMX(t *testing.T, flags Flags, tables [5][]testSymbol) []byte {
+	t.Helper()
+
+	const defSize = 8
+	offset := int32(HeaderSize)
+	var starts [5]int32
+	for i, table := range tables {
+		starts[i] = offset
+		offset += int32(len(table) * defSize)
+	}
+	nameTable := offset
+
+	var names bytes.Buffer
+	require.NoError(t, binary.Write(&names, binary.LittleEndian, int16(31)))
+	var records bytes.Buffer
+	for _, table := range tables {
+		for _, symbol := range table {
+			nameOffset := uint32(nameTable) + uint32(names.Len())
+			names.WriteString(symbol.name)
+			names.WriteByte(0)
+			require.NoError(t, binary.Write(&records, binary.LittleEndian, [2]uint32{symbol.value, nameOffset}))
+		}
+	}
+
+	cod := nameTable + int32(names.Len())
+	code := make([]byte, 276)
+	data := make([]byte, 24)
+	header := Header{
+		Size:        cod + int32(len(code)+len(data)),
+		Magic:       Magic32,
+		FileVersion: 11,
+		AMXVersion:  11,
+		Flags:       flags,
+		DefSize:     defSize,
+		COD:         cod,
+		DAT:         cod + int32(len(code)),
+		HEA:         cod + int32(len(code)+len(data)),
+		STP:         cod + int32(len(code)+len(data)) + 16384,
+		CIP:         -1,
+		Publics:     starts[0],
+		Natives:     starts[1],
+		Libraries:   starts[2],
+		PubVars:     starts[3],
+		Tags:        starts[4],
+		NameTable:   nameTable,
+	}
+
+	var file bytes.Buffer
+	require.NoError(t, binary.Write(&file, binary.LittleEndian, header))
+	file.Write(records.Bytes())
+	file.Write(names.Bytes())
+	file.Write(code)
+	file.Write(data)
+	return file.Bytes()
+}
+
+func TestParse(t *testing.T) {
+	data := writeTestAMX(t, FlagDebug|FlagCompact|FlagSysreqN, [5][]testSymbol{
+		{{"OnGameModeInit", 8}, {"OnPlayerConnect", 120}},
+		{{"print", 0}, {"SetGameModeText", 0}, {"SendClientMessage", 0}},
+		nil,
+		{{"g_Version", 4}},
+		{{"Float", 0x40000001}, {"bool", 2}},
+	})
+
+	f, err := Parse(data)
+	require.NoError(t, err)
+
+	assert.Equal(t, 32, f.CellSize)
+	assert.Equal(t, uint8(11), f.Header.FileVersion)
+	assert.Equal(t, []string{"debug", "compact", "sysreq.n"}, f.Header.Flags.Names())
+	assert.False(t, f.HasMain())
+	assert.Equal(t, []Symbol{{Name: "OnGameModeInit", Value: 8}, {Name: "OnPlayerConnect", Value: 120}}, f.Publics)
+	assert.Equal(t, []Symbol{{Name: "print"}, {Name: "SetGameModeText"}, {Name: "SendClientMessage"}}, f.Natives)
+	assert.Empty(t, f.Libraries)
+	assert.Equal(t, []Symbol{{Name: "g_Version", Value: 4}}, f.PubVars)
+	assert.Equal(t, []Symbol{{Name: "Float", Value: 0x40000001}, {Name: "bool", Value: 2}}, f.Tags)
+
+	assert.Equal(t, build.Result{
+		Header:    int(f.Header.COD),
+		Code:      276,
+		Data:      24,
+		StackHeap: 16384,
+		Total:     int(f.Header.COD) + 276 + 24 + 16384,
+	}, f.Result())
+}
+
+func TestParseInlineNames(t *testing.T) {
+	// file version 6 stores a cell sized address followed by a 20 byte name in each record
+	const defSize = 24
+	header := Header{
+		Magic:       Magic32,
+		FileVersion: 6,
+		AMXVersion:  6,
+		DefSize:     defSize,
+		Publics:     HeaderSize,
+		Natives:     HeaderSize + defSize,
+		Libraries:   HeaderSize + 2*defSize,
+		PubVars:     HeaderSize + 2*defSize,
+		Tags:        HeaderSize + 2*defSize,
+		COD:         HeaderSize + 2*defSize,
+		DAT:         HeaderSize + 2*defSize,
+		HEA:         HeaderSize + 2*defSize,
+		STP:         HeaderSize + 2*defSize + 1024,
+		NameTable:   0x1234, // not an offset before file version 7
+	}
+
+	var file bytes.Buffer
+	require.NoError(t, binary.Write(&file, binary.LittleEndian, header))
+	for _, symbol := range []testSymbol{{"main", 0}, {"printf", 0}} {
+		record := make([]byte, defSize)
+		binary.LittleEndian.PutUint32(record, symbol.value)
+		copy(record[4:], symbol.name)
+		file.Write(record)
+	}
+
+	f, err := Parse(file.Bytes())
+	require.NoError(t, err)
+	assert.True(t, f.HasMain())
+	assert.Equal(t, []Symbol{{Name: "main"}}, f.Publics)
+	assert.Equal(t, []Symbol{{Name: "printf"}}, f.Natives)
+	assert.Empty(t, f.Tags)
+	assert.Zero(t, f.Header.NameTable)
+}
+
+func TestParseErrors(t *testing.T) {
+	_, err := Parse([]byte{1, 2, 3})
+	assert.EqualError(t, err, "file is 3 bytes, too small for an AMX header")
+
+	data := writeTestAMX(t, 0, [5][]testSymbol{{{"OnGameModeInit", 8}}})
+	corrupt := append([]byte{}, data...)
+	binary.LittleEndian.PutUint16(corrupt[4:], 0x1234)
+	_, err = Parse(corrupt)
+	assert.EqualError(t, err, "invalid AMX magic 0x1234")
+
+	truncated := data[:HeaderSize+4]
+	_, err = Parse(truncated)
+	assert.ErrorContains(t, err, "failed to read publics table")
+}
+
+func TestOpen(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "script.amx")
+	require.NoError(t, os.WriteFile(path, writeTestAMX(t, 0, [5][]testSymbol{{{"OnFilterScriptInit", 8}}}), 0o644))
+
+	f, err := Open(path)
+	require.NoError(t, err)
+	assert.Equal(t, "OnFilterScriptInit", f.Publics[0].Name)
+
+	_, err = Open(filepath.Join(t.TempDir(), "missing.amx"))
+	assert.ErrorContains(t, err, "failed to read AMX file")
+}
+
+func TestCompare(t *testing.T) {
+	previous, err := Parse(writeTestAMX(t, 0, [5][]testSymbol{
+		{{"OnFilterScriptInit", 8}, {"OnPlayerConnect", 120}, {"OnRconCommand", 200}},
+		{{"print", 0}, {"SetTimer", 0}},
+		nil,
+		{{"g_Version", 4}},
+		nil,
+	}))
+	require.NoError(t, err)
+	current, err := Parse(writeTestAMX(t, 0, [5][]testSymbol{
+		{{"OnFilterScriptInit", 8}, {"OnPlayerConnect", 136}, {"OnPlayerSpawn", 240}},
+		{{"print", 0}, {"SetTimerEx", 0}},
+		nil,
+		{{"g_Version", 4}},
+		nil,
+	}))
+	require.NoError(t, err)
+
+	diff := Compare(previous, current)
+	assert.Equal(t, TableDiff{
+		Added:   []Symbol{{Name: "OnPlayerSpawn", Value: 240}},
+		Removed: []Symbol{{Name: "OnRconCommand", Value: 200}},
+		Changed: []SymbolChange{{Name: "OnPlayerConnect", Old: 120, New: 136}},
+	}, diff.Publics)
+	assert.Equal(t, []Symbol{{Name: "SetTimerEx"}}, diff.Natives.Added)
+	assert.Equal(t, []Symbol{{Name: "SetTimer"}}, diff.Natives.Removed)
+	assert.True(t, diff.PubVars.Empty())
+	assert.Equal(t, 2, diff.Removed())
+
+	var out bytes.Buffer
+	require.NoError(t, diff.WriteText(&out))
+	assert.Equal(t, `Publics
+  + OnPlayerSpawn
+  - OnRconCommand
+  ~ OnPlayerConnect (0x78 -> 0x88)
+Natives
+  + SetTimerEx
+  - SetTimer
+`, out.String())
+
+	same := Compare(previous, previous)
+	assert.True(t, same.Empty())
+	out.Reset()
+	require.NoError(t, same.WriteText(&out))
+	assert.Equal(t, "no exported symbols changed\n", out.String())
+}
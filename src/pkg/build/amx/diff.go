@@ -0,0 +1,116 @@
+package amx
+
+import (
+	"fmt"
+	"io"
+	"strings"
+)
+
+// SymbolChange is a symbol that exists in both scripts with a different value
+type SymbolChange struct {
+	Name string `json:"name"`
+	Old  uint32 `json:"old"`
+	New  uint32 `json:"new"`
+}
+
+// TableDiff lists the differences between the same symbol table of two scripts
+type TableDiff struct {
+	Added   []Symbol       `json:"added"`
+	Removed []Symbol       `json:"removed"`
+	Changed []SymbolChange `json:"changed"` // the address of a public function or public variable moved
+}
+
+// Empty returns true if the tables are identical
+func (d TableDiff) Empty() bool {
+	return len(d.Added) == 0 && len(d.Removed) == 0 && len(d.Changed) == 0
+}
+
+// Diff lists the differences between the exported symbols of two scripts, the symbols other
+// scripts, plugins and the server call into or look up by name
+type Diff struct {
+	Publics TableDiff `json:"publics"`
+	Natives TableDiff `json:"natives"`
+	PubVars TableDiff `json:"pubvars"`
+}
+
+// Compare returns the symbols added to, removed from and changed in the current script since the
+// previous one
+func Compare(previous, current File) Diff {
+	return Diff{
+		Publics: compareTable(previous.Publics, current.Publics),
+		Natives: compareTable(previous.Natives, current.Natives),
+		PubVars: compareTable(previous.PubVars, current.PubVars),
+	}
+}
+
+func compareTable(previous, current []Symbol) TableDiff {
+	diff := TableDiff{Added: []Symbol{}, Removed: []Symbol{}, Changed: []SymbolChange{}}
+
+	previousValues := make(map[string]uint32, len(previous))
+	for _, symbol := range previous {
+		previousValues[symbol.Name] = symbol.Value
+	}
+	currentNames := make(map[string]bool, len(current))
+	for _, symbol := range current {
+		currentNames[symbol.Name] = true
+		value, existed := previousValues[symbol.Name]
+		switch {
+		case !existed:
+			diff.Added = append(diff.Added, symbol)
+		case value != symbol.Value:
+			diff.Changed = append(diff.Changed, SymbolChange{Name: symbol.Name, Old: value, New: symbol.Value})
+		}
+	}
+	for _, symbol := range previous {
+		if !currentNames[symbol.Name] {
+			diff.Removed = append(diff.Removed, symbol)
+		}
+	}
+	return diff
+}
+
+// Empty returns true if no exported symbol was added, removed or changed
+func (d Diff) Empty() bool {
+	return d.Publics.Empty() && d.Natives.Empty() && d.PubVars.Empty()
+}
+
+// Removed returns how many symbols the current script no longer has, removing a public or public
+// variable breaks anything that calls or reads it by name
+func (d Diff) Removed() int {
+	return len(d.Publics.Removed) + len(d.Natives.Removed) + len(d.PubVars.Removed)
+}
+
+// WriteText writes the differences with one line per symbol, prefixed with `+` when it was
+// added, `-` when it was removed and `~` when its address changed
+func (d Diff) WriteText(w io.Writer) error {
+	if d.Empty() {
+		_, err := fmt.Fprintln(w, "no exported symbols changed")
+		return err
+	}
+
+	var b strings.Builder
+	for _, table := range []struct {
+		title string
+		diff  TableDiff
+	}{
+		{"Publics", d.Publics},
+		{"Natives", d.Natives},
+		{"Public variables", d.PubVars},
+	} {
+		if table.diff.Empty() {
+			continue
+		}
+		fmt.Fprintf(&b, "%s\n", table.title)
+		for _, symbol := range table.diff.Added {
+			fmt.Fprintf(&b, "  + %s\n", symbol.Name)
+		}
+		for _, symbol := range table.diff.Removed {
+			fmt.Fprintf(&b, "  - %s\n", symbol.Name)
+		}
+		for _, change := range table.diff.Changed {
+			fmt.Fprintf(&b, "  ~ %s (0x%X -> 0x%X)\n", change.Name, change.Old, change.New)
+		}
+	}
+	_, err := io.WriteString(w, b.String())
+	return err
+}
@@ -0,0 +1,55 @@
+package build
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// Budget declares limits on the size of a compiled script, checked once a build succeeds
+type Budget struct {
+	MaxTotal         int     `json:"max_total,omitempty" yaml:"max_total,omitempty"`                   // maximum total requirements in bytes
+	MinStackHeadroom int     `json:"min_stack_headroom,omitempty" yaml:"min_stack_headroom,omitempty"` // minimum bytes left between the stack/heap size and the estimated usage
+	MaxCodeGrowth    float64 `json:"max_code_growth,omitempty" yaml:"max_code_growth,omitempty"`       // warn when code grows by more than this percentage since the last locked build
+}
+
+// StackHeadroom returns the bytes left between the stack/heap size and the compiler's estimated
+// maximum usage. It returns false when the compiler did not report an estimate, such as when the
+// script uses recursion.
+func (r Result) StackHeadroom() (int, bool) {
+	if r.StackHeap == 0 {
+		return 0, false
+	}
+	return r.StackHeap - r.Estimate, true
+}
+
+// Check returns an error describing every budget the result exceeds
+func (b Budget) Check(result Result) error {
+	var exceeded []string
+
+	if b.MaxTotal > 0 && result.Total > b.MaxTotal {
+		exceeded = append(exceeded, fmt.Sprintf("total requirements of %d bytes exceed max_total of %d bytes", result.Total, b.MaxTotal))
+	}
+
+	if b.MinStackHeadroom > 0 {
+		if headroom, ok := result.StackHeadroom(); ok && headroom < b.MinStackHeadroom {
+			exceeded = append(exceeded, fmt.Sprintf("stack headroom of %d bytes is below min_stack_headroom of %d bytes", headroom, b.MinStackHeadroom))
+		}
+	}
+
+	if len(exceeded) == 0 {
+		return nil
+	}
+	return errors.Errorf("build exceeded its size budget: %s", strings.Join(exceeded, ", "))
+}
+
+// CodeGrowth returns the percentage the code size grew by between two builds and whether that
+// exceeds MaxCodeGrowth. Nothing is reported when the previous code size is unknown.
+func (b Budget) CodeGrowth(previousCode, currentCode int) (float64, bool) {
+	if previousCode <= 0 {
+		return 0, false
+	}
+	growth := float64(currentCode-previousCode) / float64(previousCode) * 100
+	return growth, b.MaxCodeGrowth > 0 && growth > b.MaxCodeGrowth
+}
@@ -0,0 +1,46 @@
+package build
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestResultStackHeadroom(t *testing.T) {
+	headroom, ok := Result{StackHeap: 16384, Estimate: 32}.StackHeadroom()
+	assert.True(t, ok)
+	assert.Equal(t, 16352, headroom)
+
+	_, ok = Result{}.StackHeadroom()
+	assert.False(t, ok)
+}
+
+func TestBudgetCheck(t *testing.T) {
+	result := Result{StackHeap: 1024, Estimate: 1000, Total: 20000}
+
+	assert.NoError(t, Budget{}.Check(result))
+	assert.NoError(t, Budget{MaxTotal: 20000, MinStackHeadroom: 24}.Check(result))
+
+	assert.EqualError(t, Budget{MaxTotal: 16384}.Check(result),
+		"build exceeded its size budget: total requirements of 20000 bytes exceed max_total of 16384 bytes")
+	assert.EqualError(t, Budget{MaxTotal: 16384, MinStackHeadroom: 512}.Check(result),
+		"build exceeded its size budget: total requirements of 20000 bytes exceed max_total of 16384 bytes, "+
+			"stack headroom of 24 bytes is below min_stack_headroom of 512 bytes")
+
+	assert.NoError(t, Budget{MinStackHeadroom: 512}.Check(Result{Total: 100}), "unknown estimates are not checked")
+}
+
+func TestBudgetCodeGrowth(t *testing.T) {
+	growth, exceeded := Budget{MaxCodeGrowth: 10}.CodeGrowth(1000, 1200)
+	assert.InDelta(t, 20.0, growth, 0.001)
+	assert.True(t, exceeded)
+
+	_, exceeded = Budget{MaxCodeGrowth: 10}.CodeGrowth(1000, 1050)
+	assert.False(t, exceeded)
+
+	_, exceeded = Budget{}.CodeGrowth(1000, 5000)
+	assert.False(t, exceeded, "growth is only reported when a limit is set")
+
+	_, exceeded = Budget{MaxCodeGrowth: 10}.CodeGrowth(0, 5000)
+	assert.False(t, exceeded, "no previous size to compare against")
+}
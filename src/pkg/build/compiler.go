@@ -19,6 +19,7 @@ type CompilerOptions struct {
 	ShowWarnings           *bool   `json:"show_warnings,omitempty" yaml:"show_warnings,omitempty"`                       // -w+ to enable, -w- to disable | default=enabled
 	CompactEncoding        *bool   `json:"compact_encoding,omitempty" yaml:"compact_encoding,omitempty"`                 // -C+ to enable, -C- to disable | default=disabled
 	TabSize                *int    `json:"tab_size,omitempty" yaml:"tab_size,omitempty"`                                 // -t<spaces> | default=4
+	ReportFile             *string `json:"report_file,omitempty" yaml:"report_file,omitempty"`                           // -r<filename> | XML report of functions, constants and doc comments | default=""
 }
 
 // ToArgs converts CompilerOptions to a slice of command-line arguments
@@ -47,6 +48,7 @@ var compilerOptionBuilders = []func(*CompilerOptions) []string{
 	func(o *CompilerOptions) []string { return boolOption(o.ShowWarnings, "-w+", "-w-") },
 	func(o *CompilerOptions) []string { return boolOption(o.CompactEncoding, "-C+", "-C-") },
 	func(o *CompilerOptions) []string { return intOption(o.TabSize, "-t") },
+	func(o *CompilerOptions) []string { return stringOption(o.ReportFile, "-r") },
 }
 
 func intOption(value *int, prefix string, bounds ...int) []string {
@@ -92,19 +94,26 @@ func stringOption(value *string, prefix string) []string {
 
 // Config represents a configuration for compiling a file
 type Config struct {
-	Name              string            `json:"name" yaml:"name"`                                 // name of the configuration
-	Version           CompilerVersion   `json:"version,omitempty" yaml:"version,omitempty"`       // compiler version to use for this build
-	WorkingDir        string            `json:"workingDir,omitempty" yaml:"workingDir,omitempty"` // working directory for the -D flag
-	Args              []string          `json:"args,omitempty" yaml:"args,omitempty"`             // list of arguments to pass to the compiler (deprecated)
-	Options           *CompilerOptions  `json:"options,omitempty" yaml:"options,omitempty"`       // human-readable compiler options (use this in future over args)
-	Input             string            `json:"input,omitempty" yaml:"input,omitempty"`           // input .pwn file
-	Output            string            `json:"output,omitempty" yaml:"output,omitempty"`         // output .amx file
-	Includes          []string          `json:"includes,omitempty" yaml:"includes,omitempty"`     // list of include files to pass to compiler via -i flags
-	Constants         map[string]string `json:"constants,omitempty" yaml:"constants,omitempty"`   // set of constant definitions to pass to the compiler
-	Plugins           [][]string        `json:"plugins,omitempty" yaml:"plugins,omitempty"`       // set of commands to run before compilation
-	Compiler          CompilerConfig    `json:"compiler,omitempty" yaml:"compiler,omitempty"`     // a set of configurations for using a compiler
-	PreBuildCommands  [][]string        `json:"prebuild,omitempty" yaml:"prebuild,omitempty"`     // allows the execution of commands before a build is ran
-	PostBuildCommands [][]string        `json:"postbuild,omitempty" yaml:"postbuild,omitempty"`   // allows the execution of commands after a build is ran
+	Name               string             `json:"name" yaml:"name"`                                                   // name of the configuration
+	Version            CompilerVersion    `json:"version,omitempty" yaml:"version,omitempty"`                         // compiler version to use for this build
+	WorkingDir         string             `json:"workingDir,omitempty" yaml:"workingDir,omitempty"`                   // working directory for the -D flag
+	Args               []string           `json:"args,omitempty" yaml:"args,omitempty"`                               // list of arguments to pass to the compiler (deprecated)
+	Options            *CompilerOptions   `json:"options,omitempty" yaml:"options,omitempty"`                         // human-readable compiler options (use this in future over args)
+	Input              string             `json:"input,omitempty" yaml:"input,omitempty"`                             // input .pwn file
+	Output             string             `json:"output,omitempty" yaml:"output,omitempty"`                           // output .amx file
+	Includes           []string           `json:"includes,omitempty" yaml:"includes,omitempty"`                       // list of include files to pass to compiler via -i flags
+	Constants          map[string]string  `json:"constants,omitempty" yaml:"constants,omitempty"`                     // set of constant definitions to pass to the compiler
+	Plugins            [][]string         `json:"plugins,omitempty" yaml:"plugins,omitempty"`                         // set of commands to run before compilation
+	Compiler           CompilerConfig     `json:"compiler,omitempty" yaml:"compiler,omitempty"`                       // a set of configurations for using a compiler
+	PreBuildCommands   [][]string         `json:"prebuild,omitempty" yaml:"prebuild,omitempty"`                       // allows the execution of commands before a build is ran
+	PostBuildCommands  [][]string         `json:"postbuild,omitempty" yaml:"postbuild,omitempty"`                     // allows the execution of commands after a build is ran
+	WarningsAsErrors   []int              `json:"warnings_as_errors,omitempty" yaml:"warnings_as_errors,omitempty"`   // warning codes that fail the build
+	IgnoreWarnings     []int              `json:"ignore_warnings,omitempty" yaml:"ignore_warnings,omitempty"`         // warning codes that are not reported
+	Budget             *Budget            `json:"budget,omitempty" yaml:"budget,omitempty"`                           // size limits checked after a successful build
+	DependencyWarnings DependencyWarnings `json:"dependency_warnings,omitempty" yaml:"dependency_warnings,omitempty"` // how warnings from inside dependencies are reported
+	Depfile            bool               `json:"depfile,omitempty" yaml:"depfile,omitempty"`                         // write a depfile and include manifest next to the output
+	CheckNatives       bool               `json:"check_natives,omitempty" yaml:"check_natives,omitempty"`             // fail when the output calls natives that no configured plugin provides
+	DiffPrevious       bool               `json:"diff_previous,omitempty" yaml:"diff_previous,omitempty"`             // compare exported symbols with the last locked output and fail when any are removed
 }
 
 // CompilerVersion represents a compiler version number
@@ -235,12 +244,12 @@ func ensureCompilerVersion(version string) string {
 
 // Result represents the final statistics (in bytes) of a successfully built .amx file.
 type Result struct {
-	Header    int
-	Code      int
-	Data      int
-	StackHeap int
-	Estimate  int
-	Total     int
+	Header    int `json:"header"`
+	Code      int `json:"code"`
+	Data      int `json:"data"`
+	StackHeap int `json:"stack_heap"`
+	Estimate  int `json:"estimate"`
+	Total     int `json:"total"`
 }
 
 // ProblemSeverity represents the severity of a problem, warning error or fatal
@@ -267,14 +276,46 @@ func (ps ProblemSeverity) String() string {
 	return "unknown"
 }
 
+// MarshalText encodes the severity by name, for structured diagnostics output
+func (ps ProblemSeverity) MarshalText() ([]byte, error) {
+	return []byte(ps.String()), nil
+}
+
+// UnmarshalText decodes a severity from its name
+func (ps *ProblemSeverity) UnmarshalText(text []byte) error {
+	switch string(text) {
+	case "warning":
+		*ps = ProblemWarning
+	case "error":
+		*ps = ProblemError
+	case "fatal":
+		*ps = ProblemFatal
+	default:
+		return fmt.Errorf("unknown problem severity %q", text)
+	}
+	return nil
+}
+
 // Problem represents an issue with a line in a file with a severity level, these have a full
 // file path, a line number, a severity level (warnings, errors and fatal errors) and a short
 // description of the problem.
 type Problem struct {
-	File        string
-	Line        int
-	Severity    ProblemSeverity
-	Description string
+	File string `json:"file"`
+	Line int    `json:"line"`
+	// EndLine is the last line of the statement when the compiler reports a range of lines, such as
+	// `script.pwn(12 -- 15)`, otherwise it is zero. The compiler never reports columns, so a
+	// problem has no column range.
+	EndLine  int             `json:"end_line,omitempty"`
+	Severity ProblemSeverity `json:"severity"`
+	// Code is the compiler's diagnostic number, such as 213 for `warning 213`, or zero when the
+	// compiler did not report one.
+	Code        int    `json:"code,omitempty"`
+	Description string `json:"description"`
+	// Promoted is set when a warning was turned into an error by `warnings_as_errors`.
+	Promoted bool `json:"promoted,omitempty"`
+	// Dependency is the package that owns the file, using its lockfile key such as
+	// `github.com/user/repo`, or empty when the file belongs to the package being built.
+	Dependency string `json:"dependency,omitempty"`
 }
 
 // String creates a structured representation of a problem, for editor integration
@@ -315,7 +356,8 @@ func (bps Problems) Fatal() (fatal bool) {
 	return false
 }
 
-// IsValid returns true if the Problems only contains warnings, if there are errors it's false
+// IsValid returns true if the Problems only contains warnings, if there are errors it's false.
+// Warnings promoted by `warnings_as_errors` count as errors.
 func (bps Problems) IsValid() bool {
 	return len(bps.Errors()) == 0
 }
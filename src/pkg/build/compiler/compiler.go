@@ -29,7 +29,7 @@ import (
 //nolint:lll
 var (
 	// matches warnings or errors
-	matchCompilerProblem = regexp.MustCompile(`^(.*?)\(([0-9]*)[- 0-9]*\) \: (fatal error|error|user warning|warning)\s?[0-9]*\: (.*)$`)
+	matchCompilerProblem = regexp.MustCompile(`^(.*?)\(([0-9]*)(?: -- ([0-9]+))?[- 0-9]*\) \: (fatal error|error|user warning|warning)\s?([0-9]*)\: (.*)$`)
 
 	// Header size:             60 bytes
 	matchHeader = regexp.MustCompile(`^Header size:\s*([0-9]+) bytes$`)
@@ -55,6 +55,29 @@ type CompileRequest struct {
 	Platform string
 	Config   build.Config
 	Relative bool
+	// Output receives the output of the compiler and the build commands, nil writes it to stdout.
+	Output io.Writer
+	// Dependency attributes problems to dependencies, see CompileCommandRequest.
+	Dependency func(file string) string
+}
+
+// CompileCommandRequest describes how a prepared compiler command is executed and how its output
+// is interpreted.
+type CompileCommandRequest struct {
+	// Context cancels the compiler when it is run again after a crash, the command itself is
+	// already bound to a context when it is prepared.
+	Context    context.Context
+	Command    *exec.Cmd
+	WorkingDir string
+	ErrorDir   string
+	Relative   bool
+	// Output receives problems as the compiler reports them, nil discards them.
+	Output io.Writer
+	// Config supplies the warning policy applied to reported problems.
+	Config build.Config
+	// Dependency returns the dependency that owns a problem's absolute file path, or an empty
+	// string for the package's own files. When nil, problems are not attributed to dependencies.
+	Dependency func(file string) string
 }
 
 type PrepareCommandRequest struct {
@@ -85,22 +108,31 @@ func CompileSource(
 		return
 	}
 
-	err = RunPreBuildCommands(ctx, request.Config, os.Stdout)
+	output := request.Output
+	if output == nil {
+		output = os.Stdout
+	}
+
+	err = RunPreBuildCommands(ctx, request.Config, output)
 	if err != nil {
 		return
 	}
 
-	problems, result, err = CompileWithCommand(
-		cmd,
-		request.Config.WorkingDir,
-		request.ErrorDir,
-		request.Relative,
-	)
+	problems, result, err = CompileWithCommand(CompileCommandRequest{
+		Context:    ctx,
+		Command:    cmd,
+		WorkingDir: request.Config.WorkingDir,
+		ErrorDir:   request.ErrorDir,
+		Relative:   request.Relative,
+		Output:     output,
+		Config:     request.Config,
+		Dependency: request.Dependency,
+	})
 	if err != nil {
 		return
 	}
 
-	err = RunPostBuildCommands(ctx, request.Config, os.Stdout)
+	err = RunPostBuildCommands(ctx, request.Config, output)
 	if err != nil {
 		return
 	}
@@ -269,12 +301,12 @@ func compilerOptionArgs(config build.Config) []string {
 	return append([]string{}, config.Args...)
 }
 
-func buildIncludeArgs(execDir string, includes []string) ([]string, error) {
+// IncludeDirs returns the include directories passed to the compiler as `-i` flags, in the order
+// the compiler searches them. Relative directories are resolved against execDir and duplicates
+// are removed.
+func IncludeDirs(execDir string, includes []string) []string {
 	includePaths := make(map[string]struct{})
-	includeFiles := make(map[string]string)
-	includeErrors := []string{}
-	args := make([]string, 0, len(includes))
-
+	dirs := make([]string, 0, len(includes))
 	for _, inc := range includes {
 		fullPath := inc
 		if !filepath.IsAbs(inc) {
@@ -285,13 +317,23 @@ func buildIncludeArgs(execDir string, includes []string) ([]string, error) {
 			continue
 		}
 		includePaths[fullPath] = struct{}{}
+		dirs = append(dirs, fullPath)
+	}
+	return dirs
+}
+
+func buildIncludeArgs(execDir string, includes []string) ([]string, error) {
+	includeFiles := make(map[string]string)
+	includeErrors := []string{}
+	args := make([]string, 0, len(includes))
 
+	for _, fullPath := range IncludeDirs(execDir, includes) {
 		print.Verb("using include path", fullPath)
 		args = append(args, "-i"+fullPath)
 
 		contents, err := os.ReadDir(fullPath)
 		if err != nil {
-			return nil, errors.Wrapf(err, "failed to list dependency include path: %s", inc)
+			return nil, errors.Wrapf(err, "failed to list dependency include path: %s", fullPath)
 		}
 
 		for _, dependencyFile := range contents {
@@ -359,13 +401,19 @@ func isNumeric(value string) bool {
 	return false
 }
 
-// CompileWithCommand takes a prepared command and executes it
-func CompileWithCommand(
-	cmd *exec.Cmd,
-	workingDir,
-	errorDir string,
-	relative bool,
-) (problems build.Problems, result build.Result, err error) {
+// CompileWithCommand takes a prepared command and executes it. When the compiler crashes, a
+// crash report is written to the output and returned in a CrashError, and when the crash looks
+// like a stack overflow the compiler is run once more with a larger stack first.
+func CompileWithCommand(request CompileCommandRequest) (problems build.Problems, result build.Result, err error) {
+	ctx := request.Context
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	workingDir, err := fs.Abs(request.WorkingDir)
+	if err != nil {
+		return nil, build.Result{}, errors.Wrap(err, "failed to resolve working directory")
+	}
+	errorDir := request.ErrorDir
 	if errorDir == "" {
 		errorDir = workingDir
 	}
@@ -374,27 +422,48 @@ func CompileWithCommand(
 		return nil, build.Result{}, errors.Wrap(err, "failed to resolve error directory")
 	}
 
-	outputReader, outputWriter := io.Pipe()
-	cmd.Stdout = outputWriter
-	cmd.Stderr = outputWriter
-	workingDir, err = fs.Abs(workingDir)
-	if err != nil {
-		return nil, build.Result{}, errors.Wrap(err, "failed to resolve working directory")
-	}
-
-	parser := newCompilerOutputParser(outputReader, workingDir, errorDir, relative)
-	go parser.Run()
+	cmd := request.Command
+	retried := false
+	var cmdError error
+	for {
+		var parser *compilerOutputParser
+		parser, cmdError = runCompiler(cmd, request, workingDir, errorDir)
+		problems, result = parser.Wait()
 
-	print.Verb("executing compiler in", workingDir, "as", cmd.Env, cmd.Args)
-	cmdError := cmd.Run()
+		status, overflow, crashed := compilerCrash(cmdError, parser.tail)
+		if !crashed || ctx.Err() != nil {
+			break
+		}
+		if overflow && !retried {
+			var size int
+			cmd, size = withLargerStack(ctx, cmd)
+			retried = true
+			print.Fwarn(request.Output, fmt.Sprintf("The compiler crashed with %s which looks like a stack overflow, retrying with -S%d", status, size))
+			continue
+		}
 
-	err = outputWriter.Close()
-	if err != nil {
-		print.Erro("Compiler output read error:", err)
+		report := CrashReport{
+			Status:   status,
+			Overflow: overflow,
+			Retried:  retried,
+			Version:  parser.version,
+			Command:  cmd.Args,
+			Output:   parser.tail,
+		}
+		if report.Version == "" {
+			report.Version = request.Config.Compiler.Version
+		}
+		if report.Version == "" {
+			report.Version = "unknown"
+		}
+		if request.Output != nil {
+			if writeErr := report.Write(request.Output); writeErr != nil {
+				print.Erro("Failed to write compiler crash report:", writeErr)
+			}
+		}
+		return nil, build.Result{}, &CrashError{Report: report}
 	}
 
-	problems, result = parser.Wait()
-
 	if cmdError != nil {
 		if cmdError.Error() == "exit status 1" {
 			// compilation failed with errors and warnings
@@ -418,11 +487,37 @@ func CompileWithCommand(
 	return problems, result, err
 }
 
+// runCompiler runs a compiler command once, the returned parser finishes reading its output
+func runCompiler(cmd *exec.Cmd, request CompileCommandRequest, workingDir, errorDir string) (*compilerOutputParser, error) {
+	outputReader, outputWriter := io.Pipe()
+	cmd.Stdout = outputWriter
+	cmd.Stderr = outputWriter
+
+	parser := newCompilerOutputParser(outputReader, workingDir, errorDir, request.Relative)
+	parser.output = request.Output
+	parser.config = request.Config
+	parser.dependency = request.Dependency
+	go parser.Run()
+
+	print.Verb("executing compiler in", workingDir, "as", cmd.Env, cmd.Args)
+	cmdError := cmd.Run()
+
+	if err := outputWriter.Close(); err != nil {
+		print.Erro("Compiler output read error:", err)
+	}
+	return parser, cmdError
+}
+
 type compilerOutputParser struct {
 	reader     io.Reader
 	workingDir string
 	errorDir   string
 	relative   bool
+	output     io.Writer
+	config     build.Config
+	dependency func(file string) string
+	version    string   // the banner the compiler prints first
+	tail       []string // the last lines of output, kept for crash reports
 	done       chan struct{}
 	problems   build.Problems
 	result     build.Result
@@ -455,8 +550,13 @@ func (p *compilerOutputParser) Wait() (build.Problems, build.Result) {
 }
 
 func (p *compilerOutputParser) handleLine(line string) {
+	if len(p.tail) == crashOutputLines {
+		p.tail = p.tail[1:]
+	}
+	p.tail = append(p.tail, line)
+
 	groups := matchCompilerProblem.FindStringSubmatch(line)
-	if len(groups) == 5 {
+	if len(groups) == 7 {
 		p.handleProblem(groups)
 		return
 	}
@@ -475,6 +575,9 @@ func (p *compilerOutputParser) handleProblem(groups []string) {
 		problem.File = strings.ReplaceAll(problem.File, "\\", "/")
 	}
 	problem.File = filepath.Clean(problem.File)
+	if p.dependency != nil {
+		problem.Dependency = p.dependency(problem.File)
+	}
 	if p.relative {
 		if rel, err := filepath.Rel(p.errorDir, problem.File); err == nil {
 			problem.File = rel
@@ -486,8 +589,13 @@ func (p *compilerOutputParser) handleProblem(groups []string) {
 		return
 	}
 	problem.Line = lineNumber
+	if groups[3] != "" {
+		if endLine, err := strconv.Atoi(groups[3]); err == nil && endLine > lineNumber {
+			problem.EndLine = endLine
+		}
+	}
 
-	switch groups[3] {
+	switch groups[4] {
 	case "user warning":
 		fallthrough
 	case "warning":
@@ -498,14 +606,26 @@ func (p *compilerOutputParser) handleProblem(groups []string) {
 		problem.Severity = build.ProblemFatal
 	}
 
-	problem.Description = groups[4]
-	fmt.Println(problem.String())
+	if code, err := strconv.Atoi(groups[5]); err == nil {
+		problem.Code = code
+	}
+
+	problem.Description = groups[6]
+	problem, keep := p.config.ApplyWarningPolicy(problem)
+	if !keep {
+		print.Verb("ignoring", problem.String())
+		return
+	}
+	if p.output != nil && !p.config.SummarisedWarning(problem) {
+		fmt.Fprintln(p.output, problem.String())
+	}
 	p.problems = append(p.problems, problem)
 }
 
 func (p *compilerOutputParser) handleResultLine(line string) {
 	switch {
 	case strings.HasPrefix(line, "Pawn compiler"):
+		p.version = strings.TrimSpace(strings.SplitN(line, "\t", 2)[0])
 		return
 	case strings.HasPrefix(line, "Compilation aborted"):
 		return
@@ -85,10 +85,10 @@ func TestCompileSource(t *testing.T) {
 				false,
 			},
 			build.Problems{
-				{File: "script.pwn", Line: 1, Severity: build.ProblemError, Description: `invalid function or declaration`},
-				{File: "script.pwn", Line: 3, Severity: build.ProblemError, Description: `invalid function or declaration`},
-				{File: "script.pwn", Line: 2, Severity: build.ProblemWarning, Description: `symbol is never used: "a"`},
-				{File: "script.pwn", Line: 2, Severity: build.ProblemError, Description: `no entry point (no public functions)`},
+				{File: "script.pwn", Line: 1, Severity: build.ProblemError, Code: 1, Description: `invalid function or declaration`},
+				{File: "script.pwn", Line: 3, Severity: build.ProblemError, Code: 1, Description: `invalid function or declaration`},
+				{File: "script.pwn", Line: 2, Severity: build.ProblemWarning, Code: 203, Description: `symbol is never used: "a"`},
+				{File: "script.pwn", Line: 2, Severity: build.ProblemError, Code: 13, Description: `no entry point (no public functions)`},
 			},
 			build.Result{},
 			false, false,
@@ -107,10 +107,10 @@ func TestCompileSource(t *testing.T) {
 				true,
 			},
 			build.Problems{
-				{File: "script.pwn", Line: 1, Severity: build.ProblemError, Description: `invalid function or declaration`},
-				{File: "script.pwn", Line: 3, Severity: build.ProblemError, Description: `invalid function or declaration`},
-				{File: "script.pwn", Line: 2, Severity: build.ProblemWarning, Description: `symbol is never used: "a"`},
-				{File: "script.pwn", Line: 2, Severity: build.ProblemError, Description: `no entry point (no public functions)`},
+				{File: "script.pwn", Line: 1, Severity: build.ProblemError, Code: 1, Description: `invalid function or declaration`},
+				{File: "script.pwn", Line: 3, Severity: build.ProblemError, Code: 1, Description: `invalid function or declaration`},
+				{File: "script.pwn", Line: 2, Severity: build.ProblemWarning, Code: 203, Description: `symbol is never used: "a"`},
+				{File: "script.pwn", Line: 2, Severity: build.ProblemError, Code: 13, Description: `no entry point (no public functions)`},
 			},
 			build.Result{},
 			false, false,
@@ -155,8 +155,8 @@ func TestCompileSource(t *testing.T) {
 				false,
 			},
 			build.Problems{
-				{File: "library.inc", Line: 6, Severity: build.ProblemWarning, Description: `symbol is never used: "b"`},
-				{File: "script.pwn", Line: 5, Severity: build.ProblemWarning, Description: `symbol is never used: "a"`},
+				{File: "library.inc", Line: 6, Severity: build.ProblemWarning, Code: 203, Description: `symbol is never used: "b"`},
+				{File: "script.pwn", Line: 5, Severity: build.ProblemWarning, Code: 203, Description: `symbol is never used: "a"`},
 			},
 			build.Result{
 				Header:    60,
@@ -183,7 +183,7 @@ func TestCompileSource(t *testing.T) {
 				false,
 			},
 			build.Problems{
-				{File: "script.pwn", Line: 1, Severity: build.ProblemFatal, Description: `cannot read from file: "idonotexist"`},
+				{File: "script.pwn", Line: 1, Severity: build.ProblemFatal, Code: 100, Description: `cannot read from file: "idonotexist"`},
 			},
 			build.Result{},
 			false, false,
@@ -0,0 +1,132 @@
+package compiler
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os/exec"
+	"regexp"
+	"runtime"
+	"strconv"
+	"strings"
+	"syscall"
+
+	"rs3.io/go/mserr/ntstatus"
+)
+
+const (
+	// statusStackOverflow is the NTSTATUS a Windows process exits with when it overflows its stack
+	statusStackOverflow = 0xC00000FD
+
+	// crashOutputLines is how many of the last lines of compiler output a crash report keeps
+	crashOutputLines = 20
+
+	// defaultStackSize is the stack/heap size in cells the compiler uses when -S is not passed
+	defaultStackSize = 4096
+)
+
+var (
+	// -S16384
+	matchStackArg = regexp.MustCompile(`^-S([0-9]+)$`)
+
+	// some compiler builds catch the overflow and report it before aborting
+	matchStackOverflow = regexp.MustCompile(`(?i)stack overflow`)
+)
+
+// CrashReport describes a compiler that was terminated abnormally instead of exiting with a
+// status, usually a segmentation fault or stack overflow caused by deeply nested macros
+type CrashReport struct {
+	Status   string   `json:"status"`   // the signal or exit status the compiler terminated with
+	Overflow bool     `json:"overflow"` // the crash looks like the compiler overflowed its stack
+	Retried  bool     `json:"retried"`  // the crash happened again with a larger stack
+	Version  string   `json:"version"`
+	Command  []string `json:"command"`
+	Output   []string `json:"output"` // the last lines the compiler wrote before it crashed
+}
+
+// Write prints the report in a form that can be attached to a bug report
+func (r CrashReport) Write(w io.Writer) error {
+	var b strings.Builder
+	fmt.Fprintf(&b, "The compiler crashed with %s\n", r.Status)
+	if r.Overflow {
+		if r.Retried {
+			b.WriteString("This looks like a stack overflow and the compiler also crashed with a larger stack.\n")
+		} else {
+			b.WriteString("This looks like a stack overflow.\n")
+		}
+	}
+	fmt.Fprintf(&b, "Compiler: %s\n", r.Version)
+	fmt.Fprintf(&b, "Command:  %s\n", strings.Join(r.Command, " "))
+	if len(r.Output) == 0 {
+		b.WriteString("The compiler wrote no output before crashing.\n")
+	} else {
+		fmt.Fprintf(&b, "Last %d lines of output:\n", len(r.Output))
+		for _, line := range r.Output {
+			fmt.Fprintf(&b, "    %s\n", line)
+		}
+	}
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+// CrashError is returned when the compiler crashed, the report holds what is known about the crash
+type CrashError struct {
+	Report CrashReport
+}
+
+func (e *CrashError) Error() string {
+	if e.Report.Retried {
+		return fmt.Sprintf("compiler crashed with %s, also after retrying with a larger stack", e.Report.Status)
+	}
+	return fmt.Sprintf("compiler crashed with %s", e.Report.Status)
+}
+
+// compilerCrash describes how the compiler terminated if it crashed rather than exiting with a
+// status. On Linux and macOS the compiler overflowing its stack is reported as a segmentation
+// fault so every segmentation fault is treated as a possible overflow.
+func compilerCrash(err error, output []string) (status string, overflow, crashed bool) {
+	exitErr, ok := err.(*exec.ExitError)
+	if !ok {
+		return "", false, false
+	}
+
+	if waitStatus, ok := exitErr.Sys().(syscall.WaitStatus); ok && waitStatus.Signaled() {
+		status = exitErr.String()
+		overflow = waitStatus.Signal() == syscall.SIGSEGV
+	} else if code := uint32(exitErr.ExitCode()); runtime.GOOS == "windows" && code&0xC0000000 == 0xC0000000 {
+		// NTSTATUS values with the error severity bits set are exceptions, not exit codes
+		status = "exit status " + ntstatus.NTStatus(code).String()
+		overflow = code == statusStackOverflow
+	} else {
+		return "", false, false
+	}
+
+	for _, line := range output {
+		if matchStackOverflow.MatchString(line) {
+			overflow = true
+		}
+	}
+	return status, overflow, true
+}
+
+// withLargerStack returns a copy of a compiler command with the stack size raised to four times
+// its current -S value, or four times the compiler's default when none is set
+func withLargerStack(ctx context.Context, cmd *exec.Cmd) (*exec.Cmd, int) {
+	size := defaultStackSize
+	args := make([]string, 0, len(cmd.Args))
+	for _, arg := range cmd.Args[1:] {
+		if g := matchStackArg.FindStringSubmatch(arg); len(g) == 2 {
+			if value, err := strconv.Atoi(g[1]); err == nil && value > 0 {
+				size = value
+			}
+			continue
+		}
+		args = append(args, arg)
+	}
+	size *= 4
+
+	retry := exec.CommandContext(ctx, cmd.Path, append(args, fmt.Sprintf("-S%d", size))...) //nolint:gosec
+	retry.Env = cmd.Env
+	retry.Dir = cmd.Dir
+	return retry, size
+}
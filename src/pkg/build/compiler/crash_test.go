@@ -0,0 +1,135 @@
+package compiler
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/Southclaws/sampctl/src/pkg/build"
+)
+
+// writeCrashingCompiler writes a compiler script that prints its banner, counts its runs and
+// then runs body
+func writeCrashingCompiler(t *testing.T, body string) (string, string) {
+	t.Helper()
+	if runtime.GOOS == "windows" {
+		t.Skip("crashing compiler script requires a POSIX shell")
+	}
+
+	dir := t.TempDir()
+	script := filepath.Join(dir, "pawncc")
+	require.NoError(t, os.WriteFile(script, []byte(`#!/bin/sh
+echo "Pawn compiler 3.10.10	 	 	Copyright (c) 1997-2006, ITB CompuPhase"
+echo run >> "$(dirname "$0")/runs"
+`+body+"\n"), 0o755))
+	return script, filepath.Join(dir, "runs")
+}
+
+func countRuns(t *testing.T, runs string) int {
+	t.Helper()
+	contents, err := os.ReadFile(runs)
+	require.NoError(t, err)
+	return strings.Count(string(contents), "run")
+}
+
+func TestCompileWithCommandRetriesStackOverflowWithLargerStack(t *testing.T) {
+	script, runs := writeCrashingCompiler(t, `case "$*" in
+	*-S16384*) echo "Total requirements:   16720 bytes" ;;
+	*) kill -SEGV $$ ;;
+esac`)
+
+	var output bytes.Buffer
+	_, result, err := CompileWithCommand(CompileCommandRequest{
+		Command:    exec.Command(script, "script.pwn", "-S4096"),
+		WorkingDir: t.TempDir(),
+		Output:     &output,
+	})
+	require.NoError(t, err)
+	assert.Equal(t, 16720, result.Total)
+	assert.Equal(t, 2, countRuns(t, runs))
+	assert.Contains(t, output.String(), "looks like a stack overflow, retrying with -S16384")
+	assert.NotContains(t, output.String(), "Compiler: ", "no crash report is written when the retry succeeds")
+}
+
+func TestCompileWithCommandReportsCrash(t *testing.T) {
+	script, runs := writeCrashingCompiler(t, `echo "expanding y_hooks"
+kill -ABRT $$`)
+
+	var output bytes.Buffer
+	_, _, err := CompileWithCommand(CompileCommandRequest{
+		Command:    exec.Command(script, "script.pwn", "-d3"),
+		WorkingDir: t.TempDir(),
+		Output:     &output,
+		Config:     build.Config{Compiler: build.CompilerConfig{Version: "3.10.10"}},
+	})
+	require.Error(t, err)
+
+	crash, ok := err.(*CrashError)
+	require.True(t, ok, "error is a CrashError")
+	assert.Contains(t, crash.Report.Status, "signal: aborted")
+	assert.False(t, crash.Report.Overflow)
+	assert.False(t, crash.Report.Retried)
+	assert.Equal(t, "Pawn compiler 3.10.10", crash.Report.Version)
+	assert.Equal(t, []string{script, "script.pwn", "-d3"}, crash.Report.Command)
+	assert.Equal(t, []string{
+		"Pawn compiler 3.10.10	 	 	Copyright (c) 1997-2006, ITB CompuPhase",
+		"expanding y_hooks",
+	}, crash.Report.Output)
+	assert.Equal(t, 1, countRuns(t, runs), "crashes that are not overflows are not retried")
+
+	assert.Contains(t, output.String(), "The compiler crashed with signal: aborted")
+	assert.Contains(t, output.String(), "Command:  "+script+" script.pwn -d3\n")
+	assert.Contains(t, output.String(), "Last 2 lines of output:\n")
+}
+
+func TestCompileWithCommandReportsOverflowAfterRetry(t *testing.T) {
+	script, runs := writeCrashingCompiler(t, `kill -SEGV $$`)
+
+	_, _, err := CompileWithCommand(CompileCommandRequest{
+		Command:    exec.Command(script, "script.pwn"),
+		WorkingDir: t.TempDir(),
+	})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "also after retrying with a larger stack")
+
+	crash, ok := err.(*CrashError)
+	require.True(t, ok, "error is a CrashError")
+	assert.True(t, crash.Report.Overflow)
+	assert.True(t, crash.Report.Retried)
+	assert.Equal(t, []string{script, "script.pwn", "-S16384"}, crash.Report.Command)
+	assert.Equal(t, 2, countRuns(t, runs))
+}
+
+func TestCompileWithCommandKeepsExitStatusErrors(t *testing.T) {
+	script, _ := writeCrashingCompiler(t, `exit 3`)
+
+	_, _, err := CompileWithCommand(CompileCommandRequest{
+		Command:    exec.Command(script, "script.pwn"),
+		WorkingDir: t.TempDir(),
+	})
+	require.Error(t, err)
+	_, crashed := err.(*CrashError)
+	assert.False(t, crashed)
+	assert.EqualError(t, err, "exit status 3")
+}
+
+func TestWithLargerStack(t *testing.T) {
+	cmd := exec.Command("pawncc", "script.pwn", "-S8192", "-d3")
+	cmd.Env = []string{"LD_LIBRARY_PATH=/compiler"}
+
+	retry, size := withLargerStack(context.Background(), cmd)
+	assert.Equal(t, 32768, size)
+	assert.Equal(t, []string{"pawncc", "script.pwn", "-d3", "-S32768"}, retry.Args)
+	assert.Equal(t, cmd.Env, retry.Env)
+
+	_, size = withLargerStack(context.Background(), exec.Command("pawncc", "script.pwn"))
+	assert.Equal(t, 16384, size)
+}
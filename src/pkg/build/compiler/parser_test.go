@@ -0,0 +1,77 @@
+package compiler
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/Southclaws/sampctl/src/pkg/build"
+)
+
+func TestCompilerOutputParserCapturesCodesAndRanges(t *testing.T) {
+	output := strings.Join([]string{
+		`/src/script.pwn(12 -- 15) : warning 213: tag mismatch`,
+		`/src/script.pwn(20) : warning 239: literal array/string passed to a non-const parameter`,
+		`/src/script.pwn(21) : warning 203: symbol is never used: "a"`,
+		`/src/script.pwn(30) : error 017: undefined symbol "b"`,
+		`/src/script.pwn(31) : user warning: custom`,
+	}, "\n")
+
+	var echoed bytes.Buffer
+	parser := newCompilerOutputParser(strings.NewReader(output), "/src", "/src", false)
+	parser.output = &echoed
+	parser.config = build.Config{WarningsAsErrors: []int{213}, IgnoreWarnings: []int{239}}
+	go parser.Run()
+	problems, _ := parser.Wait()
+
+	require.Len(t, problems, 4)
+	assert.Equal(t, build.Problem{
+		File: "/src/script.pwn", Line: 12, EndLine: 15, Severity: build.ProblemError, Code: 213,
+		Description: "tag mismatch", Promoted: true,
+	}, problems[0])
+	assert.Equal(t, 203, problems[1].Code)
+	assert.Equal(t, build.ProblemWarning, problems[1].Severity)
+	assert.Equal(t, 17, problems[2].Code)
+	assert.Equal(t, 0, problems[3].Code)
+	assert.Equal(t, "custom", problems[3].Description)
+
+	assert.False(t, problems.IsValid())
+	assert.NotContains(t, echoed.String(), "warning 239")
+	assert.NotContains(t, echoed.String(), "literal array")
+	assert.Contains(t, echoed.String(), "/src/script.pwn:12 (error) tag mismatch")
+}
+
+func TestCompilerOutputParserAttributesDependencies(t *testing.T) {
+	output := strings.Join([]string{
+		`/src/script.pwn(1) : warning 203: symbol is never used: "a"`,
+		`/src/dependencies/lib/lib.inc(2) : warning 219: local variable "b" shadows a variable at a preceding level`,
+		`/src/dependencies/lib/lib.inc(3) : error 017: undefined symbol "c"`,
+	}, "\n")
+
+	var echoed bytes.Buffer
+	parser := newCompilerOutputParser(strings.NewReader(output), "/src", "/src", true)
+	parser.output = &echoed
+	parser.config = build.Config{DependencyWarnings: build.DependencyWarningsSummary}
+	parser.dependency = func(file string) string {
+		if strings.HasPrefix(file, "/src/dependencies/lib/") {
+			return "github.com/user/lib"
+		}
+		return ""
+	}
+	go parser.Run()
+	problems, _ := parser.Wait()
+
+	require.Len(t, problems, 3)
+	assert.Empty(t, problems[0].Dependency)
+	assert.Equal(t, "github.com/user/lib", problems[1].Dependency)
+	assert.Equal(t, "dependencies/lib/lib.inc", problems[1].File, "attribution uses the absolute path")
+	assert.Equal(t, "github.com/user/lib", problems[2].Dependency)
+
+	assert.Contains(t, echoed.String(), "script.pwn:1 (warning)")
+	assert.NotContains(t, echoed.String(), "shadows a variable", "summarised warnings are not printed")
+	assert.Contains(t, echoed.String(), "lib.inc:3 (error)")
+	assert.False(t, problems.IsValid())
+}
@@ -98,6 +98,13 @@ func TestCompilerOptions_ToArgs(t *testing.T) {
 			},
 			want: []string{"-t4"},
 		},
+		{
+			name: "with report file",
+			opts: &CompilerOptions{
+				ReportFile: strPtr("report.xml"),
+			},
+			want: []string{"-rreport.xml"},
+		},
 		{
 			name: "complex combination",
 			opts: &CompilerOptions{
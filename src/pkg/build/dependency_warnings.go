@@ -0,0 +1,68 @@
+package build
+
+import (
+	"fmt"
+	"sort"
+)
+
+// DependencyWarnings selects how warnings from files inside dependencies are reported
+type DependencyWarnings string
+
+const (
+	// DependencyWarningsShow reports warnings from dependencies like any other warning, this is
+	// the default
+	DependencyWarningsShow DependencyWarnings = "show"
+	// DependencyWarningsSummary keeps warnings from dependencies out of the compiler output and
+	// prints a count for each dependency instead, they are still included in structured reports
+	DependencyWarningsSummary DependencyWarnings = "summary"
+	// DependencyWarningsHide drops warnings from dependencies entirely
+	DependencyWarningsHide DependencyWarnings = "hide"
+)
+
+// ParseDependencyWarnings validates a dependency warnings mode, an empty name is the default
+func ParseDependencyWarnings(name string) (DependencyWarnings, error) {
+	switch mode := DependencyWarnings(name); mode {
+	case "":
+		return DependencyWarningsShow, nil
+	case DependencyWarningsShow, DependencyWarningsSummary, DependencyWarningsHide:
+		return mode, nil
+	}
+	return "", fmt.Errorf("unsupported dependency warnings mode %q, must be one of show, summary, hide", name)
+}
+
+// FromDependency returns true if the problem was reported from a file inside a dependency
+func (bp Problem) FromDependency() bool {
+	return bp.Dependency != ""
+}
+
+// SummarisedWarning returns true for warnings that are counted in the dependency warning summary
+// instead of being printed as they are reported
+func (cfg Config) SummarisedWarning(problem Problem) bool {
+	return cfg.DependencyWarnings == DependencyWarningsSummary &&
+		problem.Severity == ProblemWarning &&
+		problem.FromDependency()
+}
+
+// DependencyWarningCount is the number of warnings reported from inside a single dependency
+type DependencyWarningCount struct {
+	Dependency string
+	Warnings   int
+}
+
+// DependencyWarnings counts the warnings reported from inside each dependency, ordered by
+// dependency
+func (bps Problems) DependencyWarnings() (counts []DependencyWarningCount) {
+	byDependency := make(map[string]int)
+	for _, problem := range bps {
+		if problem.Severity == ProblemWarning && problem.FromDependency() {
+			byDependency[problem.Dependency]++
+		}
+	}
+	for dependency, warnings := range byDependency {
+		counts = append(counts, DependencyWarningCount{Dependency: dependency, Warnings: warnings})
+	}
+	sort.Slice(counts, func(i, j int) bool {
+		return counts[i].Dependency < counts[j].Dependency
+	})
+	return
+}
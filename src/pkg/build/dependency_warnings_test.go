@@ -0,0 +1,47 @@
+package build
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseDependencyWarnings(t *testing.T) {
+	for name, expected := range map[string]DependencyWarnings{
+		"":        DependencyWarningsShow,
+		"show":    DependencyWarningsShow,
+		"summary": DependencyWarningsSummary,
+		"hide":    DependencyWarningsHide,
+	} {
+		mode, err := ParseDependencyWarnings(name)
+		require.NoError(t, err)
+		assert.Equal(t, expected, mode)
+	}
+
+	_, err := ParseDependencyWarnings("quiet")
+	assert.EqualError(t, err, `unsupported dependency warnings mode "quiet", must be one of show, summary, hide`)
+}
+
+func TestSummarisedWarning(t *testing.T) {
+	cfg := Config{DependencyWarnings: DependencyWarningsSummary}
+	assert.True(t, cfg.SummarisedWarning(Problem{Severity: ProblemWarning, Dependency: "github.com/user/lib"}))
+	assert.False(t, cfg.SummarisedWarning(Problem{Severity: ProblemError, Dependency: "github.com/user/lib"}))
+	assert.False(t, cfg.SummarisedWarning(Problem{Severity: ProblemWarning}))
+	assert.False(t, Config{}.SummarisedWarning(Problem{Severity: ProblemWarning, Dependency: "github.com/user/lib"}))
+}
+
+func TestProblemsDependencyWarnings(t *testing.T) {
+	problems := Problems{
+		{Severity: ProblemWarning, Dependency: "github.com/user/b"},
+		{Severity: ProblemWarning, Dependency: "github.com/user/a"},
+		{Severity: ProblemWarning, Dependency: "github.com/user/b"},
+		{Severity: ProblemError, Dependency: "github.com/user/a"},
+		{Severity: ProblemWarning},
+	}
+	assert.Equal(t, []DependencyWarningCount{
+		{Dependency: "github.com/user/a", Warnings: 1},
+		{Dependency: "github.com/user/b", Warnings: 2},
+	}, problems.DependencyWarnings())
+	assert.Empty(t, Problems{{Severity: ProblemWarning}}.DependencyWarnings())
+}
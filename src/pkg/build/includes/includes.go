@@ -0,0 +1,288 @@
+// Package includes resolves the files a Pawn script pulls in through `#include` and `#tryinclude`
+// directives, following the same search rules as the compiler.
+package includes
+
+import (
+	"bufio"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// extensions are tried, in order, when an include name does not match a file exactly
+var extensions = []string{".inc", ".p", ".pawn"}
+
+// Directive is a single `#include` or `#tryinclude` line in a source file
+type Directive struct {
+	File   string `json:"file"`   // the file containing the directive
+	Line   int    `json:"line"`   // the line number of the directive
+	Name   string `json:"name"`   // the include name as written, without delimiters
+	Quoted bool   `json:"quoted"` // true for `#include "name"`, which searches the including file's directory first
+	Try    bool   `json:"try"`    // true for `#tryinclude`, which does not fail when nothing is found
+}
+
+// Resolution is a directive and the file that satisfied it
+type Resolution struct {
+	Directive
+	Path     string   `json:"path,omitempty"`     // the resolved file, empty when no file was found
+	Shadowed []string `json:"shadowed,omitempty"` // files later in the search path that would also satisfy the directive
+}
+
+// Found returns true if the directive was satisfied by a file
+func (r Resolution) Found() bool {
+	return r.Path != ""
+}
+
+// Graph is the set of files reachable from an entry script
+type Graph struct {
+	Entry    string       `json:"entry"`
+	Files    []string     `json:"files"`    // every reachable file, starting with the entry, in the order they are first included
+	Includes []Resolution `json:"includes"` // every directive in every reachable file
+}
+
+// Missing returns the directives that were not satisfied by any file
+func (g Graph) Missing() (missing []Resolution) {
+	for _, r := range g.Includes {
+		if !r.Found() {
+			missing = append(missing, r)
+		}
+	}
+	return
+}
+
+// Resolve walks the include directives reachable from the entry file. Include directories are
+// searched in the order given, which should match the order of the compiler's `-i` flags.
+//
+// Preprocessor conditions are not evaluated so the graph is a superset of what the compiler
+// actually reads, each file is only walked once.
+func Resolve(entry string, includeDirs []string) (Graph, error) {
+	entry, err := filepath.Abs(entry)
+	if err != nil {
+		return Graph{}, errors.Wrap(err, "failed to resolve entry path")
+	}
+
+	r := resolver{
+		includeDirs: includeDirs,
+		visited:     make(map[string]bool),
+		graph:       Graph{Entry: entry},
+	}
+	if err := r.walk(entry); err != nil {
+		return Graph{}, err
+	}
+	return r.graph, nil
+}
+
+type resolver struct {
+	includeDirs []string
+	visited     map[string]bool
+	graph       Graph
+}
+
+func (r *resolver) walk(file string) error {
+	if r.visited[file] {
+		return nil
+	}
+	r.visited[file] = true
+	r.graph.Files = append(r.graph.Files, file)
+
+	directives, err := ParseFile(file)
+	if err != nil {
+		return err
+	}
+
+	for _, directive := range directives {
+		resolution := r.resolve(directive)
+		r.graph.Includes = append(r.graph.Includes, resolution)
+		if resolution.Found() {
+			if err := r.walk(resolution.Path); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+func (r *resolver) resolve(directive Directive) Resolution {
+	resolution := Resolution{Directive: directive}
+	for _, dir := range SearchPath(directive, r.includeDirs) {
+		path := FindInDir(dir, directive.Name)
+		switch {
+		case path == "":
+		case resolution.Path == "":
+			resolution.Path = path
+		case path != resolution.Path && !contains(resolution.Shadowed, path):
+			resolution.Shadowed = append(resolution.Shadowed, path)
+		}
+	}
+	return resolution
+}
+
+func contains(paths []string, path string) bool {
+	for _, p := range paths {
+		if p == path {
+			return true
+		}
+	}
+	return false
+}
+
+// SearchPath returns the directories searched for a directive, in order
+func SearchPath(directive Directive, includeDirs []string) []string {
+	name := normaliseName(directive.Name)
+	if filepath.IsAbs(name) {
+		return []string{""}
+	}
+	dirs := make([]string, 0, len(includeDirs)+1)
+	if directive.Quoted {
+		dirs = append(dirs, filepath.Dir(directive.File))
+	}
+	return append(dirs, includeDirs...)
+}
+
+// FindInDir returns the file an include name refers to within a single directory, or an empty
+// string if the directory does not contain it
+func FindInDir(dir, name string) string {
+	base := filepath.Join(dir, normaliseName(name))
+	if isFile(base) {
+		return base
+	}
+	for _, ext := range extensions {
+		if isFile(base + ext) {
+			return base + ext
+		}
+	}
+	return ""
+}
+
+func normaliseName(name string) string {
+	return filepath.FromSlash(strings.ReplaceAll(name, "\\", "/"))
+}
+
+func isFile(path string) bool {
+	info, err := os.Stat(path)
+	return err == nil && info.Mode().IsRegular()
+}
+
+// ParseFile reads the include directives from a single source file
+func ParseFile(file string) ([]Directive, error) {
+	var directives []Directive
+	err := scanSource(file, func(line string, lineNumber int) {
+		if directive, ok := parseDirective(line); ok {
+			directive.File = file
+			directive.Line = lineNumber
+			directives = append(directives, directive)
+		}
+	})
+	if err != nil {
+		return nil, err
+	}
+	return directives, nil
+}
+
+// scanSource calls fn with every line of a source file after comments have been removed
+func scanSource(file string, fn func(line string, lineNumber int)) error {
+	f, err := os.Open(file)
+	if err != nil {
+		return errors.Wrapf(err, "failed to open %s", file)
+	}
+	defer f.Close() // nolint
+
+	var (
+		inComment  bool
+		lineNumber int
+	)
+
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		lineNumber++
+
+		var line string
+		line, inComment = stripComments(scanner.Text(), inComment)
+		fn(line, lineNumber)
+	}
+	if err := scanner.Err(); err != nil {
+		return errors.Wrapf(err, "failed to read %s", file)
+	}
+	return nil
+}
+
+// stripComments removes line and block comments from a line, tracking whether a block comment
+// continues on to the next line
+func stripComments(line string, inComment bool) (string, bool) {
+	var b strings.Builder
+	for i := 0; i < len(line); i++ {
+		if inComment {
+			if strings.HasPrefix(line[i:], "*/") {
+				inComment = false
+				i++
+			}
+			continue
+		}
+		if strings.HasPrefix(line[i:], "//") {
+			break
+		}
+		if strings.HasPrefix(line[i:], "/*") {
+			inComment = true
+			i++
+			continue
+		}
+		b.WriteByte(line[i])
+	}
+	return b.String(), inComment
+}
+
+func parseDirective(line string) (Directive, bool) {
+	line = strings.TrimSpace(line)
+	if !strings.HasPrefix(line, "#") {
+		return Directive{}, false
+	}
+	line = strings.TrimSpace(line[1:])
+
+	var directive Directive
+	switch {
+	case strings.HasPrefix(line, "include"):
+		line = line[len("include"):]
+	case strings.HasPrefix(line, "tryinclude"):
+		line = line[len("tryinclude"):]
+		directive.Try = true
+	default:
+		return Directive{}, false
+	}
+
+	// reject longer words such as `#included`
+	if line == "" || !strings.ContainsRune(" \t<\"", rune(line[0])) {
+		return Directive{}, false
+	}
+	rest := strings.TrimSpace(line)
+	if rest == "" {
+		return Directive{}, false
+	}
+
+	switch rest[0] {
+	case '<':
+		end := strings.IndexByte(rest, '>')
+		if end < 0 {
+			return Directive{}, false
+		}
+		directive.Name = rest[1:end]
+	case '"':
+		end := strings.IndexByte(rest[1:], '"')
+		if end < 0 {
+			return Directive{}, false
+		}
+		directive.Name = rest[1 : end+1]
+		directive.Quoted = true
+	default:
+		// names without delimiters are searched for like `<name>`
+		directive.Name = strings.Fields(rest)[0]
+	}
+
+	directive.Name = strings.TrimSpace(directive.Name)
+	if directive.Name == "" {
+		return Directive{}, false
+	}
+	return directive, true
+}
@@ -0,0 +1,163 @@
+package includes
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func writeFile(t *testing.T, path, contents string) string {
+	t.Helper()
+	require.NoError(t, os.MkdirAll(filepath.Dir(path), 0o755))
+	require.NoError(t, os.WriteFile(path, []byte(contents), 0o644))
+	return path
+}
+
+func TestParseDirective(t *testing.T) {
+	for _, tt := range []struct {
+		line      string
+		directive Directive
+		ok        bool
+	}{
+		{`#include <a_samp>`, Directive{Name: "a_samp"}, true},
+		{`  #  include   <YSI_Coding\y_hooks>  `, Directive{Name: `YSI_Coding\y_hooks`}, true},
+		{`#include "local.inc"`, Directive{Name: "local.inc", Quoted: true}, true},
+		{`#tryinclude <optional>`, Directive{Name: "optional", Try: true}, true},
+		{`#include bare`, Directive{Name: "bare"}, true},
+		{`#included`, Directive{}, false},
+		{`#include`, Directive{}, false},
+		{`#include <>`, Directive{}, false},
+		{`#define include`, Directive{}, false},
+		{`main() {}`, Directive{}, false},
+	} {
+		t.Run(tt.line, func(t *testing.T) {
+			directive, ok := parseDirective(tt.line)
+			assert.Equal(t, tt.ok, ok)
+			assert.Equal(t, tt.directive, directive)
+		})
+	}
+}
+
+func TestParseFileSkipsComments(t *testing.T) {
+	file := writeFile(t, filepath.Join(t.TempDir(), "main.pwn"), `#include <a>
+// #include <commented>
+/* #include <block>
+#include <still-block> */ #include <after-block>
+#include <b> // trailing comment
+`)
+
+	directives, err := ParseFile(file)
+	require.NoError(t, err)
+
+	var names []string
+	for _, d := range directives {
+		names = append(names, d.Name)
+	}
+	assert.Equal(t, []string{"a", "after-block", "b"}, names)
+	assert.Equal(t, 5, directives[2].Line)
+	assert.Equal(t, file, directives[2].File)
+}
+
+func TestResolve(t *testing.T) {
+	root := t.TempDir()
+	src := filepath.Join(root, "gamemodes")
+	depA := filepath.Join(root, "dependencies", "a")
+	depB := filepath.Join(root, "dependencies", "b")
+
+	entry := writeFile(t, filepath.Join(src, "main.pwn"), `
+#include <a_samp>
+#include "utils"
+#include <lib\nested>
+#tryinclude <missing>
+`)
+	utils := writeFile(t, filepath.Join(src, "utils.inc"), "#include <a_samp>\n")
+	samp := writeFile(t, filepath.Join(depA, "a_samp.inc"), "#include <core>\n")
+	core := writeFile(t, filepath.Join(depA, "core.inc"), "")
+	writeFile(t, filepath.Join(depB, "a_samp.inc"), "shadowed by dependencies/a")
+	nested := writeFile(t, filepath.Join(depB, "lib", "nested.inc"), `#include "core"`)
+
+	graph, err := Resolve(entry, []string{depA, depB})
+	require.NoError(t, err)
+
+	assert.Equal(t, entry, graph.Entry)
+	assert.Equal(t, []string{entry, samp, core, utils, nested}, graph.Files)
+	require.Len(t, graph.Includes, 7)
+	assert.Equal(t, samp, graph.Includes[0].Path)
+	assert.Equal(t, []string{filepath.Join(depB, "a_samp.inc")}, graph.Includes[0].Shadowed)
+	assert.Equal(t, nested, graph.Includes[4].Path)
+	assert.Equal(t, core, graph.Includes[5].Path, "quoted includes fall back to the include directories")
+
+	missing := graph.Missing()
+	require.Len(t, missing, 1)
+	assert.Equal(t, "missing", missing[0].Name)
+	assert.True(t, missing[0].Try)
+}
+
+func TestResolveQuotedPrefersIncludingDirectory(t *testing.T) {
+	root := t.TempDir()
+	entry := writeFile(t, filepath.Join(root, "src", "main.pwn"), `#include "config"`+"\n"+`#include <config>`)
+	local := writeFile(t, filepath.Join(root, "src", "config.inc"), "")
+	shared := writeFile(t, filepath.Join(root, "include", "config.inc"), "")
+
+	graph, err := Resolve(entry, []string{filepath.Join(root, "include")})
+	require.NoError(t, err)
+	require.Len(t, graph.Includes, 2)
+	assert.Equal(t, local, graph.Includes[0].Path)
+	assert.Equal(t, []string{shared}, graph.Includes[0].Shadowed)
+	assert.Equal(t, shared, graph.Includes[1].Path)
+	assert.Empty(t, graph.Includes[1].Shadowed)
+}
+
+func TestResolveMissingEntry(t *testing.T) {
+	_, err := Resolve(filepath.Join(t.TempDir(), "nope.pwn"), nil)
+	assert.Error(t, err)
+}
+
+func TestParseNative(t *testing.T) {
+	for _, tt := range []struct {
+		line string
+		name string
+		ok   bool
+	}{
+		{`native SetGameModeText(const string[]);`, "SetGameModeText", true},
+		{`native Float:floatsqroot(Float:value);`, "floatsqroot", true},
+		{`native bool:IsValidVehicle(vehicleid);`, "IsValidVehicle", true},
+		{`native {Float, _}:Clamp(value, min, max);`, "Clamp", true},
+		{`	native print(const string[]);`, "print", true},
+		{`native @Internal(id);`, "@Internal", true},
+		{`native SSCANF_Init(players, invalid, len) = sscanf_init;`, "sscanf_init", true},
+		{`native Float:operator*(Float:oper1, Float:oper2) = floatmul;`, "floatmul", true},
+		{`native Float:operator-(Float:oper);`, "", false},
+		{`stock NotNative(native) {}`, "", false},
+		{`forward OnGameModeInit();`, "", false},
+		{`#define native_count 3`, "", false},
+	} {
+		t.Run(tt.line, func(t *testing.T) {
+			name, ok := parseNative(tt.line)
+			assert.Equal(t, tt.ok, ok)
+			assert.Equal(t, tt.name, name)
+		})
+	}
+}
+
+func TestParseNativesSkipsComments(t *testing.T) {
+	file := writeFile(t, filepath.Join(t.TempDir(), "plugin.inc"), `native Plugin_Init();
+/*
+native Plugin_Documented();
+*/
+// native Plugin_Commented();
+native Plugin_Call(
+	id
+);
+`)
+
+	natives, err := ParseNatives(file)
+	require.NoError(t, err)
+	assert.Equal(t, []Native{
+		{File: file, Line: 1, Name: "Plugin_Init"},
+		{File: file, Line: 6, Name: "Plugin_Call"},
+	}, natives)
+}
@@ -0,0 +1,56 @@
+package includes
+
+import (
+	"regexp"
+	"strings"
+)
+
+var (
+	// nativeDeclaration matches `native`, an optional tag such as `Float:` or `{Float, _}:` and
+	// the function name, operators are matched so their external name can be read
+	nativeDeclaration = regexp.MustCompile(`(?:^|[^\w@])native\s+(?:(?:\{[^}]*\}|[A-Za-z_@][\w@]*)\s*:\s*)?([A-Za-z_@][\w@.]*|operator[^\s(]+)\s*\(`)
+	// nativeExternalName matches the `= external` suffix that binds a declaration to a differently
+	// named native
+	nativeExternalName = regexp.MustCompile(`\)\s*=\s*([A-Za-z_@][\w@.]*)\s*;?\s*$`)
+	identifier         = regexp.MustCompile(`^[A-Za-z_@][\w@.]*$`)
+)
+
+// Native is a `native` function declaration in a source file
+type Native struct {
+	File string `json:"file"`
+	Line int    `json:"line"`
+	// Name is the name the native is registered under, which is the external name when it is
+	// declared as `native name() = external;`
+	Name string `json:"name"`
+}
+
+// ParseNatives reads the native function declarations from a single source file, declarations
+// inside comments are ignored
+func ParseNatives(file string) ([]Native, error) {
+	var natives []Native
+	err := scanSource(file, func(line string, lineNumber int) {
+		if name, ok := parseNative(line); ok {
+			natives = append(natives, Native{File: file, Line: lineNumber, Name: name})
+		}
+	})
+	if err != nil {
+		return nil, err
+	}
+	return natives, nil
+}
+
+func parseNative(line string) (string, bool) {
+	match := nativeDeclaration.FindStringSubmatchIndex(line)
+	if match == nil {
+		return "", false
+	}
+	name := line[match[2]:match[3]]
+	if external := nativeExternalName.FindStringSubmatch(line[match[1]:]); external != nil {
+		return external[1], true
+	}
+	// an operator without an external name is not registered under a name a script can call
+	if strings.HasPrefix(name, "operator") && !identifier.MatchString(name) {
+		return "", false
+	}
+	return name, true
+}
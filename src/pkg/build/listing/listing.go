@@ -0,0 +1,145 @@
+// Package listing reads the listing file the Pawn compiler writes with `-l`, the source after
+// the preprocessor expanded every macro, and maps it back to the files it came from.
+package listing
+
+import (
+	"bufio"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// Region is a run of consecutive non-blank lines of the listing that came from consecutive
+// lines of one source file, usually a declaration or a statement after macro expansion
+type Region struct {
+	File  string   `json:"file"`
+	Start int      `json:"start"` // the source line of the first line
+	End   int      `json:"end"`   // the source line of the last line
+	Lines []string `json:"lines"`
+}
+
+// Listing is the preprocessed source of a script split into regions
+type Listing struct {
+	Regions []Region `json:"regions"`
+}
+
+// FileSummary is how much of the listing came from one source file
+type FileSummary struct {
+	File    string `json:"file"`
+	Regions int    `json:"regions"`
+	Lines   int    `json:"lines"`
+}
+
+var (
+	fileDirective = regexp.MustCompile(`^\s*#\s*file\s+"?([^"]*?)"?\s*$`)
+	lineDirective = regexp.MustCompile(`^\s*#\s*line\s+(\d+)\s*$`)
+)
+
+// Path returns where the compiler writes the listing of a build, the compiler replaces the
+// extension of the output file
+func Path(output string) string {
+	return strings.TrimSuffix(output, filepath.Ext(output)) + ".lst"
+}
+
+// Open reads a listing file
+func Open(path string) (Listing, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return Listing{}, errors.Wrap(err, "failed to open listing")
+	}
+	defer f.Close() // nolint
+	return Parse(f)
+}
+
+// Parse reads a listing, the compiler marks where the source switches between files with
+// `#file` and `#line` directives that set the file and line number of the following line
+func Parse(r io.Reader) (Listing, error) {
+	var (
+		listing Listing
+		current *Region
+		file    string
+		line    = 1
+	)
+	flush := func() {
+		if current != nil {
+			listing.Regions = append(listing.Regions, *current)
+			current = nil
+		}
+	}
+
+	reader := bufio.NewReader(r)
+	for {
+		// expanded macros can produce lines far longer than a scanner's buffer
+		text, err := reader.ReadString('\n')
+		if err != nil && err != io.EOF {
+			return Listing{}, errors.Wrap(err, "failed to read listing")
+		}
+		if text == "" && err == io.EOF {
+			break
+		}
+		text = strings.TrimRight(text, "\r\n")
+
+		switch {
+		case fileDirective.MatchString(text):
+			flush()
+			file = fileDirective.FindStringSubmatch(text)[1]
+			line = 1
+		case lineDirective.MatchString(text):
+			flush()
+			line, _ = strconv.Atoi(lineDirective.FindStringSubmatch(text)[1])
+		case strings.TrimSpace(text) == "":
+			flush()
+			line++
+		default:
+			if current == nil {
+				current = &Region{File: file, Start: line}
+			}
+			current.End = line
+			current.Lines = append(current.Lines, text)
+			line++
+		}
+
+		if err == io.EOF {
+			break
+		}
+	}
+	flush()
+	return listing, nil
+}
+
+// Grep returns the regions with a line that contains the symbol as a whole identifier
+func (l Listing) Grep(symbol string) []Region {
+	pattern := regexp.MustCompile(`(?:^|[^\w@])` + regexp.QuoteMeta(symbol) + `(?:$|[^\w@])`)
+	var matches []Region
+	for _, region := range l.Regions {
+		for _, text := range region.Lines {
+			if pattern.MatchString(text) {
+				matches = append(matches, region)
+				break
+			}
+		}
+	}
+	return matches
+}
+
+// Files summarises the listing by source file, in the order the files first appear
+func (l Listing) Files() []FileSummary {
+	var summaries []FileSummary
+	index := make(map[string]int)
+	for _, region := range l.Regions {
+		i, ok := index[region.File]
+		if !ok {
+			i = len(summaries)
+			index[region.File] = i
+			summaries = append(summaries, FileSummary{File: region.File})
+		}
+		summaries[i].Regions++
+		summaries[i].Lines += len(region.Lines)
+	}
+	return summaries
+}
@@ -0,0 +1,92 @@
+package listing
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const testListing = `#file "/project/gamemodes/test.pwn"
+#line 1
+
+#file "/project/dependencies/YSI/y_iterate.inc"
+#line 10
+new Iterator@Player[501] = {0, ...};
+stock Iter_Add_(&count, arr[], value)
+{
+	return ++count, arr[value] = 1;
+}
+#file "/project/gamemodes/test.pwn"
+#line 3
+main()
+{
+	for (new playerid = 0; playerid != 500; playerid++) Iter_Add_(Iterator@Player[500], Iterator@Player, playerid);
+}
+`
+
+func TestParse(t *testing.T) {
+	t.Parallel()
+
+	listing, err := Parse(strings.NewReader(testListing))
+	require.NoError(t, err)
+	assert.Equal(t, []Region{
+		{
+			File: "/project/dependencies/YSI/y_iterate.inc", Start: 10, End: 14,
+			Lines: []string{
+				"new Iterator@Player[501] = {0, ...};",
+				"stock Iter_Add_(&count, arr[], value)",
+				"{",
+				"\treturn ++count, arr[value] = 1;",
+				"}",
+			},
+		},
+		{
+			File: "/project/gamemodes/test.pwn", Start: 3, End: 6,
+			Lines: []string{
+				"main()",
+				"{",
+				"\tfor (new playerid = 0; playerid != 500; playerid++) Iter_Add_(Iterator@Player[500], Iterator@Player, playerid);",
+				"}",
+			},
+		},
+	}, listing.Regions)
+
+	assert.Equal(t, []FileSummary{
+		{File: "/project/dependencies/YSI/y_iterate.inc", Regions: 1, Lines: 5},
+		{File: "/project/gamemodes/test.pwn", Regions: 1, Lines: 4},
+	}, listing.Files())
+}
+
+func TestParseSplitsRegionsOnBlankLines(t *testing.T) {
+	t.Parallel()
+
+	listing, err := Parse(strings.NewReader("#file a.pwn\nnew a;\n\nnew b;\r\nnew c;"))
+	require.NoError(t, err)
+	require.Len(t, listing.Regions, 2)
+	assert.Equal(t, Region{File: "a.pwn", Start: 1, End: 1, Lines: []string{"new a;"}}, listing.Regions[0])
+	assert.Equal(t, Region{File: "a.pwn", Start: 3, End: 4, Lines: []string{"new b;", "new c;"}}, listing.Regions[1])
+}
+
+func TestGrepMatchesWholeIdentifiers(t *testing.T) {
+	t.Parallel()
+
+	listing, err := Parse(strings.NewReader(testListing))
+	require.NoError(t, err)
+
+	matches := listing.Grep("Iter_Add_")
+	require.Len(t, matches, 2)
+
+	matches = listing.Grep("Iterator@Player")
+	require.Len(t, matches, 2)
+
+	assert.Empty(t, listing.Grep("Iter"), "part of an identifier does not match")
+	assert.Len(t, listing.Grep("main"), 1)
+}
+
+func TestPath(t *testing.T) {
+	t.Parallel()
+
+	assert.Equal(t, "gamemodes/test.lst", Path("gamemodes/test.amx"))
+}
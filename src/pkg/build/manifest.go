@@ -0,0 +1,111 @@
+package build
+
+import (
+	"encoding/json"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/pkg/errors"
+
+	"github.com/Southclaws/sampctl/src/pkg/build/includes"
+)
+
+// Manifest lists every source and include file that contributed to a build's output, resolved
+// from the include graph of its entry script
+type Manifest struct {
+	Output      string                `json:"output"`
+	Entry       string                `json:"entry"`
+	IncludeDirs []string              `json:"include_dirs"`
+	Files       []string              `json:"files"`    // the entry followed by every resolved include, in the order they are first included
+	Includes    []includes.Resolution `json:"includes"` // every directive in every file, including those that were not found
+}
+
+// NewManifest creates the manifest of a build from the include graph of its entry script
+func NewManifest(output string, graph includes.Graph, includeDirs []string) Manifest {
+	return Manifest{
+		Output:      output,
+		Entry:       graph.Entry,
+		IncludeDirs: append([]string{}, includeDirs...),
+		Files:       append([]string{}, graph.Files...),
+		Includes:    append([]includes.Resolution{}, graph.Includes...),
+	}
+}
+
+// DepfilePath returns where the depfile of a build output is written
+func DepfilePath(output string) string {
+	return output + ".d"
+}
+
+// ManifestPath returns where the include manifest of a build output is written
+func ManifestPath(output string) string {
+	return output + ".includes.json"
+}
+
+// WriteDepfile writes the manifest as a Makefile rule with the output as its target and every
+// file as a prerequisite. Each file also gets an empty rule so make and ninja do not fail when a
+// file is deleted or no longer included.
+func (m Manifest) WriteDepfile(w io.Writer) error {
+	var b strings.Builder
+	b.WriteString(escapeDepfilePath(m.Output))
+	b.WriteString(":")
+	for _, file := range m.Files {
+		b.WriteString(" \\\n  ")
+		b.WriteString(escapeDepfilePath(file))
+	}
+	b.WriteString("\n")
+	for _, file := range m.Files {
+		b.WriteString("\n")
+		b.WriteString(escapeDepfilePath(file))
+		b.WriteString(":\n")
+	}
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+// WriteJSON writes the manifest as an indented JSON document
+func (m Manifest) WriteJSON(w io.Writer) error {
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "\t")
+	return encoder.Encode(m)
+}
+
+// WriteFiles writes the depfile and the JSON manifest next to the build output
+func (m Manifest) WriteFiles() error {
+	if m.Output == "" {
+		return errors.New("build has no output file")
+	}
+	if err := writeManifestFile(DepfilePath(m.Output), m.WriteDepfile); err != nil {
+		return err
+	}
+	return writeManifestFile(ManifestPath(m.Output), m.WriteJSON)
+}
+
+func writeManifestFile(path string, write func(io.Writer) error) error {
+	var b strings.Builder
+	if err := write(&b); err != nil {
+		return errors.Wrapf(err, "failed to encode %s", path)
+	}
+	if err := os.WriteFile(path, []byte(b.String()), 0o644); err != nil {
+		return errors.Wrapf(err, "failed to write %s", path)
+	}
+	return nil
+}
+
+// escapeDepfilePath escapes the characters make treats specially in rules, paths always use
+// forward slashes since backslashes are escape characters
+func escapeDepfilePath(path string) string {
+	path = filepath.ToSlash(path)
+	var b strings.Builder
+	for _, r := range path {
+		switch r {
+		case ' ', '#', '\\':
+			b.WriteRune('\\')
+		case '$':
+			b.WriteRune('$')
+		}
+		b.WriteRune(r)
+	}
+	return b.String()
+}
@@ -0,0 +1,55 @@
+package build
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/Southclaws/sampctl/src/pkg/build/includes"
+)
+
+func testManifest(output string) Manifest {
+	return NewManifest(output, includes.Graph{
+		Entry: "/project/gamemodes/main.pwn",
+		Files: []string{"/project/gamemodes/main.pwn", "/project/my includes/util.inc", "/project/dependencies/lib/$lib.inc"},
+		Includes: []includes.Resolution{
+			{Directive: includes.Directive{File: "/project/gamemodes/main.pwn", Line: 1, Name: "util", Quoted: true}, Path: "/project/my includes/util.inc"},
+			{Directive: includes.Directive{File: "/project/gamemodes/main.pwn", Line: 2, Name: "optional", Try: true}},
+		},
+	}, []string{"/project", "/project/dependencies/lib"})
+}
+
+func TestManifestWriteDepfile(t *testing.T) {
+	var out bytes.Buffer
+	require.NoError(t, testManifest("/project/gamemodes/main.amx").WriteDepfile(&out))
+	assert.Equal(t, "/project/gamemodes/main.amx: \\\n"+
+		"  /project/gamemodes/main.pwn \\\n"+
+		"  /project/my\\ includes/util.inc \\\n"+
+		"  /project/dependencies/lib/$$lib.inc\n"+
+		"\n/project/gamemodes/main.pwn:\n"+
+		"\n/project/my\\ includes/util.inc:\n"+
+		"\n/project/dependencies/lib/$$lib.inc:\n", out.String())
+}
+
+func TestManifestWriteFiles(t *testing.T) {
+	output := filepath.Join(t.TempDir(), "main.amx")
+	manifest := testManifest(output)
+	require.NoError(t, manifest.WriteFiles())
+
+	depfile, err := os.ReadFile(output + ".d")
+	require.NoError(t, err)
+	assert.Contains(t, string(depfile), "/project/gamemodes/main.pwn")
+
+	contents, err := os.ReadFile(output + ".includes.json")
+	require.NoError(t, err)
+	var decoded Manifest
+	require.NoError(t, json.Unmarshal(contents, &decoded))
+	assert.Equal(t, manifest, decoded)
+
+	assert.EqualError(t, Manifest{}.WriteFiles(), "build has no output file")
+}
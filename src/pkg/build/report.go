@@ -0,0 +1,361 @@
+package build
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/url"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// ReportFormat selects how build problems are written once a build finishes
+type ReportFormat string
+
+const (
+	// ReportText leaves problems as the plain `file:line (severity) description` lines printed
+	// while compiling
+	ReportText ReportFormat = "text"
+	// ReportJSON writes problems and size statistics as a single JSON document
+	ReportJSON ReportFormat = "json"
+	// ReportSARIF writes problems as a SARIF 2.1.0 log for code scanning tools
+	ReportSARIF ReportFormat = "sarif"
+	// ReportGitHubAnnotations writes problems as GitHub Actions workflow commands
+	ReportGitHubAnnotations ReportFormat = "github-annotations"
+)
+
+// ReportFormats lists every supported report format
+var ReportFormats = []ReportFormat{ReportText, ReportJSON, ReportSARIF, ReportGitHubAnnotations}
+
+// ParseReportFormat validates a report format name
+func ParseReportFormat(name string) (ReportFormat, error) {
+	for _, format := range ReportFormats {
+		if string(format) == name {
+			return format, nil
+		}
+	}
+	names := make([]string, 0, len(ReportFormats))
+	for _, format := range ReportFormats {
+		names = append(names, string(format))
+	}
+	return "", fmt.Errorf("unsupported format %q, must be one of %s", name, strings.Join(names, ", "))
+}
+
+// Structured returns true for formats that must be the only thing written to standard output
+func (f ReportFormat) Structured() bool {
+	return f == ReportJSON || f == ReportSARIF
+}
+
+// Report holds everything needed to describe the outcome of a build
+type Report struct {
+	Build    string
+	Compiler string
+	// BaseDir is used to turn absolute problem paths into paths relative to the package
+	BaseDir  string
+	Problems Problems
+	Result   Result
+}
+
+// Write encodes the report in the given format, the text format writes nothing as problems are
+// already printed while compiling.
+func (r Report) Write(w io.Writer, format ReportFormat) error {
+	switch format {
+	case ReportText:
+		return nil
+	case ReportJSON:
+		return r.writeJSON(w)
+	case ReportSARIF:
+		return r.writeSARIF(w)
+	case ReportGitHubAnnotations:
+		return r.writeGitHubAnnotations(w, "")
+	}
+	return fmt.Errorf("unsupported format %q", format)
+}
+
+func (r Report) relativePath(file string) string {
+	if r.BaseDir != "" && filepath.IsAbs(file) {
+		if rel, err := filepath.Rel(r.BaseDir, file); err == nil && !strings.HasPrefix(rel, "..") {
+			file = rel
+		}
+	}
+	return filepath.ToSlash(file)
+}
+
+// Reports holds the reports of several builds that ran together
+type Reports []Report
+
+// Write encodes every report as a single document: a JSON array, a SARIF log with one run per
+// build or a list of annotations titled with the build name.
+func (rs Reports) Write(w io.Writer, format ReportFormat) error {
+	switch format {
+	case ReportText:
+		return nil
+	case ReportJSON:
+		documents := make([]jsonReport, 0, len(rs))
+		for _, r := range rs {
+			documents = append(documents, r.jsonReport())
+		}
+		return encodeJSON(w, documents)
+	case ReportSARIF:
+		runs := make([]sarifRun, 0, len(rs))
+		for _, r := range rs {
+			run := r.sarifRun()
+			run.AutomationDetails = &sarifAutomationDetails{ID: r.Build + "/"}
+			runs = append(runs, run)
+		}
+		return encodeSARIF(w, runs)
+	case ReportGitHubAnnotations:
+		for _, r := range rs {
+			if err := r.writeGitHubAnnotations(w, "["+r.Build+"] "); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	return fmt.Errorf("unsupported format %q", format)
+}
+
+type jsonReport struct {
+	Build    string   `json:"build"`
+	Success  bool     `json:"success"`
+	Problems Problems `json:"problems"`
+	Result   Result   `json:"result"`
+}
+
+func (r Report) jsonReport() jsonReport {
+	problems := make(Problems, 0, len(r.Problems))
+	for _, problem := range r.Problems {
+		problem.File = r.relativePath(problem.File)
+		problems = append(problems, problem)
+	}
+
+	return jsonReport{
+		Build:    r.Build,
+		Success:  !r.Problems.Fatal() && r.Problems.IsValid(),
+		Problems: problems,
+		Result:   r.Result,
+	}
+}
+
+func (r Report) writeJSON(w io.Writer) error {
+	return encodeJSON(w, r.jsonReport())
+}
+
+func encodeJSON(w io.Writer, v interface{}) error {
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "\t")
+	return encoder.Encode(v)
+}
+
+// ProblemRuleID returns the identifier used for a problem's diagnostic code in structured reports
+func ProblemRuleID(problem Problem) string {
+	if problem.Code == 0 {
+		return ""
+	}
+	return fmt.Sprintf("%03d", problem.Code)
+}
+
+func sarifLevel(severity ProblemSeverity) string {
+	if severity == ProblemWarning {
+		return "warning"
+	}
+	return "error"
+}
+
+type sarifLog struct {
+	Schema  string     `json:"$schema"`
+	Version string     `json:"version"`
+	Runs    []sarifRun `json:"runs"`
+}
+
+type sarifRun struct {
+	Tool               sarifTool                   `json:"tool"`
+	AutomationDetails  *sarifAutomationDetails     `json:"automationDetails,omitempty"`
+	OriginalURIBaseIDs map[string]sarifArtifactURI `json:"originalUriBaseIds,omitempty"`
+	Results            []sarifResult               `json:"results"`
+}
+
+type sarifAutomationDetails struct {
+	ID string `json:"id"`
+}
+
+type sarifTool struct {
+	Driver sarifDriver `json:"driver"`
+}
+
+type sarifDriver struct {
+	Name           string      `json:"name"`
+	Version        string      `json:"version,omitempty"`
+	InformationURI string      `json:"informationUri"`
+	Rules          []sarifRule `json:"rules"`
+}
+
+type sarifRule struct {
+	ID                   string            `json:"id"`
+	DefaultConfiguration sarifRuleDefaults `json:"defaultConfiguration"`
+}
+
+type sarifRuleDefaults struct {
+	Level string `json:"level"`
+}
+
+type sarifResult struct {
+	RuleID    string          `json:"ruleId,omitempty"`
+	RuleIndex *int            `json:"ruleIndex,omitempty"`
+	Level     string          `json:"level"`
+	Message   sarifMessage    `json:"message"`
+	Locations []sarifLocation `json:"locations"`
+}
+
+type sarifMessage struct {
+	Text string `json:"text"`
+}
+
+type sarifLocation struct {
+	PhysicalLocation sarifPhysicalLocation `json:"physicalLocation"`
+}
+
+type sarifPhysicalLocation struct {
+	ArtifactLocation sarifArtifactURI `json:"artifactLocation"`
+	Region           sarifRegion      `json:"region"`
+}
+
+type sarifArtifactURI struct {
+	URI       string `json:"uri"`
+	URIBaseID string `json:"uriBaseId,omitempty"`
+}
+
+type sarifRegion struct {
+	StartLine int `json:"startLine"`
+	EndLine   int `json:"endLine,omitempty"`
+}
+
+const sarifSourceRoot = "SRCROOT"
+
+func (r Report) writeSARIF(w io.Writer) error {
+	return encodeSARIF(w, []sarifRun{r.sarifRun()})
+}
+
+func encodeSARIF(w io.Writer, runs []sarifRun) error {
+	return encodeJSON(w, sarifLog{
+		Schema:  "https://json.schemastore.org/sarif-2.1.0.json",
+		Version: "2.1.0",
+		Runs:    runs,
+	})
+}
+
+func (r Report) sarifRun() sarifRun {
+	run := sarifRun{
+		Tool: sarifTool{Driver: sarifDriver{
+			Name:           "pawncc",
+			Version:        strings.TrimPrefix(r.Compiler, "v"),
+			InformationURI: "https://github.com/pawn-lang/compiler",
+			Rules:          []sarifRule{},
+		}},
+		Results: []sarifResult{},
+	}
+	if r.BaseDir != "" {
+		run.OriginalURIBaseIDs = map[string]sarifArtifactURI{
+			sarifSourceRoot: {URI: fileURI(r.BaseDir) + "/"},
+		}
+	}
+
+	rules := make(map[string]int)
+	var ruleIDs []string
+	for _, problem := range r.Problems {
+		id := ProblemRuleID(problem)
+		if id == "" {
+			continue
+		}
+		if _, ok := rules[id]; !ok {
+			rules[id] = -1
+			ruleIDs = append(ruleIDs, id)
+		}
+	}
+	sort.Strings(ruleIDs)
+	for i, id := range ruleIDs {
+		rules[id] = i
+		level := "warning"
+		if code, err := strconv.Atoi(id); err == nil && code < 200 {
+			level = "error"
+		}
+		run.Tool.Driver.Rules = append(run.Tool.Driver.Rules, sarifRule{
+			ID:                   id,
+			DefaultConfiguration: sarifRuleDefaults{Level: level},
+		})
+	}
+
+	for _, problem := range r.Problems {
+		result := sarifResult{
+			RuleID:  ProblemRuleID(problem),
+			Level:   sarifLevel(problem.Severity),
+			Message: sarifMessage{Text: problem.Description},
+			Locations: []sarifLocation{{PhysicalLocation: sarifPhysicalLocation{
+				ArtifactLocation: r.sarifArtifact(problem.File),
+				Region:           sarifRegion{StartLine: problem.Line, EndLine: problem.EndLine},
+			}}},
+		}
+		if index, ok := rules[result.RuleID]; ok {
+			result.RuleIndex = &index
+		}
+		run.Results = append(run.Results, result)
+	}
+
+	return run
+}
+
+func (r Report) sarifArtifact(file string) sarifArtifactURI {
+	rel := r.relativePath(file)
+	if r.BaseDir != "" && !filepath.IsAbs(filepath.FromSlash(rel)) {
+		return sarifArtifactURI{URI: (&url.URL{Path: rel}).EscapedPath(), URIBaseID: sarifSourceRoot}
+	}
+	if filepath.IsAbs(file) {
+		return sarifArtifactURI{URI: fileURI(file)}
+	}
+	return sarifArtifactURI{URI: (&url.URL{Path: rel}).EscapedPath()}
+}
+
+func fileURI(path string) string {
+	path = filepath.ToSlash(path)
+	if !strings.HasPrefix(path, "/") {
+		// Windows drive paths such as C:/ need a leading slash in file URIs
+		path = "/" + path
+	}
+	return (&url.URL{Scheme: "file", Path: path}).String()
+}
+
+func (r Report) writeGitHubAnnotations(w io.Writer, titlePrefix string) error {
+	for _, problem := range r.Problems {
+		command := "warning"
+		if problem.Severity != ProblemWarning {
+			command = "error"
+		}
+
+		title := titlePrefix + problem.Severity.String()
+		if id := ProblemRuleID(problem); id != "" {
+			title += " " + id
+		}
+
+		_, err := fmt.Fprintf(w, "::%s file=%s,line=%d,title=%s::%s\n",
+			command,
+			escapeAnnotationProperty(r.relativePath(problem.File)),
+			problem.Line,
+			escapeAnnotationProperty(title),
+			escapeAnnotationData(problem.Description),
+		)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func escapeAnnotationData(value string) string {
+	return strings.NewReplacer("%", "%25", "\r", "%0D", "\n", "%0A").Replace(value)
+}
+
+func escapeAnnotationProperty(value string) string {
+	return strings.NewReplacer("%", "%25", "\r", "%0D", "\n", "%0A", ":", "%3A", ",", "%2C").Replace(value)
+}
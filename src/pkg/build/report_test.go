@@ -0,0 +1,160 @@
+package build
+
+import (
+	"bytes"
+	"encoding/json"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func testReport(t *testing.T) Report {
+	t.Helper()
+
+	base := filepath.Join(t.TempDir(), "project")
+	return Report{
+		Build:    "default",
+		Compiler: "v3.10.10",
+		BaseDir:  base,
+		Problems: Problems{
+			{File: filepath.Join(base, "gamemodes", "main.pwn"), Line: 12, EndLine: 14, Severity: ProblemWarning, Code: 213, Description: "tag mismatch"},
+			{File: filepath.Join(base, "dependencies", "lib", "lib.inc"), Line: 3, Severity: ProblemError, Code: 17, Description: `undefined symbol "a,b"`},
+			{File: "relative.pwn", Line: 1, Severity: ProblemFatal, Code: 100, Description: "cannot read from file: \"x\"\nnext"},
+		},
+		Result: Result{Code: 100, Total: 200},
+	}
+}
+
+func TestParseReportFormat(t *testing.T) {
+	for _, format := range ReportFormats {
+		parsed, err := ParseReportFormat(string(format))
+		require.NoError(t, err)
+		assert.Equal(t, format, parsed)
+	}
+
+	_, err := ParseReportFormat("xml")
+	assert.EqualError(t, err, `unsupported format "xml", must be one of text, json, sarif, github-annotations`)
+
+	assert.True(t, ReportJSON.Structured())
+	assert.True(t, ReportSARIF.Structured())
+	assert.False(t, ReportText.Structured())
+	assert.False(t, ReportGitHubAnnotations.Structured())
+}
+
+func TestReportWriteText(t *testing.T) {
+	var out bytes.Buffer
+	require.NoError(t, testReport(t).Write(&out, ReportText))
+	assert.Empty(t, out.String())
+}
+
+func TestReportWriteJSON(t *testing.T) {
+	var out bytes.Buffer
+	require.NoError(t, testReport(t).Write(&out, ReportJSON))
+
+	var decoded struct {
+		Build    string   `json:"build"`
+		Success  bool     `json:"success"`
+		Problems Problems `json:"problems"`
+		Result   Result   `json:"result"`
+	}
+	require.NoError(t, json.Unmarshal(out.Bytes(), &decoded))
+	assert.Equal(t, "default", decoded.Build)
+	assert.False(t, decoded.Success)
+	require.Len(t, decoded.Problems, 3)
+	assert.Equal(t, Problem{File: "gamemodes/main.pwn", Line: 12, EndLine: 14, Severity: ProblemWarning, Code: 213, Description: "tag mismatch"}, decoded.Problems[0])
+	assert.Equal(t, ProblemFatal, decoded.Problems[2].Severity)
+	assert.Equal(t, 200, decoded.Result.Total)
+	assert.Contains(t, out.String(), `"severity": "warning"`)
+}
+
+func TestReportWriteSARIF(t *testing.T) {
+	report := testReport(t)
+
+	var out bytes.Buffer
+	require.NoError(t, report.Write(&out, ReportSARIF))
+
+	var log sarifLog
+	require.NoError(t, json.Unmarshal(out.Bytes(), &log))
+	assert.Equal(t, "2.1.0", log.Version)
+	require.Len(t, log.Runs, 1)
+
+	run := log.Runs[0]
+	assert.Equal(t, "pawncc", run.Tool.Driver.Name)
+	assert.Equal(t, "3.10.10", run.Tool.Driver.Version)
+	require.Len(t, run.Tool.Driver.Rules, 3)
+	assert.Equal(t, "017", run.Tool.Driver.Rules[0].ID)
+	assert.Equal(t, "error", run.Tool.Driver.Rules[0].DefaultConfiguration.Level)
+	assert.Equal(t, "213", run.Tool.Driver.Rules[2].ID)
+	assert.Equal(t, "warning", run.Tool.Driver.Rules[2].DefaultConfiguration.Level)
+	assert.Contains(t, run.OriginalURIBaseIDs[sarifSourceRoot].URI, "file:///")
+
+	require.Len(t, run.Results, 3)
+	first := run.Results[0]
+	assert.Equal(t, "213", first.RuleID)
+	require.NotNil(t, first.RuleIndex)
+	assert.Equal(t, 2, *first.RuleIndex)
+	assert.Equal(t, "warning", first.Level)
+	assert.Equal(t, "gamemodes/main.pwn", first.Locations[0].PhysicalLocation.ArtifactLocation.URI)
+	assert.Equal(t, sarifSourceRoot, first.Locations[0].PhysicalLocation.ArtifactLocation.URIBaseID)
+	assert.Equal(t, 12, first.Locations[0].PhysicalLocation.Region.StartLine)
+	assert.Equal(t, 14, first.Locations[0].PhysicalLocation.Region.EndLine)
+
+	assert.Equal(t, "error", run.Results[2].Level)
+}
+
+func TestReportWriteSARIFWithoutProblems(t *testing.T) {
+	var out bytes.Buffer
+	require.NoError(t, Report{}.Write(&out, ReportSARIF))
+	assert.Contains(t, out.String(), `"results": []`)
+	assert.Contains(t, out.String(), `"rules": []`)
+}
+
+func TestReportWriteGitHubAnnotations(t *testing.T) {
+	var out bytes.Buffer
+	require.NoError(t, testReport(t).Write(&out, ReportGitHubAnnotations))
+
+	assert.Equal(t, "::warning file=gamemodes/main.pwn,line=12,title=warning 213::tag mismatch\n"+
+		"::error file=dependencies/lib/lib.inc,line=3,title=error 017::undefined symbol \"a,b\"\n"+
+		"::error file=relative.pwn,line=1,title=fatal 100::cannot read from file: \"x\"%0Anext\n", out.String())
+}
+
+func TestReportsWrite(t *testing.T) {
+	first := testReport(t)
+	second := Report{Build: "tests", BaseDir: first.BaseDir, Problems: Problems{first.Problems[0]}}
+	reports := Reports{first, second}
+
+	var out bytes.Buffer
+	require.NoError(t, reports.Write(&out, ReportJSON))
+	var decoded []struct {
+		Build    string   `json:"build"`
+		Success  bool     `json:"success"`
+		Problems Problems `json:"problems"`
+	}
+	require.NoError(t, json.Unmarshal(out.Bytes(), &decoded))
+	require.Len(t, decoded, 2)
+	assert.Equal(t, "default", decoded[0].Build)
+	assert.False(t, decoded[0].Success)
+	assert.Equal(t, "tests", decoded[1].Build)
+	assert.True(t, decoded[1].Success)
+	assert.Len(t, decoded[1].Problems, 1)
+
+	out.Reset()
+	require.NoError(t, reports.Write(&out, ReportSARIF))
+	var log sarifLog
+	require.NoError(t, json.Unmarshal(out.Bytes(), &log))
+	require.Len(t, log.Runs, 2)
+	assert.Equal(t, "default/", log.Runs[0].AutomationDetails.ID)
+	assert.Equal(t, "tests/", log.Runs[1].AutomationDetails.ID)
+	assert.Len(t, log.Runs[1].Results, 1)
+
+	out.Reset()
+	require.NoError(t, reports.Write(&out, ReportGitHubAnnotations))
+	assert.Contains(t, out.String(), "title=[default] warning 213::tag mismatch\n")
+	assert.Contains(t, out.String(), "title=[tests] warning 213::tag mismatch\n")
+
+	out.Reset()
+	require.NoError(t, reports.Write(&out, ReportText))
+	assert.Empty(t, out.String())
+}
@@ -0,0 +1,56 @@
+package build
+
+// ApplyWarningPolicy applies the build's `ignore_warnings`, `warnings_as_errors` and
+// `dependency_warnings` settings to a problem reported by the compiler. It returns false when the
+// problem should not be reported at all. Only warnings are affected, errors can never be ignored.
+//
+// Warnings from dependencies that are hidden or summarised are never promoted to errors.
+func (cfg Config) ApplyWarningPolicy(problem Problem) (Problem, bool) {
+	if problem.Severity != ProblemWarning {
+		return problem, true
+	}
+
+	if problem.FromDependency() {
+		switch cfg.DependencyWarnings {
+		case DependencyWarningsHide:
+			return problem, false
+		case DependencyWarningsSummary:
+			return problem, true
+		}
+	}
+
+	if problem.Code == 0 {
+		return problem, true
+	}
+
+	if containsCode(cfg.IgnoreWarnings, problem.Code) {
+		return problem, false
+	}
+
+	if containsCode(cfg.WarningsAsErrors, problem.Code) {
+		problem.Severity = ProblemError
+		problem.Promoted = true
+	}
+
+	return problem, true
+}
+
+// ApplyWarningPolicy applies the build's warning policy to a list of problems, see
+// Config.ApplyWarningPolicy.
+func (bps Problems) ApplyWarningPolicy(cfg Config) (result Problems) {
+	for _, problem := range bps {
+		if problem, keep := cfg.ApplyWarningPolicy(problem); keep {
+			result = append(result, problem)
+		}
+	}
+	return
+}
+
+func containsCode(codes []int, code int) bool {
+	for _, c := range codes {
+		if c == code {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,65 @@
+package build
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestApplyWarningPolicy(t *testing.T) {
+	cfg := Config{WarningsAsErrors: []int{203, 213}, IgnoreWarnings: []int{239}}
+
+	promoted, keep := cfg.ApplyWarningPolicy(Problem{Severity: ProblemWarning, Code: 213})
+	assert.True(t, keep)
+	assert.Equal(t, Problem{Severity: ProblemError, Code: 213, Promoted: true}, promoted)
+
+	_, keep = cfg.ApplyWarningPolicy(Problem{Severity: ProblemWarning, Code: 239})
+	assert.False(t, keep)
+
+	unchanged, keep := cfg.ApplyWarningPolicy(Problem{Severity: ProblemWarning, Code: 217})
+	assert.True(t, keep)
+	assert.Equal(t, ProblemWarning, unchanged.Severity)
+
+	errorProblem, keep := Config{IgnoreWarnings: []int{17}}.ApplyWarningPolicy(Problem{Severity: ProblemError, Code: 17})
+	assert.True(t, keep, "errors can not be ignored")
+	assert.Equal(t, ProblemError, errorProblem.Severity)
+}
+
+func TestProblemsApplyWarningPolicyAffectsValidity(t *testing.T) {
+	problems := Problems{
+		{Severity: ProblemWarning, Code: 213},
+		{Severity: ProblemWarning, Code: 239},
+	}
+	assert.True(t, problems.IsValid())
+
+	applied := problems.ApplyWarningPolicy(Config{WarningsAsErrors: []int{213}, IgnoreWarnings: []int{239}})
+	assert.Len(t, applied, 1)
+	assert.False(t, applied.IsValid())
+	assert.Len(t, applied.Errors(), 1)
+	assert.Empty(t, applied.Warnings())
+}
+
+func TestApplyWarningPolicyDependencyWarnings(t *testing.T) {
+	warning := Problem{Severity: ProblemWarning, Code: 213, Dependency: "github.com/user/lib"}
+	dependencyError := Problem{Severity: ProblemError, Code: 17, Dependency: "github.com/user/lib"}
+	own := Problem{Severity: ProblemWarning, Code: 213}
+
+	_, keep := Config{DependencyWarnings: DependencyWarningsHide}.ApplyWarningPolicy(warning)
+	assert.False(t, keep)
+
+	summarised, keep := Config{DependencyWarnings: DependencyWarningsSummary, WarningsAsErrors: []int{213}}.ApplyWarningPolicy(warning)
+	assert.True(t, keep)
+	assert.Equal(t, warning, summarised, "summarised warnings are not promoted")
+
+	shown, keep := Config{WarningsAsErrors: []int{213}}.ApplyWarningPolicy(warning)
+	assert.True(t, keep)
+	assert.Equal(t, ProblemError, shown.Severity)
+
+	kept, keep := Config{DependencyWarnings: DependencyWarningsHide}.ApplyWarningPolicy(dependencyError)
+	assert.True(t, keep, "errors in dependencies still fail the build")
+	assert.Equal(t, dependencyError, kept)
+
+	ownWarning, keep := Config{DependencyWarnings: DependencyWarningsHide}.ApplyWarningPolicy(own)
+	assert.True(t, keep)
+	assert.Equal(t, own, ownWarning)
+}
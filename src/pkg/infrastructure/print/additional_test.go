@@ -48,3 +48,46 @@ func captureStdout(fn func()) string {
 	_ = r.Close()
 	return buf.String()
 }
+
+func TestSetOutputRedirectsMessages(t *testing.T) {
+	origColoured := isColoured.Load()
+	defer func() {
+		isColoured.Store(origColoured)
+		SetOutput(nil)
+	}()
+	isColoured.Store(false)
+
+	var buf bytes.Buffer
+	SetOutput(&buf)
+	assert.Equal(t, "", captureStdout(func() { Info("redirected") }))
+	assert.Equal(t, "INFO: redirected\n", buf.String())
+
+	SetOutput(nil)
+	assert.Contains(t, captureStdout(func() { Warn("restored") }), "WARN: restored")
+}
+
+func TestWriterVariantsWriteToTheGivenWriter(t *testing.T) {
+	origVerbose := isVerbose.Load()
+	origColoured := isColoured.Load()
+	defer func() {
+		isVerbose.Store(origVerbose)
+		isColoured.Store(origColoured)
+		SetOutput(nil)
+	}()
+	isVerbose.Store(true)
+	isColoured.Store(false)
+
+	var shared, own bytes.Buffer
+	SetOutput(&shared)
+	Finfo(&own, "info")
+	Fwarn(&own, "warn")
+	Fverb(&own, "verbose")
+	assert.Equal(t, "INFO: info\nWARN: warn\nINFO: verbose\n", own.String())
+	assert.Empty(t, shared.String())
+
+	Fwarn(nil, "shared")
+	assert.Equal(t, "WARN: shared\n", shared.String())
+
+	Write(own.Bytes())
+	assert.Equal(t, "WARN: shared\nINFO: info\nWARN: warn\nINFO: verbose\n", shared.String())
+}
@@ -2,12 +2,18 @@ package print
 
 import (
 	"fmt"
+	"io"
+	"os"
+	"sync"
 	"sync/atomic"
 
 	"github.com/fatih/color"
 )
 
 var (
+	// mu serialises writes so lines from concurrent callers never interleave
+	mu         sync.Mutex
+	output     io.Writer
 	isVerbose  atomic.Bool
 	isColoured atomic.Bool
 	infoStyle  = color.New(color.FgBlack).Add(color.BgYellow)
@@ -25,36 +31,80 @@ func SetColoured() {
 	isColoured.Store(true)
 }
 
+// SetOutput redirects all messages to w, a nil writer restores standard output
+func SetOutput(w io.Writer) {
+	mu.Lock()
+	defer mu.Unlock()
+
+	output = w
+}
+
+func writer() io.Writer {
+	if output != nil {
+		return output
+	}
+	return os.Stdout
+}
+
 // Verb prints a message only if Verb is set - controlled via the -v flag
 func Verb(a ...interface{}) {
-	if isVerbose.Load() {
-		Info(a...)
-	}
+	Fverb(nil, a...)
 }
 
 // Info is for general purpose messages that are always shown
 func Info(a ...interface{}) {
-	if isColoured.Load() {
-		fmt.Print(infoStyle.Sprint("INFO:"), " ", color.WhiteString(fmt.Sprintln(a...)))
-	} else {
-		fmt.Print("INFO: ", fmt.Sprintln(a...))
-	}
+	Finfo(nil, a...)
 }
 
 // Warn is for warnings that do not prevent the command from finishing
 func Warn(a ...interface{}) {
-	if isColoured.Load() {
-		fmt.Print(warnStyle.Sprint("WARN:"), " ", color.YellowString(fmt.Sprintln(a...)))
-	} else {
-		fmt.Print("WARN: ", fmt.Sprintln(a...))
-	}
+	Fwarn(nil, a...)
 }
 
 // Erro is for warnings that do not prevent the command from finishing
 func Erro(a ...interface{}) {
+	write(nil, erroStyle, "ERROR:", color.RedString, a)
+}
+
+// Fverb is Verb written to w, a nil writer writes to the same output as Verb
+func Fverb(w io.Writer, a ...interface{}) {
+	if isVerbose.Load() {
+		Finfo(w, a...)
+	}
+}
+
+// Finfo is Info written to w, a nil writer writes to the same output as Info
+func Finfo(w io.Writer, a ...interface{}) {
+	write(w, infoStyle, "INFO:", color.WhiteString, a)
+}
+
+// Fwarn is Warn written to w, a nil writer writes to the same output as Warn
+func Fwarn(w io.Writer, a ...interface{}) {
+	write(w, warnStyle, "WARN:", color.YellowString, a)
+}
+
+// Write writes messages that were collected with the F functions to the output in one piece
+func Write(b []byte) {
+	mu.Lock()
+	defer mu.Unlock()
+
+	writer().Write(b) // nolint
+}
+
+// write formats a message as a single write, only writes to the shared output are serialised
+// here so writers that serialise their own writes are never called with the lock held
+func write(w io.Writer, style *color.Color, label string, colour func(string, ...interface{}) string, a []interface{}) {
+	var message string
 	if isColoured.Load() {
-		fmt.Print(erroStyle.Sprint("ERROR:"), " ", color.RedString(fmt.Sprintln(a...)))
+		message = style.Sprint(label) + " " + colour(fmt.Sprintln(a...))
 	} else {
-		fmt.Print("ERROR: ", fmt.Sprintln(a...))
+		message = label + " " + fmt.Sprintln(a...)
+	}
+
+	if w == nil {
+		mu.Lock()
+		defer mu.Unlock()
+		w = writer()
 	}
+	fmt.Fprint(w, message) // nolint
 }
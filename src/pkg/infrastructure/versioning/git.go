@@ -44,6 +44,14 @@ func (c VersionedTags) Swap(i, j int) {
 	c[i], c[j] = c[j], c[i]
 }
 
+// ShortCommit returns the abbreviated form of a commit hash that is shown to users
+func ShortCommit(commit string) string {
+	if len(commit) > 8 {
+		return commit[:8]
+	}
+	return commit
+}
+
 // RefFromTag returns a ref from a given tag
 func RefFromTag(repo *git.Repository, meta DependencyMeta) (ref *plumbing.Reference, err error) {
 	constraint, constraintErr := semver.NewConstraint(meta.Tag)
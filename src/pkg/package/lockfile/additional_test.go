@@ -1,8 +1,10 @@
 package lockfile
 
 import (
+	"fmt"
 	"os"
 	"path/filepath"
+	"sync"
 	"testing"
 	"time"
 
@@ -169,6 +171,74 @@ func TestResolverIgnoresLockedVersionWhenConstraintChanges(t *testing.T) {
 	assert.Empty(t, resolver.GetLockedVersion(oldMeta).Branch)
 }
 
+func TestResolverRecordsIntersectedConstraints(t *testing.T) {
+	t.Parallel()
+
+	resolver, err := NewResolver(t.TempDir(), "1.0.0", true)
+	require.NoError(t, err)
+
+	meta := versioning.DependencyMeta{User: "u", Repo: "r", Tag: "1.1.3"}
+	commit := "0123456789abcdef0123456789abcdef01234567"
+	require.NoError(t, resolver.RecordResolution(meta, DependencyResolution{Commit: commit, Resolved: "1.1.3"}, false, ""))
+	assert.Empty(t, resolver.GetLockfile().Dependencies[DependencyKey(meta)].Constraints)
+
+	constraints := []string{":1.x", ":~1.1"}
+	require.NoError(t, resolver.RecordResolution(meta, DependencyResolution{Commit: commit, Resolved: "1.1.3", Constraints: constraints}, false, ""))
+	assert.Equal(t, constraints, resolver.GetLockfile().Dependencies[DependencyKey(meta)].Constraints)
+	assert.Equal(t, ":1.1.3", resolver.GetLockfile().Dependencies[DependencyKey(meta)].Constraint)
+}
+
+func TestResolverKeepsParentsWhenCommitChanges(t *testing.T) {
+	t.Parallel()
+
+	resolver, err := NewResolver(t.TempDir(), "1.0.0", true)
+	require.NoError(t, err)
+
+	meta := versioning.DependencyMeta{User: "u", Repo: "r"}
+	before := "0123456789abcdef0123456789abcdef01234567"
+	after := "89abcdef0123456789abcdef0123456789abcdef"
+	require.NoError(t, resolver.RecordResolution(meta, DependencyResolution{Commit: before, Resolved: "1.0.0"}, true, "github.com/u/a"))
+	require.NoError(t, resolver.RecordResolution(meta, DependencyResolution{Commit: before, Resolved: "1.0.0"}, true, "github.com/u/b"))
+
+	require.NoError(t, resolver.RecordResolution(meta, DependencyResolution{Commit: after, Resolved: "1.1.0"}, true, "github.com/u/b"))
+	locked := resolver.GetLockfile().Dependencies[DependencyKey(meta)]
+	assert.Equal(t, after, locked.Commit)
+	assert.True(t, locked.Transitive)
+	assert.Equal(t, []string{"github.com/u/a", "github.com/u/b"}, locked.RequiredBy)
+
+	require.NoError(t, resolver.RecordResolution(meta, DependencyResolution{Commit: before, Resolved: "1.0.0"}, false, ""))
+	locked = resolver.GetLockfile().Dependencies[DependencyKey(meta)]
+	assert.False(t, locked.Transitive, "a dependency that is also direct is not transitive")
+	assert.Equal(t, []string{"github.com/u/a", "github.com/u/b"}, locked.RequiredBy)
+}
+
+func TestResolverRecordResolutionIsSafeForConcurrentUse(t *testing.T) {
+	t.Parallel()
+
+	resolver, err := NewResolver(t.TempDir(), "1.0.0", true)
+	require.NoError(t, err)
+
+	var wg sync.WaitGroup
+	for i := 0; i < 32; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			meta := versioning.DependencyMeta{User: "u", Repo: fmt.Sprintf("r%d", i%8), Tag: "1.0.0"}
+			commit := fmt.Sprintf("%040d", i%8)
+			assert.NoError(t, resolver.RecordResolution(meta, DependencyResolution{Commit: commit}, true, fmt.Sprintf("parent-%d", i)))
+			resolver.GetLockedVersion(meta)
+		}(i)
+	}
+	wg.Wait()
+
+	assert.Equal(t, 8, resolver.GetLockfile().DependencyCount())
+	total := 0
+	for _, dep := range resolver.GetLockfile().Dependencies {
+		total += len(dep.RequiredBy)
+	}
+	assert.Equal(t, 32, total)
+}
+
 func TestVerifyIntegrityCommitChecksHeadAndDirtyState(t *testing.T) {
 	t.Parallel()
 
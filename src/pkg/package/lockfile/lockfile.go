@@ -4,6 +4,8 @@ import (
 	"fmt"
 	"time"
 
+	"github.com/Southclaws/sampctl/src/pkg/build"
+	"github.com/Southclaws/sampctl/src/pkg/build/amx"
 	"github.com/Southclaws/sampctl/src/pkg/infrastructure/versioning"
 )
 
@@ -18,6 +20,7 @@ type Lockfile struct {
 	Dependencies   map[string]LockedDependency `json:"dependencies"`
 	Runtime        *LockedRuntime              `json:"runtime,omitempty"`
 	Build          *LockedBuild                `json:"build,omitempty"`
+	Builds         map[string]LockedBuild      `json:"builds,omitempty"`
 }
 
 type LockedRuntime struct {
@@ -35,36 +38,65 @@ type LockedFileInfo struct {
 }
 
 type LockedBuild struct {
-	CompilerVersion string `json:"compiler_version,omitempty"`
-	CompilerPreset  string `json:"compiler_preset,omitempty"`
-	Entry           string `json:"entry,omitempty"`
-	Output          string `json:"output,omitempty"`
-	OutputHash      string `json:"output_hash,omitempty"`
+	Name              string         `json:"name,omitempty"`
+	CompilerVersion   string         `json:"compiler_version,omitempty"`
+	CompilerPreset    string         `json:"compiler_preset,omitempty"`
+	Entry             string         `json:"entry,omitempty"`
+	Output            string         `json:"output,omitempty"`
+	OutputHash        string         `json:"output_hash,omitempty"`
+	InputHash         string         `json:"input_hash,omitempty"`
+	HeaderSize        int            `json:"header_size,omitempty"`
+	CodeSize          int            `json:"code_size,omitempty"`
+	DataSize          int            `json:"data_size,omitempty"`
+	StackHeapSize     int            `json:"stack_heap_size,omitempty"`
+	StackHeapEstimate int            `json:"stack_heap_estimate,omitempty"`
+	TotalSize         int            `json:"total_size,omitempty"`
+	Problems          build.Problems `json:"problems,omitempty"` // warnings of the build, files in the package are relative to it
+	Exports           *LockedExports `json:"exports,omitempty"`  // exported symbols of the output, nil when they were not read
+}
+
+// LockedExports are the exported symbols of a build output, the next build compares its own with
+// these to find the symbols it removed
+type LockedExports struct {
+	Publics []amx.Symbol `json:"publics,omitempty"`
+	Natives []amx.Symbol `json:"natives,omitempty"`
+	PubVars []amx.Symbol `json:"pubvars,omitempty"`
 }
 
 // BuildRecord describes build metadata stored in the lockfile.
 type BuildRecord struct {
-	CompilerVersion string
-	CompilerPreset  string
-	Entry           string
-	Output          string
-	OutputHash      string
+	Name              string
+	CompilerVersion   string
+	CompilerPreset    string
+	Entry             string
+	Output            string
+	OutputHash        string
+	InputHash         string
+	HeaderSize        int
+	CodeSize          int
+	DataSize          int
+	StackHeapSize     int
+	StackHeapEstimate int
+	TotalSize         int
+	Problems          build.Problems
+	Exports           *LockedExports
 }
 
 type LockedDependency struct {
-	Constraint string   `json:"constraint"`
-	Resolved   string   `json:"resolved"`
-	Commit     string   `json:"commit"`
-	Integrity  string   `json:"integrity,omitempty"`
-	Site       string   `json:"site,omitempty"`
-	User       string   `json:"user"`
-	Repo       string   `json:"repo"`
-	Path       string   `json:"path,omitempty"`
-	Branch     string   `json:"branch,omitempty"`
-	Transitive bool     `json:"transitive,omitempty"`
-	RequiredBy []string `json:"required_by,omitempty"`
-	Scheme     string   `json:"scheme,omitempty"`
-	Local      string   `json:"local,omitempty"`
+	Constraint  string   `json:"constraint"`
+	Constraints []string `json:"constraints,omitempty"`
+	Resolved    string   `json:"resolved"`
+	Commit      string   `json:"commit"`
+	Integrity   string   `json:"integrity,omitempty"`
+	Site        string   `json:"site,omitempty"`
+	User        string   `json:"user"`
+	Repo        string   `json:"repo"`
+	Path        string   `json:"path,omitempty"`
+	Branch      string   `json:"branch,omitempty"`
+	Transitive  bool     `json:"transitive,omitempty"`
+	RequiredBy  []string `json:"required_by,omitempty"`
+	Scheme      string   `json:"scheme,omitempty"`
+	Local       string   `json:"local,omitempty"`
 }
 
 func New(sampctlVersion string) *Lockfile {
@@ -135,11 +167,13 @@ func (l *Lockfile) IsOutdated(meta versioning.DependencyMeta) bool {
 	if !ok {
 		return true
 	}
-	constraint := getConstraint(meta)
+	constraint := Constraint(meta)
 	return locked.Constraint != constraint
 }
 
-func getConstraint(meta versioning.DependencyMeta) string {
+// Constraint formats the version constraint of a dependency as stored in the
+// lockfile: `:tag`, `@branch` or `#commit`, or empty when unconstrained.
+func Constraint(meta versioning.DependencyMeta) string {
 	switch {
 	case meta.Tag != "":
 		return ":" + meta.Tag
@@ -199,14 +233,29 @@ func (l *Lockfile) SetRuntime(version, platform, runtimeType string, files []Loc
 	}
 }
 
+// SetBuild records a build under its name, `build` keeps the most recently recorded one.
 func (l *Lockfile) SetBuild(record BuildRecord) {
 	l.Build = &LockedBuild{
-		CompilerVersion: record.CompilerVersion,
-		CompilerPreset:  record.CompilerPreset,
-		Entry:           record.Entry,
-		Output:          record.Output,
-		OutputHash:      record.OutputHash,
+		Name:              record.Name,
+		CompilerVersion:   record.CompilerVersion,
+		CompilerPreset:    record.CompilerPreset,
+		Entry:             record.Entry,
+		Output:            record.Output,
+		OutputHash:        record.OutputHash,
+		InputHash:         record.InputHash,
+		HeaderSize:        record.HeaderSize,
+		CodeSize:          record.CodeSize,
+		DataSize:          record.DataSize,
+		StackHeapSize:     record.StackHeapSize,
+		StackHeapEstimate: record.StackHeapEstimate,
+		TotalSize:         record.TotalSize,
+		Problems:          record.Problems,
+		Exports:           record.Exports,
 	}
+	if l.Builds == nil {
+		l.Builds = make(map[string]LockedBuild)
+	}
+	l.Builds[record.Name] = *l.Build
 }
 
 func (l *Lockfile) GetRuntime() *LockedRuntime {
@@ -217,6 +266,18 @@ func (l *Lockfile) GetBuild() *LockedBuild {
 	return l.Build
 }
 
+// GetNamedBuild returns the build recorded under a name, lockfiles written before builds were
+// recorded by name only hold the most recent build.
+func (l *Lockfile) GetNamedBuild(name string) *LockedBuild {
+	if locked, ok := l.Builds[name]; ok {
+		return &locked
+	}
+	if l.Build != nil && l.Build.Name == name {
+		return l.Build
+	}
+	return nil
+}
+
 func (l *Lockfile) HasRuntime() bool {
 	return l.Runtime != nil
 }
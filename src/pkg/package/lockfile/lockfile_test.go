@@ -470,11 +470,15 @@ func TestLockfileRuntimeAndBuild(t *testing.T) {
 	assert.Len(t, runtime.Files, 2)
 
 	lf.SetBuild(BuildRecord{
+		Name:            "default",
 		CompilerVersion: "3.10.11",
 		CompilerPreset:  "openmp",
 		Entry:           "gamemodes/main.pwn",
 		Output:          "gamemodes/main.amx",
 		OutputHash:      "sha256:build123",
+		CodeSize:        1024,
+		DataSize:        512,
+		TotalSize:       17920,
 	})
 
 	assert.True(t, lf.HasBuild())
@@ -485,4 +489,25 @@ func TestLockfileRuntimeAndBuild(t *testing.T) {
 	assert.Equal(t, "gamemodes/main.pwn", build.Entry)
 	assert.Equal(t, "gamemodes/main.amx", build.Output)
 	assert.Equal(t, "sha256:build123", build.OutputHash)
+	assert.Equal(t, "default", build.Name)
+	assert.Equal(t, 1024, build.CodeSize)
+	assert.Equal(t, 512, build.DataSize)
+	assert.Equal(t, 17920, build.TotalSize)
+
+	lf.SetBuild(BuildRecord{Name: "test", Output: "gamemodes/test.amx"})
+	assert.Equal(t, "test", lf.GetBuild().Name)
+	require.NotNil(t, lf.GetNamedBuild("default"))
+	assert.Equal(t, "gamemodes/main.amx", lf.GetNamedBuild("default").Output)
+	assert.Equal(t, "gamemodes/test.amx", lf.GetNamedBuild("test").Output)
+	assert.Nil(t, lf.GetNamedBuild("other"))
+}
+
+func TestLockfileNamedBuildFallsBackToSingleBuild(t *testing.T) {
+	// lockfiles written before builds were recorded by name only have the `build` field
+	lf := New("1.0.0")
+	lf.Build = &LockedBuild{Name: "default", Output: "gamemodes/main.amx"}
+
+	require.NotNil(t, lf.GetNamedBuild("default"))
+	assert.Equal(t, "gamemodes/main.amx", lf.GetNamedBuild("default").Output)
+	assert.Nil(t, lf.GetNamedBuild("test"))
 }
@@ -6,6 +6,9 @@ import "github.com/Southclaws/sampctl/src/pkg/infrastructure/versioning"
 type DependencyResolution struct {
 	Commit   string
 	Resolved string
+	// Constraints is the set of constraints that were intersected to select the
+	// resolved version when the dependency is required at more than one constraint.
+	Constraints []string
 }
 
 func defaultResolvedVersion(meta versioning.DependencyMeta, commitSHA string) string {
@@ -2,6 +2,7 @@ package lockfile
 
 import (
 	"path/filepath"
+	"sync"
 
 	"github.com/pkg/errors"
 
@@ -9,7 +10,10 @@ import (
 	"github.com/Southclaws/sampctl/src/pkg/infrastructure/versioning"
 )
 
+// Resolver records dependency resolutions into a lockfile. It is safe for
+// concurrent use so dependencies can be ensured in parallel.
 type Resolver struct {
+	mu             sync.Mutex
 	lockfile       *Lockfile
 	previous       *Lockfile
 	dir            string
@@ -45,6 +49,9 @@ func NewResolver(dir, sampctlVersion string, useLockfile bool) (*Resolver, error
 }
 
 func (r *Resolver) GetLockedVersion(meta versioning.DependencyMeta) versioning.DependencyMeta {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
 	if !r.useLockfile || r.lockfile == nil {
 		return meta
 	}
@@ -73,6 +80,9 @@ func (r *Resolver) GetLockedVersion(meta versioning.DependencyMeta) versioning.D
 }
 
 func (r *Resolver) GetPreviousDependency(meta versioning.DependencyMeta) (LockedDependency, bool) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
 	if !r.useLockfile {
 		return LockedDependency{}, false
 	}
@@ -88,7 +98,23 @@ func (r *Resolver) GetPreviousDependency(meta versioning.DependencyMeta) (Locked
 	return LockedDependency{}, false
 }
 
+// GetDependency returns the dependency currently recorded in the lockfile
+// being built, if any.
+func (r *Resolver) GetDependency(meta versioning.DependencyMeta) (LockedDependency, bool) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.lockfile == nil {
+		return LockedDependency{}, false
+	}
+
+	return r.lockfile.GetDependency(DependencyKey(meta))
+}
+
 func (r *Resolver) RecordResolution(meta versioning.DependencyMeta, resolution DependencyResolution, transitive bool, requiredBy string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
 	if !r.useLockfile || r.lockfile == nil {
 		return nil
 	}
@@ -105,39 +131,41 @@ func (r *Resolver) RecordResolution(meta versioning.DependencyMeta, resolution D
 
 	existing, exists := r.lockfile.Dependencies[key]
 	if exists && existing.Commit == commitSHA {
-		if transitive && requiredBy != "" {
-			found := false
-			for _, rb := range existing.RequiredBy {
-				if rb == requiredBy {
-					found = true
-					break
-				}
-			}
-			if !found {
-				existing.RequiredBy = append(existing.RequiredBy, requiredBy)
-				r.lockfile.Dependencies[key] = existing
-				r.modified = true
-			}
+		if !sameConstraints(existing.Constraints, resolution.Constraints) {
+			existing.Constraints = resolution.Constraints
+			r.lockfile.Dependencies[key] = existing
+			r.modified = true
+		}
+		if transitive && requiredBy != "" && !containsString(existing.RequiredBy, requiredBy) {
+			existing.RequiredBy = append(existing.RequiredBy, requiredBy)
+			r.lockfile.Dependencies[key] = existing
+			r.modified = true
 		}
 		return nil
 	}
 
 	locked := LockedDependency{
-		Constraint: getConstraint(meta),
-		Resolved:   resolvedVersion,
-		Commit:     commitSHA,
-		Site:       meta.Site,
-		User:       meta.User,
-		Repo:       meta.Repo,
-		Path:       meta.Path,
-		Branch:     meta.Branch,
-		Scheme:     meta.Scheme,
-		Local:      meta.Local,
-		Transitive: transitive,
+		Constraint:  Constraint(meta),
+		Constraints: resolution.Constraints,
+		Resolved:    resolvedVersion,
+		Commit:      commitSHA,
+		Site:        meta.Site,
+		User:        meta.User,
+		Repo:        meta.Repo,
+		Path:        meta.Path,
+		Branch:      meta.Branch,
+		Scheme:      meta.Scheme,
+		Local:       meta.Local,
+		Transitive:  transitive,
 	}
 
-	if transitive && requiredBy != "" {
-		locked.RequiredBy = []string{requiredBy}
+	// a dependency that moved to another commit is still required by the same packages
+	if exists {
+		locked.Transitive = transitive && existing.Transitive
+		locked.RequiredBy = append([]string(nil), existing.RequiredBy...)
+	}
+	if transitive && requiredBy != "" && !containsString(locked.RequiredBy, requiredBy) {
+		locked.RequiredBy = append(locked.RequiredBy, requiredBy)
 	}
 
 	if meta.Repo != "" {
@@ -151,7 +179,31 @@ func (r *Resolver) RecordResolution(meta versioning.DependencyMeta, resolution D
 	return nil
 }
 
+func containsString(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
+
+func sameConstraints(left, right []string) bool {
+	if len(left) != len(right) {
+		return false
+	}
+	for i := range left {
+		if left[i] != right[i] {
+			return false
+		}
+	}
+	return true
+}
+
 func (r *Resolver) RecordLocalDependency(meta versioning.DependencyMeta) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
 	if !r.useLockfile || r.lockfile == nil {
 		return nil
 	}
@@ -177,6 +229,9 @@ func (r *Resolver) RecordLocalDependency(meta versioning.DependencyMeta) error {
 }
 
 func (r *Resolver) Save() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
 	if !r.useLockfile || r.lockfile == nil {
 		return nil
 	}
@@ -190,6 +245,9 @@ func (r *Resolver) Save() error {
 }
 
 func (r *Resolver) ForceUpdate() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
 	if r.lockfile != nil {
 		r.previous = r.lockfile
 		r.lockfile = New(r.sampctlVersion)
@@ -199,14 +257,38 @@ func (r *Resolver) ForceUpdate() {
 }
 
 func (r *Resolver) HasLockfile() bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
 	return r.lockfile != nil && r.lockfile.DependencyCount() > 0
 }
 
 func (r *Resolver) GetLockfile() *Lockfile {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
 	return r.lockfile
 }
 
+// GetBuild returns a copy of the build recorded in the lockfile under a name.
+func (r *Resolver) GetBuild(name string) (LockedBuild, bool) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.lockfile == nil {
+		return LockedBuild{}, false
+	}
+	locked := r.lockfile.GetNamedBuild(name)
+	if locked == nil {
+		return LockedBuild{}, false
+	}
+	return *locked, true
+}
+
 func (r *Resolver) IsLocked(meta versioning.DependencyMeta) bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
 	if !r.useLockfile || r.lockfile == nil {
 		return false
 	}
@@ -215,6 +297,9 @@ func (r *Resolver) IsLocked(meta versioning.DependencyMeta) bool {
 }
 
 func (r *Resolver) PruneMissing(currentDeps []versioning.DependencyMeta) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
 	if !r.useLockfile || r.lockfile == nil {
 		return
 	}
@@ -234,6 +319,9 @@ func (r *Resolver) PruneMissing(currentDeps []versioning.DependencyMeta) {
 }
 
 func (r *Resolver) RecordRuntime(version, platform, runtimeType string, files []LockedFileInfo) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
 	if !r.useLockfile || r.lockfile == nil {
 		return
 	}
@@ -243,10 +331,13 @@ func (r *Resolver) RecordRuntime(version, platform, runtimeType string, files []
 }
 
 func (r *Resolver) RecordBuild(record BuildRecord) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
 	if !r.useLockfile || r.lockfile == nil {
 		return
 	}
 	r.lockfile.SetBuild(record)
 	r.modified = true
-	print.Verb("recorded build for", record.Entry)
+	print.Verb("recorded build", record.Name, "for", record.Entry)
 }
@@ -0,0 +1,188 @@
+package pawnapi
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+)
+
+// Bump is the part of a semantic version that a change requires to be incremented
+type Bump int
+
+// Bumps in order of severity
+const (
+	BumpPatch Bump = iota // backwards-compatible bug fixes, the public API is unchanged
+	BumpMinor             // backwards-compatible additions
+	BumpMajor             // incompatible API changes
+)
+
+func (b Bump) String() string {
+	switch b {
+	case BumpMajor:
+		return "major"
+	case BumpMinor:
+		return "minor"
+	default:
+		return "patch"
+	}
+}
+
+// Change is a symbol that was added, removed or changed between two versions, Previous is nil
+// when it was added and Current is nil when it was removed
+type Change struct {
+	Previous *Symbol `json:"previous,omitempty"`
+	Current  *Symbol `json:"current,omitempty"`
+	Bump     Bump    `json:"bump"`
+}
+
+// Diff lists the differences between the public API of two versions of a package
+type Diff struct {
+	Changes []Change `json:"changes"`
+}
+
+// Compare returns the changes to the public API from the previous version to the current one
+func Compare(previous, current API) Diff {
+	diff := Diff{Changes: []Change{}}
+	for _, symbol := range current.Symbols() {
+		symbol := symbol
+		old, existed := previous.symbols[symbol.Key()]
+		switch {
+		case !existed:
+			diff.Changes = append(diff.Changes, Change{Current: &symbol, Bump: BumpMinor})
+		case old.Kind != symbol.Kind || old.Signature != symbol.Signature:
+			old := old
+			diff.Changes = append(diff.Changes, Change{Previous: &old, Current: &symbol, Bump: changeBump(old, symbol)})
+		}
+	}
+	for _, symbol := range previous.Symbols() {
+		symbol := symbol
+		if _, exists := current.symbols[symbol.Key()]; !exists {
+			diff.Changes = append(diff.Changes, Change{Previous: &symbol, Bump: BumpMajor})
+		}
+	}
+	sort.SliceStable(diff.Changes, func(i, j int) bool {
+		return diff.Changes[i].Bump > diff.Changes[j].Bump
+	})
+	return diff
+}
+
+// changeBump decides whether a symbol that exists in both versions changed in a way that breaks
+// code written against the previous version
+func changeBump(previous, current Symbol) Bump {
+	switch current.Kind {
+	case KindNative, KindStock, KindPublic:
+		// callbacks are called by name, a public that stops being one is never called again
+		if previous.Kind != current.Kind && (previous.Kind == KindPublic || current.Kind == KindPublic) {
+			return BumpMajor
+		}
+		previousTag, previousParams := splitFunctionSignature(previous.Signature)
+		currentTag, currentParams := splitFunctionSignature(current.Signature)
+		if previousTag != currentTag {
+			return BumpMajor
+		}
+		if previousParams == currentParams || onlyAddsOptionalParams(previousParams, currentParams) {
+			return BumpMinor
+		}
+		return BumpMajor
+	case KindDefine:
+		previousPattern, _, _ := strings.Cut(previous.Signature, " ")
+		currentPattern, _, _ := strings.Cut(current.Signature, " ")
+		if previousPattern != currentPattern {
+			return BumpMajor
+		}
+		return BumpMinor
+	default:
+		return BumpMinor
+	}
+}
+
+// onlyAddsOptionalParams returns true when the current parameters start with every previous
+// parameter and the rest have default values, so existing calls still compile
+func onlyAddsOptionalParams(previous, current string) bool {
+	previousList := splitTopLevel(strings.TrimSuffix(strings.TrimPrefix(previous, "("), ")"))
+	currentList := splitTopLevel(strings.TrimSuffix(strings.TrimPrefix(current, "("), ")"))
+	if len(currentList) <= len(previousList) {
+		return false
+	}
+	for i, param := range previousList {
+		if strings.TrimSpace(currentList[i]) != strings.TrimSpace(param) {
+			return false
+		}
+	}
+	for _, param := range currentList[len(previousList):] {
+		if !strings.Contains(param, "=") && !strings.HasSuffix(param, "...") {
+			return false
+		}
+	}
+	return true
+}
+
+// splitFunctionSignature splits a function signature into its return tag, including the colon,
+// and its parameter list, including the parentheses
+func splitFunctionSignature(signature string) (tag, params string) {
+	if i := strings.Index(signature, "("); i >= 0 && !strings.HasPrefix(signature, "{") {
+		return signature[:i], signature[i:]
+	}
+	// a tag group like `{Float,_}:` cannot contain parentheses, the list starts after it
+	if i := strings.Index(signature, "}:"); i >= 0 {
+		return signature[:i+2], signature[i+2:]
+	}
+	return "", signature
+}
+
+// Empty returns true if the public API did not change
+func (d Diff) Empty() bool {
+	return len(d.Changes) == 0
+}
+
+// Bump returns the smallest version increment that covers every change, a patch when the public
+// API did not change
+func (d Diff) Bump() Bump {
+	bump := BumpPatch
+	for _, change := range d.Changes {
+		if change.Bump > bump {
+			bump = change.Bump
+		}
+	}
+	return bump
+}
+
+// WriteText writes the changes grouped by the increment they require, with one line per symbol
+// prefixed with `+` when it was added, `-` when it was removed and `~` when it changed
+func (d Diff) WriteText(w io.Writer) error {
+	if d.Empty() {
+		_, err := fmt.Fprintln(w, "no changes to the public API")
+		return err
+	}
+
+	var b strings.Builder
+	for _, group := range []struct {
+		title string
+		bump  Bump
+	}{
+		{"Breaking changes", BumpMajor},
+		{"Backwards-compatible changes", BumpMinor},
+	} {
+		written := false
+		for _, change := range d.Changes {
+			if change.Bump != group.bump {
+				continue
+			}
+			if !written {
+				fmt.Fprintf(&b, "%s\n", group.title)
+				written = true
+			}
+			switch {
+			case change.Previous == nil:
+				fmt.Fprintf(&b, "  + %s\n", change.Current)
+			case change.Current == nil:
+				fmt.Fprintf(&b, "  - %s\n", change.Previous)
+			default:
+				fmt.Fprintf(&b, "  ~ %s\n    now %s\n", change.Previous, change.Current)
+			}
+		}
+	}
+	_, err := io.WriteString(w, b.String())
+	return err
+}
@@ -0,0 +1,378 @@
+// Package pawnapi extracts the public API of Pawn include files, the functions, macros and enums
+// other scripts can use, so two versions of a library can be compared.
+package pawnapi
+
+import (
+	"path"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// Kind is the kind of declaration a symbol comes from
+type Kind string
+
+// Kinds of symbols that make up the public API of an include
+const (
+	KindNative   Kind = "native"
+	KindStock    Kind = "stock"
+	KindPublic   Kind = "public"
+	KindDefine   Kind = "define"
+	KindEnum     Kind = "enum"
+	KindConstant Kind = "constant" // a member of an enum
+)
+
+// Symbol is a single declaration in the public API
+type Symbol struct {
+	Kind Kind   `json:"kind"`
+	Name string `json:"name"`
+	// Signature is the normalised part of the declaration that callers depend on: the return tag
+	// and parameters of a function, the pattern and replacement of a macro or the tag and size of
+	// an enum member. It is empty for enums, whose members are separate symbols.
+	Signature string `json:"signature"`
+	// Enum is the name of the enum a constant is a member of, empty for anonymous enums
+	Enum string `json:"enum,omitempty"`
+	File string `json:"file"`
+	Line int    `json:"line"`
+}
+
+// Key identifies a symbol across versions, natives, stocks and publics share a key since
+// changing how a function is implemented does not change how it is called
+func (s Symbol) Key() string {
+	switch s.Kind {
+	case KindNative, KindStock, KindPublic:
+		return "function " + s.Name
+	default:
+		return string(s.Kind) + " " + s.Name
+	}
+}
+
+// String returns the symbol as it would be declared
+func (s Symbol) String() string {
+	switch s.Kind {
+	case KindNative, KindStock, KindPublic:
+		tag, params := splitFunctionSignature(s.Signature)
+		return string(s.Kind) + " " + tag + s.Name + params
+	case KindDefine:
+		return "#define " + s.Name + s.Signature
+	case KindConstant:
+		return "enum member " + s.Signature
+	default:
+		return string(s.Kind) + " " + s.Name
+	}
+}
+
+// API is the set of public symbols declared by a package's include files
+type API struct {
+	symbols map[string]Symbol
+}
+
+// New creates an empty API
+func New() API {
+	return API{symbols: make(map[string]Symbol)}
+}
+
+// Add adds symbols to the API, when a symbol is declared more than once, for example in both
+// branches of an `#if`, the first declaration is kept
+func (a API) Add(symbols ...Symbol) {
+	for _, symbol := range symbols {
+		if _, exists := a.symbols[symbol.Key()]; !exists {
+			a.symbols[symbol.Key()] = symbol
+		}
+	}
+}
+
+// Symbols returns every symbol ordered by file, line and name
+func (a API) Symbols() []Symbol {
+	symbols := make([]Symbol, 0, len(a.symbols))
+	for _, symbol := range a.symbols {
+		symbols = append(symbols, symbol)
+	}
+	sort.Slice(symbols, func(i, j int) bool {
+		if symbols[i].File != symbols[j].File {
+			return symbols[i].File < symbols[j].File
+		}
+		if symbols[i].Line != symbols[j].Line {
+			return symbols[i].Line < symbols[j].Line
+		}
+		return symbols[i].Name < symbols[j].Name
+	})
+	return symbols
+}
+
+// IsIncludeFile returns true when a slash-separated path relative to a package is one of its own
+// include files rather than a dependency's or a file in a hidden directory like `.git`
+func IsIncludeFile(name string) bool {
+	if !strings.EqualFold(path.Ext(name), ".inc") {
+		return false
+	}
+	for _, dir := range strings.Split(path.Dir(name), "/") {
+		if dir == "dependencies" || (strings.HasPrefix(dir, ".") && dir != ".") {
+			return false
+		}
+	}
+	return true
+}
+
+var (
+	defineDirective     = regexp.MustCompile(`^\s*#\s*define\s+([A-Za-z_@][\w@]*)(\S*)\s*(.*)$`)
+	functionDeclaration = regexp.MustCompile(`(?m)^[ \t]*((?:(?:static|stock|public|native|forward)\s+)+)((?:[A-Za-z_@][\w@]*|\{[^}]*\})\s*:\s*)?([A-Za-z_@][\w@]*)\s*\(`)
+	enumDeclaration     = regexp.MustCompile(`(?m)(?:^|[^\w@])(static\s+)?enum\b([^{;]*)\{`)
+	enumIncrement       = regexp.MustCompile(`\([^)]*\)`)
+	enumMemberName      = regexp.MustCompile(`^(?:[A-Za-z_@][\w@]*\s*:\s*)?([A-Za-z_@][\w@]*)`)
+	whitespace          = regexp.MustCompile(`\s+`)
+	punctuationSpace    = regexp.MustCompile(`\s*([()\[\]{}:&])\s*`)
+	separatorSpace      = regexp.MustCompile(`\s*(,|[<>!]?=+)\s*`)
+)
+
+// Parse extracts the public symbols declared in the source of a single file. Declarations
+// marked `static` are private to the file and are skipped, preprocessor conditions are not
+// evaluated.
+func Parse(file, source string) []Symbol {
+	source = stripComments(source)
+
+	var (
+		symbols []Symbol
+		code    strings.Builder
+	)
+	lines := strings.Split(source, "\n")
+	for i := 0; i < len(lines); i++ {
+		line, start := lines[i], i
+		// directives continue on to the next line when they end with a backslash, the following
+		// lines are blanked so line numbers in the remaining code stay the same
+		for strings.HasSuffix(strings.TrimRight(line, " \t\r"), "\\") && i+1 < len(lines) {
+			line = strings.TrimSuffix(strings.TrimRight(line, " \t\r"), "\\") + " " + lines[i+1]
+			i++
+		}
+		if strings.HasPrefix(strings.TrimSpace(line), "#") {
+			if match := defineDirective.FindStringSubmatch(line); match != nil {
+				symbols = append(symbols, Symbol{
+					Kind:      KindDefine,
+					Name:      match[1],
+					Signature: normaliseDefine(match[2], match[3]),
+					File:      file,
+					Line:      start + 1,
+				})
+			}
+			code.WriteString(strings.Repeat("\n", i-start+1))
+			continue
+		}
+		for j := start; j <= i; j++ {
+			code.WriteString(lines[j])
+			code.WriteString("\n")
+		}
+	}
+
+	text := code.String()
+	symbols = append(symbols, parseFunctions(file, text)...)
+	symbols = append(symbols, parseEnums(file, text)...)
+	return symbols
+}
+
+func parseFunctions(file, code string) []Symbol {
+	var symbols []Symbol
+	for _, match := range functionDeclaration.FindAllStringSubmatchIndex(code, -1) {
+		modifiers := strings.Fields(code[match[2]:match[3]])
+		if contains(modifiers, "static") {
+			continue
+		}
+		kind := KindStock
+		switch {
+		case contains(modifiers, "native"):
+			kind = KindNative
+		case contains(modifiers, "public"), contains(modifiers, "forward"):
+			kind = KindPublic
+		case !contains(modifiers, "stock"):
+			continue
+		}
+
+		var tag string
+		if match[4] >= 0 {
+			tag = normalise(code[match[4]:match[5]])
+		}
+		open := match[1] - 1
+		end := matchingBracket(code, open, '(', ')')
+		if end < 0 {
+			continue
+		}
+		symbols = append(symbols, Symbol{
+			Kind:      kind,
+			Name:      code[match[6]:match[7]],
+			Signature: tag + "(" + normalise(code[open+1:end]) + ")",
+			File:      file,
+			Line:      lineOf(code, match[6]),
+		})
+	}
+	return symbols
+}
+
+func parseEnums(file, code string) []Symbol {
+	var symbols []Symbol
+	for _, match := range enumDeclaration.FindAllStringSubmatchIndex(code, -1) {
+		if match[2] >= 0 {
+			continue
+		}
+		open := match[1] - 1
+		end := matchingBracket(code, open, '{', '}')
+		if end < 0 {
+			continue
+		}
+
+		// `enum _:E_DATA (<<= 1)` declares E_DATA, the tag and increment are not part of the name
+		header := strings.TrimSpace(enumIncrement.ReplaceAllString(code[match[4]:match[5]], ""))
+		if i := strings.LastIndexByte(header, ':'); i >= 0 {
+			header = strings.TrimSpace(header[i+1:])
+		}
+		if header != "" {
+			symbols = append(symbols, Symbol{Kind: KindEnum, Name: header, File: file, Line: lineOf(code, match[4])})
+		}
+
+		offset := open + 1
+		for _, member := range splitTopLevel(code[open+1 : end]) {
+			declaration := strings.TrimSpace(member)
+			if i := strings.IndexByte(declaration, '='); i >= 0 {
+				declaration = strings.TrimSpace(declaration[:i])
+			}
+			if name := enumMemberName.FindStringSubmatch(declaration); name != nil {
+				symbols = append(symbols, Symbol{
+					Kind:      KindConstant,
+					Name:      name[1],
+					Signature: normalise(declaration),
+					Enum:      header,
+					File:      file,
+					Line:      lineOf(code, offset+strings.Index(member, name[1])),
+				})
+			}
+			offset += len(member) + 1
+		}
+	}
+	return symbols
+}
+
+// stripComments removes line and block comments while keeping line breaks and the contents of
+// string and character literals
+func stripComments(source string) string {
+	var b strings.Builder
+	for i := 0; i < len(source); i++ {
+		c := source[i]
+		switch {
+		case c == '"' || c == '\'':
+			end := i + 1
+			for end < len(source) && source[end] != c && source[end] != '\n' {
+				if source[end] == '\\' {
+					end++
+				}
+				end++
+			}
+			if end >= len(source) {
+				end = len(source) - 1
+			}
+			b.WriteString(source[i : end+1])
+			i = end
+		case strings.HasPrefix(source[i:], "//"):
+			for i < len(source) && source[i] != '\n' {
+				i++
+			}
+			if i < len(source) {
+				b.WriteByte('\n')
+			}
+		case strings.HasPrefix(source[i:], "/*"):
+			end := strings.Index(source[i+2:], "*/")
+			comment := source[i:]
+			if end >= 0 {
+				comment = source[i : i+2+end+2]
+			}
+			b.WriteString(strings.Repeat("\n", strings.Count(comment, "\n")))
+			i += len(comment) - 1
+		default:
+			b.WriteByte(c)
+		}
+	}
+	return b.String()
+}
+
+// matchingBracket returns the index of the bracket that closes the one at open, skipping
+// brackets inside string literals, or -1 when it is never closed
+func matchingBracket(code string, open int, left, right byte) int {
+	depth := 0
+	for i := open; i < len(code); i++ {
+		switch code[i] {
+		case '"', '\'':
+			quote := code[i]
+			for i++; i < len(code) && code[i] != quote; i++ {
+				if code[i] == '\\' {
+					i++
+				}
+			}
+		case left:
+			depth++
+		case right:
+			depth--
+			if depth == 0 {
+				return i
+			}
+		}
+	}
+	return -1
+}
+
+// splitTopLevel splits a list on commas that are not inside brackets
+func splitTopLevel(list string) []string {
+	var (
+		parts []string
+		depth int
+		start int
+	)
+	for i := 0; i < len(list); i++ {
+		switch list[i] {
+		case '(', '[', '{':
+			depth++
+		case ')', ']', '}':
+			depth--
+		case ',':
+			if depth == 0 {
+				parts = append(parts, list[start:i])
+				start = i + 1
+			}
+		}
+	}
+	if strings.TrimSpace(list[start:]) != "" {
+		parts = append(parts, list[start:])
+	}
+	return parts
+}
+
+// normalise collapses whitespace so formatting changes are not reported as API changes, commas
+// are followed by a space and default values surrounded by spaces so signatures stay readable
+func normalise(text string) string {
+	text = whitespace.ReplaceAllString(strings.TrimSpace(text), " ")
+	text = punctuationSpace.ReplaceAllString(text, "$1")
+	return separatorSpace.ReplaceAllStringFunc(text, func(separator string) string {
+		separator = strings.TrimSpace(separator)
+		if separator == "," {
+			return ", "
+		}
+		return " " + separator + " "
+	})
+}
+
+func normaliseDefine(pattern, replacement string) string {
+	replacement = whitespace.ReplaceAllString(strings.TrimSpace(replacement), " ")
+	if replacement == "" {
+		return pattern
+	}
+	return pattern + " " + replacement
+}
+
+func lineOf(code string, offset int) int {
+	return strings.Count(code[:offset], "\n") + 1
+}
+
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
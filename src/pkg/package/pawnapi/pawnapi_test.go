@@ -0,0 +1,131 @@
+package pawnapi
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const librarySource = `// library.inc
+#define MAX_THINGS (32)
+#define Thing_IsValid(%0) \
+	((0 <= (%0) < MAX_THINGS))
+
+/*
+native Thing_Commented();
+*/
+
+enum E_THING_DATA {
+	E_THING_NAME[MAX_THINGS],
+	Float:E_THING_X,
+	E_THING_OWNER = 5
+}
+
+static enum E_PRIVATE { E_PRIVATE_MEMBER }
+
+native Thing_Create(const name[], Float:x = 0.0);
+forward OnThingCreated(thingid);
+stock Float:Thing_GetX(thingid)
+{
+	return Things[thingid][E_THING_X];
+}
+static stock Thing_Internal() {}
+stock const THING_NONE = -1;
+`
+
+func TestParse(t *testing.T) {
+	t.Parallel()
+
+	symbols := Parse("library.inc", librarySource)
+	var got []string
+	for _, symbol := range symbols {
+		got = append(got, string(symbol.Kind)+" "+symbol.Name+" "+symbol.Signature)
+	}
+	assert.Equal(t, []string{
+		"define MAX_THINGS  (32)",
+		"define Thing_IsValid (%0) ((0 <= (%0) < MAX_THINGS))",
+		"native Thing_Create (const name[], Float:x = 0.0)",
+		"public OnThingCreated (thingid)",
+		"stock Thing_GetX Float:(thingid)",
+		"enum E_THING_DATA ",
+		"constant E_THING_NAME E_THING_NAME[MAX_THINGS]",
+		"constant E_THING_X Float:E_THING_X",
+		"constant E_THING_OWNER E_THING_OWNER",
+	}, got)
+
+	lines := make(map[string]int)
+	for _, symbol := range symbols {
+		lines[symbol.Name] = symbol.Line
+	}
+	assert.Equal(t, 3, lines["Thing_IsValid"])
+	assert.Equal(t, 12, lines["E_THING_X"])
+	assert.Equal(t, 18, lines["Thing_Create"])
+	assert.Equal(t, 20, lines["Thing_GetX"])
+}
+
+func apiOf(source string) API {
+	api := New()
+	api.Add(Parse("library.inc", source)...)
+	return api
+}
+
+func TestCompare(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name     string
+		previous string
+		current  string
+		want     Bump
+	}{
+		{"unchanged", "native A(a);", "native  A( a );\n// comment", BumpPatch},
+		{"implementation", "stock A(a) { return 1; }", "stock A(a) { return 2; }", BumpPatch},
+		{"function added", "native A();", "native A();\nnative B();", BumpMinor},
+		{"function removed", "native A();\nnative B();", "native A();", BumpMajor},
+		{"optional parameter added", "native A(a);", "native A(a, b = 1);", BumpMinor},
+		{"required parameter added", "native A(a);", "native A(a, b);", BumpMajor},
+		{"parameter tag changed", "native A(a);", "native A(Float:a);", BumpMajor},
+		{"return tag changed", "native A();", "native bool:A();", BumpMajor},
+		{"native became a stock", "native A(a);", "stock A(a) {}", BumpMinor},
+		{"public became a stock", "forward A(a);", "stock A(a) {}", BumpMajor},
+		{"macro value changed", "#define MAX_A (10)", "#define MAX_A (20)", BumpMinor},
+		{"macro parameters changed", "#define A(%0) (%0)", "#define A(%0,%1) (%0)", BumpMajor},
+		{"enum member added", "enum E { E_A }", "enum E { E_A, E_B }", BumpMinor},
+		{"enum member removed", "enum E { E_A, E_B }", "enum E { E_A }", BumpMajor},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			assert.Equal(t, tt.want, Compare(apiOf(tt.previous), apiOf(tt.current)).Bump())
+		})
+	}
+}
+
+func TestDiffWriteText(t *testing.T) {
+	t.Parallel()
+
+	diff := Compare(
+		apiOf("native A(a);\nnative B();\n#define MAX_A (10)"),
+		apiOf("native A(a, b);\n#define MAX_A (20)\nnative Float:C();"),
+	)
+	require.Equal(t, BumpMajor, diff.Bump())
+
+	var out bytes.Buffer
+	require.NoError(t, diff.WriteText(&out))
+	assert.Equal(t, `Breaking changes
+  ~ native A(a)
+    now native A(a, b)
+  - native B()
+Backwards-compatible changes
+  ~ #define MAX_A (10)
+    now #define MAX_A (20)
+  + native Float:C()
+`, out.String())
+
+	out.Reset()
+	require.NoError(t, Compare(New(), New()).WriteText(&out))
+	assert.Equal(t, "no changes to the public API\n", out.String())
+}
@@ -0,0 +1,301 @@
+package pawndoc
+
+import (
+	"fmt"
+	"html/template"
+	"io"
+	"path"
+	"sort"
+	"strings"
+
+	"github.com/pkg/errors"
+
+	"github.com/Southclaws/sampctl/src/pkg/package/pawnapi"
+)
+
+// Format is the markup documentation pages are written in
+type Format string
+
+// Formats documentation pages can be written in
+const (
+	FormatMarkdown Format = "markdown"
+	FormatHTML     Format = "html"
+)
+
+// ParseFormat validates a page format
+func ParseFormat(format string) (Format, error) {
+	switch Format(format) {
+	case FormatMarkdown, FormatHTML:
+		return Format(format), nil
+	}
+	return "", errors.Errorf("unsupported format %q, must be one of markdown or html", format)
+}
+
+func (f Format) extension() string {
+	if f == FormatHTML {
+		return ".html"
+	}
+	return ".md"
+}
+
+// IndexFilename returns the name of the page that links to every include's page
+func IndexFilename(format Format) string {
+	if format == FormatHTML {
+		return "index.html"
+	}
+	return "README.md"
+}
+
+// Entry is a symbol of the public API and its doc comment from the compiler report
+type Entry struct {
+	pawnapi.Symbol
+	Documentation
+}
+
+// Page documents the symbols declared by one include file
+type Page struct {
+	Include string  `json:"include"` // slash-separated path relative to the package
+	Entries []Entry `json:"entries"`
+}
+
+// Pages groups the public symbols of a package by the include that declares them and attaches
+// the doc comments found in the report. Symbols the compiler did not report, like macros and
+// stocks it removed because they are unused, are still listed with their declaration.
+func Pages(symbols []pawnapi.Symbol, report Report) []Page {
+	byFile := make(map[string][]Entry)
+	for _, symbol := range symbols {
+		entry := Entry{Symbol: symbol}
+		if prefix := reportPrefix(symbol.Kind); prefix != "" {
+			if member, ok := report.Find(prefix, symbol.Name); ok {
+				entry.Documentation = member.Documentation()
+			}
+		}
+		byFile[symbol.File] = append(byFile[symbol.File], entry)
+	}
+
+	pages := make([]Page, 0, len(byFile))
+	for file, entries := range byFile {
+		sort.SliceStable(entries, func(i, j int) bool { return entries[i].Line < entries[j].Line })
+		pages = append(pages, Page{Include: file, Entries: entries})
+	}
+	sort.Slice(pages, func(i, j int) bool { return pages[i].Include < pages[j].Include })
+	return pages
+}
+
+func reportPrefix(kind pawnapi.Kind) string {
+	switch kind {
+	case pawnapi.KindNative, pawnapi.KindStock, pawnapi.KindPublic:
+		return "M"
+	case pawnapi.KindConstant:
+		return "C"
+	case pawnapi.KindEnum:
+		return "T"
+	default:
+		return ""
+	}
+}
+
+// Filename returns where the page is written relative to the documentation directory, it
+// mirrors the path of the include
+func (p Page) Filename(format Format) string {
+	return strings.TrimSuffix(p.Include, path.Ext(p.Include)) + format.extension()
+}
+
+// section is a group of entries of the same kind on a page
+type section struct {
+	Title   string
+	Entries []pageEntry
+}
+
+// pageEntry is how an entry is rendered, enums list their members in the table that lists the
+// parameters of functions
+type pageEntry struct {
+	Name        string
+	Declaration string
+	Doc         Documentation
+	Column      string
+	Rows        []ParamDoc
+}
+
+func (p Page) sections() []section {
+	sections := []section{
+		{Title: "Functions"},
+		{Title: "Callbacks"},
+		{Title: "Macros"},
+		{Title: "Enums"},
+		{Title: "Constants"},
+	}
+	for _, entry := range p.Entries {
+		rendered := pageEntry{Name: entry.Name, Declaration: entry.Symbol.String(), Doc: entry.Documentation}
+		var index int
+		switch entry.Kind {
+		case pawnapi.KindNative, pawnapi.KindStock:
+			index = 0
+		case pawnapi.KindPublic:
+			index = 1
+		case pawnapi.KindDefine:
+			index = 2
+		case pawnapi.KindEnum:
+			index = 3
+			rendered.Declaration = "enum " + entry.Name
+			rendered.Column = "Member"
+			for _, member := range p.Entries {
+				if member.Kind == pawnapi.KindConstant && member.Enum == entry.Name {
+					rendered.Rows = append(rendered.Rows, ParamDoc{Name: member.Signature, Description: member.Summary})
+				}
+			}
+		case pawnapi.KindConstant:
+			if entry.Enum != "" {
+				continue
+			}
+			index = 4
+			rendered.Declaration = entry.Signature
+		}
+		if rendered.Column == "" && !entry.Documentation.Empty() {
+			rendered.Column = "Parameter"
+			rendered.Rows = entry.Params
+		}
+		sections[index].Entries = append(sections[index].Entries, rendered)
+	}
+
+	nonEmpty := sections[:0]
+	for _, s := range sections {
+		if len(s.Entries) > 0 {
+			nonEmpty = append(nonEmpty, s)
+		}
+	}
+	return nonEmpty
+}
+
+// Write writes the page in the given format
+func (p Page) Write(w io.Writer, format Format) error {
+	if format == FormatHTML {
+		return pageTemplate.Execute(w, struct {
+			Title    string
+			Sections []section
+		}{p.Include, p.sections()})
+	}
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "# %s\n", p.Include)
+	for _, s := range p.sections() {
+		fmt.Fprintf(&b, "\n## %s\n", s.Title)
+		for _, entry := range s.Entries {
+			fmt.Fprintf(&b, "\n### %s\n\n```pawn\n%s\n```\n", entry.Name, entry.Declaration)
+			if entry.Doc.Summary != "" {
+				fmt.Fprintf(&b, "\n%s\n", entry.Doc.Summary)
+			}
+			if len(entry.Rows) > 0 {
+				fmt.Fprintf(&b, "\n| %s | Description |\n| --- | --- |\n", entry.Column)
+				for _, row := range entry.Rows {
+					fmt.Fprintf(&b, "| `%s` | %s |\n", row.Name, tableCell(row.Description))
+				}
+			}
+			if entry.Doc.Returns != "" {
+				fmt.Fprintf(&b, "\n**Returns:** %s\n", entry.Doc.Returns)
+			}
+			if entry.Doc.Remarks != "" {
+				fmt.Fprintf(&b, "\n**Remarks:** %s\n", entry.Doc.Remarks)
+			}
+		}
+	}
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+// tableCell keeps a description on one line of a Markdown table
+func tableCell(text string) string {
+	return strings.ReplaceAll(strings.ReplaceAll(text, "\n\n", "<br>"), "|", "\\|")
+}
+
+// WriteIndex writes a page that links to the page of every include
+func WriteIndex(w io.Writer, title string, pages []Page, format Format) error {
+	if format == FormatHTML {
+		return indexTemplate.Execute(w, struct {
+			Title string
+			Pages []Page
+		}{title, pages})
+	}
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "# %s\n\n", title)
+	for _, page := range pages {
+		fmt.Fprintf(&b, "- [%s](%s) (%d symbols)\n", page.Include, page.Filename(format), len(page.Entries))
+	}
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+var templateFuncs = template.FuncMap{
+	// text renders the paragraphs of a doc comment and its inline code
+	"text": func(text string) template.HTML {
+		var b strings.Builder
+		for _, paragraph := range strings.Split(text, "\n\n") {
+			b.WriteString("<p>")
+			for i, part := range strings.Split(template.HTMLEscapeString(paragraph), "`") {
+				if i%2 == 1 {
+					part = "<code>" + part + "</code>"
+				}
+				b.WriteString(part)
+			}
+			b.WriteString("</p>")
+		}
+		return template.HTML(b.String()) // nolint:gosec
+	},
+	"link": func(page Page) string { return page.Filename(FormatHTML) },
+}
+
+var pageTemplate = template.Must(template.New("page").Funcs(templateFuncs).Parse(`<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<title>{{.Title}}</title>
+</head>
+<body>
+<h1>{{.Title}}</h1>
+{{- range .Sections}}
+<h2>{{.Title}}</h2>
+{{- range .Entries}}
+<h3 id="{{.Name}}">{{.Name}}</h3>
+<pre><code>{{.Declaration}}</code></pre>
+{{- if .Doc.Summary}}
+{{text .Doc.Summary}}
+{{- end}}
+{{- if .Rows}}
+<table>
+<tr><th>{{.Column}}</th><th>Description</th></tr>
+{{- range .Rows}}
+<tr><td><code>{{.Name}}</code></td><td>{{text .Description}}</td></tr>
+{{- end}}
+</table>
+{{- end}}
+{{- if .Doc.Returns}}
+<h4>Returns</h4>
+{{text .Doc.Returns}}
+{{- end}}
+{{- if .Doc.Remarks}}
+<h4>Remarks</h4>
+{{text .Doc.Remarks}}
+{{- end}}
+{{- end}}
+{{- end}}
+</body>
+</html>
+`))
+
+var indexTemplate = template.Must(template.New("index").Funcs(templateFuncs).Parse(`<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<title>{{.Title}}</title>
+</head>
+<body>
+<h1>{{.Title}}</h1>
+<ul>
+{{- range .Pages}}
+<li><a href="{{link .}}">{{.Include}}</a> ({{len .Entries}} symbols)</li>
+{{- end}}
+</ul>
+</body>
+</html>
+`))
@@ -0,0 +1,191 @@
+package pawndoc
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/Southclaws/sampctl/src/pkg/package/pawnapi"
+)
+
+const testReport = `<?xml version="1.0" encoding="UTF-8"?>
+<?xml-stylesheet href="file:///pawndoc.xsl" type="text/xsl"?>
+<doc source="/tmp/docs.pwn">
+	<assembly>
+		<name>docs</name>
+	</assembly>
+	<members>
+		<member name="T:E_THING" value="2">
+			<summary>Data stored for each thing.</summary>
+			<member name="C:E_THING_NAME" value="0">
+				<tagname value="E_THING"/>
+				<summary>The name of the thing.</summary>
+			</member>
+		</member>
+		<member name="M:Thing_Create" syntax="Thing_Create(const name[], flags=0)">
+			<attribute name="native"/>
+			<param name="name">
+				<paraminfo> [] </paraminfo>
+			</param>
+			<param name="flags">
+			</param>
+			<summary>Creates a thing, see <paramref name="name"/>.</summary>
+			<param name="name">The name of the thing, use <c>""</c> for none &amp; more.</param>
+			<returns>The ID of the thing.</returns>
+			<remarks>
+				Things are limited.
+				There can be at most <c>MAX_THINGS</c>.
+
+				Check the return value.
+			</remarks>
+		</member>
+		<member name="M:Thing_Destroy" syntax="Thing_Destroy(thingid)">
+			<param name="thingid">
+			</param>
+		</member>
+	</members>
+</doc>
+`
+
+func TestParseReport(t *testing.T) {
+	t.Parallel()
+
+	report, err := Parse(strings.NewReader(testReport))
+	require.NoError(t, err)
+	assert.Equal(t, "/tmp/docs.pwn", report.Source)
+
+	member, ok := report.Find("M", "Thing_Create")
+	require.True(t, ok)
+	assert.Equal(t, Documentation{
+		Summary: "Creates a thing, see `name`.",
+		Params: []ParamDoc{
+			{Name: "name", Description: "The name of the thing, use `\"\"` for none & more."},
+			{Name: "flags"},
+		},
+		Returns: "The ID of the thing.",
+		Remarks: "Things are limited. There can be at most `MAX_THINGS`.\n\nCheck the return value.",
+	}, member.Documentation())
+
+	member, ok = report.Find("C", "E_THING_NAME")
+	require.True(t, ok, "constants of enums are found")
+	assert.Equal(t, "The name of the thing.", member.Documentation().Summary)
+
+	member, ok = report.Find("M", "Thing_Destroy")
+	require.True(t, ok)
+	assert.True(t, member.Documentation().Empty())
+
+	_, ok = report.Find("M", "Thing_Missing")
+	assert.False(t, ok)
+}
+
+func testPages(t *testing.T) []Page {
+	t.Helper()
+	report, err := Parse(strings.NewReader(testReport))
+	require.NoError(t, err)
+
+	symbols := pawnapi.Parse("things.inc", `#define MAX_THINGS (32)
+enum E_THING { E_THING_NAME[32] }
+native Thing_Create(const name[], flags = 0);
+stock Thing_Destroy(thingid) {}
+forward OnThingCreated(thingid);
+`)
+	symbols = append(symbols, pawnapi.Parse("things/util.inc", "stock Thing_Util() {}\n")...)
+	return Pages(symbols, report)
+}
+
+func TestPageWriteMarkdown(t *testing.T) {
+	t.Parallel()
+
+	pages := testPages(t)
+	require.Len(t, pages, 2)
+	assert.Equal(t, "things.md", pages[0].Filename(FormatMarkdown))
+	assert.Equal(t, "things/util.html", pages[1].Filename(FormatHTML))
+
+	var out bytes.Buffer
+	require.NoError(t, pages[0].Write(&out, FormatMarkdown))
+	assert.Equal(t, "# things.inc\n"+`
+## Functions
+
+### Thing_Create
+
+`+"```pawn\nnative Thing_Create(const name[], flags = 0)\n```"+`
+
+Creates a thing, see `+"`name`"+`.
+
+| Parameter | Description |
+| --- | --- |
+| `+"`name`"+` | The name of the thing, use `+"`\"\"`"+` for none & more. |
+| `+"`flags`"+` |  |
+
+**Returns:** The ID of the thing.
+
+**Remarks:** Things are limited. There can be at most `+"`MAX_THINGS`"+`.
+
+Check the return value.
+
+### Thing_Destroy
+
+`+"```pawn\nstock Thing_Destroy(thingid)\n```"+`
+
+## Callbacks
+
+### OnThingCreated
+
+`+"```pawn\npublic OnThingCreated(thingid)\n```"+`
+
+## Macros
+
+### MAX_THINGS
+
+`+"```pawn\n#define MAX_THINGS (32)\n```"+`
+
+## Enums
+
+### E_THING
+
+`+"```pawn\nenum E_THING\n```"+`
+
+Data stored for each thing.
+
+| Member | Description |
+| --- | --- |
+| `+"`E_THING_NAME[32]`"+` | The name of the thing. |
+`, out.String())
+
+	out.Reset()
+	require.NoError(t, WriteIndex(&out, "things", pages, FormatMarkdown))
+	assert.Equal(t, "# things\n\n- [things.inc](things.md) (6 symbols)\n- [things/util.inc](things/util.md) (1 symbols)\n", out.String())
+}
+
+func TestPageWriteHTML(t *testing.T) {
+	t.Parallel()
+
+	pages := testPages(t)
+	var out bytes.Buffer
+	require.NoError(t, pages[0].Write(&out, FormatHTML))
+	html := out.String()
+	assert.Contains(t, html, "<h1>things.inc</h1>")
+	assert.Contains(t, html, `<h3 id="Thing_Create">Thing_Create</h3>`)
+	assert.Contains(t, html, "<pre><code>native Thing_Create(const name[], flags = 0)</code></pre>")
+	assert.Contains(t, html, "<p>Creates a thing, see <code>name</code>.</p>")
+	assert.Contains(t, html, "<tr><td><code>name</code></td><td><p>The name of the thing, use <code>&#34;&#34;</code> for none &amp; more.</p></td></tr>")
+	assert.Contains(t, html, "<h4>Remarks</h4>\n<p>Things are limited. There can be at most <code>MAX_THINGS</code>.</p><p>Check the return value.</p>")
+
+	out.Reset()
+	require.NoError(t, WriteIndex(&out, "things", pages, FormatHTML))
+	assert.Contains(t, out.String(), `<li><a href="things/util.html">things/util.inc</a> (1 symbols)</li>`)
+}
+
+func TestParseFormat(t *testing.T) {
+	t.Parallel()
+
+	format, err := ParseFormat("html")
+	require.NoError(t, err)
+	assert.Equal(t, FormatHTML, format)
+
+	_, err = ParseFormat("pdf")
+	assert.EqualError(t, err, `unsupported format "pdf", must be one of markdown or html`)
+}
@@ -0,0 +1,177 @@
+// Package pawndoc reads the XML report the Pawn compiler writes with `-r` and renders the doc
+// comments it contains as documentation pages for each include of a package.
+package pawndoc
+
+import (
+	"encoding/xml"
+	"html"
+	"io"
+	"os"
+	"regexp"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// Report is the XML report of a compiled script, it lists every function, constant, enum and
+// variable the compiler saw along with the doc comments written above them
+type Report struct {
+	Source  string   `xml:"source,attr"`
+	Members []Member `xml:"members>member"`
+}
+
+// Member is a single entry in the report, its name is prefixed with the kind of symbol: `M:` for
+// functions, `C:` for constants, `T:` for enums and tags and `F:` for variables
+type Member struct {
+	Name    string   `xml:"name,attr"`
+	Value   string   `xml:"value,attr"`
+	Syntax  string   `xml:"syntax,attr"`
+	Params  []Param  `xml:"param"`
+	Summary docXML   `xml:"summary"`
+	Returns docXML   `xml:"returns"`
+	Remarks docXML   `xml:"remarks"`
+	Members []Member `xml:"member"` // the constants of an enum
+}
+
+// Param is a parameter of a function, the compiler writes one element per parameter and doc
+// comments may add another with the same name that holds the description
+type Param struct {
+	Name  string `xml:"name,attr"`
+	Inner string `xml:",innerxml"`
+}
+
+type docXML struct {
+	Inner string `xml:",innerxml"`
+}
+
+// Documentation is the text of the doc comment of a member
+type Documentation struct {
+	Summary string     `json:"summary,omitempty"`
+	Params  []ParamDoc `json:"params,omitempty"`
+	Returns string     `json:"returns,omitempty"`
+	Remarks string     `json:"remarks,omitempty"`
+}
+
+// ParamDoc is the description of a parameter
+type ParamDoc struct {
+	Name        string `json:"name"`
+	Description string `json:"description"`
+}
+
+// Empty returns true when the member has no doc comment
+func (d Documentation) Empty() bool {
+	if d.Summary != "" || d.Returns != "" || d.Remarks != "" {
+		return false
+	}
+	for _, param := range d.Params {
+		if param.Description != "" {
+			return false
+		}
+	}
+	return true
+}
+
+// Open reads a report written by the compiler
+func Open(path string) (Report, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return Report{}, errors.Wrap(err, "failed to open compiler report")
+	}
+	defer f.Close() // nolint
+	return Parse(f)
+}
+
+// Parse reads a report, doc comments are copied into it verbatim so the document is read
+// leniently to tolerate markup that is not well formed
+func Parse(r io.Reader) (Report, error) {
+	var report Report
+	decoder := xml.NewDecoder(r)
+	decoder.Strict = false
+	// <param> is a void element in HTML, so only the line breaks doc comments use are closed
+	decoder.AutoClose = []string{"br"}
+	decoder.Entity = xml.HTMLEntity
+	if err := decoder.Decode(&report); err != nil {
+		return Report{}, errors.Wrap(err, "failed to parse compiler report")
+	}
+	return report, nil
+}
+
+// Find returns the member with a kind prefix and name, the constants of enums are searched too
+func (r Report) Find(prefix, name string) (Member, bool) {
+	return findMember(r.Members, prefix+":"+name)
+}
+
+func findMember(members []Member, name string) (Member, bool) {
+	for _, member := range members {
+		if member.Name == name {
+			return member, true
+		}
+		if found, ok := findMember(member.Members, name); ok {
+			return found, true
+		}
+	}
+	return Member{}, false
+}
+
+// Documentation returns the doc comment of the member as plain text with inline code in
+// backticks
+func (m Member) Documentation() Documentation {
+	doc := Documentation{
+		Summary: docText(m.Summary.Inner),
+		Returns: docText(m.Returns.Inner),
+		Remarks: docText(m.Remarks.Inner),
+	}
+	index := make(map[string]int)
+	for _, param := range m.Params {
+		description := docText(paramInfo.ReplaceAllString(param.Inner, ""))
+		if i, seen := index[param.Name]; seen {
+			if doc.Params[i].Description == "" {
+				doc.Params[i].Description = description
+			}
+			continue
+		}
+		index[param.Name] = len(doc.Params)
+		doc.Params = append(doc.Params, ParamDoc{Name: param.Name, Description: description})
+	}
+	return doc
+}
+
+var (
+	paramInfo    = regexp.MustCompile(`(?s)<paraminfo>.*?</paraminfo>`)
+	paramRef     = regexp.MustCompile(`<paramref\s+name="([^"]*)"\s*/>`)
+	inlineCode   = regexp.MustCompile(`(?s)<(c|code)>(.*?)</(?:c|code)>`)
+	paragraphTag = regexp.MustCompile(`</?p>|<br\s*/?>`)
+	listItem     = regexp.MustCompile(`<li>`)
+	anyTag       = regexp.MustCompile(`<[^>]*>`)
+)
+
+// docText converts the markup of a doc comment to text, paragraphs and list items are separated
+// by blank lines and the lines within them are joined
+func docText(inner string) string {
+	inner = paramRef.ReplaceAllString(inner, "`$1`")
+	inner = inlineCode.ReplaceAllString(inner, "`$2`")
+	inner = paragraphTag.ReplaceAllString(inner, "\n\n")
+	inner = listItem.ReplaceAllString(inner, "\n\n- ")
+	inner = html.UnescapeString(anyTag.ReplaceAllString(inner, ""))
+
+	var (
+		paragraphs []string
+		current    []string
+	)
+	flush := func() {
+		if len(current) > 0 {
+			paragraphs = append(paragraphs, strings.Join(current, " "))
+			current = nil
+		}
+	}
+	for _, line := range strings.Split(inner, "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" {
+			flush()
+			continue
+		}
+		current = append(current, line)
+	}
+	flush()
+	return strings.Join(paragraphs, "\n\n")
+}
@@ -3,6 +3,7 @@ package pkgcontext
 import (
 	"context"
 	"fmt"
+	"io"
 	"os"
 	"os/exec"
 	"path/filepath"
@@ -18,6 +19,7 @@ import (
 
 	"github.com/Southclaws/sampctl/src/pkg/build"
 	"github.com/Southclaws/sampctl/src/pkg/build/compiler"
+	"github.com/Southclaws/sampctl/src/pkg/build/includes"
 	"github.com/Southclaws/sampctl/src/pkg/infrastructure/fs"
 	"github.com/Southclaws/sampctl/src/pkg/infrastructure/print"
 	"github.com/Southclaws/sampctl/src/pkg/package/pawnpackage"
@@ -38,10 +40,58 @@ type BuildOptions struct {
 	DryRun    bool
 	Relative  bool
 	BuildFile string
-	Trigger   chan build.Problems
+	// Force compiles even when the inputs and output are unchanged since the build recorded in
+	// the lockfile.
+	Force bool
+	// DependencyWarnings overrides the build config's `dependency_warnings` when set.
+	DependencyWarnings build.DependencyWarnings
+	// Depfile writes a depfile and include manifest next to the output even when the build
+	// config does not enable `depfile`.
+	Depfile bool
+	// CheckNatives fails the build when the output calls natives that no configured plugin or
+	// component provides, even when the build config does not enable `check_natives`.
+	CheckNatives bool
+	// CheckRunNatives fails a watched build whose output calls natives declared nowhere but the
+	// package, the check `sampctl run` performs before starting the server.
+	CheckRunNatives bool
+	// DiffPrevious compares the exported symbols of the output with those of the last locked
+	// output and fails when any were removed, even when the build config does not enable
+	// `diff_previous`.
+	DiffPrevious bool
+	Trigger      chan build.Problems
+	// Output receives compiler diagnostics and build command output as they
+	// happen, defaults to standard output.
+	Output io.Writer
+}
+
+func (options BuildOptions) output() io.Writer {
+	if options.Output != nil {
+		return options.Output
+	}
+	return os.Stdout
+}
+
+// applyTo overrides the settings of a prepared build config with those given on the command line
+func (options BuildOptions) applyTo(config *build.Config) {
+	if options.DependencyWarnings != "" {
+		config.DependencyWarnings = options.DependencyWarnings
+	}
+	if options.Depfile {
+		config.Depfile = true
+	}
+	if options.CheckNatives {
+		config.CheckNatives = true
+	}
+	if options.DiffPrevious {
+		config.DiffPrevious = true
+	}
 }
 
 // Build compiles a package, dependencies are ensured and a list of paths are sent to the compiler.
+// Compilation is skipped when nothing changed since the build recorded in the lockfile. A build
+// that succeeds but exceeds the size budget in its config, calls natives that no plugin provides
+// when `check_natives` is enabled or removes exported symbols when `diff_previous` is enabled
+// returns an error, otherwise it is recorded in the lockfile.
 func (pcx *PackageContext) Build(
 	ctx context.Context,
 	options BuildOptions,
@@ -50,32 +100,101 @@ func (pcx *PackageContext) Build(
 	result build.Result,
 	err error,
 ) {
-	config, err := pcx.buildPrepare(ctx, options.Name, options.Ensure, true)
+	prepared, err := pcx.prepareBuild(ctx, options)
 	if err != nil {
 		return
 	}
+	if options.DryRun {
+		printBuildCommand(prepared.command)
+		return
+	}
+
+	return pcx.runPreparedBuild(ctx, prepared, options)
+}
+
+// preparedBuild is a build whose config has been resolved and whose compiler is ready to run
+type preparedBuild struct {
+	config      build.Config
+	command     *exec.Cmd
+	buildNumber uint32
+}
+
+func (pcx *PackageContext) prepareBuild(ctx context.Context, options BuildOptions) (preparedBuild, error) {
+	config, err := pcx.buildPrepare(ctx, options.Name, options.Ensure, true)
+	if err != nil {
+		return preparedBuild{}, err
+	}
+
+	options.applyTo(config)
 
 	buildNumber, err := readBuildNumber(options.BuildFile)
 	if err != nil {
-		return
+		return preparedBuild{}, err
 	}
 
 	command, err := pcx.prepareBuildCommand(ctx, *config)
 	if err != nil {
-		return
+		return preparedBuild{}, err
 	}
-	if options.DryRun {
-		printBuildCommand(command)
-		return
+
+	return preparedBuild{config: *config, command: command, buildNumber: buildNumber}, nil
+}
+
+func (pcx *PackageContext) runPreparedBuild(
+	ctx context.Context,
+	prepared preparedBuild,
+	options BuildOptions,
+) (
+	problems build.Problems,
+	result build.Result,
+	err error,
+) {
+	// status messages go to the build's own output so matrix builds prefix them with their name
+	w := options.Output
+	inputHash := pcx.buildInputHash(w, prepared.config, prepared.command)
+	if !options.Force {
+		if lockedProblems, locked, ok := pcx.upToDateBuild(w, options.Name, prepared.config, inputHash, options.Relative); ok {
+			print.Finfo(w, "build", BuildRecordName(options.Name), "is up to date, skipping compilation (use --force to rebuild)")
+			if err = pcx.checkBuildNatives(prepared.config); err != nil {
+				return lockedProblems, locked, err
+			}
+			return lockedProblems, locked, pcx.writeBuildManifest(prepared.config)
+		}
 	}
 
-	return pcx.executeBuild(buildExecutionRequest{
+	previous := pcx.previousBuildOutput(w, options.Name, prepared.config)
+
+	problems, result, err = pcx.executeBuild(buildExecutionRequest{
 		Context:     ctx,
-		Config:      *config,
-		Command:     command,
-		BuildNumber: buildNumber,
+		Config:      prepared.config,
+		Command:     prepared.command,
+		BuildNumber: prepared.buildNumber,
 		Options:     options,
 	})
+	if err != nil || problems.Fatal() || !problems.IsValid() {
+		return
+	}
+
+	if err = pcx.checkBuildBudget(w, options.Name, prepared.config, result); err != nil {
+		return
+	}
+
+	if err = pcx.checkBuildNatives(prepared.config); err != nil {
+		return
+	}
+
+	if previous != nil {
+		if err = diffBuildOutput(options.output(), *previous, prepared.config); err != nil {
+			return
+		}
+	}
+
+	if err = pcx.writeBuildManifest(prepared.config); err != nil {
+		return
+	}
+
+	pcx.recordBuild(w, options.Name, prepared.config, problems, result, inputHash)
+	return
 }
 
 // BuildWatch runs the Build code on file changes
@@ -84,6 +203,7 @@ func (pcx *PackageContext) BuildWatch(ctx context.Context, options BuildOptions)
 	if err != nil {
 		return
 	}
+	options.applyTo(config)
 
 	buildNumber, err := readBuildNumber(options.BuildFile)
 	if err != nil {
@@ -141,6 +261,7 @@ func (pcx *PackageContext) BuildWatch(ctx context.Context, options BuildOptions)
 		ctxInner, cancel = context.WithCancel(ctx)
 		buildRunning     bool
 		debouncer        watchDebouncer
+		watchedFiles     buildWatchFiles
 	)
 
 	defer func() {
@@ -160,15 +281,7 @@ func (pcx *PackageContext) BuildWatch(ctx context.Context, options BuildOptions)
 		fmt.Printf("%s compiling %s with compiler version %s [%d]\n", watcherColour("WATCHER:"), config.Input, config.Compiler.Version, buildRun)
 
 		go func(run uint32, changedFile string, buildCtx context.Context) {
-			problems, _, buildErr := compiler.CompileSource(buildCtx, compiler.CompileRequest{
-				GitHub:   pcx.GitHub,
-				ExecDir:  pcx.Package.LocalPath,
-				ErrorDir: pcx.Package.LocalPath,
-				CacheDir: pcx.CacheDir,
-				Platform: pcx.Platform,
-				Config:   *config,
-				Relative: options.Relative,
-			})
+			problems, buildErr := pcx.compileWatchedBuild(buildCtx, *config, options)
 			resultCh <- buildWatchResult{
 				problems:    problems,
 				err:         buildErr,
@@ -202,6 +315,10 @@ loop:
 			if !shouldWatchBuildEvent(event) {
 				continue
 			}
+			if !watchedFiles.affects(event) {
+				print.Verb("ignoring change to", event.Name, "which is not included by the build")
+				continue
+			}
 			queueBuild(event.Name)
 
 		case <-debouncer.Channel():
@@ -215,6 +332,23 @@ loop:
 			buildRunning = false
 			cancel()
 
+			var nativesErr error
+			if graph, dirs, graphErr := pcx.buildIncludeGraph(*config); graphErr != nil {
+				print.Warn("failed to resolve includes, rebuilding on any change:", graphErr)
+				watchedFiles = nil
+			} else {
+				watchedFiles = newBuildWatchFiles(graph)
+				watchIncludedDirs(watcher, graph)
+				if result.err == nil && config.Depfile {
+					if manifestErr := writeBuildManifestFiles(*config, graph, dirs); manifestErr != nil {
+						print.Warn(manifestErr)
+					}
+				}
+				if result.err == nil && (config.CheckNatives || options.CheckRunNatives) && !hasBlockingBuildProblem(result.problems) {
+					nativesErr = pcx.checkNatives(config.Output, graph, config.CheckNatives)
+				}
+			}
+
 			if result.err != nil {
 				if result.err.Error() == "signal: killed" || result.err.Error() == "context canceled" {
 					print.Verb("non-fatal error occurred:", result.err)
@@ -224,7 +358,9 @@ loop:
 			} else {
 				fmt.Printf("%s finished building: %s [%d]\n", watcherColour("WATCHER:"), result.eventName, result.buildNumber)
 
-				if options.Trigger != nil {
+				if nativesErr != nil {
+					print.Erro(nativesErr)
+				} else if options.Trigger != nil {
 					options.Trigger <- result.problems
 				}
 
@@ -250,6 +386,29 @@ loop:
 	return err
 }
 
+// compileWatchedBuild compiles a build started by the watcher, problems are attributed to
+// dependencies and their warnings shown, summarised or hidden like a regular build
+func (pcx *PackageContext) compileWatchedBuild(ctx context.Context, config build.Config, options BuildOptions) (build.Problems, error) {
+	problems, _, err := compiler.CompileSource(ctx, compiler.CompileRequest{
+		GitHub:     pcx.GitHub,
+		ExecDir:    pcx.Package.LocalPath,
+		ErrorDir:   pcx.Package.LocalPath,
+		CacheDir:   pcx.CacheDir,
+		Platform:   pcx.Platform,
+		Config:     config,
+		Relative:   options.Relative,
+		Output:     options.output(),
+		Dependency: pcx.problemDependency,
+	})
+	if err != nil {
+		return problems, err
+	}
+	if config.DependencyWarnings == build.DependencyWarningsSummary {
+		writeDependencyWarningSummary(options.output(), problems)
+	}
+	return problems, nil
+}
+
 func readBuildNumber(buildFile string) (uint32, error) {
 	if buildFile == "" {
 		return 0, nil
@@ -280,27 +439,46 @@ type buildExecutionRequest struct {
 }
 
 func (pcx *PackageContext) executeBuild(request buildExecutionRequest) (problems build.Problems, result build.Result, err error) {
-	if err = compiler.RunPreBuildCommands(request.Context, request.Config, os.Stdout); err != nil {
+	if err = compiler.RunPreBuildCommands(request.Context, request.Config, request.Options.output()); err != nil {
 		print.Erro("Failed to execute pre-build command:", err)
 		return nil, build.Result{}, err
 	}
 
-	print.Verb("building", pcx.Package, "with", request.Config.Compiler.Version)
-	problems, result, err = compiler.CompileWithCommand(request.Command, request.Config.WorkingDir, pcx.Package.LocalPath, request.Options.Relative)
+	print.Fverb(request.Options.Output, "building", pcx.Package, "with", request.Config.Compiler.Version)
+	problems, result, err = compiler.CompileWithCommand(compiler.CompileCommandRequest{
+		Context:    request.Context,
+		Command:    request.Command,
+		WorkingDir: request.Config.WorkingDir,
+		ErrorDir:   pcx.Package.LocalPath,
+		Relative:   request.Options.Relative,
+		Output:     request.Options.output(),
+		Config:     request.Config,
+		Dependency: pcx.problemDependency,
+	})
 	if err != nil {
 		return nil, build.Result{}, errors.Wrap(err, "failed to compile package entry")
 	}
+	if request.Config.DependencyWarnings == build.DependencyWarningsSummary {
+		writeDependencyWarningSummary(request.Options.output(), problems)
+	}
 
 	atomic.AddUint32(&request.BuildNumber, 1)
 	writeBuildNumber(request.Options.BuildFile, request.BuildNumber)
 
-	if err = compiler.RunPostBuildCommands(request.Context, request.Config, os.Stdout); err != nil {
+	if err = compiler.RunPostBuildCommands(request.Context, request.Config, request.Options.output()); err != nil {
 		print.Erro("Failed to execute post-build command:", err)
 		return problems, result, err
 	}
 	return problems, result, nil
 }
 
+// writeDependencyWarningSummary replaces the warnings from each dependency with a single line
+func writeDependencyWarningSummary(w io.Writer, problems build.Problems) {
+	for _, count := range problems.DependencyWarnings() {
+		fmt.Fprintf(w, "%d warnings from %s not shown, set dependency_warnings to show to list them\n", count.Warnings, count.Dependency)
+	}
+}
+
 func writeBuildNumber(buildFile string, buildNumber uint32) {
 	if buildFile == "" {
 		return
@@ -310,6 +488,39 @@ func writeBuildNumber(buildFile string, buildNumber uint32) {
 	}
 }
 
+// buildWatchFiles is the set of files reachable from the entry script when it was last built,
+// changes to any other file can not affect the output
+type buildWatchFiles map[string]struct{}
+
+func newBuildWatchFiles(graph includes.Graph) buildWatchFiles {
+	files := make(buildWatchFiles, len(graph.Files))
+	for _, file := range graph.Files {
+		files[filepath.Clean(file)] = struct{}{}
+	}
+	return files
+}
+
+// affects returns true if an event can change the output of the build. New files may satisfy an
+// include that was missing or shadow an existing one so they always trigger a build, as does
+// every event before the include graph is known.
+func (files buildWatchFiles) affects(event fsnotify.Event) bool {
+	if files == nil || event.Op&fsnotify.Create != 0 {
+		return true
+	}
+	_, ok := files[filepath.Clean(event.Name)]
+	return ok
+}
+
+// watchIncludedDirs watches the directories of included files that are outside of the watched
+// package directory, such as dependencies, so changes to them trigger a build too
+func watchIncludedDirs(watcher *fsnotify.Watcher, graph includes.Graph) {
+	for _, file := range graph.Files {
+		if err := watcher.Add(filepath.Dir(file)); err != nil {
+			print.Verb("failed to watch", filepath.Dir(file), err)
+		}
+	}
+}
+
 func shouldWatchBuildEvent(event fsnotify.Event) bool {
 	ext := filepath.Ext(event.Name)
 	if ext != ".pwn" && ext != ".inc" {
@@ -320,20 +531,30 @@ func shouldWatchBuildEvent(event fsnotify.Event) bool {
 
 func (pcx *PackageContext) buildPrepare(
 	ctx context.Context,
-	build string,
+	name string,
 	ensure,
 	forceUpdate bool,
 ) (config *build.Config, err error) {
-	config = pcx.Package.GetBuildConfig(build)
-	if config == nil {
-		err = errors.Errorf("no build config named '%s'", build)
+	selected := pcx.Package.GetBuildConfig(name)
+	if selected == nil {
+		err = errors.Errorf("no build config named '%s'", name)
 		return
 	}
 
+	// the package's config is copied so preparing a build never modifies it, slices that are
+	// appended to below must not share storage with the package either
+	copied := *selected
+	copied.Includes = append([]string(nil), selected.Includes...)
+	config = &copied
+
 	if err = config.Compiler.Validate(); err != nil {
 		err = errors.Wrap(err, "invalid compiler configuration")
 		return
 	}
+	if config.DependencyWarnings, err = build.ParseDependencyWarnings(string(config.DependencyWarnings)); err != nil {
+		err = errors.Wrap(err, "invalid build configuration")
+		return
+	}
 
 	if config.Input == "" && pcx.Package.Entry != "" {
 		config.Input = packagePath(pcx.Package.LocalPath, pcx.Package.Entry)
@@ -466,13 +687,21 @@ func (pcx *PackageContext) buildPrepare(
 	return config, err
 }
 
-func (pcx *PackageContext) ensureBuildFile(config *build.Config) (err error) {
+func (pcx *PackageContext) buildFileEnabled() bool {
 	exp := pcx.Package.ExperimentalFlags()
-	if exp == nil || !exp.BuildFileEnabled() {
+	return exp != nil && exp.BuildFileEnabled()
+}
+
+func (pcx *PackageContext) buildFilePath() string {
+	return filepath.Join(pcx.Package.LocalPath, "sampctl_build_file.inc")
+}
+
+func (pcx *PackageContext) ensureBuildFile(config *build.Config) (err error) {
+	if !pcx.buildFileEnabled() {
 		return nil
 	}
 
-	buildFilePath := filepath.Join(pcx.Package.LocalPath, "sampctl_build_file.inc")
+	buildFilePath := pcx.buildFilePath()
 	var builder strings.Builder
 	builder.WriteString("// Code generated by sampctl. DO NOT EDIT.\n")
 	builder.WriteString("#if defined _sampctl_build_file_included\n")
@@ -0,0 +1,37 @@
+package pkgcontext
+
+import (
+	"fmt"
+	"io"
+
+	"github.com/Southclaws/sampctl/src/pkg/build"
+	"github.com/Southclaws/sampctl/src/pkg/infrastructure/print"
+)
+
+// BuildRecordName returns the name a build is recorded under in the lockfile.
+func BuildRecordName(name string) string {
+	if name == "" {
+		return "default"
+	}
+	return name
+}
+
+// checkBuildBudget fails when a successful build exceeds the size budget declared in its
+// config and warns when the code grew by more than the allowed percentage since the build
+// recorded in the lockfile. The warning is written to w, or the usual output when w is nil.
+func (pcx *PackageContext) checkBuildBudget(w io.Writer, name string, config build.Config, result build.Result) error {
+	if config.Budget == nil {
+		return nil
+	}
+
+	if previous, ok := pcx.PackageLockfileState.LockedBuild(name); ok {
+		if growth, exceeded := config.Budget.CodeGrowth(previous.CodeSize, result.Code); exceeded {
+			print.Fwarn(w, fmt.Sprintf(
+				"code size grew by %.1f%% from %d to %d bytes since the last locked build, more than the max_code_growth of %g%%",
+				growth, previous.CodeSize, result.Code, config.Budget.MaxCodeGrowth,
+			))
+		}
+	}
+
+	return config.Budget.Check(result)
+}
@@ -0,0 +1,37 @@
+package pkgcontext
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/Southclaws/sampctl/src/pkg/build"
+	"github.com/Southclaws/sampctl/src/pkg/package/lockfile"
+)
+
+func TestCheckBuildBudget(t *testing.T) {
+	pcx := &PackageContext{}
+	result := build.Result{Code: 1000, StackHeap: 16384, Estimate: 16000, Total: 20000}
+
+	assert.NoError(t, pcx.checkBuildBudget(nil, "", build.Config{}, result))
+	assert.NoError(t, pcx.checkBuildBudget(nil, "", build.Config{Budget: &build.Budget{MaxTotal: 20000}}, result))
+
+	err := pcx.checkBuildBudget(nil, "", build.Config{Budget: &build.Budget{MinStackHeadroom: 1024}}, result)
+	assert.EqualError(t, err, "build exceeded its size budget: stack headroom of 384 bytes is below min_stack_headroom of 1024 bytes")
+}
+
+func TestCheckBuildBudgetWarnsOnCodeGrowth(t *testing.T) {
+	lf := lockfile.New("dev")
+	lf.SetBuild(lockfile.BuildRecord{Name: "default", CodeSize: 800})
+	pcx := &PackageContext{PackageLockfileState: PackageLockfileState{lockfileResolver: &fakeDependencyLock{lockfile: lf}}}
+
+	var out bytes.Buffer
+	config := build.Config{Budget: &build.Budget{MaxCodeGrowth: 10}}
+	assert.NoError(t, pcx.checkBuildBudget(&out, "", config, build.Result{Code: 1000}))
+	assert.Contains(t, out.String(), "code size grew by 25.0% from 800 to 1000 bytes")
+
+	out.Reset()
+	assert.NoError(t, pcx.checkBuildBudget(&out, "other", config, build.Result{Code: 1000}))
+	assert.Empty(t, out.String(), "builds with a different name are not compared")
+}
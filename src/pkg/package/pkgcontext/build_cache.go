@@ -0,0 +1,200 @@
+package pkgcontext
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/pkg/errors"
+
+	"github.com/Southclaws/sampctl/src/pkg/build"
+	"github.com/Southclaws/sampctl/src/pkg/build/amx"
+	"github.com/Southclaws/sampctl/src/pkg/build/includes"
+	"github.com/Southclaws/sampctl/src/pkg/infrastructure/print"
+	"github.com/Southclaws/sampctl/src/pkg/package/lockfile"
+)
+
+// buildInputHash hashes everything that affects the output of a build: the compiler and its
+// arguments, the warning policy applied to its problems plus the contents of the entry file and
+// every include reachable from it. An empty hash is returned when there is no lockfile to store
+// it in.
+func (pcx *PackageContext) buildInputHash(w io.Writer, config build.Config, command *exec.Cmd) string {
+	if !pcx.PackageLockfileState.HasLockfileResolver() {
+		return ""
+	}
+	hash, err := hashBuildInputs(config, command)
+	if err != nil {
+		print.Fwarn(w, "failed to hash build inputs, the build can not be skipped:", err)
+		return ""
+	}
+	return hash
+}
+
+func hashBuildInputs(config build.Config, command *exec.Cmd) (string, error) {
+	graph, err := includes.Resolve(config.Input, compilerIncludeDirs(command.Args))
+	if err != nil {
+		return "", errors.Wrap(err, "failed to resolve includes")
+	}
+
+	var b strings.Builder
+	resolved := config.Compiler.ResolveCompilerConfig()
+	b.WriteString(fmt.Sprintf("compiler %s/%s/%s:%s %s\n", resolved.Site, resolved.User, resolved.Repo, resolved.Version, config.Compiler.Path))
+	b.WriteString(fmt.Sprintf("warnings %v %v %s\n", config.IgnoreWarnings, config.WarningsAsErrors, config.DependencyWarnings))
+
+	// constants are passed in map order so the arguments are sorted to keep the hash stable, the
+	// include directory order is covered by the resolved include paths below
+	args := append([]string(nil), command.Args...)
+	sort.Strings(args)
+	for _, arg := range args {
+		b.WriteString(fmt.Sprintf("arg %q\n", arg))
+	}
+
+	for _, include := range graph.Includes {
+		b.WriteString(fmt.Sprintf("include %s:%d %q %s\n", include.File, include.Line, include.Name, include.Path))
+	}
+
+	for _, file := range graph.Files {
+		fileHash, err := hashOutputFile(file)
+		if err != nil {
+			return "", errors.Wrapf(err, "failed to hash %s", file)
+		}
+		b.WriteString(fmt.Sprintf("file %s %s\n", file, fileHash))
+	}
+
+	sum := sha256.Sum256([]byte(b.String()))
+	return "sha256:" + hex.EncodeToString(sum[:]), nil
+}
+
+// compilerIncludeDirs returns the `-i` include directories from a compiler command line in the
+// order the compiler searches them
+func compilerIncludeDirs(args []string) []string {
+	var dirs []string
+	for _, arg := range args {
+		if strings.HasPrefix(arg, "-i") {
+			dirs = append(dirs, strings.TrimPrefix(arg, "-i"))
+		}
+	}
+	return dirs
+}
+
+// upToDateBuild returns the recorded problems and result of the previous build when its inputs
+// and output are unchanged since it was recorded in the lockfile. Problem files are made relative
+// to the package when relative is set, like the compiler's own output.
+func (pcx *PackageContext) upToDateBuild(
+	w io.Writer,
+	name string,
+	config build.Config,
+	inputHash string,
+	relative bool,
+) (build.Problems, build.Result, bool) {
+	if inputHash == "" {
+		return nil, build.Result{}, false
+	}
+	if len(config.PreBuildCommands) > 0 {
+		print.Fverb(w, "build has pre-build commands which may generate sources, not skipping")
+		return nil, build.Result{}, false
+	}
+
+	locked, ok := pcx.PackageLockfileState.LockedBuild(name)
+	if !ok || locked.InputHash != inputHash || locked.OutputHash == "" {
+		return nil, build.Result{}, false
+	}
+
+	outputHash, err := hashOutputFile(config.Output)
+	if err != nil || outputHash != locked.OutputHash {
+		print.Fverb(w, "build output is missing or was modified since the last build")
+		return nil, build.Result{}, false
+	}
+
+	var problems build.Problems
+	for _, problem := range locked.Problems {
+		if !filepath.IsAbs(problem.File) {
+			problem.File = filepath.Join(pcx.Package.LocalPath, filepath.FromSlash(problem.File))
+		}
+		if relative {
+			if rel, relErr := filepath.Rel(pcx.Package.LocalPath, problem.File); relErr == nil {
+				problem.File = rel
+			}
+		}
+		problems = append(problems, problem)
+	}
+
+	return problems, build.Result{
+		Header:    locked.HeaderSize,
+		Code:      locked.CodeSize,
+		Data:      locked.DataSize,
+		StackHeap: locked.StackHeapSize,
+		Estimate:  locked.StackHeapEstimate,
+		Total:     locked.TotalSize,
+	}, true
+}
+
+// recordBuild stores a successful build and its problems in the lockfile so the next build can be
+// compared against it or skipped.
+func (pcx *PackageContext) recordBuild(
+	w io.Writer,
+	name string,
+	config build.Config,
+	problems build.Problems,
+	result build.Result,
+	inputHash string,
+) {
+	if !pcx.PackageLockfileState.HasLockfileResolver() {
+		return
+	}
+
+	record := lockfile.BuildRecord{
+		Name:              BuildRecordName(name),
+		CompilerVersion:   config.Compiler.Version,
+		CompilerPreset:    config.Compiler.Preset,
+		Entry:             pcx.packageRelativePath(config.Input),
+		Output:            pcx.packageRelativePath(config.Output),
+		InputHash:         inputHash,
+		HeaderSize:        result.Header,
+		CodeSize:          result.Code,
+		DataSize:          result.Data,
+		StackHeapSize:     result.StackHeap,
+		StackHeapEstimate: result.Estimate,
+		TotalSize:         result.Total,
+	}
+	// problems in the package are stored relative to it so the lockfile is the same on every machine
+	for _, problem := range problems {
+		if filepath.IsAbs(problem.File) {
+			problem.File = pcx.packageRelativePath(problem.File)
+		} else {
+			problem.File = filepath.ToSlash(problem.File)
+		}
+		record.Problems = append(record.Problems, problem)
+	}
+	if hash, err := hashOutputFile(config.Output); err == nil {
+		record.OutputHash = hash
+	} else if !os.IsNotExist(errors.Cause(err)) {
+		print.Fwarn(w, "failed to hash output file:", err)
+	}
+	if output, err := amx.Open(config.Output); err == nil {
+		record.Exports = &lockfile.LockedExports{
+			Publics: output.Publics,
+			Natives: output.Natives,
+			PubVars: output.PubVars,
+		}
+	}
+
+	pcx.RecordBuildToLockfile(record)
+}
+
+func (pcx *PackageContext) packageRelativePath(path string) string {
+	if path == "" || pcx.Package.LocalPath == "" {
+		return path
+	}
+	rel, err := filepath.Rel(pcx.Package.LocalPath, path)
+	if err != nil || strings.HasPrefix(rel, "..") {
+		return path
+	}
+	return filepath.ToSlash(rel)
+}
@@ -0,0 +1,181 @@
+package pkgcontext
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/Southclaws/sampctl/src/pkg/build"
+	"github.com/Southclaws/sampctl/src/pkg/package/pawnpackage"
+)
+
+// fakeCompilerScript writes the output file named by the -o flag, prints size statistics and
+// counts how many times it was invoked. It warns about the entry file when a file named warn is
+// next to it.
+const fakeCompilerScript = `#!/bin/sh
+for arg in "$@"; do
+	case "$arg" in
+		-o*) printf 'amx' > "${arg#-o}" ;;
+		*.pwn) input="$arg" ;;
+	esac
+done
+echo run >> "$(dirname "$0")/invocations"
+if [ -f "$(dirname "$0")/warn" ]; then
+	echo "$input(2) : warning 203: symbol is never used: \"unused\""
+fi
+echo "Header size:             60 bytes"
+echo "Code size:              276 bytes"
+echo "Data size:                0 bytes"
+echo "Stack/heap size:      16384 bytes; estimated max. usage=8 cells (32 bytes)"
+echo "Total requirements:   16720 bytes"
+`
+
+func newCachedBuildPackage(t *testing.T) (*PackageContext, string) {
+	t.Helper()
+	if runtime.GOOS == "windows" {
+		t.Skip("fake compiler is a shell script")
+	}
+
+	dir := t.TempDir()
+	compilerDir := filepath.Join(dir, "compiler")
+	require.NoError(t, os.MkdirAll(compilerDir, 0o755))
+	require.NoError(t, os.WriteFile(filepath.Join(compilerDir, "pawncc"), []byte(fakeCompilerScript), 0o755))
+
+	require.NoError(t, os.MkdirAll(filepath.Join(dir, "gamemodes"), 0o755))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "gamemodes", "test.pwn"), []byte("#include \"util\"\nmain() {}\n"), 0o644))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "gamemodes", "util.inc"), []byte("stock util() {}\n"), 0o644))
+
+	pcx := &PackageContext{
+		Package: pawnpackage.Package{
+			Parent:    true,
+			LocalPath: dir,
+			Entry:     "gamemodes/test.pwn",
+			Output:    "gamemodes/test.amx",
+			Build: &build.Config{
+				Compiler: build.CompilerConfig{Path: "compiler"},
+			},
+		},
+	}
+	require.NoError(t, pcx.InitLockfileResolver("dev"))
+	return pcx, dir
+}
+
+func compilerInvocations(t *testing.T, dir string) int {
+	t.Helper()
+	contents, err := os.ReadFile(filepath.Join(dir, "compiler", "invocations"))
+	if os.IsNotExist(err) {
+		return 0
+	}
+	require.NoError(t, err)
+	return len(contents) / len("run\n")
+}
+
+func TestBuildSkipsWhenInputsAreUnchanged(t *testing.T) {
+	pcx, dir := newCachedBuildPackage(t)
+	ctx := context.Background()
+
+	_, result, err := pcx.Build(ctx, BuildOptions{})
+	require.NoError(t, err)
+	assert.Equal(t, 1, compilerInvocations(t, dir))
+	assert.Equal(t, 276, result.Code)
+
+	locked := pcx.GetLockfile().GetBuild()
+	require.NotNil(t, locked)
+	assert.Equal(t, "default", locked.Name)
+	assert.Equal(t, "gamemodes/test.pwn", locked.Entry)
+	assert.Equal(t, "gamemodes/test.amx", locked.Output)
+	assert.NotEmpty(t, locked.InputHash)
+	assert.NotEmpty(t, locked.OutputHash)
+
+	_, result, err = pcx.Build(ctx, BuildOptions{})
+	require.NoError(t, err)
+	assert.Equal(t, 1, compilerInvocations(t, dir), "unchanged inputs must not invoke the compiler")
+	assert.Equal(t, build.Result{Header: 60, Code: 276, StackHeap: 16384, Estimate: 32, Total: 16720}, result)
+
+	_, _, err = pcx.Build(ctx, BuildOptions{Force: true})
+	require.NoError(t, err)
+	assert.Equal(t, 2, compilerInvocations(t, dir), "--force always compiles")
+
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "gamemodes", "util.inc"), []byte("stock util2() {}\n"), 0o644))
+	_, _, err = pcx.Build(ctx, BuildOptions{})
+	require.NoError(t, err)
+	assert.Equal(t, 3, compilerInvocations(t, dir), "a changed include must be rebuilt")
+
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "gamemodes", "test.amx"), []byte("modified"), 0o644))
+	_, _, err = pcx.Build(ctx, BuildOptions{})
+	require.NoError(t, err)
+	assert.Equal(t, 4, compilerInvocations(t, dir), "a modified output must be rebuilt")
+}
+
+func TestSkippedBuildReturnsRecordedProblems(t *testing.T) {
+	pcx, dir := newCachedBuildPackage(t)
+	ctx := context.Background()
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "compiler", "warn"), nil, 0o644))
+
+	problems, result, err := pcx.Build(ctx, BuildOptions{})
+	require.NoError(t, err)
+	require.Len(t, problems, 1)
+	assert.Equal(t, build.ProblemWarning, problems[0].Severity)
+
+	locked := pcx.GetLockfile().GetBuild()
+	require.NotNil(t, locked)
+	require.Len(t, locked.Problems, 1)
+	assert.Equal(t, "gamemodes/test.pwn", locked.Problems[0].File)
+
+	skippedProblems, skippedResult, err := pcx.Build(ctx, BuildOptions{})
+	require.NoError(t, err)
+	assert.Equal(t, 1, compilerInvocations(t, dir))
+	assert.Equal(t, problems, skippedProblems)
+	assert.Equal(t, result, skippedResult)
+
+	relativeProblems, _, err := pcx.Build(ctx, BuildOptions{Relative: true})
+	require.NoError(t, err)
+	assert.Equal(t, 1, compilerInvocations(t, dir))
+	require.Len(t, relativeProblems, 1)
+	assert.Equal(t, filepath.Join("gamemodes", "test.pwn"), relativeProblems[0].File)
+
+	pcx.Package.Build.IgnoreWarnings = []int{203}
+	problems, _, err = pcx.Build(ctx, BuildOptions{})
+	require.NoError(t, err)
+	assert.Equal(t, 2, compilerInvocations(t, dir), "a changed warning policy must be rebuilt")
+	assert.Empty(t, problems)
+}
+
+func TestRecordBuildWarnsOnTheBuildOutput(t *testing.T) {
+	pcx, dir := newCachedBuildPackage(t)
+
+	var output bytes.Buffer
+	pcx.recordBuild(&output, "", build.Config{Output: filepath.Join(dir, "gamemodes")}, nil, build.Result{}, "sha256:abc")
+	assert.Contains(t, output.String(), "failed to hash output file")
+}
+
+func TestBuildDoesNotSkipWithoutLockfile(t *testing.T) {
+	pcx, dir := newCachedBuildPackage(t)
+	pcx.PackageLockfileState = PackageLockfileState{}
+
+	for i := 0; i < 2; i++ {
+		_, _, err := pcx.Build(context.Background(), BuildOptions{})
+		require.NoError(t, err)
+	}
+	assert.Equal(t, 2, compilerInvocations(t, dir))
+}
+
+func TestUpToDateBuildIgnoresBuildsWithPreBuildCommands(t *testing.T) {
+	pcx, _ := newCachedBuildPackage(t)
+
+	_, _, ok := pcx.upToDateBuild(nil, "", build.Config{PreBuildCommands: [][]string{{"generate"}}}, "sha256:abc", false)
+	assert.False(t, ok)
+
+	_, _, ok = pcx.upToDateBuild(nil, "", build.Config{}, "", false)
+	assert.False(t, ok)
+}
+
+func TestCompilerIncludeDirs(t *testing.T) {
+	assert.Equal(t, []string{"/a", "/b"}, compilerIncludeDirs([]string{"pawncc", "main.pwn", "-i/a", "-d3", "-i/b"}))
+}
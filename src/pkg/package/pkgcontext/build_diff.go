@@ -0,0 +1,70 @@
+package pkgcontext
+
+import (
+	"fmt"
+	"io"
+
+	"github.com/pkg/errors"
+
+	"github.com/Southclaws/sampctl/src/pkg/build"
+	"github.com/Southclaws/sampctl/src/pkg/build/amx"
+	"github.com/Southclaws/sampctl/src/pkg/infrastructure/print"
+)
+
+// previousBuildOutput returns the exported symbols of the last build recorded in the lockfile.
+// They come from the record itself since a failed build may have overwritten the output. Builds
+// recorded before their symbols were stored are read from the output before the compiler
+// overwrites it. Nothing is returned when diffing is disabled, when there is no recorded build
+// with the same name or when such an older output changed since it was recorded.
+func (pcx *PackageContext) previousBuildOutput(w io.Writer, name string, config build.Config) *amx.File {
+	if !config.DiffPrevious {
+		return nil
+	}
+
+	locked, ok := pcx.PackageLockfileState.LockedBuild(name)
+	if !ok || (locked.OutputHash == "" && locked.Exports == nil) {
+		print.Fverb(w, "no previous build of", BuildRecordName(name), "in the lockfile, exported symbols are not compared")
+		return nil
+	}
+	if locked.Exports != nil {
+		return &amx.File{
+			Publics: locked.Exports.Publics,
+			Natives: locked.Exports.Natives,
+			PubVars: locked.Exports.PubVars,
+		}
+	}
+	hash, err := hashOutputFile(config.Output)
+	if err != nil || hash != locked.OutputHash {
+		print.Fwarn(w, "build output is missing or was modified since the last locked build, exported symbols are not compared")
+		return nil
+	}
+
+	previous, err := amx.Open(config.Output)
+	if err != nil {
+		print.Fwarn(w, "failed to read the previous build output, exported symbols are not compared:", err)
+		return nil
+	}
+	return &previous
+}
+
+// diffBuildOutput writes the exported symbols added, removed or moved since the previous output
+// and fails when any were removed
+func diffBuildOutput(w io.Writer, previous amx.File, config build.Config) error {
+	current, err := amx.Open(config.Output)
+	if err != nil {
+		return errors.Wrap(err, "failed to compare exported symbols")
+	}
+
+	diff := amx.Compare(previous, current)
+	if _, err = fmt.Fprintln(w, "Exported symbols since the last locked build:"); err != nil {
+		return err
+	}
+	if err = diff.WriteText(w); err != nil {
+		return err
+	}
+
+	if removed := diff.Removed(); removed > 0 {
+		return errors.Errorf("build removed %d exported symbols since the last locked build", removed)
+	}
+	return nil
+}
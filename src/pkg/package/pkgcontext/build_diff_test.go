@@ -0,0 +1,60 @@
+package pkgcontext
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// copyingCompilerScript writes next.amx from the compiler directory to the output
+const copyingCompilerScript = `#!/bin/sh
+for arg in "$@"; do
+	case "$arg" in
+		-o*) cp "$(dirname "$0")/next.amx" "${arg#-o}" ;;
+	esac
+done
+echo "Total requirements:   16720 bytes"
+`
+
+func TestBuildDiffsExportedSymbolsWithLockedOutput(t *testing.T) {
+	pcx, dir := newCachedBuildPackage(t)
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "compiler", "pawncc"), []byte(copyingCompilerScript), 0o755))
+	ctx := context.Background()
+
+	compile := func(source string, natives ...string) (string, error) {
+		t.Helper()
+		require.NoError(t, os.WriteFile(filepath.Join(dir, "gamemodes", "test.pwn"), []byte(source), 0o644))
+		writeNativesAMX(t, filepath.Join(dir, "compiler", "next.amx"), natives...)
+		var output bytes.Buffer
+		_, _, err := pcx.Build(ctx, BuildOptions{DiffPrevious: true, Output: &output})
+		return output.String(), err
+	}
+
+	output, err := compile("main() {}\n", "print", "SetTimer")
+	require.NoError(t, err)
+	assert.NotContains(t, output, "Exported symbols", "there is nothing to compare the first build with")
+
+	output, err = compile("main() { print(); }\n", "print", "SetTimer", "SetTimerEx")
+	require.NoError(t, err)
+	assert.Contains(t, output, "Exported symbols since the last locked build:\nNatives\n  + SetTimerEx\n")
+
+	output, err = compile("main() { print(); print(); }\n", "print")
+	assert.EqualError(t, err, "build removed 2 exported symbols since the last locked build")
+	assert.Contains(t, output, "  - SetTimer\n  - SetTimerEx\n")
+
+	locked, ok := pcx.PackageLockfileState.LockedBuild("")
+	require.True(t, ok)
+	hash, err := hashOutputFile(filepath.Join(dir, "gamemodes", "test.amx"))
+	require.NoError(t, err)
+	assert.NotEqual(t, hash, locked.OutputHash, "a build that removes symbols is not recorded")
+
+	output, err = compile("main() { print(); print(); }\n", "print")
+	assert.EqualError(t, err, "build removed 2 exported symbols since the last locked build",
+		"the overwritten output must not hide the removed symbols on the next build")
+	assert.Contains(t, output, "  - SetTimer\n  - SetTimerEx\n")
+}
@@ -0,0 +1,116 @@
+package pkgcontext
+
+import (
+	"context"
+
+	"github.com/pkg/errors"
+
+	"github.com/Southclaws/sampctl/src/pkg/build/includes"
+)
+
+// IncludeOwnerPackage is the owner reported for files that belong to the package itself
+const IncludeOwnerPackage = "package"
+
+// IncludeFile is a file or include directory and the dependency it belongs to
+type IncludeFile struct {
+	Path string `json:"path"`
+	// Owner is the dependency that provides the file, IncludeOwnerPackage for the package's own
+	// files or empty when the file is outside of the package.
+	Owner string `json:"owner,omitempty"`
+}
+
+// ExplainedInclude is an include directive, the file that satisfied it and the files it shadows
+type ExplainedInclude struct {
+	includes.Directive
+	Resolved *IncludeFile  `json:"resolved,omitempty"`
+	Shadowed []IncludeFile `json:"shadowed,omitempty"`
+}
+
+// IncludeExplanation describes how the include directives reachable from a build's entry script
+// resolve against the include directories passed to the compiler
+type IncludeExplanation struct {
+	Build       string             `json:"build"`
+	Entry       string             `json:"entry"`
+	IncludeDirs []IncludeFile      `json:"include_dirs"`
+	Includes    []ExplainedInclude `json:"includes"`
+}
+
+// Shadowing returns the includes satisfied by one file while another include directory also
+// contains a matching file
+func (e IncludeExplanation) Shadowing() (shadowing []ExplainedInclude) {
+	for _, include := range e.Includes {
+		if include.Resolved != nil && len(include.Shadowed) > 0 {
+			shadowing = append(shadowing, include)
+		}
+	}
+	return
+}
+
+// Missing returns the `#include` directives that no file satisfied, unresolved `#tryinclude`
+// directives are not included.
+func (e IncludeExplanation) Missing() (missing []ExplainedInclude) {
+	for _, include := range e.Includes {
+		if include.Resolved == nil && !include.Try {
+			missing = append(missing, include)
+		}
+	}
+	return
+}
+
+// ExplainIncludes resolves the include directives of a build in the same order as the compiler
+// would and attributes every file to the dependency that provides it.
+func (pcx *PackageContext) ExplainIncludes(ctx context.Context, name string, ensure bool) (IncludeExplanation, error) {
+	config, err := pcx.buildPrepare(ctx, name, ensure, false)
+	if err != nil {
+		return IncludeExplanation{}, err
+	}
+	if config.Input == "" {
+		return IncludeExplanation{}, errors.New("build has no input file")
+	}
+
+	graph, dirs, err := pcx.buildIncludeGraph(*config)
+	if err != nil {
+		return IncludeExplanation{}, err
+	}
+
+	explanation := IncludeExplanation{
+		Build:       BuildRecordName(name),
+		Entry:       graph.Entry,
+		IncludeDirs: make([]IncludeFile, 0, len(dirs)),
+		Includes:    make([]ExplainedInclude, 0, len(graph.Includes)),
+	}
+	for _, dir := range dirs {
+		explanation.IncludeDirs = append(explanation.IncludeDirs, pcx.includeFile(dir))
+	}
+	for _, resolution := range graph.Includes {
+		include := ExplainedInclude{Directive: resolution.Directive}
+		if resolution.Found() {
+			resolved := pcx.includeFile(resolution.Path)
+			include.Resolved = &resolved
+		}
+		for _, path := range resolution.Shadowed {
+			include.Shadowed = append(include.Shadowed, pcx.includeFile(path))
+		}
+		explanation.Includes = append(explanation.Includes, include)
+	}
+	return explanation, nil
+}
+
+func (pcx *PackageContext) includeFile(path string) IncludeFile {
+	return IncludeFile{Path: path, Owner: pcx.includeOwner(path)}
+}
+
+// includeOwner names the dependency a file belongs to the way it is written in the package file
+func (pcx *PackageContext) includeOwner(path string) string {
+	meta, name, ok := pcx.dependencyOwningFile(path)
+	switch {
+	case ok:
+		return meta.String()
+	case name != "":
+		return name
+	}
+	if _, inside := relativeTo(pcx.Package.LocalPath, path); inside {
+		return IncludeOwnerPackage
+	}
+	return ""
+}
@@ -0,0 +1,96 @@
+package pkgcontext
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/Southclaws/sampctl/src/pkg/build"
+	"github.com/Southclaws/sampctl/src/pkg/infrastructure/versioning"
+	"github.com/Southclaws/sampctl/src/pkg/package/pawnpackage"
+)
+
+func writeIncludeFile(t *testing.T, path, contents string) {
+	t.Helper()
+	require.NoError(t, os.MkdirAll(filepath.Dir(path), 0o755))
+	require.NoError(t, os.WriteFile(path, []byte(contents), 0o644))
+}
+
+func newIncludesPackage(t *testing.T) (*PackageContext, string) {
+	t.Helper()
+
+	dir := t.TempDir()
+	writeIncludeFile(t, filepath.Join(dir, "gamemodes", "test.pwn"), "#include <a_mysql>\n#include \"util\"\n#tryinclude <optional>\n#include <missing>\n")
+	writeIncludeFile(t, filepath.Join(dir, "gamemodes", "util.inc"), "#include <resource>\n")
+	writeIncludeFile(t, filepath.Join(dir, "dependencies", "lib-a", "a_mysql.inc"), "")
+	writeIncludeFile(t, filepath.Join(dir, "dependencies", "lib-b", "a_mysql.inc"), "")
+	writeIncludeFile(t, filepath.Join(dir, "dependencies", ".resources", "lib-c-a1b2c3", "resource.inc"), "")
+
+	pcx := &PackageContext{
+		Package: pawnpackage.Package{
+			Parent:    true,
+			LocalPath: dir,
+			Vendor:    filepath.Join(dir, "dependencies"),
+			Entry:     "gamemodes/test.pwn",
+			Output:    "gamemodes/test.amx",
+			Build:     &build.Config{},
+		},
+	}
+	pcx.AllDependencies = []versioning.DependencyMeta{
+		{User: "user", Repo: "lib-a", Tag: "1.0.0"},
+		{User: "user", Repo: "lib-b"},
+		{User: "user", Repo: "lib-c"},
+	}
+	pcx.AllIncludePaths = []string{filepath.Join(dir, "dependencies", ".resources", "lib-c-a1b2c3")}
+	return pcx, dir
+}
+
+func TestExplainIncludesAttributesFilesToDependencies(t *testing.T) {
+	t.Parallel()
+
+	pcx, dir := newIncludesPackage(t)
+	explanation, err := pcx.ExplainIncludes(context.Background(), "", false)
+	require.NoError(t, err)
+
+	assert.Equal(t, "default", explanation.Build)
+	assert.Equal(t, filepath.Join(dir, "gamemodes", "test.pwn"), explanation.Entry)
+	require.NotEmpty(t, explanation.IncludeDirs)
+	assert.Equal(t, IncludeFile{Path: dir, Owner: IncludeOwnerPackage}, explanation.IncludeDirs[0])
+
+	byName := make(map[string]ExplainedInclude)
+	for _, include := range explanation.Includes {
+		byName[include.Name] = include
+	}
+
+	mysql := byName["a_mysql"]
+	require.NotNil(t, mysql.Resolved)
+	assert.Equal(t, IncludeFile{Path: filepath.Join(dir, "dependencies", "lib-a", "a_mysql.inc"), Owner: "user/lib-a:1.0.0"}, *mysql.Resolved)
+	assert.Equal(t, []IncludeFile{{Path: filepath.Join(dir, "dependencies", "lib-b", "a_mysql.inc"), Owner: "user/lib-b"}}, mysql.Shadowed)
+
+	util := byName["util"]
+	require.NotNil(t, util.Resolved)
+	assert.Equal(t, IncludeOwnerPackage, util.Resolved.Owner)
+
+	resource := byName["resource"]
+	require.NotNil(t, resource.Resolved)
+	assert.Equal(t, "user/lib-c", resource.Resolved.Owner)
+
+	assert.Nil(t, byName["optional"].Resolved)
+
+	require.Len(t, explanation.Shadowing(), 1)
+	assert.Equal(t, "a_mysql", explanation.Shadowing()[0].Name)
+	require.Len(t, explanation.Missing(), 1)
+	assert.Equal(t, "missing", explanation.Missing()[0].Name)
+}
+
+func TestIncludeOwnerOutsidePackage(t *testing.T) {
+	t.Parallel()
+
+	pcx, dir := newIncludesPackage(t)
+	assert.Empty(t, pcx.includeOwner(filepath.Join(filepath.Dir(dir), "elsewhere.inc")))
+	assert.Equal(t, "lib-z", pcx.includeOwner(filepath.Join(dir, "dependencies", "lib-z", "z.inc")))
+}
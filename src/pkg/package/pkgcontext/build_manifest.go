@@ -0,0 +1,39 @@
+package pkgcontext
+
+import (
+	"github.com/pkg/errors"
+
+	"github.com/Southclaws/sampctl/src/pkg/build"
+	"github.com/Southclaws/sampctl/src/pkg/build/compiler"
+	"github.com/Southclaws/sampctl/src/pkg/build/includes"
+)
+
+// buildIncludeGraph resolves every file reachable from a build's entry script using the same
+// include directories, in the same order, as the compiler
+func (pcx *PackageContext) buildIncludeGraph(config build.Config) (includes.Graph, []string, error) {
+	dirs := compiler.IncludeDirs(pcx.Package.LocalPath, config.Includes)
+	graph, err := includes.Resolve(config.Input, dirs)
+	if err != nil {
+		return includes.Graph{}, nil, errors.Wrap(err, "failed to resolve includes")
+	}
+	return graph, dirs, nil
+}
+
+// writeBuildManifest writes the depfile and include manifest of a build when it is enabled
+func (pcx *PackageContext) writeBuildManifest(config build.Config) error {
+	if !config.Depfile {
+		return nil
+	}
+	graph, dirs, err := pcx.buildIncludeGraph(config)
+	if err != nil {
+		return err
+	}
+	return writeBuildManifestFiles(config, graph, dirs)
+}
+
+func writeBuildManifestFiles(config build.Config, graph includes.Graph, dirs []string) error {
+	if err := build.NewManifest(config.Output, graph, dirs).WriteFiles(); err != nil {
+		return errors.Wrap(err, "failed to write build depfile")
+	}
+	return nil
+}
@@ -0,0 +1,57 @@
+package pkgcontext
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/fsnotify/fsnotify"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/Southclaws/sampctl/src/pkg/build"
+	"github.com/Southclaws/sampctl/src/pkg/build/includes"
+)
+
+func TestBuildWritesDepfileAndManifest(t *testing.T) {
+	pcx, dir := newCachedBuildPackage(t)
+	ctx := context.Background()
+
+	_, _, err := pcx.Build(ctx, BuildOptions{})
+	require.NoError(t, err)
+	output := filepath.Join(dir, "gamemodes", "test.amx")
+	assert.NoFileExists(t, output+".d", "depfiles are only written when enabled")
+
+	_, _, err = pcx.Build(ctx, BuildOptions{Depfile: true})
+	require.NoError(t, err)
+	assert.Equal(t, 1, compilerInvocations(t, dir), "the depfile is written when the build is skipped")
+
+	depfile, err := os.ReadFile(output + ".d")
+	require.NoError(t, err)
+	assert.Contains(t, string(depfile), filepath.ToSlash(output)+": \\\n")
+	assert.Contains(t, string(depfile), filepath.ToSlash(filepath.Join(dir, "gamemodes", "test.pwn")))
+	assert.Contains(t, string(depfile), filepath.ToSlash(filepath.Join(dir, "gamemodes", "util.inc")))
+
+	contents, err := os.ReadFile(output + ".includes.json")
+	require.NoError(t, err)
+	var manifest build.Manifest
+	require.NoError(t, json.Unmarshal(contents, &manifest))
+	assert.Equal(t, output, manifest.Output)
+	assert.Equal(t, []string{filepath.Join(dir, "gamemodes", "test.pwn"), filepath.Join(dir, "gamemodes", "util.inc")}, manifest.Files)
+	require.Len(t, manifest.Includes, 1)
+	assert.Equal(t, "util", manifest.Includes[0].Name)
+}
+
+func TestBuildWatchFilesAffects(t *testing.T) {
+	t.Parallel()
+
+	var unknown buildWatchFiles
+	assert.True(t, unknown.affects(fsnotify.Event{Name: "/project/other.inc", Op: fsnotify.Write}))
+
+	files := newBuildWatchFiles(includes.Graph{Files: []string{"/project/main.pwn", "/project/util.inc"}})
+	assert.True(t, files.affects(fsnotify.Event{Name: "/project/util.inc", Op: fsnotify.Write}))
+	assert.False(t, files.affects(fsnotify.Event{Name: "/project/other.inc", Op: fsnotify.Write}))
+	assert.True(t, files.affects(fsnotify.Event{Name: "/project/new.inc", Op: fsnotify.Create}))
+}
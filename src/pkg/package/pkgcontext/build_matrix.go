@@ -0,0 +1,261 @@
+package pkgcontext
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"os"
+	"path"
+	"path/filepath"
+	"sync"
+
+	"github.com/pkg/errors"
+
+	"github.com/Southclaws/sampctl/src/pkg/build"
+	"github.com/Southclaws/sampctl/src/pkg/infrastructure/print"
+)
+
+// BuildMatrixOptions configures building several named builds at once.
+type BuildMatrixOptions struct {
+	Names    []string
+	Ensure   bool
+	DryRun   bool
+	Relative bool
+	Force    bool
+	// DependencyWarnings overrides the `dependency_warnings` of every build when set.
+	DependencyWarnings build.DependencyWarnings
+	// Depfile writes a depfile and include manifest for every build.
+	Depfile bool
+	// CheckNatives fails every build whose output calls natives that no configured plugin or
+	// component provides.
+	CheckNatives bool
+	// DiffPrevious compares the exported symbols of every build with its last locked output.
+	DiffPrevious bool
+	// Jobs limits how many compilers run at the same time, defaults to one.
+	Jobs int
+	// Output receives compiler and build command output with each line prefixed by the name of
+	// the build that wrote it, defaults to standard output.
+	Output io.Writer
+}
+
+// BuildMatrixResult is the outcome of one build in a matrix.
+type BuildMatrixResult struct {
+	Name     string
+	Compiler string
+	Problems build.Problems
+	Result   build.Result
+	Err      error
+}
+
+// Failed returns true if the build could not run or the compiler reported errors.
+func (r BuildMatrixResult) Failed() bool {
+	return r.Err != nil || r.Problems.Fatal() || !r.Problems.IsValid()
+}
+
+// MatchBuilds returns the names of the package's builds that match a glob pattern such as
+// `test-*`, in the order they are declared.
+func (pcx *PackageContext) MatchBuilds(pattern string) ([]string, error) {
+	if _, err := path.Match(pattern, ""); err != nil {
+		return nil, errors.Wrapf(err, "invalid build pattern '%s'", pattern)
+	}
+
+	var names []string
+	for _, config := range pcx.Package.Builds {
+		if matched, _ := path.Match(pattern, config.Name); matched {
+			names = append(names, config.Name)
+		}
+	}
+	if len(names) == 0 {
+		return nil, errors.Errorf("no build configs match '%s'", pattern)
+	}
+	return names, nil
+}
+
+// AllBuilds returns the names of every build declared by the package. A package without named
+// builds has a single build with an empty name.
+func (pcx *PackageContext) AllBuilds() []string {
+	if len(pcx.Package.Builds) == 0 {
+		return []string{""}
+	}
+	names := make([]string, 0, len(pcx.Package.Builds))
+	for _, config := range pcx.Package.Builds {
+		names = append(names, config.Name)
+	}
+	return names
+}
+
+// BuildMatrix compiles several builds concurrently. Dependencies are ensured once and every
+// build is prepared one at a time, so compilers are only downloaded once, before any compiler
+// runs. Results are returned in the same order as the names.
+//
+// Builds that generate different `sampctl_build_file.inc` contents share the same file in the
+// package directory so they are compiled one at a time. Builds that write the same output are
+// rejected before any compiler runs.
+func (pcx *PackageContext) BuildMatrix(ctx context.Context, options BuildMatrixOptions) ([]BuildMatrixResult, error) {
+	if len(options.Names) == 0 {
+		return nil, errors.New("no builds selected")
+	}
+
+	if options.Ensure {
+		if err := pcx.EnsureDependencies(ctx, true); err != nil {
+			return nil, errors.Wrap(err, "failed to ensure dependencies before build")
+		}
+	}
+
+	prepared := make([]preparedBuild, len(options.Names))
+	buildFiles := make([][]byte, len(options.Names))
+	distinctBuildFiles := make(map[string]struct{})
+	outputs := make(map[string]string)
+	for i, name := range options.Names {
+		var err error
+		prepared[i], err = pcx.prepareBuild(ctx, BuildOptions{
+			Name:               name,
+			DependencyWarnings: options.DependencyWarnings,
+			Depfile:            options.Depfile,
+			CheckNatives:       options.CheckNatives,
+			DiffPrevious:       options.DiffPrevious,
+		})
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to prepare build %s", BuildRecordName(name))
+		}
+		output := filepath.Clean(prepared[i].config.Output)
+		if other, ok := outputs[output]; ok {
+			return nil, errors.Errorf(
+				"builds %s and %s both write %s, give each build its own output",
+				other, BuildRecordName(name), pcx.packageRelativePath(output),
+			)
+		}
+		outputs[output] = BuildRecordName(name)
+		if pcx.buildFileEnabled() {
+			buildFiles[i], err = os.ReadFile(pcx.buildFilePath())
+			if err != nil {
+				return nil, errors.Wrap(err, "failed to read build include file")
+			}
+			distinctBuildFiles[string(buildFiles[i])] = struct{}{}
+		}
+	}
+
+	if options.DryRun {
+		for _, p := range prepared {
+			printBuildCommand(p.command)
+		}
+		return nil, nil
+	}
+
+	jobs := options.Jobs
+	if jobs < 1 {
+		jobs = 1
+	}
+	serial := len(distinctBuildFiles) > 1
+	if serial {
+		print.Verb("builds generate different build include files, compiling one at a time")
+		jobs = 1
+	}
+	if jobs > len(prepared) {
+		jobs = len(prepared)
+	}
+
+	output := options.Output
+	if output == nil {
+		output = os.Stdout
+	}
+
+	results := make([]BuildMatrixResult, len(prepared))
+	for i, name := range options.Names {
+		results[i] = BuildMatrixResult{
+			Name:     BuildRecordName(name),
+			Compiler: prepared[i].config.Compiler.ResolveCompilerConfig().Version,
+			Err:      context.Canceled,
+		}
+	}
+
+	var (
+		outputMu sync.Mutex
+		wg       sync.WaitGroup
+		indexes  = make(chan int)
+	)
+	for w := 0; w < jobs; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range indexes {
+				if serial {
+					if err := os.WriteFile(pcx.buildFilePath(), buildFiles[i], 0o600); err != nil {
+						results[i].Err = errors.Wrap(err, "failed to write build include file")
+						continue
+					}
+				}
+
+				w := newPrefixWriter(output, &outputMu, "["+results[i].Name+"] ")
+				problems, result, err := pcx.runPreparedBuild(ctx, prepared[i], BuildOptions{
+					Name:     options.Names[i],
+					Relative: options.Relative,
+					Force:    options.Force,
+					Output:   w,
+				})
+				w.Flush()
+
+				results[i].Problems = problems
+				results[i].Result = result
+				results[i].Err = err
+			}
+		}()
+	}
+
+dispatch:
+	for i := range prepared {
+		select {
+		case <-ctx.Done():
+			break dispatch
+		case indexes <- i:
+		}
+	}
+	close(indexes)
+	wg.Wait()
+
+	return results, nil
+}
+
+// prefixWriter writes whole lines to an underlying writer with a prefix, holding partial lines
+// back until they are complete so concurrent builds never interleave within a line.
+type prefixWriter struct {
+	w      io.Writer
+	mu     *sync.Mutex
+	prefix string
+	buf    bytes.Buffer
+}
+
+func newPrefixWriter(w io.Writer, mu *sync.Mutex, prefix string) *prefixWriter {
+	return &prefixWriter{w: w, mu: mu, prefix: prefix}
+}
+
+func (p *prefixWriter) Write(b []byte) (int, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.buf.Write(b)
+	for {
+		i := bytes.IndexByte(p.buf.Bytes(), '\n')
+		if i < 0 {
+			return len(b), nil
+		}
+		if _, err := io.WriteString(p.w, p.prefix+string(p.buf.Next(i+1))); err != nil {
+			return len(b), err
+		}
+	}
+}
+
+// Flush writes any incomplete final line.
+func (p *prefixWriter) Flush() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.buf.Len() == 0 {
+		return
+	}
+	line := p.prefix + p.buf.String() + "\n"
+	p.buf.Reset()
+	if _, err := io.WriteString(p.w, line); err != nil {
+		print.Verb("failed to write build output:", err)
+	}
+}
@@ -0,0 +1,212 @@
+package pkgcontext
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/Southclaws/sampctl/src/pkg/build"
+	"github.com/Southclaws/sampctl/src/pkg/infrastructure/print"
+	"github.com/Southclaws/sampctl/src/pkg/package/pawnpackage"
+)
+
+// matrixCompilerScript reports a warning against its input file and echoes the BUILD_ID defined
+// in the package's build include file so tests can check which file each build compiled with
+const matrixCompilerScript = `#!/bin/sh
+input="$1"
+for arg in "$@"; do
+	case "$arg" in
+		-o*) printf 'amx' > "${arg#-o}" ;;
+	esac
+done
+grep BUILD_ID "%s/sampctl_build_file.inc"
+echo "$input(1) : warning 203: symbol is never used: \"x\""
+echo "Code size:              100 bytes"
+echo "Total requirements:   16720 bytes"
+`
+
+func newMatrixPackage(t *testing.T, constants func(name string) map[string]string) (*PackageContext, string) {
+	t.Helper()
+	if runtime.GOOS == "windows" {
+		t.Skip("fake compiler is a shell script")
+	}
+
+	dir := t.TempDir()
+	compilerDir := filepath.Join(dir, "compiler")
+	require.NoError(t, os.MkdirAll(compilerDir, 0o755))
+	require.NoError(t, os.WriteFile(filepath.Join(compilerDir, "pawncc"), []byte(fmt.Sprintf(matrixCompilerScript, dir)), 0o755))
+
+	var builds []*build.Config
+	for _, name := range []string{"main", "test-a", "test-b"} {
+		input := filepath.Join("gamemodes", name+".pwn")
+		require.NoError(t, os.MkdirAll(filepath.Join(dir, "gamemodes"), 0o755))
+		require.NoError(t, os.WriteFile(filepath.Join(dir, input), []byte("main() {}\n"), 0o644))
+		builds = append(builds, &build.Config{
+			Name:      name,
+			Input:     input,
+			Output:    filepath.Join("gamemodes", name+".amx"),
+			Compiler:  build.CompilerConfig{Path: "compiler"},
+			Constants: constants(name),
+		})
+	}
+
+	return &PackageContext{
+		Package: pawnpackage.Package{
+			LocalPath: dir,
+			Entry:     "gamemodes/main.pwn",
+			Output:    "gamemodes/main.amx",
+			Builds:    builds,
+		},
+	}, dir
+}
+
+func TestMatchBuilds(t *testing.T) {
+	pcx, _ := newMatrixPackage(t, func(string) map[string]string { return nil })
+
+	names, err := pcx.MatchBuilds("test-*")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"test-a", "test-b"}, names)
+
+	_, err = pcx.MatchBuilds("nope-*")
+	assert.EqualError(t, err, "no build configs match 'nope-*'")
+
+	_, err = pcx.MatchBuilds("[")
+	assert.Error(t, err)
+
+	assert.Equal(t, []string{"main", "test-a", "test-b"}, pcx.AllBuilds())
+	assert.Equal(t, []string{""}, (&PackageContext{}).AllBuilds())
+}
+
+func TestBuildMatrixAttributesProblemsAndOutput(t *testing.T) {
+	pcx, dir := newMatrixPackage(t, func(string) map[string]string {
+		return map[string]string{"BUILD_ID": "shared"}
+	})
+
+	var out bytes.Buffer
+	results, err := pcx.BuildMatrix(context.Background(), BuildMatrixOptions{
+		Names:  []string{"main", "test-a", "test-b"},
+		Jobs:   3,
+		Output: &out,
+	})
+	require.NoError(t, err)
+	require.Len(t, results, 3)
+
+	for _, result := range results {
+		require.NoError(t, result.Err, result.Name)
+		assert.False(t, result.Failed())
+		assert.Equal(t, 100, result.Result.Code)
+		require.Len(t, result.Problems, 1)
+		assert.Equal(t, filepath.Join(dir, "gamemodes", result.Name+".pwn"), result.Problems[0].File)
+		assert.FileExists(t, filepath.Join(dir, "gamemodes", result.Name+".amx"))
+	}
+
+	for _, line := range strings.Split(strings.TrimSpace(out.String()), "\n") {
+		assert.Regexp(t, `^\[(main|test-a|test-b)\] `, line)
+	}
+	assert.Contains(t, out.String(), "[test-b] "+filepath.Join(dir, "gamemodes", "test-b.pwn")+":1 (warning) symbol is never used")
+}
+
+func TestBuildMatrixCompilesDifferentBuildFilesOneAtATime(t *testing.T) {
+	pcx, _ := newMatrixPackage(t, func(name string) map[string]string {
+		return map[string]string{"BUILD_ID": name}
+	})
+
+	// raw compiler output is only logged, so the log shows which build file each compiler read
+	var logs bytes.Buffer
+	print.SetOutput(&logs)
+	defer print.SetOutput(nil)
+
+	results, err := pcx.BuildMatrix(context.Background(), BuildMatrixOptions{
+		Names:  []string{"main", "test-a", "test-b"},
+		Jobs:   3,
+		Output: &bytes.Buffer{},
+	})
+	require.NoError(t, err)
+	for _, result := range results {
+		require.NoError(t, result.Err)
+		assert.Contains(t, logs.String(), fmt.Sprintf(`compiler output: #define BUILD_ID "%s"`, result.Name))
+	}
+}
+
+func TestBuildMatrixRecordsEveryBuildInTheLockfile(t *testing.T) {
+	pcx, _ := newMatrixPackage(t, func(string) map[string]string {
+		return map[string]string{"BUILD_ID": "shared"}
+	})
+	pcx.Package.Parent = true
+	require.NoError(t, pcx.InitLockfileResolver("dev"))
+
+	results, err := pcx.BuildMatrix(context.Background(), BuildMatrixOptions{
+		Names:  []string{"main", "test-a", "test-b"},
+		Jobs:   3,
+		Output: &bytes.Buffer{},
+	})
+	require.NoError(t, err)
+	for _, result := range results {
+		require.NoError(t, result.Err, result.Name)
+
+		locked, ok := pcx.PackageLockfileState.LockedBuild(result.Name)
+		require.True(t, ok, result.Name)
+		assert.Equal(t, result.Name, locked.Name)
+		assert.Equal(t, "gamemodes/"+result.Name+".amx", locked.Output)
+		assert.NotEmpty(t, locked.InputHash)
+	}
+
+	var out bytes.Buffer
+	_, err = pcx.BuildMatrix(context.Background(), BuildMatrixOptions{
+		Names:  []string{"main", "test-a", "test-b"},
+		Jobs:   3,
+		Output: &out,
+	})
+	require.NoError(t, err)
+	for _, name := range []string{"main", "test-a", "test-b"} {
+		assert.Contains(t, out.String(), "["+name+"] INFO: build "+name+" is up to date")
+	}
+}
+
+func TestBuildMatrixRejectsBuildsWithTheSameOutput(t *testing.T) {
+	pcx, dir := newMatrixPackage(t, func(string) map[string]string { return nil })
+	pcx.Package.Builds[2].Output = pcx.Package.Builds[1].Output
+
+	results, err := pcx.BuildMatrix(context.Background(), BuildMatrixOptions{
+		Names:  []string{"main", "test-a", "test-b"},
+		Jobs:   3,
+		Output: &bytes.Buffer{},
+	})
+	assert.EqualError(t, err, "builds test-a and test-b both write gamemodes/test-a.amx, give each build its own output")
+	assert.Nil(t, results)
+	assert.NoFileExists(t, filepath.Join(dir, "gamemodes", "main.amx"), "no build runs")
+}
+
+func TestBuildMatrixRequiresBuilds(t *testing.T) {
+	_, err := (&PackageContext{}).BuildMatrix(context.Background(), BuildMatrixOptions{})
+	assert.EqualError(t, err, "no builds selected")
+}
+
+func TestPrefixWriterOnlyWritesWholeLines(t *testing.T) {
+	var (
+		out bytes.Buffer
+		mu  sync.Mutex
+	)
+	a := newPrefixWriter(&out, &mu, "[a] ")
+	b := newPrefixWriter(&out, &mu, "[b] ")
+
+	_, err := a.Write([]byte("first "))
+	require.NoError(t, err)
+	_, err = b.Write([]byte("other\n"))
+	require.NoError(t, err)
+	_, err = a.Write([]byte("line\nsecond"))
+	require.NoError(t, err)
+	a.Flush()
+	b.Flush()
+
+	assert.Equal(t, "[b] other\n[a] first line\n[a] second\n", out.String())
+}
@@ -0,0 +1,181 @@
+package pkgcontext
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/pkg/errors"
+
+	"github.com/Southclaws/sampctl/src/pkg/build"
+	"github.com/Southclaws/sampctl/src/pkg/build/amx"
+	"github.com/Southclaws/sampctl/src/pkg/build/includes"
+	"github.com/Southclaws/sampctl/src/pkg/infrastructure/fs"
+	"github.com/Southclaws/sampctl/src/pkg/infrastructure/print"
+	"github.com/Southclaws/sampctl/src/pkg/infrastructure/versioning"
+)
+
+// serverIncludeRepos are the dependencies that declare the natives built into the server itself
+var serverIncludeRepos = map[string]bool{
+	"samp-stdlib": true,
+	"pawn-stdlib": true,
+	"omp-stdlib":  true,
+}
+
+// nativeDeclaration is a declaration of a native and the dependency that provides the file
+type nativeDeclaration struct {
+	includes.Native
+	owner string
+}
+
+// unprovidedNative is a native called by a script that neither the server nor any configured
+// plugin or component registers, loading the script would fail with "function not registered"
+type unprovidedNative struct {
+	name     string
+	declared []nativeDeclaration
+}
+
+// checkBuildNatives fails when the output of a build calls natives that nothing provides, it
+// only runs when the build config enables `check_natives`
+func (pcx *PackageContext) checkBuildNatives(config build.Config) error {
+	if !config.CheckNatives {
+		return nil
+	}
+	graph, _, err := pcx.buildIncludeGraph(config)
+	if err != nil {
+		return errors.Wrap(err, "failed to check natives")
+	}
+	return pcx.checkNatives(config.Output, graph, true)
+}
+
+// checkRunNatives checks the natives of the output that is about to be run against the include
+// graph of the build that produced it. Libraries often redeclare natives of the server or of a
+// plugin, so unlike `check_natives` a native declared by any dependency or the compiler's
+// includes counts as provided and only natives declared nowhere but the package are reported.
+func (pcx *PackageContext) checkRunNatives(ctx context.Context, output string) error {
+	config, err := pcx.buildPrepare(ctx, pcx.BuildName, false, false)
+	if err != nil {
+		return errors.Wrap(err, "failed to check natives")
+	}
+	if config.Input == "" || !fs.Exists(config.Input) {
+		print.Verb(pcx.Package, "build input is not available, not checking natives")
+		return nil
+	}
+	graph, _, err := pcx.buildIncludeGraph(*config)
+	if err != nil {
+		return errors.Wrap(err, "failed to check natives")
+	}
+	return pcx.checkNatives(output, graph, false)
+}
+
+// checkNatives fails when the output calls natives that are not provided, strict only accepts the
+// declarations of the server's includes and of plugin and component dependencies
+func (pcx *PackageContext) checkNatives(output string, graph includes.Graph, strict bool) error {
+	unprovided, err := pcx.unprovidedNatives(output, graph, strict)
+	if err != nil {
+		return err
+	}
+	if len(unprovided) == 0 {
+		return nil
+	}
+
+	lines := make([]string, 0, len(unprovided))
+	for _, native := range unprovided {
+		declared := make([]string, 0, len(native.declared))
+		for _, declaration := range native.declared {
+			location := fmt.Sprintf("%s:%d", pcx.packageRelativePath(declaration.File), declaration.Line)
+			if declaration.owner != "" && declaration.owner != IncludeOwnerPackage {
+				location += " (" + declaration.owner + ")"
+			}
+			declared = append(declared, location)
+		}
+		lines = append(lines, fmt.Sprintf("  %s declared in %s", native.name, strings.Join(declared, ", ")))
+	}
+	return errors.Errorf(
+		"%s calls natives that no configured plugin or component provides:\n%s",
+		pcx.packageRelativePath(output), strings.Join(lines, "\n"),
+	)
+}
+
+// unprovidedNatives reads the natives table of a compiled script and returns the natives whose
+// declarations are all outside of the server's includes and the includes of the plugins and
+// components the package depends on, or when not strict all inside the package itself. Natives
+// whose declaration cannot be found in the include graph are not reported.
+func (pcx *PackageContext) unprovidedNatives(output string, graph includes.Graph, strict bool) ([]unprovidedNative, error) {
+	script, err := amx.Open(output)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to read natives of %s", output)
+	}
+
+	declared := make(map[string][]includes.Native)
+	for _, file := range graph.Files {
+		natives, err := includes.ParseNatives(file)
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to read native declarations")
+		}
+		for _, native := range natives {
+			declared[native.Name] = append(declared[native.Name], native)
+		}
+	}
+
+	var unprovided []unprovidedNative
+	for _, native := range script.Natives {
+		declarations := declared[native.Name]
+		if len(declarations) == 0 || pcx.anyNativeProvided(declarations, strict) {
+			continue
+		}
+		missing := unprovidedNative{name: native.Name}
+		for _, declaration := range declarations {
+			missing.declared = append(missing.declared, nativeDeclaration{
+				Native: declaration,
+				owner:  pcx.includeOwner(declaration.File),
+			})
+		}
+		unprovided = append(unprovided, missing)
+	}
+	return unprovided, nil
+}
+
+func (pcx *PackageContext) anyNativeProvided(declarations []includes.Native, strict bool) bool {
+	for _, declaration := range declarations {
+		if strict && pcx.nativeProvided(declaration.File) {
+			return true
+		}
+		if !strict && pcx.includeOwner(declaration.File) != IncludeOwnerPackage {
+			return true
+		}
+	}
+	return false
+}
+
+// nativeProvided returns true when the natives declared in a file are registered by the server or
+// by a plugin or component installed alongside the package. Files outside of the package and its
+// dependencies ship with the compiler and are assumed to belong to the server.
+func (pcx *PackageContext) nativeProvided(file string) bool {
+	meta, name, ok := pcx.dependencyOwningFile(file)
+	switch {
+	case ok:
+		return serverIncludeRepos[meta.Repo] || pcx.providesPlugin(meta)
+	case name != "":
+		return serverIncludeRepos[name]
+	}
+	_, inside := relativeTo(pcx.Package.LocalPath, file)
+	return !inside
+}
+
+// providesPlugin returns true when a dependency is one of the plugins or components installed
+// into the runtime, which includes packages with plugin resources
+func (pcx *PackageContext) providesPlugin(meta versioning.DependencyMeta) bool {
+	for _, plugin := range pcx.AllPlugins {
+		if plugin.IsLocalScheme() {
+			if meta.IsLocalScheme() && plugin.Local == meta.Local {
+				return true
+			}
+			continue
+		}
+		if plugin.User == meta.User && plugin.Repo == meta.Repo {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,161 @@
+package pkgcontext
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/Southclaws/sampctl/src/pkg/build"
+	"github.com/Southclaws/sampctl/src/pkg/build/amx"
+	"github.com/Southclaws/sampctl/src/pkg/infrastructure/versioning"
+	"github.com/Southclaws/sampctl/src/pkg/package/pawnpackage"
+)
+
+// writeNativesAMX writes a script whose natives table contains the given names
+func writeNativesAMX(t *testing.T, path string, natives ...string) {
+	t.Helper()
+
+	const defSize = 8
+	nameTable := int32(amx.HeaderSize + len(natives)*defSize)
+	names := []byte{31, 0}
+	var records bytes.Buffer
+	for _, native := range natives {
+		require.NoError(t, binary.Write(&records, binary.LittleEndian, [2]uint32{0, uint32(nameTable) + uint32(len(names))}))
+		names = append(append(names, native...), 0)
+	}
+
+	cod := nameTable + int32(len(names))
+	header := amx.Header{
+		Size: cod, Magic: amx.Magic32, FileVersion: 11, AMXVersion: 11, DefSize: defSize,
+		COD: cod, DAT: cod, HEA: cod, STP: cod + 1024, CIP: -1,
+		Publics: amx.HeaderSize, Natives: amx.HeaderSize, Libraries: nameTable, PubVars: nameTable, Tags: nameTable, NameTable: nameTable,
+	}
+
+	var file bytes.Buffer
+	require.NoError(t, binary.Write(&file, binary.LittleEndian, header))
+	file.Write(records.Bytes())
+	file.Write(names)
+	require.NoError(t, os.MkdirAll(filepath.Dir(path), 0o755))
+	require.NoError(t, os.WriteFile(path, file.Bytes(), 0o644))
+}
+
+func newNativesPackage(t *testing.T) (*PackageContext, string) {
+	t.Helper()
+
+	dir := t.TempDir()
+	writeIncludeFile(t, filepath.Join(dir, "gamemodes", "test.pwn"), `#include <a_samp>
+#include <streamer>
+#include <mylib>
+
+native Local_Native();
+
+main() {}
+`)
+	writeIncludeFile(t, filepath.Join(dir, "dependencies", "samp-stdlib", "a_samp.inc"), "native print(const string[]);\n")
+	writeIncludeFile(t, filepath.Join(dir, "dependencies", "samp-streamer-plugin", "streamer.inc"), "native Streamer_Update(playerid);\n")
+	writeIncludeFile(t, filepath.Join(dir, "dependencies", "mylib", "mylib.inc"), `/*
+native Streamer_Update(playerid);
+*/
+native MyLib_Call();
+`)
+	writeNativesAMX(t, filepath.Join(dir, "gamemodes", "test.amx"), "print", "Streamer_Update", "MyLib_Call", "Local_Native", "Undeclared")
+
+	pcx := &PackageContext{
+		Package: pawnpackage.Package{
+			Parent:    true,
+			LocalPath: dir,
+			Vendor:    filepath.Join(dir, "dependencies"),
+			Entry:     "gamemodes/test.pwn",
+			Output:    "gamemodes/test.amx",
+			Build:     &build.Config{CheckNatives: true},
+		},
+	}
+	streamer := versioning.DependencyMeta{User: "samp-incognito", Repo: "samp-streamer-plugin", Tag: "v2.9.6"}
+	pcx.AllDependencies = []versioning.DependencyMeta{
+		{User: "pawn-lang", Repo: "samp-stdlib"},
+		streamer,
+		{User: "user", Repo: "mylib", Tag: "1.0.0"},
+	}
+	pcx.AllPlugins = []versioning.DependencyMeta{streamer}
+	return pcx, dir
+}
+
+func TestCheckNativesReportsNativesWithoutAPlugin(t *testing.T) {
+	t.Parallel()
+
+	pcx, _ := newNativesPackage(t)
+	config, err := pcx.buildPrepare(context.Background(), "", false, false)
+	require.NoError(t, err)
+
+	err = pcx.checkBuildNatives(*config)
+	require.Error(t, err)
+	assert.Equal(t, "gamemodes/test.amx calls natives that no configured plugin or component provides:\n"+
+		"  MyLib_Call declared in dependencies/mylib/mylib.inc:4 (user/mylib:1.0.0)\n"+
+		"  Local_Native declared in gamemodes/test.pwn:5", err.Error())
+
+	config.CheckNatives = false
+	assert.NoError(t, pcx.checkBuildNatives(*config), "natives are only checked when enabled")
+}
+
+func TestCheckNativesRequiresPluginDependency(t *testing.T) {
+	t.Parallel()
+
+	pcx, dir := newNativesPackage(t)
+	pcx.AllPlugins = nil
+	writeNativesAMX(t, filepath.Join(dir, "gamemodes", "test.amx"), "print", "Streamer_Update")
+
+	config, err := pcx.buildPrepare(context.Background(), "", false, false)
+	require.NoError(t, err)
+
+	err = pcx.checkBuildNatives(*config)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "calls natives")
+	assert.Contains(t, err.Error(), "Streamer_Update declared in dependencies/samp-streamer-plugin/streamer.inc:1 (samp-incognito/samp-streamer-plugin:v2.9.6)")
+
+	pcx.AllPlugins = []versioning.DependencyMeta{{Scheme: "plugin", Site: "github.com", User: "samp-incognito", Repo: "samp-streamer-plugin"}}
+	assert.NoError(t, pcx.checkBuildNatives(*config))
+}
+
+func TestCheckRunNativesAcceptsNativesDeclaredByLibraries(t *testing.T) {
+	t.Parallel()
+
+	pcx, dir := newNativesPackage(t)
+	pcx.AllPlugins = nil
+	// libraries such as YSI redeclare server natives that are missing from the stdlib includes
+	writeIncludeFile(t, filepath.Join(dir, "dependencies", "mylib", "mylib.inc"), "native MyLib_Call();\nnative gpci(playerid, serial[], len);\n")
+	writeNativesAMX(t, filepath.Join(dir, "gamemodes", "test.amx"), "print", "Streamer_Update", "MyLib_Call", "gpci", "Local_Native")
+
+	err := pcx.checkRunNatives(context.Background(), filepath.Join(dir, "gamemodes", "test.amx"))
+	require.Error(t, err)
+	assert.Equal(t, "gamemodes/test.amx calls natives that no configured plugin or component provides:\n"+
+		"  Local_Native declared in gamemodes/test.pwn:5", err.Error())
+
+	writeNativesAMX(t, filepath.Join(dir, "gamemodes", "test.amx"), "print", "Streamer_Update", "MyLib_Call", "gpci")
+	assert.NoError(t, pcx.checkRunNatives(context.Background(), filepath.Join(dir, "gamemodes", "test.amx")))
+}
+
+func TestCheckRunNativesSkipsMissingSource(t *testing.T) {
+	t.Parallel()
+
+	pcx, dir := newNativesPackage(t)
+	require.NoError(t, os.Remove(filepath.Join(dir, "gamemodes", "test.pwn")))
+	assert.NoError(t, pcx.checkRunNatives(context.Background(), filepath.Join(dir, "gamemodes", "test.amx")))
+}
+
+func TestNativeProvidedByLocalPlugin(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	pcx := &PackageContext{Package: pawnpackage.Package{LocalPath: dir, Vendor: filepath.Join(dir, "dependencies")}}
+	pcx.AllPlugins = []versioning.DependencyMeta{{Scheme: "component", Local: "components/mine", User: "local", Repo: "mine"}}
+
+	assert.True(t, pcx.nativeProvided(filepath.Join(dir, "components", "mine", "mine.inc")))
+	assert.False(t, pcx.nativeProvided(filepath.Join(dir, "gamemodes", "natives.inc")))
+	assert.True(t, pcx.nativeProvided(filepath.Join(t.TempDir(), "compiler", "include", "core.inc")), "the compiler's includes belong to the server")
+}
@@ -0,0 +1,83 @@
+package pkgcontext
+
+import (
+	"context"
+	"io"
+	"os"
+
+	"github.com/pkg/errors"
+
+	"github.com/Southclaws/sampctl/src/pkg/build"
+	"github.com/Southclaws/sampctl/src/pkg/build/compiler"
+	"github.com/Southclaws/sampctl/src/pkg/build/listing"
+	"github.com/Southclaws/sampctl/src/pkg/infrastructure/fs"
+)
+
+// PreprocessedBuild is the source of a build after the preprocessor expanded every macro
+type PreprocessedBuild struct {
+	Build string `json:"build"`
+	// File is the listing the compiler wrote next to the build output.
+	File string `json:"file"`
+	listing.Listing
+}
+
+// Preprocess runs the compiler of a build with listing output enabled, the compiler stops after
+// preprocessing and writes the expanded source next to the output instead of compiling it. The
+// output itself, the build number and the lockfile are left untouched.
+func (pcx *PackageContext) Preprocess(ctx context.Context, name string, ensure bool, output io.Writer) (PreprocessedBuild, error) {
+	config, err := pcx.buildPrepare(ctx, name, ensure, false)
+	if err != nil {
+		return PreprocessedBuild{}, err
+	}
+	if config.Input == "" || config.Output == "" {
+		return PreprocessedBuild{}, errors.New("build has no input or output file")
+	}
+	withCompilerOption(config, func(options *build.CompilerOptions) {
+		enabled := true
+		options.ShowListing = &enabled
+	}, "-l")
+
+	file := listing.Path(config.Output)
+	if err = os.Remove(file); err != nil && !os.IsNotExist(err) {
+		return PreprocessedBuild{}, errors.Wrap(err, "failed to remove previous listing")
+	}
+
+	command, err := pcx.prepareBuildCommand(ctx, *config)
+	if err != nil {
+		return PreprocessedBuild{}, err
+	}
+	problems, _, err := compiler.CompileWithCommand(compiler.CompileCommandRequest{
+		Context:    ctx,
+		Command:    command,
+		WorkingDir: config.WorkingDir,
+		ErrorDir:   pcx.Package.LocalPath,
+		Output:     output,
+		Config:     *config,
+		Dependency: pcx.problemDependency,
+	})
+	if err != nil {
+		return PreprocessedBuild{}, errors.Wrap(err, "failed to preprocess package entry")
+	}
+	if problems.Fatal() || !fs.Exists(file) {
+		return PreprocessedBuild{}, errors.New("preprocessing failed, the compiler did not write a listing")
+	}
+
+	expanded, err := listing.Open(file)
+	if err != nil {
+		return PreprocessedBuild{}, err
+	}
+	return PreprocessedBuild{Build: BuildRecordName(name), File: file, Listing: expanded}, nil
+}
+
+// withCompilerOption enables a compiler option for a single run of a prepared build. The
+// package's options are copied rather than modified and builds that still pass raw `args` get
+// the flag appended instead, since options replace args entirely.
+func withCompilerOption(config *build.Config, set func(*build.CompilerOptions), arg string) {
+	if config.Options == nil {
+		config.Args = append(append([]string(nil), config.Args...), arg)
+		return
+	}
+	options := *config.Options
+	set(&options)
+	config.Options = &options
+}
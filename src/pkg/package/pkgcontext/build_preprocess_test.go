@@ -0,0 +1,59 @@
+package pkgcontext
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/Southclaws/sampctl/src/pkg/build/listing"
+)
+
+// listingCompilerScript writes a listing of its input next to the output when -l is passed and
+// a compiled output otherwise
+const listingCompilerScript = `#!/bin/sh
+input="$1"
+for arg in "$@"; do
+	case "$arg" in
+		-o*) output="${arg#-o}" ;;
+		-l) preprocess=1 ;;
+	esac
+done
+if [ -n "$preprocess" ]; then
+	{ echo "#file \"$input\""; echo "#line 1"; cat "$input"; } > "${output%.*}.lst"
+else
+	printf 'amx' > "$output"
+fi
+echo run >> "$(dirname "$0")/invocations"
+`
+
+func TestPreprocessReadsListingNextToOutput(t *testing.T) {
+	pcx, dir := newCachedBuildPackage(t)
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "compiler", "pawncc"), []byte(listingCompilerScript), 0o755))
+
+	preprocessed, err := pcx.Preprocess(context.Background(), "", false, &bytes.Buffer{})
+	require.NoError(t, err)
+
+	input := filepath.Join(dir, "gamemodes", "test.pwn")
+	assert.Equal(t, "default", preprocessed.Build)
+	assert.Equal(t, filepath.Join(dir, "gamemodes", "test.lst"), preprocessed.File)
+	assert.Equal(t, []listing.Region{
+		{File: input, Start: 1, End: 2, Lines: []string{`#include "util"`, "main() {}"}},
+	}, preprocessed.Regions)
+	assert.NoFileExists(t, filepath.Join(dir, "gamemodes", "test.amx"), "preprocessing does not compile the output")
+	assert.Nil(t, pcx.Package.Build.Options, "the package's build config is not modified")
+
+	_, ok := pcx.PackageLockfileState.LockedBuild("")
+	assert.False(t, ok, "preprocessing is not recorded as a build")
+}
+
+func TestPreprocessFailsWithoutListing(t *testing.T) {
+	pcx, _ := newCachedBuildPackage(t)
+
+	_, err := pcx.Preprocess(context.Background(), "", false, &bytes.Buffer{})
+	assert.EqualError(t, err, "preprocessing failed, the compiler did not write a listing")
+}
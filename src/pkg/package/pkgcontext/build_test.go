@@ -516,3 +516,10 @@ func TestShouldWatchBuildEvent(t *testing.T) {
 	require.False(t, shouldWatchBuildEvent(fsnotify.Event{Name: "README.md", Op: fsnotify.Write}))
 	require.False(t, shouldWatchBuildEvent(fsnotify.Event{Name: "gamemodes/test.pwn", Op: fsnotify.Remove}))
 }
+
+func TestBuildOptionsOutputDefaultsToStdout(t *testing.T) {
+	t.Parallel()
+
+	require.Equal(t, os.Stdout, BuildOptions{}.output())
+	require.Equal(t, os.Stderr, BuildOptions{Output: os.Stderr}.output())
+}
@@ -15,6 +15,7 @@ import (
 	"github.com/Southclaws/sampctl/src/pkg/infrastructure/fs"
 	"github.com/Southclaws/sampctl/src/pkg/infrastructure/print"
 	"github.com/Southclaws/sampctl/src/pkg/infrastructure/versioning"
+	"github.com/Southclaws/sampctl/src/pkg/package/lockfile"
 	"github.com/Southclaws/sampctl/src/pkg/package/pawnpackage"
 )
 
@@ -48,6 +49,42 @@ func (pcx *PackageContext) refreshDependencyGraph(request DependencyUpdateReques
 		return
 	}
 
+	// Each pass walks the tree, collects every constraint declared against each
+	// dependency and intersects them. When the intersection selects a different
+	// tag than the one that was walked, the sub-tree of that dependency may have
+	// changed too so the tree is walked again with the selected tags pinned.
+	overrides := make(map[string]string)
+	for pass := 1; ; pass++ {
+		requirements, err := pcx.walkDependencyGraph(request, overrides)
+		if err != nil {
+			return err
+		}
+
+		resolution, err := pcx.resolveDependencyConstraints(requirements, request)
+		if err != nil {
+			return err
+		}
+
+		if sameDependencyOverrides(overrides, resolution.Tags) {
+			pcx.DependencyConstraints = resolution.Constraints
+			return nil
+		}
+		if pass >= maxDependencyResolutionPasses {
+			return errors.Errorf("dependency versions did not settle after %d resolution passes", pass)
+		}
+
+		overrides = resolution.Tags
+		// dependencies were already refreshed by the first pass
+		request = DependencyUpdateRequest{}
+	}
+}
+
+func (pcx *PackageContext) walkDependencyGraph(
+	request DependencyUpdateRequest,
+	overrides map[string]string,
+) (dependencyRequirements, error) {
+	requirements := make(dependencyRequirements)
+
 	// This recursive operation requires quite a lot of state! There is probably
 	// a better method to break this up but so far, this has worked fine.
 	var (
@@ -166,6 +203,20 @@ func (pcx *PackageContext) refreshDependencyGraph(request DependencyUpdateReques
 				continue
 			}
 
+			if !subPackageDepMeta.IsLocalScheme() {
+				key := lockfile.DependencyKey(normalizeLockfileDependency(subPackageDepMeta))
+				requiredBy := ""
+				if !currentIsParent {
+					requiredBy = lockfile.DependencyKey(normalizeLockfileDependency(currentMeta))
+				}
+				requirements.add(key, requiredBy, subPackageDepMeta)
+
+				if tag, ok := overrides[key]; ok {
+					print.Verb(prefix, "using tag", tag, "for", subPackageDepMeta, "to satisfy all constraints")
+					subPackageDepMeta.Tag = tag
+				}
+			}
+
 			// Handle URL-like schemes during caching phase
 			if subPackageDepMeta.IsURLScheme() {
 				errInner = pcx.handleURLSchemeCaching(subPackageDepMeta, prefix)
@@ -187,10 +238,10 @@ func (pcx *PackageContext) refreshDependencyGraph(request DependencyUpdateReques
 	recurse(pcx.Package.Dependency(), false)
 
 	if errInner != nil {
-		return errors.New("Failed to clone the repo")
+		return nil, errors.New("Failed to clone the repo")
 	}
 
-	return nil
+	return requirements, nil
 }
 
 func (pcx PackageContext) packageFromCachedRevision(
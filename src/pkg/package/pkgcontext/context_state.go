@@ -57,6 +57,9 @@ type PackageResolvedState struct {
 	AllIncludePaths []string
 	ActualRuntime   runtimecfg.Runtime
 	ActualBuild     build.Config
+	// DependencyConstraints holds, per lockfile dependency key, the set of
+	// constraints that were intersected to select the dependency's version.
+	DependencyConstraints map[string][]string
 }
 
 type PackageExecutionState struct {
@@ -69,6 +72,19 @@ type PackageExecutionState struct {
 	NoCache     bool
 	BuildFile   string
 	Relative    bool
+	// Jobs bounds how many dependencies are ensured concurrently, values
+	// below one ensure dependencies one at a time.
+	Jobs int
+	// SkipNativeCheck runs the server without first checking that every
+	// native the script calls is declared by the server or a dependency.
+	SkipNativeCheck bool
+	// ReadyFile is written once the server is ready and removed when it
+	// stops, OnReady is a shell command run once the server is ready.
+	ReadyFile string
+	OnReady   string
+	// RestartLog is a file every restart event is appended to as a line of
+	// JSON.
+	RestartLog string
 }
 
 type PackageLockfileState struct {
@@ -97,6 +113,13 @@ func (state *PackageLockfileState) PreviousDependency(meta versioning.Dependency
 	return state.lockfileResolver.GetPreviousDependency(meta)
 }
 
+func (state *PackageLockfileState) LockedDependency(meta versioning.DependencyMeta) (lockfile.LockedDependency, bool) {
+	if state == nil || state.lockfileResolver == nil {
+		return lockfile.LockedDependency{}, false
+	}
+	return state.lockfileResolver.GetDependency(meta)
+}
+
 func (state *PackageLockfileState) RecordDependencyResolution(
 	meta versioning.DependencyMeta,
 	resolution lockfile.DependencyResolution,
@@ -142,6 +165,14 @@ func (state *PackageLockfileState) RecordBuild(record lockfile.BuildRecord) {
 	state.lockfileResolver.RecordBuild(record)
 }
 
+// LockedBuild returns the build recorded in the lockfile under the record name of a build.
+func (state *PackageLockfileState) LockedBuild(name string) (lockfile.LockedBuild, bool) {
+	if state == nil || state.lockfileResolver == nil {
+		return lockfile.LockedBuild{}, false
+	}
+	return state.lockfileResolver.GetBuild(BuildRecordName(name))
+}
+
 func (state *PackageLockfileState) SaveLockfile() error {
 	if state == nil || state.lockfileResolver == nil {
 		return nil
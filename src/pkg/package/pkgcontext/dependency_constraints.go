@@ -0,0 +1,275 @@
+package pkgcontext
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/Masterminds/semver"
+	"github.com/pkg/errors"
+
+	"github.com/Southclaws/sampctl/src/pkg/infrastructure/print"
+	"github.com/Southclaws/sampctl/src/pkg/infrastructure/versioning"
+	"github.com/Southclaws/sampctl/src/pkg/package/lockfile"
+)
+
+// maxDependencyResolutionPasses bounds how many times the dependency tree is
+// re-walked while selected versions change the shape of the tree.
+const maxDependencyResolutionPasses = 5
+
+// DependencyRequirement is a single version constraint declared against a
+// dependency by a package in the dependency tree.
+type DependencyRequirement struct {
+	// RequiredBy is the lockfile key of the declaring package, or empty when
+	// the constraint is declared by the root package definition.
+	RequiredBy string
+	Meta       versioning.DependencyMeta
+}
+
+// Constraint returns the declared constraint in lockfile notation.
+func (r DependencyRequirement) Constraint() string {
+	return lockfile.Constraint(r.Meta)
+}
+
+// DependencyConflictError is returned when no tag of a dependency satisfies
+// every constraint declared against it within the dependency tree.
+type DependencyConflictError struct {
+	Dependency   string
+	Requirements []DependencyRequirement
+	Available    []string
+}
+
+func (e *DependencyConflictError) Error() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "no version of %s satisfies every constraint:", e.Dependency)
+	for _, requirement := range e.Requirements {
+		requiredBy := requirement.RequiredBy
+		if requiredBy == "" {
+			requiredBy = "package definition"
+		}
+		constraint := requirement.Meta.Tag
+		if constraint == "" || constraint == "latest" {
+			constraint = "any version"
+		}
+		fmt.Fprintf(&b, "\n  - %s requires %s", requiredBy, constraint)
+	}
+	if len(e.Available) > 0 {
+		fmt.Fprintf(&b, "\n  available tags: %s", strings.Join(e.Available, ", "))
+	} else {
+		b.WriteString("\n  the repository has no semantic version tags")
+	}
+	return b.String()
+}
+
+type dependencyRequirements map[string][]DependencyRequirement
+
+func (requirements dependencyRequirements) add(key, requiredBy string, meta versioning.DependencyMeta) {
+	for _, existing := range requirements[key] {
+		if existing.RequiredBy == requiredBy && existing.Constraint() == lockfile.Constraint(meta) {
+			return
+		}
+	}
+	requirements[key] = append(requirements[key], DependencyRequirement{RequiredBy: requiredBy, Meta: meta})
+}
+
+type dependencyConstraintResolution struct {
+	// Tags maps dependency keys to the tag selected by intersecting constraints.
+	Tags map[string]string
+	// Constraints maps dependency keys to the constraint set that was intersected.
+	Constraints map[string][]string
+}
+
+// resolveDependencyConstraints intersects the constraints collected for every
+// dependency that is required at more than one distinct semver constraint and
+// selects the highest cached tag that satisfies all of them.
+func (pcx *PackageContext) resolveDependencyConstraints(
+	requirements dependencyRequirements,
+	request DependencyUpdateRequest,
+) (dependencyConstraintResolution, error) {
+	resolution := dependencyConstraintResolution{
+		Tags:        make(map[string]string),
+		Constraints: make(map[string][]string),
+	}
+
+	keys := make([]string, 0, len(requirements))
+	for key := range requirements {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	for _, key := range keys {
+		reqs := requirements[key]
+		constraints := distinctDependencyConstraints(reqs)
+		if len(constraints) < 2 {
+			continue
+		}
+		if !semverDependencyRequirements(reqs) {
+			print.Warn(key, "is required at incompatible references", strings.Join(constraints, ", "),
+				"which cannot be intersected, using the first one found")
+			continue
+		}
+
+		meta := reqs[0].Meta
+		repo, err := pcx.PackageServices.repositoryStore().Open(meta.CachePath(pcx.CacheDir))
+		if err != nil {
+			return dependencyConstraintResolution{}, errors.Wrapf(err, "failed to open cached repository for %s", key)
+		}
+		tags, err := versioning.GetRepoSemverTags(repo)
+		if err != nil {
+			return dependencyConstraintResolution{}, errors.Wrapf(err, "failed to read tags for %s", key)
+		}
+
+		preferred := ""
+		if !request.ShouldForceDependency(meta, hasDirectRequirement(reqs)) {
+			if previous, ok := pcx.PackageLockfileState.PreviousDependency(meta); ok {
+				preferred = previous.Resolved
+			}
+		}
+
+		selected, err := selectDependencyVersion(key, reqs, tags, preferred)
+		if err != nil {
+			return dependencyConstraintResolution{}, err
+		}
+
+		print.Verb(key, "resolved to", selected.Name, "satisfying", strings.Join(constraints, ", "))
+		resolution.Tags[key] = selected.Name
+		resolution.Constraints[key] = constraints
+	}
+
+	return resolution, nil
+}
+
+// selectDependencyVersion returns the highest tag that satisfies every
+// requirement. The preferred tag, usually the one recorded in the lockfile, is
+// kept when it still satisfies every requirement.
+func selectDependencyVersion(
+	key string,
+	reqs []DependencyRequirement,
+	tags versioning.VersionedTags,
+	preferred string,
+) (versioning.VersionedTag, error) {
+	var constraints []*semver.Constraints
+	for _, requirement := range reqs {
+		if isDynamicDependencyConstraint(requirement.Meta) {
+			continue
+		}
+		constraint, err := semver.NewConstraint(requirement.Meta.Tag)
+		if err != nil {
+			return versioning.VersionedTag{}, errors.Wrapf(err, "invalid constraint %s for %s", requirement.Meta.Tag, key)
+		}
+		constraints = append(constraints, constraint)
+	}
+
+	satisfies := func(tag versioning.VersionedTag) bool {
+		for _, constraint := range constraints {
+			if !constraint.Check(tag.Version) {
+				return false
+			}
+		}
+		return true
+	}
+
+	sorted := append(versioning.VersionedTags(nil), tags...)
+	sort.Sort(sort.Reverse(sorted))
+
+	if preferred != "" {
+		for _, tag := range sorted {
+			if tag.Name == preferred && satisfies(tag) {
+				return tag, nil
+			}
+		}
+	}
+
+	for _, tag := range sorted {
+		if satisfies(tag) {
+			return tag, nil
+		}
+	}
+
+	available := make([]string, 0, len(sorted))
+	for _, tag := range sorted {
+		available = append(available, tag.Name)
+	}
+
+	return versioning.VersionedTag{}, &DependencyConflictError{
+		Dependency:   key,
+		Requirements: reqs,
+		Available:    available,
+	}
+}
+
+func distinctDependencyConstraints(reqs []DependencyRequirement) []string {
+	seen := make(map[string]bool)
+	var constraints []string
+	for _, requirement := range reqs {
+		if isDynamicDependencyConstraint(requirement.Meta) {
+			continue
+		}
+		constraint := requirement.Constraint()
+		if seen[constraint] {
+			continue
+		}
+		seen[constraint] = true
+		constraints = append(constraints, constraint)
+	}
+	sort.Strings(constraints)
+	return constraints
+}
+
+func semverDependencyRequirements(reqs []DependencyRequirement) bool {
+	for _, requirement := range reqs {
+		if requirement.Meta.Branch != "" || requirement.Meta.Commit != "" {
+			return false
+		}
+		if isDynamicDependencyConstraint(requirement.Meta) {
+			continue
+		}
+		if _, err := semver.NewConstraint(requirement.Meta.Tag); err != nil {
+			return false
+		}
+	}
+	return true
+}
+
+func hasDirectRequirement(reqs []DependencyRequirement) bool {
+	for _, requirement := range reqs {
+		if requirement.RequiredBy == "" {
+			return true
+		}
+	}
+	return false
+}
+
+func sameDependencyOverrides(left, right map[string]string) bool {
+	if len(left) != len(right) {
+		return false
+	}
+	for key, tag := range left {
+		if right[key] != tag {
+			return false
+		}
+	}
+	return true
+}
+
+// dependencyConstraintSet returns the constraint set that was intersected to
+// select the version of a dependency, if any.
+func (pcx *PackageContext) dependencyConstraintSet(meta versioning.DependencyMeta) []string {
+	return pcx.DependencyConstraints[lockfile.DependencyKey(normalizeLockfileDependency(meta))]
+}
+
+// constrainedDependencyMeta applies the tag selected by constraint resolution
+// to a dependency declaration.
+func (pcx *PackageContext) constrainedDependencyMeta(meta versioning.DependencyMeta) versioning.DependencyMeta {
+	if len(pcx.dependencyConstraintSet(meta)) == 0 {
+		return meta
+	}
+	key := lockfile.DependencyKey(normalizeLockfileDependency(meta))
+	for _, dep := range pcx.AllDependencies {
+		if lockfile.DependencyKey(normalizeLockfileDependency(dep)) == key {
+			meta.Tag = dep.Tag
+			return meta
+		}
+	}
+	return meta
+}
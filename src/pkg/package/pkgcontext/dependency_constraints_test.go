@@ -0,0 +1,178 @@
+package pkgcontext
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/Masterminds/semver"
+	git "github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing/object"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/Southclaws/sampctl/src/pkg/infrastructure/versioning"
+	"github.com/Southclaws/sampctl/src/pkg/package/lockfile"
+)
+
+func testVersionedTags(t *testing.T, names ...string) versioning.VersionedTags {
+	t.Helper()
+
+	tags := make(versioning.VersionedTags, 0, len(names))
+	for _, name := range names {
+		version, err := semver.NewVersion(name)
+		require.NoError(t, err)
+		tags = append(tags, versioning.VersionedTag{Name: name, Version: version})
+	}
+	return tags
+}
+
+func TestSelectDependencyVersionPicksHighestTagSatisfyingAllConstraints(t *testing.T) {
+	t.Parallel()
+
+	tags := testVersionedTags(t, "1.0.0", "1.1.0", "1.1.4", "1.2.0", "2.0.0")
+	reqs := []DependencyRequirement{
+		{Meta: versioning.DependencyMeta{User: "user", Repo: "lib", Tag: "1.x"}},
+		{RequiredBy: "github.com/user/other", Meta: versioning.DependencyMeta{User: "user", Repo: "lib", Tag: "~1.1"}},
+		{RequiredBy: "github.com/user/third", Meta: versioning.DependencyMeta{User: "user", Repo: "lib"}},
+	}
+
+	selected, err := selectDependencyVersion("github.com/user/lib", reqs, tags, "")
+	require.NoError(t, err)
+	assert.Equal(t, "1.1.4", selected.Name)
+
+	selected, err = selectDependencyVersion("github.com/user/lib", reqs, tags, "1.1.0")
+	require.NoError(t, err)
+	assert.Equal(t, "1.1.0", selected.Name, "a satisfying locked version should be kept")
+
+	selected, err = selectDependencyVersion("github.com/user/lib", reqs, tags, "2.0.0")
+	require.NoError(t, err)
+	assert.Equal(t, "1.1.4", selected.Name, "a locked version outside the constraints should be replaced")
+}
+
+func TestSelectDependencyVersionReportsConflicts(t *testing.T) {
+	t.Parallel()
+
+	tags := testVersionedTags(t, "4.0.0", "4.1.0", "5.0.0")
+	reqs := []DependencyRequirement{
+		{Meta: versioning.DependencyMeta{User: "pawn-lang", Repo: "YSI-Includes", Tag: "5.x"}},
+		{RequiredBy: "github.com/user/old-lib", Meta: versioning.DependencyMeta{User: "pawn-lang", Repo: "YSI-Includes", Tag: "4.x"}},
+	}
+
+	_, err := selectDependencyVersion("github.com/pawn-lang/YSI-Includes", reqs, tags, "")
+	require.Error(t, err)
+
+	var conflict *DependencyConflictError
+	require.ErrorAs(t, err, &conflict)
+	assert.Equal(t, "github.com/pawn-lang/YSI-Includes", conflict.Dependency)
+	assert.Equal(t, "no version of github.com/pawn-lang/YSI-Includes satisfies every constraint:\n"+
+		"  - package definition requires 5.x\n"+
+		"  - github.com/user/old-lib requires 4.x\n"+
+		"  available tags: 5.0.0, 4.1.0, 4.0.0", err.Error())
+}
+
+func TestDistinctDependencyConstraintsIgnoresDynamicReferences(t *testing.T) {
+	t.Parallel()
+
+	reqs := []DependencyRequirement{
+		{Meta: versioning.DependencyMeta{Tag: "1.x"}},
+		{RequiredBy: "a", Meta: versioning.DependencyMeta{Tag: "latest"}},
+		{RequiredBy: "b", Meta: versioning.DependencyMeta{}},
+		{RequiredBy: "c", Meta: versioning.DependencyMeta{Tag: "1.x"}},
+	}
+
+	assert.Equal(t, []string{":1.x"}, distinctDependencyConstraints(reqs))
+	assert.True(t, semverDependencyRequirements(reqs))
+	assert.False(t, semverDependencyRequirements(append(reqs, DependencyRequirement{Meta: versioning.DependencyMeta{Branch: "main"}})))
+}
+
+func TestRefreshDependencyGraphIntersectsTransitiveConstraints(t *testing.T) {
+	t.Parallel()
+
+	cacheDir := t.TempDir()
+	projectDir := t.TempDir()
+
+	seedMultiTagRepo(t, cacheDir, versioning.DependencyMeta{User: "user", Repo: "lib-b"}, `{"entry":"libb.pwn","output":"gamemodes/libb.amx"}`,
+		"1.0.0", "1.1.0", "1.1.3", "1.2.0", "2.0.0")
+	seedLockfileRepo(t, cacheDir, versioning.DependencyMeta{User: "user", Repo: "lib-a", Tag: "1.0.0"},
+		`{"entry":"liba.pwn","output":"gamemodes/liba.amx","dependencies":["user/lib-b:~1.1"]}`)
+
+	require.NoError(t, os.WriteFile(filepath.Join(projectDir, "pawn.json"), []byte(`{"entry":"main.pwn","output":"gamemodes/main.amx","dependencies":["user/lib-b:1.x","user/lib-a:1.0.0"]}`), 0o644))
+	require.NoError(t, os.WriteFile(filepath.Join(projectDir, "main.pwn"), []byte("main() {}"), 0o644))
+
+	pcx, err := NewPackageContext(NewPackageContextOptions{Parent: true, Dir: projectDir, Platform: "linux", CacheDir: cacheDir})
+	require.NoError(t, err)
+
+	var libB versioning.DependencyMeta
+	for _, dep := range pcx.AllDependencies {
+		if dep.Repo == "lib-b" {
+			libB = dep
+		}
+	}
+	assert.Equal(t, "1.1.3", libB.Tag)
+	assert.Equal(t, []string{":1.x", ":~1.1"}, pcx.DependencyConstraints["github.com/user/lib-b"])
+
+	require.NoError(t, pcx.InitLockfileResolver("dev"))
+	require.NoError(t, pcx.UpdateLockfile(context.Background(), DependencyUpdateRequest{}))
+
+	locked, ok := pcx.GetLockfile().GetDependency("github.com/user/lib-b")
+	require.True(t, ok)
+	assert.Equal(t, "1.1.3", locked.Resolved)
+	assert.Equal(t, []string{":1.x", ":~1.1"}, locked.Constraints)
+}
+
+func TestRefreshDependencyGraphFailsOnUnsatisfiableConstraints(t *testing.T) {
+	t.Parallel()
+
+	cacheDir := t.TempDir()
+	projectDir := t.TempDir()
+
+	seedMultiTagRepo(t, cacheDir, versioning.DependencyMeta{User: "user", Repo: "lib-b"}, `{"entry":"libb.pwn","output":"gamemodes/libb.amx"}`,
+		"1.0.0", "2.0.0")
+	seedLockfileRepo(t, cacheDir, versioning.DependencyMeta{User: "user", Repo: "lib-a", Tag: "1.0.0"},
+		`{"entry":"liba.pwn","output":"gamemodes/liba.amx","dependencies":["user/lib-b:1.x"]}`)
+
+	require.NoError(t, os.WriteFile(filepath.Join(projectDir, "pawn.json"), []byte(`{"entry":"main.pwn","output":"gamemodes/main.amx","dependencies":["user/lib-b:2.x","user/lib-a:1.0.0"]}`), 0o644))
+
+	_, err := NewPackageContext(NewPackageContextOptions{Parent: true, Dir: projectDir, Platform: "linux", CacheDir: cacheDir})
+	require.Error(t, err)
+
+	var conflict *DependencyConflictError
+	require.ErrorAs(t, err, &conflict)
+	assert.Equal(t, lockfile.DependencyKey(versioning.DependencyMeta{User: "user", Repo: "lib-b"}), conflict.Dependency)
+	assert.Contains(t, err.Error(), "github.com/user/lib-a requires 1.x")
+}
+
+func seedMultiTagRepo(t *testing.T, cacheDir string, meta versioning.DependencyMeta, pawnJSON string, tags ...string) {
+	t.Helper()
+
+	cachePath := meta.CachePath(cacheDir)
+	require.NoError(t, os.MkdirAll(cachePath, 0o755))
+
+	repo, err := git.PlainInit(cachePath, false)
+	require.NoError(t, err)
+	wt, err := repo.Worktree()
+	require.NoError(t, err)
+
+	require.NoError(t, os.WriteFile(filepath.Join(cachePath, "pawn.json"), []byte(pawnJSON), 0o644))
+	_, err = wt.Add("pawn.json")
+	require.NoError(t, err)
+
+	for i, tag := range tags {
+		source := meta.Repo + ".inc"
+		require.NoError(t, os.WriteFile(filepath.Join(cachePath, source), []byte("// "+tag), 0o644))
+		_, err = wt.Add(source)
+		require.NoError(t, err)
+
+		when := time.Unix(int64(100+i), 0)
+		hash, err := wt.Commit("release "+tag, &git.CommitOptions{
+			Author:    &object.Signature{Name: "test", Email: "test@example.com", When: when},
+			Committer: &object.Signature{Name: "test", Email: "test@example.com", When: when},
+		})
+		require.NoError(t, err)
+		_, err = repo.CreateTag(tag, hash, nil)
+		require.NoError(t, err)
+	}
+}
@@ -0,0 +1,78 @@
+package pkgcontext
+
+import (
+	"path/filepath"
+	"strings"
+
+	"github.com/Southclaws/sampctl/src/pkg/infrastructure/versioning"
+	"github.com/Southclaws/sampctl/src/pkg/package/lockfile"
+)
+
+// dependencyOwningFile finds the dependency a file was installed from. Dependencies are
+// extracted to `dependencies/<repo>`, their resources to `dependencies/.resources/<repo>-*` and
+// local plugins and components stay where the package file points to. When a file is inside
+// the dependencies directory but no known dependency matches, the directory name is returned
+// without a dependency.
+func (pcx *PackageContext) dependencyOwningFile(path string) (meta versioning.DependencyMeta, name string, ok bool) {
+	vendorDirs := []string{pcx.Package.Vendor, filepath.Join(pcx.Package.LocalPath, "dependencies")}
+	for _, vendor := range vendorDirs {
+		rel, inside := relativeTo(vendor, path)
+		if !inside || rel == "." {
+			continue
+		}
+		parts := strings.Split(rel, string(filepath.Separator))
+		repo := parts[0]
+		if repo == ".resources" && len(parts) > 1 {
+			repo = parts[1]
+			if i := strings.LastIndexByte(repo, '-'); i > 0 {
+				repo = repo[:i]
+			}
+		}
+		if meta, ok := pcx.dependencyByRepo(repo); ok {
+			return meta, meta.Repo, true
+		}
+		return versioning.DependencyMeta{}, repo, false
+	}
+
+	for _, meta := range pcx.AllPlugins {
+		if !meta.IsLocalScheme() {
+			continue
+		}
+		if _, inside := relativeTo(filepath.Join(pcx.Package.LocalPath, meta.Local), path); inside {
+			return meta, meta.Local, true
+		}
+	}
+
+	return versioning.DependencyMeta{}, "", false
+}
+
+// problemDependency identifies the dependency that owns a file reported by the compiler by its
+// lockfile key, files that belong to the package itself return an empty string
+func (pcx *PackageContext) problemDependency(file string) string {
+	meta, name, ok := pcx.dependencyOwningFile(file)
+	if ok {
+		return lockfile.DependencyKey(meta)
+	}
+	return name
+}
+
+func (pcx *PackageContext) dependencyByRepo(repo string) (versioning.DependencyMeta, bool) {
+	for _, meta := range pcx.AllDependencies {
+		if meta.Repo == repo {
+			return meta, true
+		}
+	}
+	return versioning.DependencyMeta{}, false
+}
+
+// relativeTo returns the path relative to dir when it is dir or inside of it
+func relativeTo(dir, path string) (string, bool) {
+	if dir == "" {
+		return "", false
+	}
+	rel, err := filepath.Rel(dir, path)
+	if err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return "", false
+	}
+	return rel, true
+}
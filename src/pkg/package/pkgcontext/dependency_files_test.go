@@ -0,0 +1,152 @@
+package pkgcontext
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/Southclaws/sampctl/src/pkg/build"
+	"github.com/Southclaws/sampctl/src/pkg/infrastructure/versioning"
+	"github.com/Southclaws/sampctl/src/pkg/package/pawnpackage"
+)
+
+// dependencyWarningsCompilerScript reports a warning from the package, two warnings and an
+// optional error from a dependency
+const dependencyWarningsCompilerScript = `#!/bin/sh
+dir="$(dirname "$0")/.."
+echo "$dir/gamemodes/test.pwn(1) : warning 203: symbol is never used: \"a\""
+echo "$dir/dependencies/lib-a/a.inc(2) : warning 219: local variable \"b\" shadows a variable at a preceding level"
+echo "$dir/dependencies/lib-a/a.inc(3) : warning 219: local variable \"c\" shadows a variable at a preceding level"
+if [ -f "$dir/fail" ]; then
+	echo "$dir/dependencies/lib-a/a.inc(4) : error 017: undefined symbol \"d\""
+	exit 1
+fi
+echo "Total requirements:   16720 bytes"
+`
+
+func newDependencyWarningsPackage(t *testing.T, mode build.DependencyWarnings) (*PackageContext, string) {
+	t.Helper()
+	if runtime.GOOS == "windows" {
+		t.Skip("fake compiler is a shell script")
+	}
+
+	dir := t.TempDir()
+	writeIncludeFile(t, filepath.Join(dir, "compiler", "pawncc"), dependencyWarningsCompilerScript)
+	require.NoError(t, os.Chmod(filepath.Join(dir, "compiler", "pawncc"), 0o755))
+	writeIncludeFile(t, filepath.Join(dir, "gamemodes", "test.pwn"), "main() {}\n")
+	writeIncludeFile(t, filepath.Join(dir, "dependencies", "lib-a", "a.inc"), "")
+
+	pcx := &PackageContext{
+		Package: pawnpackage.Package{
+			Parent:    true,
+			LocalPath: dir,
+			Vendor:    filepath.Join(dir, "dependencies"),
+			Entry:     "gamemodes/test.pwn",
+			Output:    "gamemodes/test.amx",
+			Build: &build.Config{
+				Compiler:           build.CompilerConfig{Path: "compiler"},
+				DependencyWarnings: mode,
+			},
+		},
+	}
+	pcx.AllDependencies = []versioning.DependencyMeta{{User: "user", Repo: "lib-a", Tag: "1.0.0"}}
+	return pcx, dir
+}
+
+func TestBuildAttributesProblemsToDependencies(t *testing.T) {
+	t.Parallel()
+
+	pcx, _ := newDependencyWarningsPackage(t, "")
+	var output bytes.Buffer
+	problems, _, err := pcx.Build(context.Background(), BuildOptions{Output: &output})
+	require.NoError(t, err)
+
+	require.Len(t, problems, 3)
+	assert.Empty(t, problems[0].Dependency)
+	assert.Equal(t, "github.com/user/lib-a", problems[1].Dependency)
+	assert.Equal(t, "github.com/user/lib-a", problems[2].Dependency)
+	assert.Contains(t, output.String(), "shadows a variable")
+}
+
+func TestBuildSummarisesDependencyWarnings(t *testing.T) {
+	t.Parallel()
+
+	pcx, _ := newDependencyWarningsPackage(t, build.DependencyWarningsSummary)
+	var output bytes.Buffer
+	problems, _, err := pcx.Build(context.Background(), BuildOptions{Output: &output})
+	require.NoError(t, err)
+
+	assert.Len(t, problems, 3, "summarised warnings are still reported")
+	assert.NotContains(t, output.String(), "shadows a variable")
+	assert.Contains(t, output.String(), "symbol is never used")
+	assert.Contains(t, output.String(), "2 warnings from github.com/user/lib-a not shown")
+}
+
+func TestWatchedBuildSummarisesDependencyWarnings(t *testing.T) {
+	t.Parallel()
+
+	pcx, _ := newDependencyWarningsPackage(t, build.DependencyWarningsShow)
+	options := BuildOptions{DependencyWarnings: build.DependencyWarningsSummary}
+	config, err := pcx.buildPrepare(context.Background(), options.Name, false, true)
+	require.NoError(t, err)
+	options.applyTo(config)
+
+	var output bytes.Buffer
+	options.Output = &output
+	problems, err := pcx.compileWatchedBuild(context.Background(), *config, options)
+	require.NoError(t, err)
+
+	require.Len(t, problems, 3)
+	assert.Equal(t, "github.com/user/lib-a", problems[1].Dependency)
+	assert.NotContains(t, output.String(), "shadows a variable")
+	assert.Contains(t, output.String(), "2 warnings from github.com/user/lib-a not shown")
+}
+
+func TestBuildHidesDependencyWarningsButNotErrors(t *testing.T) {
+	t.Parallel()
+
+	pcx, dir := newDependencyWarningsPackage(t, build.DependencyWarningsShow)
+	writeIncludeFile(t, filepath.Join(dir, "fail"), "")
+
+	var output bytes.Buffer
+	problems, _, err := pcx.Build(context.Background(), BuildOptions{
+		Output:             &output,
+		DependencyWarnings: build.DependencyWarningsHide,
+	})
+	require.NoError(t, err)
+
+	require.Len(t, problems, 2)
+	assert.Empty(t, problems[0].Dependency)
+	assert.Equal(t, build.ProblemError, problems[1].Severity)
+	assert.Equal(t, "github.com/user/lib-a", problems[1].Dependency)
+	assert.False(t, problems.IsValid(), "errors in dependencies still fail the build")
+	assert.NotContains(t, output.String(), "shadows a variable")
+	assert.NotContains(t, output.String(), "not shown")
+}
+
+func TestBuildRejectsUnknownDependencyWarningsMode(t *testing.T) {
+	t.Parallel()
+
+	pcx, _ := newDependencyWarningsPackage(t, "quiet")
+	_, _, err := pcx.Build(context.Background(), BuildOptions{DryRun: true})
+	assert.ErrorContains(t, err, `unsupported dependency warnings mode "quiet"`)
+}
+
+func TestProblemDependency(t *testing.T) {
+	t.Parallel()
+
+	pcx, dir := newIncludesPackage(t)
+	pcx.AllPlugins = []versioning.DependencyMeta{{Scheme: "plugin", Local: "plugins/local"}}
+
+	assert.Equal(t, "github.com/user/lib-a", pcx.problemDependency(filepath.Join(dir, "dependencies", "lib-a", "a_mysql.inc")))
+	assert.Equal(t, "github.com/user/lib-c", pcx.problemDependency(filepath.Join(dir, "dependencies", ".resources", "lib-c-a1b2c3", "resource.inc")))
+	assert.Equal(t, "lib-z", pcx.problemDependency(filepath.Join(dir, "dependencies", "lib-z", "z.inc")))
+	assert.Equal(t, "plugin://local/plugins/local", pcx.problemDependency(filepath.Join(dir, "plugins", "local", "p.inc")))
+	assert.Empty(t, pcx.problemDependency(filepath.Join(dir, "gamemodes", "test.pwn")))
+}
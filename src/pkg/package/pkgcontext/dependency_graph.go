@@ -0,0 +1,251 @@
+package pkgcontext
+
+import (
+	"sort"
+	"strings"
+
+	"github.com/pkg/errors"
+
+	"github.com/Southclaws/sampctl/src/pkg/infrastructure/print"
+	"github.com/Southclaws/sampctl/src/pkg/infrastructure/versioning"
+	"github.com/Southclaws/sampctl/src/pkg/package/lockfile"
+)
+
+// DependencyGraph is the resolved dependency graph of a package, combining the
+// entries recorded in `pawn.lock` with the live dependency tree of the package.
+type DependencyGraph struct {
+	Root  string                `json:"root"`
+	Nodes []DependencyGraphNode `json:"nodes"`
+}
+
+// DependencyGraphNode describes a single package within a DependencyGraph.
+type DependencyGraphNode struct {
+	Key        string `json:"key"`
+	Constraint string `json:"constraint,omitempty"`
+	Resolved   string `json:"resolved,omitempty"`
+	Commit     string `json:"commit,omitempty"`
+	Direct     bool   `json:"direct"`
+	// Locked is set when the dependency has an entry in `pawn.lock`.
+	Locked bool `json:"locked"`
+	// Active is set when the dependency is part of the live dependency tree.
+	Active     bool     `json:"active"`
+	RequiredBy []string `json:"required_by,omitempty"`
+	Requires   []string `json:"requires,omitempty"`
+	// Constraints maps each requiring package to the constraint it declared.
+	Constraints map[string]string `json:"constraints,omitempty"`
+}
+
+// DependencyGraphStep is a single hop of a path through the dependency graph.
+type DependencyGraphStep struct {
+	Key        string `json:"key"`
+	Constraint string `json:"constraint,omitempty"`
+}
+
+// DependencyGraph builds the dependency graph for the package from the lockfile
+// and the cached package definitions of its dependencies.
+func (pcx *PackageContext) DependencyGraph() (DependencyGraph, error) {
+	lf := pcx.PackageLockfileState.GetLockfile()
+	if lf == nil && pcx.Package.LocalPath != "" {
+		loaded, err := lockfile.Load(pcx.Package.LocalPath)
+		if err != nil {
+			return DependencyGraph{}, errors.Wrap(err, "failed to load lockfile")
+		}
+		lf = loaded
+	}
+
+	live, err := pcx.currentLockfileDependencies()
+	if err != nil {
+		print.Warn("failed to read live dependency tree, falling back to lockfile only:", err)
+		live = nil
+	}
+
+	return newDependencyGraph(pcx.Package.String(), lf, live, pcx.AllDependencies), nil
+}
+
+func newDependencyGraph(
+	root string,
+	lf *lockfile.Lockfile,
+	live []lockfileDependencyState,
+	active []versioning.DependencyMeta,
+) DependencyGraph {
+	nodes := make(map[string]*DependencyGraphNode)
+	node := func(key string) *DependencyGraphNode {
+		n, ok := nodes[key]
+		if !ok {
+			n = &DependencyGraphNode{Key: key, Constraints: make(map[string]string)}
+			nodes[key] = n
+		}
+		return n
+	}
+	requiredBy := make(map[string]map[string]struct{})
+	addEdge := func(parent, child string) {
+		if requiredBy[child] == nil {
+			requiredBy[child] = make(map[string]struct{})
+		}
+		requiredBy[child][parent] = struct{}{}
+	}
+
+	if lf != nil {
+		for key, dep := range lf.Dependencies {
+			n := node(key)
+			n.Locked = true
+			n.Constraint = dep.Constraint
+			n.Resolved = dep.Resolved
+			n.Commit = dep.Commit
+			if !dep.Transitive {
+				n.Direct = true
+			}
+			for _, parent := range dep.RequiredBy {
+				addEdge(parent, key)
+			}
+		}
+	}
+
+	for _, state := range live {
+		key := lockfile.DependencyKey(state.Meta)
+		n := node(key)
+		n.Active = true
+		if state.Direct {
+			n.Direct = true
+		}
+		if n.Constraint == "" {
+			n.Constraint = lockfile.Constraint(state.Meta)
+		}
+		for parent := range state.RequiredBy {
+			addEdge(parent, key)
+		}
+		for parent, constraint := range state.Constraints {
+			if parent == "" {
+				parent = root
+			}
+			n.Constraints[parent] = constraint
+		}
+	}
+
+	for _, meta := range active {
+		if meta.IsLocalScheme() {
+			continue
+		}
+		n := node(lockfile.DependencyKey(normalizeLockfileDependency(meta)))
+		n.Active = true
+		if n.Constraint == "" {
+			n.Constraint = lockfile.Constraint(meta)
+		}
+	}
+
+	for child, parents := range requiredBy {
+		for parent := range parents {
+			if parent == root || parent == child {
+				continue
+			}
+			node(child).RequiredBy = append(node(child).RequiredBy, parent)
+			node(parent).Requires = append(node(parent).Requires, child)
+		}
+	}
+
+	graph := DependencyGraph{Root: root, Nodes: make([]DependencyGraphNode, 0, len(nodes))}
+	for _, n := range nodes {
+		sort.Strings(n.RequiredBy)
+		sort.Strings(n.Requires)
+		if len(n.Constraints) == 0 {
+			n.Constraints = nil
+		}
+		graph.Nodes = append(graph.Nodes, *n)
+	}
+	sort.Slice(graph.Nodes, func(i, j int) bool {
+		return graph.Nodes[i].Key < graph.Nodes[j].Key
+	})
+
+	return graph
+}
+
+// Node returns the node with the given key.
+func (g DependencyGraph) Node(key string) (DependencyGraphNode, bool) {
+	for _, n := range g.Nodes {
+		if n.Key == key {
+			return n, true
+		}
+	}
+	return DependencyGraphNode{}, false
+}
+
+// Direct returns the nodes that the root package depends on directly.
+func (g DependencyGraph) Direct() []DependencyGraphNode {
+	direct := make([]DependencyGraphNode, 0)
+	for _, n := range g.Nodes {
+		if n.Direct {
+			direct = append(direct, n)
+		}
+	}
+	return direct
+}
+
+// Find resolves a user supplied dependency selector such as `user/repo`,
+// `repo` or a full lockfile key to the keys of matching nodes.
+func (g DependencyGraph) Find(query string) []string {
+	query = strings.TrimSpace(query)
+	if query == "" {
+		return nil
+	}
+	if meta, err := versioning.DependencyString(query).Explode(); err == nil && meta.Repo != "" {
+		key := lockfile.DependencyKey(normalizeLockfileDependency(meta))
+		if _, ok := g.Node(key); ok {
+			return []string{key}
+		}
+	}
+
+	var matches []string
+	for _, n := range g.Nodes {
+		if n.Key == query || strings.HasSuffix(n.Key, "/"+query) {
+			matches = append(matches, n.Key)
+		}
+	}
+	return matches
+}
+
+// Why returns every path from a direct dependency of the root package to the
+// node with the given key. Each path starts with a direct dependency and ends
+// with the requested node.
+func (g DependencyGraph) Why(key string) [][]DependencyGraphStep {
+	target, ok := g.Node(key)
+	if !ok {
+		return nil
+	}
+
+	var paths [][]DependencyGraphStep
+	var walk func(n DependencyGraphNode, suffix []DependencyGraphStep, seen map[string]bool)
+	walk = func(n DependencyGraphNode, suffix []DependencyGraphStep, seen map[string]bool) {
+		if n.Direct {
+			step := DependencyGraphStep{Key: n.Key, Constraint: g.constraintFrom(g.Root, n)}
+			path := append([]DependencyGraphStep{step}, suffix...)
+			paths = append(paths, path)
+		}
+		for _, parentKey := range n.RequiredBy {
+			if seen[parentKey] {
+				continue
+			}
+			parent, ok := g.Node(parentKey)
+			if !ok {
+				continue
+			}
+			step := DependencyGraphStep{Key: n.Key, Constraint: g.constraintFrom(parentKey, n)}
+			seen[parentKey] = true
+			walk(parent, append([]DependencyGraphStep{step}, suffix...), seen)
+			delete(seen, parentKey)
+		}
+	}
+	walk(target, nil, map[string]bool{target.Key: true})
+
+	sort.SliceStable(paths, func(i, j int) bool {
+		return len(paths[i]) < len(paths[j])
+	})
+
+	return paths
+}
+
+func (g DependencyGraph) constraintFrom(parent string, n DependencyGraphNode) string {
+	if constraint, ok := n.Constraints[parent]; ok {
+		return constraint
+	}
+	return n.Constraint
+}
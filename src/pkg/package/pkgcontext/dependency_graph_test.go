@@ -0,0 +1,141 @@
+package pkgcontext
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/Southclaws/sampctl/src/pkg/infrastructure/versioning"
+	"github.com/Southclaws/sampctl/src/pkg/package/lockfile"
+)
+
+func testDependencyGraph() DependencyGraph {
+	lf := lockfile.New("dev")
+	lf.AddDependency("github.com/user/lib-a", lockfile.LockedDependency{
+		Constraint: ":1.0.0", Resolved: "1.0.0", Commit: "aaaaaaaaaaaa", User: "user", Repo: "lib-a",
+	})
+	lf.AddDependency("github.com/user/lib-b", lockfile.LockedDependency{
+		Constraint: ":2.0.0", Resolved: "2.0.0", Commit: "bbbbbbbbbbbb", User: "user", Repo: "lib-b",
+		Transitive: true, RequiredBy: []string{"github.com/user/lib-a"},
+	})
+	lf.AddDependency("github.com/user/old", lockfile.LockedDependency{
+		Constraint: ":0.1.0", Resolved: "0.1.0", Commit: "cccccccccccc", User: "user", Repo: "old",
+	})
+
+	live := []lockfileDependencyState{
+		{
+			Meta:        versioning.DependencyMeta{User: "user", Repo: "lib-a", Tag: "1.0.0"},
+			Direct:      true,
+			RequiredBy:  map[string]struct{}{},
+			Constraints: map[string]string{"": ":1.0.0"},
+		},
+		{
+			Meta:        versioning.DependencyMeta{User: "user", Repo: "lib-c"},
+			Direct:      true,
+			RequiredBy:  map[string]struct{}{},
+			Constraints: map[string]string{"": ""},
+		},
+		{
+			Meta:       versioning.DependencyMeta{User: "user", Repo: "lib-b", Tag: "2.0.0"},
+			RequiredBy: map[string]struct{}{"github.com/user/lib-a": {}, "github.com/user/lib-c": {}},
+			Constraints: map[string]string{
+				"github.com/user/lib-a": ":2.0.0",
+				"github.com/user/lib-c": ":2.1.0",
+			},
+		},
+	}
+
+	return newDependencyGraph("user/root", lf, live, nil)
+}
+
+func TestNewDependencyGraphMergesLockfileAndLiveTree(t *testing.T) {
+	t.Parallel()
+
+	graph := testDependencyGraph()
+
+	require.Len(t, graph.Nodes, 4)
+	assert.Equal(t, "user/root", graph.Root)
+
+	libA, ok := graph.Node("github.com/user/lib-a")
+	require.True(t, ok)
+	assert.True(t, libA.Direct)
+	assert.True(t, libA.Locked)
+	assert.True(t, libA.Active)
+	assert.Equal(t, []string{"github.com/user/lib-b"}, libA.Requires)
+
+	libB, ok := graph.Node("github.com/user/lib-b")
+	require.True(t, ok)
+	assert.False(t, libB.Direct)
+	assert.Equal(t, []string{"github.com/user/lib-a", "github.com/user/lib-c"}, libB.RequiredBy)
+	assert.Equal(t, ":2.1.0", libB.Constraints["github.com/user/lib-c"])
+
+	libC, ok := graph.Node("github.com/user/lib-c")
+	require.True(t, ok)
+	assert.True(t, libC.Active)
+	assert.False(t, libC.Locked)
+
+	old, ok := graph.Node("github.com/user/old")
+	require.True(t, ok)
+	assert.True(t, old.Locked)
+	assert.False(t, old.Active)
+}
+
+func TestDependencyGraphWhyReturnsPathsFromDirectDependencies(t *testing.T) {
+	t.Parallel()
+
+	graph := testDependencyGraph()
+
+	paths := graph.Why("github.com/user/lib-b")
+	require.Len(t, paths, 2)
+	assert.Equal(t, []DependencyGraphStep{
+		{Key: "github.com/user/lib-a", Constraint: ":1.0.0"},
+		{Key: "github.com/user/lib-b", Constraint: ":2.0.0"},
+	}, paths[0])
+	assert.Equal(t, []DependencyGraphStep{
+		{Key: "github.com/user/lib-c", Constraint: ""},
+		{Key: "github.com/user/lib-b", Constraint: ":2.1.0"},
+	}, paths[1])
+
+	direct := graph.Why("github.com/user/lib-a")
+	assert.Equal(t, [][]DependencyGraphStep{{{Key: "github.com/user/lib-a", Constraint: ":1.0.0"}}}, direct)
+
+	assert.Nil(t, graph.Why("github.com/user/missing"))
+}
+
+func TestDependencyGraphFindAcceptsShortSelectors(t *testing.T) {
+	t.Parallel()
+
+	graph := testDependencyGraph()
+
+	assert.Equal(t, []string{"github.com/user/lib-b"}, graph.Find("user/lib-b"))
+	assert.Equal(t, []string{"github.com/user/lib-b"}, graph.Find("lib-b"))
+	assert.Equal(t, []string{"github.com/user/lib-b"}, graph.Find("github.com/user/lib-b"))
+	assert.Empty(t, graph.Find("nope"))
+}
+
+func TestPackageContextDependencyGraphUsesCachedDefinitions(t *testing.T) {
+	t.Parallel()
+
+	cacheDir := t.TempDir()
+	projectDir := t.TempDir()
+
+	seedLockfileRepo(t, cacheDir, versioning.DependencyMeta{User: "user", Repo: "lib-b", Tag: "1.0.0"}, `{"entry":"libb.pwn","output":"gamemodes/libb.amx"}`)
+	seedLockfileRepo(t, cacheDir, versioning.DependencyMeta{User: "user", Repo: "lib-a", Tag: "1.0.0"}, `{"entry":"liba.pwn","output":"gamemodes/liba.amx","dependencies":["user/lib-b:1.0.0"]}`)
+
+	require.NoError(t, os.WriteFile(filepath.Join(projectDir, "pawn.json"), []byte(`{"user":"user","repo":"root","entry":"main.pwn","output":"gamemodes/main.amx","dependencies":["user/lib-a:1.0.0"]}`), 0o644))
+	require.NoError(t, os.WriteFile(filepath.Join(projectDir, "main.pwn"), []byte("main() {}"), 0o644))
+
+	pcx, err := NewPackageContext(NewPackageContextOptions{Parent: true, Dir: projectDir, Platform: "linux", CacheDir: cacheDir})
+	require.NoError(t, err)
+
+	graph, err := pcx.DependencyGraph()
+	require.NoError(t, err)
+
+	paths := graph.Why("github.com/user/lib-b")
+	require.Len(t, paths, 1)
+	assert.Equal(t, "github.com/user/lib-a", paths[0][0].Key)
+	assert.Equal(t, ":1.0.0", paths[0][1].Constraint)
+}
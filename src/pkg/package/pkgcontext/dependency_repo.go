@@ -17,37 +17,37 @@ func (pcx *PackageContext) ensureDependencyRepository(meta versioning.Dependency
 	if err == nil {
 		head, headErr := repo.Head()
 		if headErr != nil {
-			print.Verb(meta, "existing repository has invalid HEAD, re-cloning")
+			print.Fverb(pcx.output, meta, "existing repository has invalid HEAD, re-cloning")
 			return pcx.recloneDependency(meta, dependencyPath)
 		}
-		print.Verb(meta, "repository already exists at", head.Hash().String()[:8])
+		print.Fverb(pcx.output, meta, "repository already exists at", head.Hash().String()[:8])
 		return repo, nil
 	}
 
 	if err != git.ErrRepositoryNotExists {
-		print.Verb(meta, "error opening repository:", err)
+		print.Fverb(pcx.output, meta, "error opening repository:", err)
 		return pcx.recloneDependency(meta, dependencyPath)
 	}
 
-	print.Verb(meta, "repository does not exist, cloning from cache")
+	print.Fverb(pcx.output, meta, "repository does not exist, cloning from cache")
 	return pcx.cloneDependencyFromCache(meta, dependencyPath)
 }
 
 func (pcx *PackageContext) cloneDependencyFromCache(meta versioning.DependencyMeta, dependencyPath string) (*git.Repository, error) {
 	repo, err := pcx.EnsureDependencyFromCache(meta, dependencyPath, false)
 	if err != nil {
-		print.Verb(meta, "failed to clone from cache:", err)
+		print.Fverb(pcx.output, meta, "failed to clone from cache:", err)
 		if removeErr := os.RemoveAll(dependencyPath); removeErr != nil {
-			print.Warn("failed to clean up dependency clone:", removeErr)
+			print.Fwarn(pcx.output, "failed to clean up dependency clone:", removeErr)
 		}
 		return nil, errors.Wrap(err, "failed to clone dependency from cache")
 	}
 
 	valid, validationErr := pcx.PackageServices.repositoryHealth().Validate(dependencyPath)
 	if validationErr != nil || !valid {
-		print.Verb(meta, "cloned repository failed validation")
+		print.Fverb(pcx.output, meta, "cloned repository failed validation")
 		if removeErr := os.RemoveAll(dependencyPath); removeErr != nil {
-			print.Warn("failed to clean up invalid dependency clone:", removeErr)
+			print.Fwarn(pcx.output, "failed to clean up invalid dependency clone:", removeErr)
 		}
 		if validationErr != nil {
 			return nil, errors.Wrap(validationErr, "cloned repository is invalid")
@@ -59,7 +59,7 @@ func (pcx *PackageContext) cloneDependencyFromCache(meta versioning.DependencyMe
 }
 
 func (pcx *PackageContext) recloneDependency(meta versioning.DependencyMeta, dependencyPath string) (*git.Repository, error) {
-	print.Verb(meta, "re-cloning dependency at", dependencyPath)
+	print.Fverb(pcx.output, meta, "re-cloning dependency at", dependencyPath)
 
 	if err := os.RemoveAll(dependencyPath); err != nil {
 		return nil, errors.Wrap(err, "failed to remove corrupted dependency")
@@ -74,10 +74,10 @@ func (pcx *PackageContext) updateRepoStateWithRecovery(repo *git.Repository, met
 		return nil
 	}
 
-	print.Verb(meta, "first update attempt failed:", err)
+	print.Fverb(pcx.output, meta, "first update attempt failed:", err)
 
 	if repairErr := pcx.PackageServices.repositoryHealth().Repair(dependencyPath); repairErr == nil {
-		print.Verb(meta, "repository repaired, retrying update")
+		print.Fverb(pcx.output, meta, "repository repaired, retrying update")
 		if repo, openErr := pcx.PackageServices.repositoryStore().Open(dependencyPath); openErr == nil {
 			if err = pcx.updateRepoState(repo, meta, true); err == nil {
 				return nil
@@ -85,13 +85,13 @@ func (pcx *PackageContext) updateRepoStateWithRecovery(repo *git.Repository, met
 		}
 	}
 
-	print.Verb(meta, "attempting force update")
+	print.Fverb(pcx.output, meta, "attempting force update")
 	err = pcx.updateRepoState(repo, meta, true)
 	if err == nil {
 		return nil
 	}
 
-	print.Verb(meta, "all update attempts failed, re-cloning dependency")
+	print.Fverb(pcx.output, meta, "all update attempts failed, re-cloning dependency")
 	if _, cloneErr := pcx.recloneDependency(meta, dependencyPath); cloneErr != nil {
 		return errors.Wrap(cloneErr, "failed to recover by re-cloning")
 	}
@@ -109,7 +109,7 @@ func (pcx *PackageContext) updateRepoState(
 	meta versioning.DependencyMeta,
 	forcePull bool,
 ) error {
-	print.Verb(meta, "updating repository state with", pcx.GitAuth, "authentication method")
+	print.Fverb(pcx.output, meta, "updating repository state with", pcx.GitAuth, "authentication method")
 	remoteURL := getRepositoryOriginURL(repo)
 
 	var (
@@ -118,7 +118,7 @@ func (pcx *PackageContext) updateRepoState(
 	)
 
 	if forcePull {
-		print.Verb(meta, "performing forced pull to latest tip")
+		print.Fverb(pcx.output, meta, "performing forced pull to latest tip")
 		repo, err = pcx.EnsureDependencyFromCache(meta, filepath.Join(pcx.Package.Vendor, meta.Repo), true)
 		if err != nil {
 			return errors.Wrap(err, "failed to ensure dependency in cache")
@@ -151,13 +151,13 @@ func (pcx *PackageContext) updateRepoState(
 	var ref *plumbing.Reference
 	switch {
 	case meta.Tag != "":
-		print.Verb(meta, "package has tag constraint:", meta.Tag)
+		print.Fverb(pcx.output, meta, "package has tag constraint:", meta.Tag)
 		ref, err = versioning.RefFromTag(repo, meta)
 		if err != nil {
 			return errors.Wrap(err, "failed to get ref from tag")
 		}
 	case meta.Branch != "":
-		print.Verb(meta, "package has branch constraint:", meta.Branch)
+		print.Fverb(pcx.output, meta, "package has branch constraint:", meta.Branch)
 		pullOpts.Depth = 1000
 		pullOpts.ReferenceName = plumbing.ReferenceName("refs/heads/" + meta.Branch)
 		if err = wt.Pull(pullOpts); err != nil && err != git.NoErrAlreadyUpToDate {
@@ -182,11 +182,11 @@ func (pcx *PackageContext) updateRepoState(
 		if err = wt.Checkout(&git.CheckoutOptions{Hash: ref.Hash(), Force: true}); err != nil {
 			return errors.Wrapf(err, "failed to checkout necessary commit %s", ref.Hash())
 		}
-		print.Verb(meta, "successfully checked out to", ref.Hash())
+		print.Fverb(pcx.output, meta, "successfully checked out to", ref.Hash())
 		return nil
 	}
 
-	print.Verb(meta, "package does not have version constraint pulling latest")
+	print.Fverb(pcx.output, meta, "package does not have version constraint pulling latest")
 	if err = wt.Pull(pullOpts); err != nil {
 		if err == git.NoErrAlreadyUpToDate {
 			return nil
@@ -0,0 +1,218 @@
+package pkgcontext
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/pkg/errors"
+
+	"github.com/Southclaws/sampctl/src/pkg/build"
+	"github.com/Southclaws/sampctl/src/pkg/build/compiler"
+	"github.com/Southclaws/sampctl/src/pkg/infrastructure/fs"
+	"github.com/Southclaws/sampctl/src/pkg/infrastructure/print"
+	"github.com/Southclaws/sampctl/src/pkg/package/pawnapi"
+	"github.com/Southclaws/sampctl/src/pkg/package/pawndoc"
+)
+
+// DocsOptions configures how documentation is generated for the include files of a package
+type DocsOptions struct {
+	// Build is the build config whose compiler, options and include paths compile the harness.
+	Build  string
+	Ensure bool
+	// Harness is a script that includes the package, when empty a harness that includes every
+	// include file of the package is generated.
+	Harness string
+	// OutputDir is the directory pages are written to, relative to the package.
+	OutputDir string
+	Format    pawndoc.Format
+	// Output receives compiler diagnostics, defaults to standard output.
+	Output io.Writer
+}
+
+func (options DocsOptions) output() io.Writer {
+	if options.Output != nil {
+		return options.Output
+	}
+	return os.Stdout
+}
+
+// serverIncludes are the includes a generated harness starts with when the package depends on
+// the server's includes, most libraries expect them to be included first
+var serverIncludes = []struct{ repo, include string }{
+	{"omp-stdlib", "open.mp"},
+	{"samp-stdlib", "a_samp"},
+}
+
+// Docs compiles a documentation harness with the compiler's XML report enabled and writes a
+// page for each include file of the package with the functions, macros and enums it declares
+// and the doc comments the report contains. The paths of the written pages are returned.
+func (pcx *PackageContext) Docs(ctx context.Context, options DocsOptions) ([]string, error) {
+	files, err := pcx.packageIncludeFiles()
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to find include files")
+	}
+	if len(files) == 0 {
+		return nil, errors.New("package has no include files to document")
+	}
+
+	config, err := pcx.buildPrepare(ctx, options.Build, options.Ensure, false)
+	if err != nil {
+		return nil, err
+	}
+
+	workDir, err := os.MkdirTemp("", "sampctl-docs-")
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to create documentation build directory")
+	}
+	defer os.RemoveAll(workDir) // nolint
+
+	harness := options.Harness
+	if harness == "" {
+		harness = filepath.Join(workDir, "docs.pwn")
+		if err = os.WriteFile(harness, pcx.docsHarness(files), fs.PermFilePrivate); err != nil {
+			return nil, errors.Wrap(err, "failed to write documentation harness")
+		}
+	} else {
+		harness = packagePath(pcx.Package.LocalPath, harness)
+	}
+
+	reportFile := filepath.Join(workDir, "docs.xml")
+	if err = pcx.compileDocsHarness(ctx, *config, harness, reportFile, options.output()); err != nil {
+		return nil, err
+	}
+	report, err := pawndoc.Open(reportFile)
+	if err != nil {
+		return nil, err
+	}
+
+	var symbols []pawnapi.Symbol
+	for _, file := range files {
+		source, err := os.ReadFile(file)
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to read include file")
+		}
+		symbols = append(symbols, pawnapi.Parse(pcx.packageRelativePath(file), string(source))...)
+	}
+	pages := pawndoc.Pages(symbols, report)
+
+	outputDir := packagePath(pcx.Package.LocalPath, options.OutputDir)
+	written := make([]string, 0, len(pages)+1)
+	write := func(name string, render func(io.Writer) error) error {
+		var b bytes.Buffer
+		if err := render(&b); err != nil {
+			return err
+		}
+		path := filepath.Join(outputDir, filepath.FromSlash(name))
+		if err := fs.WriteFileAtomic(path, b.Bytes(), fs.PermDirShared, fs.PermFileShared); err != nil {
+			return errors.Wrap(err, "failed to write documentation page")
+		}
+		written = append(written, path)
+		return nil
+	}
+	for _, page := range pages {
+		page := page
+		if err = write(page.Filename(options.Format), func(w io.Writer) error { return page.Write(w, options.Format) }); err != nil {
+			return written, err
+		}
+	}
+	title := pcx.Package.Repo
+	if title == "" {
+		title = filepath.Base(pcx.Package.LocalPath)
+	}
+	err = write(pawndoc.IndexFilename(options.Format), func(w io.Writer) error {
+		return pawndoc.WriteIndex(w, title, pages, options.Format)
+	})
+	return written, err
+}
+
+// compileDocsHarness compiles the harness with the build's compiler and include paths and writes
+// the compiler's XML report. Build commands, budgets and the lockfile are not involved since the
+// harness is not the package's build.
+func (pcx *PackageContext) compileDocsHarness(ctx context.Context, config build.Config, harness, reportFile string, output io.Writer) error {
+	config.Input = harness
+	config.Output = filepath.Join(filepath.Dir(reportFile), "docs.amx")
+	withCompilerOption(&config, func(options *build.CompilerOptions) {
+		options.ReportFile = &reportFile
+	}, "-r"+reportFile)
+
+	command, err := pcx.prepareBuildCommand(ctx, config)
+	if err != nil {
+		return err
+	}
+	print.Verb("compiling documentation harness", harness)
+	problems, _, err := compiler.CompileWithCommand(compiler.CompileCommandRequest{
+		Context:    ctx,
+		Command:    command,
+		WorkingDir: config.WorkingDir,
+		ErrorDir:   pcx.Package.LocalPath,
+		Output:     output,
+		Config:     config,
+		Dependency: pcx.problemDependency,
+	})
+	if err != nil {
+		return errors.Wrap(err, "failed to compile documentation harness")
+	}
+	if problems.Fatal() || !problems.IsValid() {
+		return errors.New("documentation harness failed to compile, use --harness to provide a script that includes the package")
+	}
+	if !fs.Exists(reportFile) {
+		return errors.New("compiler did not write a report, the compiler may not support -r")
+	}
+	return nil
+}
+
+// docsHarness generates a script that includes every include file of the package after the
+// server's includes, when the package depends on them
+func (pcx *PackageContext) docsHarness(files []string) []byte {
+	var b strings.Builder
+	b.WriteString("// generated by \"sampctl docs package\" to document the package's includes\n\n")
+	for _, server := range serverIncludes {
+		if pcx.dependsOnRepo(server.repo) {
+			fmt.Fprintf(&b, "#include <%s>\n", server.include)
+			break
+		}
+	}
+	for _, file := range files {
+		fmt.Fprintf(&b, "#include \"%s\"\n", filepath.ToSlash(file))
+	}
+	b.WriteString("\nmain() {}\n")
+	return []byte(b.String())
+}
+
+func (pcx *PackageContext) dependsOnRepo(repo string) bool {
+	for _, dependency := range pcx.AllDependencies {
+		if dependency.Repo == repo {
+			return true
+		}
+	}
+	return false
+}
+
+// packageIncludeFiles returns the include files of the package under its include path, files in
+// dependencies, hidden directories and the generated build file are skipped
+func (pcx *PackageContext) packageIncludeFiles() ([]string, error) {
+	root := packagePath(pcx.Package.LocalPath, pcx.Package.IncludePath)
+	var files []string
+	err := filepath.Walk(root, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		rel := pcx.packageRelativePath(path)
+		if info.IsDir() {
+			if path != root && (info.Name() == "dependencies" || strings.HasPrefix(info.Name(), ".")) {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if pawnapi.IsIncludeFile(rel) && path != pcx.buildFilePath() {
+			files = append(files, path)
+		}
+		return nil
+	})
+	return files, err
+}
@@ -0,0 +1,79 @@
+package pkgcontext
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/Southclaws/sampctl/src/pkg/infrastructure/versioning"
+	"github.com/Southclaws/sampctl/src/pkg/package/pawndoc"
+)
+
+// reportingCompilerScript keeps a copy of the script it compiled and writes report.xml from the
+// compiler directory to the file named by the -r flag
+const reportingCompilerScript = `#!/bin/sh
+dir="$(dirname "$0")"
+cp "$1" "$dir/harness.pwn"
+for arg in "$@"; do
+	case "$arg" in
+		-o*) printf 'amx' > "${arg#-o}" ;;
+		-r*) cp "$dir/report.xml" "${arg#-r}" ;;
+	esac
+done
+echo "Total requirements:   16720 bytes"
+`
+
+const docsReport = `<?xml version="1.0" encoding="UTF-8"?>
+<doc source="docs.pwn">
+	<members>
+		<member name="M:util" syntax="util(value)">
+			<param name="value"></param>
+			<summary>Does something useful.</summary>
+			<param name="value">The value to use.</param>
+		</member>
+	</members>
+</doc>
+`
+
+func TestDocsWritesPagesForEachInclude(t *testing.T) {
+	pcx, dir := newCachedBuildPackage(t)
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "compiler", "pawncc"), []byte(reportingCompilerScript), 0o755))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "compiler", "report.xml"), []byte(docsReport), 0o644))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "gamemodes", "util.inc"), []byte("stock util(value) {}\n"), 0o644))
+	writeIncludeFile(t, filepath.Join(dir, "dependencies", "samp-stdlib", "a_samp.inc"), "native print(const string[]);\n")
+	pcx.AllDependencies = []versioning.DependencyMeta{{User: "pawn-lang", Repo: "samp-stdlib"}}
+
+	var output bytes.Buffer
+	written, err := pcx.Docs(context.Background(), DocsOptions{
+		OutputDir: "docs/api",
+		Format:    pawndoc.FormatMarkdown,
+		Output:    &output,
+	})
+	require.NoError(t, err)
+	assert.Equal(t, []string{
+		filepath.Join(dir, "docs", "api", "gamemodes", "util.md"),
+		filepath.Join(dir, "docs", "api", "README.md"),
+	}, written)
+
+	harness, err := os.ReadFile(filepath.Join(dir, "compiler", "harness.pwn"))
+	require.NoError(t, err)
+	assert.Contains(t, string(harness), "#include <a_samp>\n#include \""+filepath.ToSlash(filepath.Join(dir, "gamemodes", "util.inc"))+"\"\n")
+	assert.NotContains(t, string(harness), "dependencies", "only the package's own includes are documented")
+
+	page, err := os.ReadFile(written[0])
+	require.NoError(t, err)
+	assert.Contains(t, string(page), "# gamemodes/util.inc\n")
+	assert.Contains(t, string(page), "Does something useful.\n\n| Parameter | Description |\n| --- | --- |\n| `value` | The value to use. |\n")
+}
+
+func TestDocsFailsWithoutReport(t *testing.T) {
+	pcx, _ := newCachedBuildPackage(t)
+
+	_, err := pcx.Docs(context.Background(), DocsOptions{Format: pawndoc.FormatMarkdown, Output: &bytes.Buffer{}})
+	assert.EqualError(t, err, "compiler did not write a report, the compiler may not support -r")
+}
@@ -7,11 +7,9 @@ import (
 	"io"
 	"os"
 	"path/filepath"
-	"time"
 
 	"github.com/go-git/go-git/v5"
 	"github.com/pkg/errors"
-	"gopkg.in/eapache/go-resiliency.v1/retrier"
 
 	"github.com/Southclaws/sampctl/src/pkg/infrastructure/fs"
 	"github.com/Southclaws/sampctl/src/pkg/infrastructure/print"
@@ -47,40 +45,29 @@ func (pcx *PackageContext) ensureDependencies(
 	pcx.Package.Vendor = filepath.Join(pcx.Package.LocalPath, "dependencies")
 	directDependencies := pcx.directDependencySet()
 
-	for _, dependency := range pcx.AllDependencies {
-		if ctxErr := ctx.Err(); ctxErr != nil {
-			return ctxErr
-		}
-
-		dep := dependency
-		_, direct := directDependencies[dependencyUpdateIdentity(dep)]
-		forceDependencyUpdate := request.ShouldForceDependency(dep, direct)
-		r := retrier.New(retrier.ConstantBackoff(1, 100*time.Millisecond), nil)
-		err := r.Run(func() error {
-			if ctxErr := ctx.Err(); ctxErr != nil {
-				return ctxErr
-			}
+	results := pcx.ensureDependencyJobs(ctx, request, directDependencies)
+	if ctxErr := ctx.Err(); ctxErr != nil {
+		return ctxErr
+	}
 
-			print.Verb("attempting to ensure dependency", dep)
-			errInner := pcx.ensurePackage(ctx, dep, forceDependencyUpdate)
-			if errInner != nil {
-				print.Warn(errors.Wrapf(errInner, "failed to ensure package %s", dep))
-				return errInner
-			}
-			print.Info(pcx.Package, "successfully ensured dependency files for", dep)
-			return nil
-		})
-		if err != nil {
-			if ctxErr := ctx.Err(); ctxErr != nil || errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
-				if ctxErr != nil {
-					return ctxErr
-				}
-				return err
+	// Results are applied in dependency order regardless of which job finished
+	// first so the context state and the output are the same for any job count.
+	for i, dep := range pcx.AllDependencies {
+		result := results[i]
+		print.Write(result.output)
+		if result.err != nil {
+			if errors.Is(result.err, context.Canceled) || errors.Is(result.err, context.DeadlineExceeded) {
+				return result.err
 			}
 
+			print.Warn(errors.Wrapf(result.err, "failed to ensure package %s", dep))
 			print.Warn("failed to ensure package", dep, "after 2 attempts, skipping")
 			continue
 		}
+
+		pcx.AllIncludePaths = append(pcx.AllIncludePaths, result.includePaths...)
+		pcx.AllPlugins = append(pcx.AllPlugins, result.plugins...)
+		print.Info(pcx.Package, "successfully ensured dependency files for", dep)
 	}
 
 	// Ensure runtime binaries/plugins for the root package so all ensure entrypoints
@@ -174,7 +161,7 @@ func (pcx *PackageContext) ensurePluginDependency(ctx context.Context, meta vers
 		}
 
 		pcx.AllPlugins = append(pcx.AllPlugins, pluginMeta)
-		print.Verb(meta, "added local plugin dependency:", pluginPath)
+		print.Fverb(pcx.output, meta, "added local plugin dependency:", pluginPath)
 		return nil
 	}
 
@@ -202,7 +189,7 @@ func (pcx *PackageContext) ensurePluginDependency(ctx context.Context, meta vers
 	remoteMeta.Scheme = "plugin"
 
 	pcx.AllPlugins = append(pcx.AllPlugins, remoteMeta)
-	print.Verb(meta, "added remote plugin dependency:", remoteMeta)
+	print.Fverb(pcx.output, meta, "added remote plugin dependency:", remoteMeta)
 	return nil
 }
 
@@ -224,7 +211,7 @@ func (pcx *PackageContext) ensureComponentDependency(ctx context.Context, meta v
 		}
 
 		pcx.AllPlugins = append(pcx.AllPlugins, componentMeta)
-		print.Verb(meta, "added local component dependency:", componentPath)
+		print.Fverb(pcx.output, meta, "added local component dependency:", componentPath)
 		return nil
 	}
 
@@ -251,7 +238,7 @@ func (pcx *PackageContext) ensureComponentDependency(ctx context.Context, meta v
 	remoteMeta.Scheme = "component"
 
 	pcx.AllPlugins = append(pcx.AllPlugins, remoteMeta)
-	print.Verb(meta, "added remote component dependency:", remoteMeta)
+	print.Fverb(pcx.output, meta, "added remote component dependency:", remoteMeta)
 	return nil
 }
 
@@ -265,7 +252,7 @@ func (pcx *PackageContext) ensureIncludesDependency(ctx context.Context, meta ve
 		}
 
 		pcx.AllIncludePaths = append(pcx.AllIncludePaths, includesPath)
-		print.Verb(meta, "added local includes path:", includesPath)
+		print.Fverb(pcx.output, meta, "added local includes path:", includesPath)
 		return nil
 	}
 
@@ -291,7 +278,7 @@ func (pcx *PackageContext) ensureIncludesDependency(ctx context.Context, meta ve
 		includesPath = filepath.Join(includesPath, remoteMeta.Path)
 	}
 	pcx.AllIncludePaths = append(pcx.AllIncludePaths, includesPath)
-	print.Verb(meta, "added remote includes path:", includesPath)
+	print.Fverb(pcx.output, meta, "added remote includes path:", includesPath)
 	return nil
 }
 
@@ -304,7 +291,7 @@ func (pcx *PackageContext) ensureFilterscriptDependency(ctx context.Context, met
 			return errors.Errorf("local filterscript path does not exist: %s", filterscriptPath)
 		}
 
-		print.Verb(meta, "added local filterscript dependency:", filterscriptPath)
+		print.Fverb(pcx.output, meta, "added local filterscript dependency:", filterscriptPath)
 		return nil
 	}
 
@@ -325,7 +312,7 @@ func (pcx *PackageContext) ensureFilterscriptDependency(ctx context.Context, met
 		return err
 	}
 
-	print.Verb(meta, "added filterscript dependency:", remoteMeta)
+	print.Fverb(pcx.output, meta, "added filterscript dependency:", remoteMeta)
 	return nil
 }
 
@@ -0,0 +1,119 @@
+package pkgcontext
+
+import (
+	"bytes"
+	"context"
+	"sync"
+	"time"
+
+	"gopkg.in/eapache/go-resiliency.v1/retrier"
+
+	"github.com/Southclaws/sampctl/src/pkg/infrastructure/print"
+	"github.com/Southclaws/sampctl/src/pkg/infrastructure/versioning"
+)
+
+// dependencyEnsureResult holds the outcome of ensuring a single dependency.
+// State that ensuring a package would normally append to the context, and
+// the messages it would print, are collected here instead so they can be
+// merged in dependency order.
+type dependencyEnsureResult struct {
+	includePaths []string
+	plugins      []versioning.DependencyMeta
+	output       []byte
+	err          error
+}
+
+func (pcx *PackageContext) ensureJobCount(dependencies int) int {
+	jobs := pcx.Jobs
+	if jobs < 1 {
+		jobs = 1
+	}
+	if jobs > dependencies {
+		jobs = dependencies
+	}
+	return jobs
+}
+
+// ensureDependencyJobs ensures every dependency in AllDependencies using a
+// bounded pool of workers. The returned results are indexed the same as
+// AllDependencies.
+func (pcx *PackageContext) ensureDependencyJobs(
+	ctx context.Context,
+	request DependencyUpdateRequest,
+	directDependencies map[string]struct{},
+) []dependencyEnsureResult {
+	deps := pcx.AllDependencies
+	results := make([]dependencyEnsureResult, len(deps))
+	for i := range results {
+		results[i].err = context.Canceled
+	}
+	if len(deps) == 0 {
+		return results
+	}
+
+	jobs := pcx.ensureJobCount(len(deps))
+	print.Verb(pcx.Package, "ensuring", len(deps), "dependencies with", jobs, "jobs")
+
+	indexes := make(chan int)
+	var wg sync.WaitGroup
+	for w := 0; w < jobs; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range indexes {
+				_, direct := directDependencies[dependencyUpdateIdentity(deps[i])]
+				results[i] = pcx.ensureDependencyJob(ctx, deps[i], request.ShouldForceDependency(deps[i], direct))
+			}
+		}()
+	}
+
+dispatch:
+	for i := range deps {
+		select {
+		case <-ctx.Done():
+			break dispatch
+		case indexes <- i:
+		}
+	}
+	close(indexes)
+	wg.Wait()
+
+	return results
+}
+
+func (pcx *PackageContext) ensureDependencyJob(
+	ctx context.Context,
+	dep versioning.DependencyMeta,
+	forceUpdate bool,
+) dependencyEnsureResult {
+	// Each job works on a shallow copy of the context with its own include path
+	// and plugin lists, everything else it touches is either read-only or, like
+	// the lockfile resolver, safe for concurrent use.
+	var output bytes.Buffer
+	worker := *pcx
+	worker.AllIncludePaths = nil
+	worker.AllPlugins = nil
+	worker.output = &output
+
+	r := retrier.New(retrier.ConstantBackoff(1, 100*time.Millisecond), nil)
+	err := r.Run(func() error {
+		if ctxErr := ctx.Err(); ctxErr != nil {
+			return ctxErr
+		}
+
+		worker.AllIncludePaths = nil
+		worker.AllPlugins = nil
+		print.Fverb(worker.output, "attempting to ensure dependency", dep)
+		return worker.ensurePackage(ctx, dep, forceUpdate)
+	})
+	if ctxErr := ctx.Err(); ctxErr != nil {
+		return dependencyEnsureResult{output: output.Bytes(), err: ctxErr}
+	}
+
+	return dependencyEnsureResult{
+		includePaths: worker.AllIncludePaths,
+		plugins:      worker.AllPlugins,
+		output:       output.Bytes(),
+		err:          err,
+	}
+}
@@ -0,0 +1,124 @@
+package pkgcontext
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/Southclaws/sampctl/src/pkg/infrastructure/print"
+	"github.com/Southclaws/sampctl/src/pkg/infrastructure/versioning"
+	"github.com/Southclaws/sampctl/src/pkg/package/lockfile"
+	run "github.com/Southclaws/sampctl/src/pkg/runtime/config"
+)
+
+func ensureProjectWithJobs(t *testing.T, jobs int) (*PackageContext, *lockfile.Lockfile) {
+	t.Helper()
+
+	cacheDir := t.TempDir()
+	projectDir := t.TempDir()
+
+	deps := make([]string, 0, 6)
+	for i := 0; i < 6; i++ {
+		meta := versioning.DependencyMeta{User: "user", Repo: fmt.Sprintf("lib-%d", i), Tag: "1.0.0"}
+		seedLockfileRepo(t, cacheDir, meta, fmt.Sprintf(`{"entry":"lib%d.pwn","output":"gamemodes/lib%d.amx"}`, i, i))
+		deps = append(deps, `"user/`+meta.Repo+`:1.0.0"`)
+	}
+
+	definition := `{"entry":"main.pwn","output":"gamemodes/main.amx","runtime":{"version":"0.3.7"},"dependencies":[` + strings.Join(deps, ",") + `]}`
+	require.NoError(t, os.WriteFile(filepath.Join(projectDir, "pawn.json"), []byte(definition), 0o644))
+	require.NoError(t, os.WriteFile(filepath.Join(projectDir, "main.pwn"), []byte("main() {}"), 0o644))
+	seedStagedRuntime(t, cacheDir, run.Runtime{Version: "0.3.7", Platform: "linux"})
+
+	pcx, err := NewPackageContext(NewPackageContextOptions{Parent: true, Dir: projectDir, Platform: "linux", CacheDir: cacheDir})
+	require.NoError(t, err)
+	require.NoError(t, pcx.InitLockfileResolver("dev"))
+	pcx.Jobs = jobs
+
+	_, err = pcx.EnsureProject(context.Background(), DependencyUpdateRequest{})
+	require.NoError(t, err)
+
+	for i := 0; i < 6; i++ {
+		require.DirExists(t, filepath.Join(projectDir, "dependencies", fmt.Sprintf("lib-%d", i)))
+	}
+
+	lf, err := lockfile.Load(projectDir)
+	require.NoError(t, err)
+	require.NotNil(t, lf)
+
+	return pcx, lf
+}
+
+func TestEnsureDependenciesWithJobsMatchesSerialResult(t *testing.T) {
+	t.Parallel()
+
+	serial, serialLock := ensureProjectWithJobs(t, 1)
+	parallel, parallelLock := ensureProjectWithJobs(t, 4)
+
+	assert.Equal(t, serial.AllDependencies, parallel.AllDependencies)
+	require.Len(t, parallelLock.Dependencies, len(serialLock.Dependencies))
+	for key, locked := range serialLock.Dependencies {
+		other, ok := parallelLock.Dependencies[key]
+		require.True(t, ok, key)
+		assert.Equal(t, locked.Resolved, other.Resolved, key)
+		assert.Equal(t, locked.Commit, other.Commit, key)
+	}
+}
+
+func TestEnsureDependenciesWithJobsPrintsInDependencyOrder(t *testing.T) {
+	var out bytes.Buffer
+	print.SetOutput(&out)
+	defer print.SetOutput(nil)
+
+	pcx, _ := ensureProjectWithJobs(t, 4)
+	text := out.String()
+
+	// every job's messages are printed together, after the previous dependency finished
+	previous := -1
+	for _, dep := range pcx.AllDependencies {
+		attempt := strings.Index(text, fmt.Sprint("attempting to ensure dependency ", dep))
+		success := strings.Index(text, fmt.Sprint("successfully ensured dependency files for ", dep))
+		require.NotEqual(t, -1, attempt, dep)
+		require.NotEqual(t, -1, success, dep)
+		assert.Greater(t, attempt, previous, dep)
+		assert.Greater(t, success, attempt, dep)
+		previous = success
+	}
+}
+
+func TestEnsureJobCountIsBounded(t *testing.T) {
+	t.Parallel()
+
+	pcx := &PackageContext{}
+	assert.Equal(t, 1, pcx.ensureJobCount(10))
+
+	pcx.Jobs = 8
+	assert.Equal(t, 3, pcx.ensureJobCount(3))
+	assert.Equal(t, 8, pcx.ensureJobCount(20))
+}
+
+func TestEnsureDependencyJobsStopsDispatchingWhenCancelled(t *testing.T) {
+	t.Parallel()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	pcx := &PackageContext{
+		PackageResolvedState: PackageResolvedState{
+			AllDependencies: []versioning.DependencyMeta{{User: "user", Repo: "a"}, {User: "user", Repo: "b"}},
+		},
+		PackageExecutionState: PackageExecutionState{Jobs: 2},
+	}
+
+	results := pcx.ensureDependencyJobs(ctx, DependencyUpdateRequest{}, map[string]struct{}{})
+	require.Len(t, results, 2)
+	for _, result := range results {
+		assert.ErrorIs(t, result.err, context.Canceled)
+	}
+}
@@ -22,7 +22,7 @@ func (pcx *PackageContext) resolveDynamicDependencyReference(
 	resolvedTag, err := pcx.resolveLatestTag(ctx, effectiveMeta, forceUpdate)
 	if err != nil {
 		if isMissingLatestReleaseError(err) {
-			print.Warn(originalMeta, "uses :latest but does not publish tags or releases, using the default branch instead")
+			print.Fwarn(pcx.output, originalMeta, "uses :latest but does not publish tags or releases, using the default branch instead")
 
 			updatedMeta := effectiveMeta
 			updatedMeta.Tag = ""
@@ -39,7 +39,7 @@ func (pcx *PackageContext) resolveDynamicDependencyReference(
 	updatedMeta.Tag = resolvedTag
 
 	if previous, ok := pcx.PackageLockfileState.PreviousDependency(originalMeta); ok && previous.Resolved != "" && previous.Resolved != resolvedTag {
-		print.Verb(originalMeta, "resolved latest from", previous.Resolved, "to", resolvedTag)
+		print.Fverb(pcx.output, originalMeta, "resolved latest from", previous.Resolved, "to", resolvedTag)
 	}
 
 	return updatedMeta, nil
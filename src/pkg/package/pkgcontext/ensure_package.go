@@ -108,11 +108,11 @@ func (pcx *PackageContext) removeInvalidDependencyRepo(meta versioning.Dependenc
 		return nil
 	}
 
-	print.Verb(meta, "existing repository is invalid or corrupted")
+	print.Fverb(pcx.output, meta, "existing repository is invalid or corrupted")
 	if validationErr != nil {
-		print.Verb(meta, "validation error:", validationErr)
+		print.Fverb(pcx.output, meta, "validation error:", validationErr)
 	}
-	print.Verb(meta, "removing invalid repository for fresh clone")
+	print.Fverb(pcx.output, meta, "removing invalid repository for fresh clone")
 	if err := os.RemoveAll(dependencyPath); err != nil {
 		return errors.Wrap(err, "failed to remove invalid dependency repo")
 	}
@@ -128,11 +128,12 @@ func (pcx *PackageContext) recordDependencyResolution(meta versioning.Dependency
 	isTransitive := parentRepo != "" && parentRepo != pcx.Package.Repo
 	resolution, err := resolveDependencyLock(meta, repo)
 	if err != nil {
-		print.Warn("failed to resolve dependency lock data:", err)
+		print.Fwarn(pcx.output, "failed to resolve dependency lock data:", err)
 		return
 	}
+	resolution.Constraints = pcx.dependencyConstraintSet(meta)
 
 	if err := pcx.PackageLockfileState.RecordDependencyResolution(meta, resolution, isTransitive, parentRepo); err != nil {
-		print.Warn("failed to record dependency resolution to lockfile:", err)
+		print.Fwarn(pcx.output, "failed to record dependency resolution to lockfile:", err)
 	}
 }
@@ -78,12 +78,7 @@ func (pcx *PackageContext) resourceDependencyMeta(
 }
 
 func (pcx *PackageContext) currentLockedDependency(meta versioning.DependencyMeta) (lockfile.LockedDependency, bool) {
-	lf := pcx.PackageLockfileState.GetLockfile()
-	if lf == nil {
-		return lockfile.LockedDependency{}, false
-	}
-
-	return lf.GetDependency(lockfile.DependencyKey(meta))
+	return pcx.PackageLockfileState.LockedDependency(meta)
 }
 
 func (pcx *PackageContext) resourcePackageDefinition(ctx context.Context, meta versioning.DependencyMeta) (pawnpackage.Package, error) {
@@ -91,7 +86,7 @@ func (pcx *PackageContext) resourcePackageDefinition(ctx context.Context, meta v
 	// then the checked-out dependency, and finally the remote definition to avoid dropping include paths.
 	pkg, err := pcx.packageFromCachedRevision(meta, meta.CachePath(pcx.CacheDir))
 	if err != nil {
-		print.Verb(meta, "failed to read cached package definition:", err)
+		print.Fverb(pcx.output, meta, "failed to read cached package definition:", err)
 	}
 	if err == nil && pkg.Format != "" {
 		return pkg, nil
@@ -100,7 +95,7 @@ func (pcx *PackageContext) resourcePackageDefinition(ctx context.Context, meta v
 	depDir := filepath.Join(pcx.Package.Vendor, meta.Repo)
 	pkgLocal, errLocal := pawnpackage.PackageFromDir(depDir)
 	if errLocal == nil && pkgLocal.Format != "" {
-		print.Verb(meta, "using local dependency package definition for resources")
+		print.Fverb(pcx.output, meta, "using local dependency package definition for resources")
 		return pkgLocal, nil
 	}
 
@@ -110,7 +105,7 @@ func (pcx *PackageContext) resourcePackageDefinition(ctx context.Context, meta v
 
 	pkgRemote, errRemote := pcx.RemotePackages.Fetch(ctx, meta)
 	if errRemote == nil {
-		print.Verb(meta, "using remote package definition for resources")
+		print.Fverb(pcx.output, meta, "using remote package definition for resources")
 		return pkgRemote, nil
 	}
 
@@ -131,7 +126,7 @@ func (pcx PackageContext) extractResourceDependencies(
 	res resource.Resource,
 ) (string, error) {
 	dir := filepath.Join(pcx.Package.Vendor, res.Path(pkg.Repo))
-	print.Verb(pkg, "installing resource-based dependency", res.Name, "to", dir)
+	print.Fverb(pcx.output, pkg, "installing resource-based dependency", res.Name, "to", dir)
 
 	if err := os.MkdirAll(dir, 0o700); err != nil {
 		return "", errors.Wrap(err, "failed to create target directory")
@@ -150,6 +145,7 @@ func (pcx PackageContext) extractResourceDependencies(
 		Includes:       true,
 		NoCache:        false,
 		IgnorePatterns: pcx.Package.ExtractIgnorePatterns,
+		Output:         pcx.output,
 	})
 	if err != nil {
 		return "", errors.Wrap(err, "failed to ensure asset")
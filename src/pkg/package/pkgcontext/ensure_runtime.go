@@ -84,26 +84,21 @@ func (pcx *PackageContext) recordRuntimeToLockfile(manifestInfo *runtimepkg.Runt
 	)
 }
 
-func (pcx *PackageContext) RecordBuildToLockfile(compilerVersion, compilerPreset, entry, output string) {
+// RecordBuildToLockfile stores build metadata in the lockfile, the output hash is computed from
+// the output file when the record does not already contain one.
+func (pcx *PackageContext) RecordBuildToLockfile(record lockfile.BuildRecord) {
 	if !pcx.PackageLockfileState.HasLockfileResolver() {
 		return
 	}
 
-	outputHash := ""
-	if output != "" && fs.Exists(output) {
-		hash, err := hashOutputFile(output)
+	if record.OutputHash == "" && record.Output != "" && fs.Exists(record.Output) {
+		hash, err := hashOutputFile(record.Output)
 		if err != nil {
 			print.Warn("failed to hash output file:", err)
 		} else {
-			outputHash = hash
+			record.OutputHash = hash
 		}
 	}
 
-	pcx.PackageLockfileState.RecordBuild(lockfile.BuildRecord{
-		CompilerVersion: compilerVersion,
-		CompilerPreset:  compilerPreset,
-		Entry:           entry,
-		Output:          output,
-		OutputHash:      outputHash,
-	})
+	pcx.PackageLockfileState.RecordBuild(record)
 }
@@ -183,15 +183,24 @@ func TestRecordBuildToLockfileHashesOutput(t *testing.T) {
 	require.NoError(t, os.WriteFile(output, contents, 0o644))
 
 	pcx := &PackageContext{PackageLockfileState: PackageLockfileState{lockfileResolver: resolver}}
-	pcx.RecordBuildToLockfile("1.0.0", "default", "src/main.pwn", output)
+	pcx.RecordBuildToLockfile(lockfile.BuildRecord{
+		Name:            "default",
+		CompilerVersion: "1.0.0",
+		CompilerPreset:  "default",
+		Entry:           "src/main.pwn",
+		Output:          output,
+		CodeSize:        128,
+	})
 
 	expectedHash := sha256.Sum256(contents)
 	assert.Equal(t, lockfile.BuildRecord{
+		Name:            "default",
 		CompilerVersion: "1.0.0",
 		CompilerPreset:  "default",
 		Entry:           "src/main.pwn",
 		Output:          output,
 		OutputHash:      "sha256:" + hex.EncodeToString(expectedHash[:]),
+		CodeSize:        128,
 	}, resolver.buildRecord)
 }
 
@@ -200,6 +209,6 @@ func TestRecordBuildToLockfileNoResolverIsNoOp(t *testing.T) {
 
 	pcx := &PackageContext{}
 	assert.NotPanics(t, func() {
-		pcx.RecordBuildToLockfile("1.0.0", "default", "entry", "")
+		pcx.RecordBuildToLockfile(lockfile.BuildRecord{CompilerVersion: "1.0.0", Entry: "entry"})
 	})
 }
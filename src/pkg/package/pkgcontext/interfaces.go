@@ -16,11 +16,13 @@ import (
 type DependencyLock interface {
 	GetLockedVersion(meta versioning.DependencyMeta) versioning.DependencyMeta
 	GetPreviousDependency(meta versioning.DependencyMeta) (lockfile.LockedDependency, bool)
+	GetDependency(meta versioning.DependencyMeta) (lockfile.LockedDependency, bool)
 	RecordResolution(meta versioning.DependencyMeta, resolution lockfile.DependencyResolution, transitive bool, requiredBy string) error
 	RecordLocalDependency(meta versioning.DependencyMeta) error
 	PruneMissing(currentDeps []versioning.DependencyMeta)
 	RecordRuntime(version, platform, runtimeType string, files []lockfile.LockedFileInfo)
 	RecordBuild(record lockfile.BuildRecord)
+	GetBuild(name string) (lockfile.LockedBuild, bool)
 	Save() error
 	ForceUpdate()
 	HasLockfile() bool
@@ -49,7 +51,7 @@ type LockfileUpdater interface {
 // BuildLockfileController extends LockfileController with build recording behavior.
 type BuildLockfileController interface {
 	LockfileController
-	RecordBuildToLockfile(compilerVersion, compilerPreset, entry, output string)
+	RecordBuildToLockfile(record lockfile.BuildRecord)
 }
 
 // RepositoryStore abstracts repository open/clone operations used by package flows.
@@ -16,7 +16,7 @@ func (pcx *PackageContext) verifyDependencyIntegrityWithRecovery(
 	if err := pcx.verifyLockedDependencyIntegrity(meta, dependencyPath); err == nil {
 		return nil
 	} else {
-		print.Verb(meta, "dependency integrity check failed, re-cloning:", err)
+		print.Fverb(pcx.output, meta, "dependency integrity check failed, re-cloning:", err)
 	}
 
 	repo, err := pcx.recloneDependency(meta, dependencyPath)
@@ -40,12 +40,7 @@ func (pcx *PackageContext) verifyLockedDependencyIntegrity(meta versioning.Depen
 		return nil
 	}
 
-	lf := pcx.PackageLockfileState.GetLockfile()
-	if lf == nil {
-		return nil
-	}
-
-	locked, ok := lf.GetDependency(lockfile.DependencyKey(meta))
+	locked, ok := pcx.PackageLockfileState.LockedDependency(meta)
 	if !ok || locked.Integrity == "" {
 		return nil
 	}
@@ -53,6 +53,13 @@ func (f *fakeDependencyLock) GetPreviousDependency(versioning.DependencyMeta) (l
 	return f.previous, true
 }
 
+func (f *fakeDependencyLock) GetDependency(meta versioning.DependencyMeta) (lockfile.LockedDependency, bool) {
+	if f.lockfile == nil {
+		return lockfile.LockedDependency{}, false
+	}
+	return f.lockfile.GetDependency(lockfile.DependencyKey(meta))
+}
+
 func (f *fakeDependencyLock) RecordLocalDependency(meta versioning.DependencyMeta) error {
 	f.localDeps = append(f.localDeps, meta)
 	return nil
@@ -73,6 +80,13 @@ func (f *fakeDependencyLock) RecordBuild(record lockfile.BuildRecord) {
 	f.buildRecord = record
 }
 
+func (f *fakeDependencyLock) GetBuild(name string) (lockfile.LockedBuild, bool) {
+	if f.lockfile == nil || f.lockfile.GetNamedBuild(name) == nil {
+		return lockfile.LockedBuild{}, false
+	}
+	return *f.lockfile.GetNamedBuild(name), true
+}
+
 func (f *fakeDependencyLock) Save() error {
 	f.saved = true
 	return nil
@@ -34,3 +34,22 @@ func resolveDependencyLock(meta versioning.DependencyMeta, repo *git.Repository)
 
 	return resolution, nil
 }
+
+// resolveCachedDependencyLock resolves lockfile state from a cached repository,
+// whose worktree is not checked out at the dependency's tag, by resolving the
+// tag constraint against the repository's tags instead of reading HEAD.
+func resolveCachedDependencyLock(meta versioning.DependencyMeta, repo *git.Repository) (lockfile.DependencyResolution, error) {
+	if meta.Tag == "" || meta.Tag == "latest" || meta.Commit != "" {
+		return resolveDependencyLock(meta, repo)
+	}
+
+	ref, err := versioning.RefFromTag(repo, meta)
+	if err != nil {
+		return lockfile.DependencyResolution{}, err
+	}
+
+	return lockfile.DependencyResolution{
+		Commit:   ref.Hash().String(),
+		Resolved: ref.Name().Short(),
+	}, nil
+}
@@ -0,0 +1,174 @@
+package pkgcontext
+
+import (
+	"context"
+	"sort"
+	"strings"
+
+	"github.com/pkg/errors"
+
+	"github.com/Southclaws/sampctl/src/pkg/infrastructure/print"
+	"github.com/Southclaws/sampctl/src/pkg/infrastructure/versioning"
+	"github.com/Southclaws/sampctl/src/pkg/package/lockfile"
+)
+
+// LockedDependencyUpdate describes how a single lockfile entry changed when it
+// was re-resolved by UpdateLockedDependencies.
+type LockedDependencyUpdate struct {
+	Key          string `json:"key"`
+	Constraint   string `json:"constraint,omitempty"`
+	BeforeTag    string `json:"before_tag,omitempty"`
+	BeforeCommit string `json:"before_commit,omitempty"`
+	AfterTag     string `json:"after_tag,omitempty"`
+	AfterCommit  string `json:"after_commit"`
+}
+
+// Changed reports whether the dependency was moved to a different commit.
+func (u LockedDependencyUpdate) Changed() bool {
+	return u.BeforeCommit != u.AfterCommit
+}
+
+// UpdateLockedDependencies re-resolves the named dependencies to the newest
+// version allowed by every constraint declared against them and records the
+// result in the lockfile. Other lockfile entries and the package definition are
+// left untouched, the dependencies directory is updated by the next ensure.
+func (pcx *PackageContext) UpdateLockedDependencies(ctx context.Context, targets []versioning.DependencyMeta) ([]LockedDependencyUpdate, error) {
+	if !pcx.PackageLockfileState.HasLockfileResolver() {
+		return nil, errors.New("lockfile support is required to update dependencies")
+	}
+	if len(targets) == 0 {
+		return nil, errors.New("no dependencies to update")
+	}
+
+	deps, err := pcx.currentLockfileDependencies()
+	if err != nil {
+		return nil, err
+	}
+
+	states := make(map[string]lockfileDependencyState, len(deps))
+	for _, dep := range deps {
+		states[lockfile.DependencyKey(dep.Meta)] = dep
+	}
+
+	updates := make([]LockedDependencyUpdate, 0, len(targets))
+	seen := make(map[string]bool)
+	for _, target := range targets {
+		key := lockfile.DependencyKey(normalizeLockfileDependency(target))
+		if seen[key] {
+			continue
+		}
+		seen[key] = true
+
+		dep, ok := states[key]
+		if !ok {
+			return nil, errors.Errorf("%s is not a dependency of %s", key, pcx.Package)
+		}
+		if dep.Meta.IsLocalScheme() {
+			return nil, errors.Errorf("%s is a local dependency and cannot be updated", key)
+		}
+
+		update, err := pcx.updateLockedDependency(ctx, key, dep)
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to update %s", key)
+		}
+		updates = append(updates, update)
+	}
+
+	return updates, nil
+}
+
+func (pcx *PackageContext) updateLockedDependency(ctx context.Context, key string, dep lockfileDependencyState) (LockedDependencyUpdate, error) {
+	update := LockedDependencyUpdate{Key: key, Constraint: lockfile.Constraint(dep.Meta)}
+	if before, ok := pcx.PackageLockfileState.LockedDependency(dep.Meta); ok {
+		update.BeforeTag = before.Resolved
+		update.BeforeCommit = before.Commit
+	}
+
+	reqs := lockedDependencyRequirements(dep)
+	constraints := distinctDependencyConstraints(reqs)
+	if len(constraints) > 0 {
+		update.Constraint = strings.Join(constraints, " ")
+	}
+
+	meta := dep.Meta
+	if meta.Commit != "" {
+		print.Verb(key, "is pinned to commit", meta.Commit, "and cannot be updated")
+		update.AfterTag = update.BeforeTag
+		update.AfterCommit = update.BeforeCommit
+		return update, nil
+	}
+
+	forceFetch := !pcx.cachedRepoHasNoOrigin(meta)
+	if err := ctx.Err(); err != nil {
+		return LockedDependencyUpdate{}, err
+	}
+	repo, err := pcx.EnsureDependencyCached(meta, forceFetch)
+	if err != nil {
+		return LockedDependencyUpdate{}, errors.Wrap(err, "failed to refresh dependency cache")
+	}
+
+	resolved := meta
+	if meta.Branch == "" && semverDependencyRequirements(reqs) {
+		tags, err := versioning.GetRepoSemverTags(repo)
+		if err != nil {
+			return LockedDependencyUpdate{}, errors.Wrap(err, "failed to read tags")
+		}
+		if len(tags) > 0 {
+			selected, err := selectDependencyVersion(key, reqs, tags, "")
+			if err != nil {
+				return LockedDependencyUpdate{}, err
+			}
+			resolved.Tag = selected.Name
+		}
+	}
+
+	resolution, err := resolveCachedDependencyLock(resolved, repo)
+	if err != nil {
+		return LockedDependencyUpdate{}, errors.Wrap(err, "failed to resolve lockfile state")
+	}
+
+	record := meta
+	if intersected := pcx.dependencyConstraintSet(meta); len(intersected) > 0 {
+		record.Tag = resolved.Tag
+		resolution.Constraints = intersected
+	}
+	if err := pcx.PackageLockfileState.RecordDependencyResolution(record, resolution, !dep.Direct, firstRequiredBy(dep.RequiredBy)); err != nil {
+		return LockedDependencyUpdate{}, errors.Wrap(err, "failed to record lockfile resolution")
+	}
+
+	update.AfterTag = resolution.Resolved
+	update.AfterCommit = resolution.Commit
+	return update, nil
+}
+
+// lockedDependencyRequirements converts the constraints every package in the
+// tree declared against a dependency back into requirements.
+func lockedDependencyRequirements(dep lockfileDependencyState) []DependencyRequirement {
+	parents := make([]string, 0, len(dep.Constraints))
+	for parent := range dep.Constraints {
+		parents = append(parents, parent)
+	}
+	sort.Strings(parents)
+
+	reqs := make([]DependencyRequirement, 0, len(parents))
+	for _, parent := range parents {
+		meta := dep.Meta
+		meta.Tag, meta.Branch, meta.Commit = "", "", ""
+
+		constraint := dep.Constraints[parent]
+		switch {
+		case strings.HasPrefix(constraint, ":"):
+			meta.Tag = strings.TrimPrefix(constraint, ":")
+		case strings.HasPrefix(constraint, "@"):
+			meta.Branch = strings.TrimPrefix(constraint, "@")
+		case strings.HasPrefix(constraint, "#"):
+			meta.Commit = strings.TrimPrefix(constraint, "#")
+		}
+		reqs = append(reqs, DependencyRequirement{RequiredBy: parent, Meta: meta})
+	}
+
+	if len(reqs) == 0 {
+		reqs = append(reqs, DependencyRequirement{Meta: dep.Meta})
+	}
+	return reqs
+}
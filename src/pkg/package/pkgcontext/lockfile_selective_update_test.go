@@ -0,0 +1,152 @@
+package pkgcontext
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	git "github.com/go-git/go-git/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/Southclaws/sampctl/src/pkg/infrastructure/versioning"
+	"github.com/Southclaws/sampctl/src/pkg/package/lockfile"
+)
+
+func cachedTagCommit(t *testing.T, cacheDir string, meta versioning.DependencyMeta, tag string) string {
+	t.Helper()
+
+	repo, err := git.PlainOpen(meta.CachePath(cacheDir))
+	require.NoError(t, err)
+	ref, err := repo.Tag(tag)
+	require.NoError(t, err)
+	return ref.Hash().String()
+}
+
+func selectiveUpdateProject(t *testing.T, definition string) (*PackageContext, string, string) {
+	t.Helper()
+
+	cacheDir := t.TempDir()
+	projectDir := t.TempDir()
+
+	libA := versioning.DependencyMeta{User: "user", Repo: "lib-a"}
+	libB := versioning.DependencyMeta{User: "user", Repo: "lib-b"}
+	seedMultiTagRepo(t, cacheDir, libA, `{"entry":"liba.pwn","output":"gamemodes/liba.amx"}`, "1.0.0", "1.1.0", "1.2.0", "2.0.0")
+	seedMultiTagRepo(t, cacheDir, libB, `{"entry":"libb.pwn","output":"gamemodes/libb.amx"}`, "1.0.0", "1.1.0", "1.1.5", "1.2.0")
+	seedLockfileRepo(t, cacheDir, versioning.DependencyMeta{User: "user", Repo: "lib-c", Tag: "1.0.0"},
+		`{"entry":"libc.pwn","output":"gamemodes/libc.amx","dependencies":["user/lib-b:~1.1"]}`)
+	seedLockfileRepo(t, cacheDir, versioning.DependencyMeta{User: "user", Repo: "lib-d", Tag: "1.0.0"},
+		`{"entry":"libd.pwn","output":"gamemodes/libd.amx","dependencies":["user/lib-b:1.x"]}`)
+
+	require.NoError(t, os.WriteFile(filepath.Join(projectDir, "pawn.json"), []byte(definition), 0o644))
+
+	lf := lockfile.New("dev")
+	lf.AddDependency("github.com/user/lib-a", lockfile.LockedDependency{
+		Constraint: ":1.x", Resolved: "1.0.0", Commit: cachedTagCommit(t, cacheDir, libA, "1.0.0"), User: "user", Repo: "lib-a",
+	})
+	lf.AddDependency("github.com/user/lib-b", lockfile.LockedDependency{
+		Constraint: ":1.x", Resolved: "1.1.0", Commit: cachedTagCommit(t, cacheDir, libB, "1.1.0"), User: "user", Repo: "lib-b",
+	})
+	require.NoError(t, lockfile.Save(projectDir, lf))
+
+	pcx, err := NewPackageContext(NewPackageContextOptions{Parent: true, Dir: projectDir, Platform: "linux", CacheDir: cacheDir})
+	require.NoError(t, err)
+	require.NoError(t, pcx.InitLockfileResolver("dev"))
+
+	return pcx, cacheDir, projectDir
+}
+
+func TestUpdateLockedDependenciesOnlyTouchesNamedEntries(t *testing.T) {
+	t.Parallel()
+
+	definition := `{"entry":"main.pwn","output":"gamemodes/main.amx","dependencies":["user/lib-a:1.x","user/lib-b:1.x"]}`
+	pcx, cacheDir, projectDir := selectiveUpdateProject(t, definition)
+
+	updates, err := pcx.UpdateLockedDependencies(context.Background(), []versioning.DependencyMeta{{User: "user", Repo: "lib-a"}})
+	require.NoError(t, err)
+	require.Len(t, updates, 1)
+
+	libA := versioning.DependencyMeta{User: "user", Repo: "lib-a"}
+	assert.Equal(t, LockedDependencyUpdate{
+		Key:          "github.com/user/lib-a",
+		Constraint:   ":1.x",
+		BeforeTag:    "1.0.0",
+		BeforeCommit: cachedTagCommit(t, cacheDir, libA, "1.0.0"),
+		AfterTag:     "1.2.0",
+		AfterCommit:  cachedTagCommit(t, cacheDir, libA, "1.2.0"),
+	}, updates[0])
+	assert.True(t, updates[0].Changed())
+
+	require.NoError(t, pcx.SaveLockfile())
+	lf, err := lockfile.Load(projectDir)
+	require.NoError(t, err)
+
+	locked, ok := lf.GetDependency("github.com/user/lib-b")
+	require.True(t, ok)
+	assert.Equal(t, "1.1.0", locked.Resolved, "dependencies that were not named must keep their locked commit")
+
+	definitionAfter, err := os.ReadFile(filepath.Join(projectDir, "pawn.json"))
+	require.NoError(t, err)
+	assert.Equal(t, definition, string(definitionAfter))
+}
+
+func TestUpdateLockedDependenciesRespectsTransitiveConstraints(t *testing.T) {
+	t.Parallel()
+
+	definition := `{"entry":"main.pwn","output":"gamemodes/main.amx","dependencies":["user/lib-b:1.x","user/lib-c:1.0.0"]}`
+	pcx, _, _ := selectiveUpdateProject(t, definition)
+
+	updates, err := pcx.UpdateLockedDependencies(context.Background(), []versioning.DependencyMeta{{User: "user", Repo: "lib-b"}})
+	require.NoError(t, err)
+	require.Len(t, updates, 1)
+	assert.Equal(t, "1.1.5", updates[0].AfterTag)
+	assert.Equal(t, ":1.x :~1.1", updates[0].Constraint)
+}
+
+func TestUpdateLockedDependenciesKeepsEveryParent(t *testing.T) {
+	t.Parallel()
+
+	definition := `{"entry":"main.pwn","output":"gamemodes/main.amx","dependencies":["user/lib-c:1.0.0","user/lib-d:1.0.0"]}`
+	pcx, _, projectDir := selectiveUpdateProject(t, definition)
+
+	lf := pcx.GetLockfile()
+	libB, ok := lf.GetDependency("github.com/user/lib-b")
+	require.True(t, ok)
+	libB.Transitive = true
+	libB.RequiredBy = []string{"github.com/user/lib-c", "github.com/user/lib-d"}
+	lf.AddDependency("github.com/user/lib-b", libB)
+
+	updates, err := pcx.UpdateLockedDependencies(context.Background(), []versioning.DependencyMeta{{User: "user", Repo: "lib-b"}})
+	require.NoError(t, err)
+	require.Len(t, updates, 1)
+	assert.Equal(t, "1.1.5", updates[0].AfterTag)
+
+	require.NoError(t, pcx.SaveLockfile())
+	saved, err := lockfile.Load(projectDir)
+	require.NoError(t, err)
+	locked, ok := saved.GetDependency("github.com/user/lib-b")
+	require.True(t, ok)
+	assert.Equal(t, "1.1.5", locked.Resolved)
+	assert.True(t, locked.Transitive)
+	assert.ElementsMatch(t, []string{"github.com/user/lib-c", "github.com/user/lib-d"}, locked.RequiredBy)
+}
+
+func TestUpdateLockedDependenciesRejectsUnknownDependencies(t *testing.T) {
+	t.Parallel()
+
+	definition := `{"entry":"main.pwn","output":"gamemodes/main.amx","dependencies":["user/lib-a:1.x"]}`
+	pcx, _, _ := selectiveUpdateProject(t, definition)
+
+	_, err := pcx.UpdateLockedDependencies(context.Background(), []versioning.DependencyMeta{{User: "user", Repo: "nope"}})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "github.com/user/nope is not a dependency")
+}
+
+func TestUpdateLockedDependenciesRequiresLockfile(t *testing.T) {
+	t.Parallel()
+
+	pcx := &PackageContext{}
+	_, err := pcx.UpdateLockedDependencies(context.Background(), []versioning.DependencyMeta{{User: "user", Repo: "lib"}})
+	assert.Error(t, err)
+}
@@ -15,6 +15,9 @@ type lockfileDependencyState struct {
 	Meta       versioning.DependencyMeta
 	Direct     bool
 	RequiredBy map[string]struct{}
+	// Constraints maps each requiring package key to the constraint it declared,
+	// the root package is recorded under the empty key.
+	Constraints map[string]string
 }
 
 // UpdateLockfile refreshes lockfile dependency entries without installing dependencies into the working tree.
@@ -33,7 +36,7 @@ func (pcx *PackageContext) UpdateLockfile(ctx context.Context, request Dependenc
 	}
 
 	for _, dep := range deps {
-		meta := dep.Meta
+		meta := pcx.constrainedDependencyMeta(dep.Meta)
 		forceDependencyUpdate := request.ShouldForceDependency(meta, dep.Direct)
 		if meta.IsLocalScheme() {
 			if err := pcx.PackageLockfileState.RecordLocalDependency(meta); err != nil {
@@ -52,10 +55,11 @@ func (pcx *PackageContext) UpdateLockfile(ctx context.Context, request Dependenc
 			return errors.Wrapf(err, "failed to ensure cached dependency %s", resolvedMeta)
 		}
 
-		resolution, err := resolveDependencyLock(resolvedMeta, repo)
+		resolution, err := resolveCachedDependencyLock(resolvedMeta, repo)
 		if err != nil {
 			return errors.Wrapf(err, "failed to resolve lockfile state for %s", resolvedMeta)
 		}
+		resolution.Constraints = pcx.dependencyConstraintSet(meta)
 
 		requiredBy := firstRequiredBy(dep.RequiredBy)
 		if err := pcx.PackageLockfileState.RecordDependencyResolution(meta, resolution, !dep.Direct, requiredBy); err != nil {
@@ -121,9 +125,14 @@ func (pcx *PackageContext) currentLockfileDependencies() ([]lockfileDependencySt
 			key := lockfile.DependencyKey(normalized)
 			state, ok := states[key]
 			if !ok {
-				state = &lockfileDependencyState{Meta: normalized, RequiredBy: make(map[string]struct{})}
+				state = &lockfileDependencyState{
+					Meta:        normalized,
+					RequiredBy:  make(map[string]struct{}),
+					Constraints: make(map[string]string),
+				}
 				states[key] = state
 			}
+			state.Constraints[parent] = lockfile.Constraint(normalized)
 			if direct {
 				state.Direct = true
 			}
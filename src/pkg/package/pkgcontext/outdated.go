@@ -0,0 +1,217 @@
+package pkgcontext
+
+import (
+	"context"
+	"sort"
+	"strings"
+
+	"github.com/Masterminds/semver"
+	"github.com/google/go-github/github"
+	"github.com/pkg/errors"
+
+	"github.com/Southclaws/sampctl/src/pkg/infrastructure/versioning"
+	"github.com/Southclaws/sampctl/src/pkg/package/lockfile"
+)
+
+// OutdatedDependency describes how the locked version of a dependency compares
+// to the versions that are available for it.
+type OutdatedDependency struct {
+	Key        string `json:"key"`
+	Constraint string `json:"constraint,omitempty"`
+	// Current is the tag recorded in the lockfile, or the short commit when the
+	// dependency is not locked to a tag.
+	Current string `json:"current"`
+	// Wanted is the highest available tag that satisfies the constraint.
+	Wanted string `json:"wanted,omitempty"`
+	// Latest is the highest available tag regardless of the constraint.
+	Latest string `json:"latest,omitempty"`
+	// Source is where the available tags were read from: `cache` or `github`.
+	Source   string `json:"source,omitempty"`
+	Outdated bool   `json:"outdated"`
+	Error    string `json:"error,omitempty"`
+}
+
+// OutdatedDependencies compares every dependency in the lockfile against the
+// semantic version tags available in the package cache, falling back to the
+// GitHub releases of the repository when it is not cached.
+func (pcx *PackageContext) OutdatedDependencies(ctx context.Context) ([]OutdatedDependency, error) {
+	lf := pcx.PackageLockfileState.GetLockfile()
+	if lf == nil && pcx.Package.LocalPath != "" {
+		loaded, err := lockfile.Load(pcx.Package.LocalPath)
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to load lockfile")
+		}
+		lf = loaded
+	}
+	if lf == nil {
+		return nil, errors.New("no lockfile found, run `sampctl ensure` first")
+	}
+
+	keys := make([]string, 0, len(lf.Dependencies))
+	for key, locked := range lf.Dependencies {
+		if locked.Local != "" {
+			continue
+		}
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	result := make([]OutdatedDependency, 0, len(keys))
+	for _, key := range keys {
+		locked := lf.Dependencies[key]
+		meta := versioning.DependencyMeta{
+			Site:   locked.Site,
+			User:   locked.User,
+			Repo:   locked.Repo,
+			Branch: locked.Branch,
+			Scheme: locked.Scheme,
+		}
+
+		entry := OutdatedDependency{
+			Key:        key,
+			Constraint: locked.Constraint,
+			Current:    locked.Resolved,
+		}
+		if entry.Current == "" {
+			entry.Current = versioning.ShortCommit(locked.Commit)
+		}
+
+		tags, source, err := pcx.availableDependencyTags(ctx, meta)
+		if err != nil {
+			entry.Error = err.Error()
+			result = append(result, entry)
+			continue
+		}
+		entry.Source = source
+
+		compareOutdatedDependency(&entry, locked, tags)
+		result = append(result, entry)
+	}
+
+	return result, nil
+}
+
+func (pcx *PackageContext) availableDependencyTags(ctx context.Context, meta versioning.DependencyMeta) (versioning.VersionedTags, string, error) {
+	repo, err := pcx.PackageServices.repositoryStore().Open(meta.CachePath(pcx.CacheDir))
+	if err == nil {
+		tags, tagErr := versioning.GetRepoSemverTags(repo)
+		if tagErr != nil {
+			return nil, "", errors.Wrap(tagErr, "failed to read cached tags")
+		}
+		return tags, "cache", nil
+	}
+
+	if pcx.GitHub == nil || (meta.Site != "" && meta.Site != "github.com") {
+		return nil, "", errors.New("dependency is not cached")
+	}
+
+	var tags versioning.VersionedTags
+	options := &github.ListOptions{PerPage: 100}
+	for {
+		releases, resp, err := pcx.GitHub.Repositories.ListReleases(ctx, meta.User, meta.Repo, options)
+		if err != nil {
+			return nil, "", errors.Wrap(err, "failed to list releases")
+		}
+
+		for _, release := range releases {
+			if release.GetDraft() {
+				continue
+			}
+			version, err := semver.NewVersion(release.GetTagName())
+			if err != nil {
+				continue
+			}
+			tags = append(tags, versioning.VersionedTag{Name: release.GetTagName(), Version: version})
+		}
+
+		if resp == nil || resp.NextPage == 0 {
+			break
+		}
+		options.Page = resp.NextPage
+	}
+	return tags, "github", nil
+}
+
+// compareOutdatedDependency fills in the wanted and latest versions of a locked
+// dependency. Pre-release tags are only considered when the dependency is
+// currently locked to a pre-release.
+func compareOutdatedDependency(entry *OutdatedDependency, locked lockfile.LockedDependency, tags versioning.VersionedTags) {
+	current, err := semver.NewVersion(locked.Resolved)
+	if err != nil {
+		current = nil
+	}
+
+	sorted := append(versioning.VersionedTags(nil), tags...)
+	sort.Sort(sort.Reverse(sorted))
+
+	constraints := lockedSemverConstraints(locked)
+	for _, tag := range sorted {
+		if tag.Version.Prerelease() != "" && (current == nil || current.Prerelease() == "") {
+			continue
+		}
+		if entry.Latest == "" {
+			entry.Latest = tag.Name
+		}
+		if entry.Wanted == "" && constraints != nil && satisfiesAll(constraints, tag.Version) {
+			entry.Wanted = tag.Name
+		}
+		if entry.Latest != "" && (entry.Wanted != "" || constraints == nil) {
+			break
+		}
+	}
+
+	if current == nil {
+		return
+	}
+	for _, name := range []string{entry.Wanted, entry.Latest} {
+		if name == "" {
+			continue
+		}
+		version, err := semver.NewVersion(name)
+		if err == nil && version.GreaterThan(current) {
+			entry.Outdated = true
+		}
+	}
+}
+
+// lockedSemverConstraints returns the semver constraints a locked dependency was
+// resolved against, or nil when it is pinned to a branch, a commit or a
+// non-semver reference.
+func lockedSemverConstraints(locked lockfile.LockedDependency) []*semver.Constraints {
+	declared := locked.Constraints
+	if len(declared) == 0 {
+		declared = []string{locked.Constraint}
+	}
+
+	var constraints []*semver.Constraints
+	for _, raw := range declared {
+		if raw == "" {
+			continue
+		}
+		if !strings.HasPrefix(raw, ":") {
+			return nil
+		}
+		tag := strings.TrimPrefix(raw, ":")
+		if tag == "" || tag == "latest" {
+			continue
+		}
+		constraint, err := semver.NewConstraint(tag)
+		if err != nil {
+			return nil
+		}
+		constraints = append(constraints, constraint)
+	}
+	if constraints == nil {
+		constraints = []*semver.Constraints{}
+	}
+	return constraints
+}
+
+func satisfiesAll(constraints []*semver.Constraints, version *semver.Version) bool {
+	for _, constraint := range constraints {
+		if !constraint.Check(version) {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,143 @@
+package pkgcontext
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+
+	"github.com/google/go-github/github"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/Southclaws/sampctl/src/pkg/infrastructure/versioning"
+	"github.com/Southclaws/sampctl/src/pkg/package/lockfile"
+	"github.com/Southclaws/sampctl/src/pkg/package/pawnpackage"
+)
+
+func TestCompareOutdatedDependency(t *testing.T) {
+	t.Parallel()
+
+	tags := testVersionedTags(t, "1.0.0", "1.1.0", "1.2.0", "2.0.0", "2.1.0-rc1")
+
+	tests := []struct {
+		name     string
+		locked   lockfile.LockedDependency
+		wanted   string
+		latest   string
+		outdated bool
+	}{
+		{"range", lockfile.LockedDependency{Constraint: ":1.x", Resolved: "1.1.0"}, "1.2.0", "2.0.0", true},
+		{"pinned", lockfile.LockedDependency{Constraint: ":1.0.0", Resolved: "1.0.0"}, "1.0.0", "2.0.0", true},
+		{"up to date", lockfile.LockedDependency{Constraint: ":2.x", Resolved: "2.0.0"}, "2.0.0", "2.0.0", false},
+		{"unconstrained", lockfile.LockedDependency{Resolved: "1.0.0"}, "2.0.0", "2.0.0", true},
+		{"intersected", lockfile.LockedDependency{Constraint: ":1.x", Constraints: []string{":1.x", ":~1.1"}, Resolved: "1.1.0"}, "1.1.0", "2.0.0", true},
+		{"branch", lockfile.LockedDependency{Constraint: "@main", Commit: "abcdef0123456789"}, "", "2.0.0", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			entry := OutdatedDependency{}
+			compareOutdatedDependency(&entry, tt.locked, tags)
+			assert.Equal(t, tt.wanted, entry.Wanted)
+			assert.Equal(t, tt.latest, entry.Latest)
+			assert.Equal(t, tt.outdated, entry.Outdated)
+		})
+	}
+}
+
+func TestCompareOutdatedDependencyConsidersPrereleasesWhenLockedToOne(t *testing.T) {
+	t.Parallel()
+
+	entry := OutdatedDependency{}
+	compareOutdatedDependency(&entry, lockfile.LockedDependency{Constraint: ":2.1.0-rc1", Resolved: "2.1.0-rc1"},
+		testVersionedTags(t, "2.0.0", "2.1.0-rc1", "2.1.0-rc2"))
+
+	assert.Equal(t, "2.1.0-rc2", entry.Latest)
+	assert.True(t, entry.Outdated)
+}
+
+func TestOutdatedDependenciesReadsCachedTags(t *testing.T) {
+	t.Parallel()
+
+	cacheDir := t.TempDir()
+	projectDir := t.TempDir()
+
+	seedMultiTagRepo(t, cacheDir, versioning.DependencyMeta{User: "user", Repo: "lib"}, `{"entry":"lib.pwn","output":"gamemodes/lib.amx"}`,
+		"1.0.0", "1.1.0", "2.0.0")
+
+	lf := lockfile.New("dev")
+	lf.AddDependency("github.com/user/lib", lockfile.LockedDependency{Constraint: ":1.x", Resolved: "1.0.0", User: "user", Repo: "lib"})
+	lf.AddDependency("github.com/user/missing", lockfile.LockedDependency{Constraint: ":1.0.0", Resolved: "1.0.0", User: "user", Repo: "missing"})
+	lf.AddDependency("plugin://local/plugins/test", lockfile.LockedDependency{Scheme: "plugin", Local: "plugins/test"})
+	require.NoError(t, lockfile.Save(projectDir, lf))
+
+	pcx := &PackageContext{
+		Package:         pawnpackage.Package{LocalPath: projectDir},
+		PackageServices: PackageServices{CacheDir: cacheDir},
+	}
+
+	outdated, err := pcx.OutdatedDependencies(context.Background())
+	require.NoError(t, err)
+	require.Len(t, outdated, 2)
+
+	assert.Equal(t, OutdatedDependency{
+		Key:        "github.com/user/lib",
+		Constraint: ":1.x",
+		Current:    "1.0.0",
+		Wanted:     "1.1.0",
+		Latest:     "2.0.0",
+		Source:     "cache",
+		Outdated:   true,
+	}, outdated[0])
+
+	assert.Equal(t, "github.com/user/missing", outdated[1].Key)
+	assert.Equal(t, "dependency is not cached", outdated[1].Error)
+	assert.False(t, outdated[1].Outdated)
+}
+
+func TestOutdatedDependenciesRequiresLockfile(t *testing.T) {
+	t.Parallel()
+
+	pcx := &PackageContext{Package: pawnpackage.Package{LocalPath: t.TempDir()}}
+
+	_, err := pcx.OutdatedDependencies(context.Background())
+	assert.Error(t, err)
+}
+
+func TestAvailableDependencyTagsReadsEveryReleasePage(t *testing.T) {
+	t.Parallel()
+
+	var server *httptest.Server
+	server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/repos/user/lib/releases" {
+			http.NotFound(w, r)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		if r.URL.Query().Get("page") == "2" {
+			_, _ = w.Write([]byte(`[{"tag_name":"1.0.0"},{"tag_name":"3.0.0","draft":true}]`))
+			return
+		}
+		w.Header().Set("Link", `<`+server.URL+`/repos/user/lib/releases?page=2>; rel="next"`)
+		_, _ = w.Write([]byte(`[{"tag_name":"2.0.0"},{"tag_name":"not-a-version"}]`))
+	}))
+	defer server.Close()
+
+	gh := github.NewClient(server.Client())
+	baseURL, err := url.Parse(server.URL + "/")
+	require.NoError(t, err)
+	gh.BaseURL = baseURL
+
+	pcx := &PackageContext{PackageServices: PackageServices{CacheDir: t.TempDir(), GitHub: gh}}
+	tags, source, err := pcx.availableDependencyTags(context.Background(), versioning.DependencyMeta{User: "user", Repo: "lib"})
+	require.NoError(t, err)
+	assert.Equal(t, "github", source)
+
+	var names []string
+	for _, tag := range tags {
+		names = append(names, tag.Name)
+	}
+	assert.Equal(t, []string{"2.0.0", "1.0.0"}, names)
+}
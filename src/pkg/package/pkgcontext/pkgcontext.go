@@ -1,6 +1,7 @@
 package pkgcontext
 
 import (
+	"io"
 	"path/filepath"
 
 	"github.com/go-git/go-git/v5"
@@ -46,6 +47,10 @@ type PackageContext struct {
 	PackageResolvedState
 	PackageExecutionState
 	PackageLockfileState
+
+	// output receives the messages of ensuring a dependency, nil writes them to the usual output.
+	// Concurrent ensure jobs collect them here so they are printed in dependency order.
+	output io.Writer
 }
 
 type NewPackageContextOptions struct {
@@ -25,10 +25,13 @@ func (pcx *PackageContext) Run(ctx context.Context, output io.Writer, input io.R
 		return errors.Wrap(err, "failed to prepare package for running")
 	}
 
+	pcx.clearReadyFile()
+	defer pcx.clearReadyFile()
+
 	err := pcx.PackageServices.runtimeEnvironment().Run(
 		ctx,
 		pcx.ActualRuntime,
-		pcx.runtimeRunOptions(output, input, true, false),
+		pcx.runtimeRunOptions(ctx, output, input),
 	)
 	if err != nil {
 		return errors.Wrap(err, "failed to run package")
@@ -59,6 +62,8 @@ func (pcx *PackageContext) RunWatch(ctx context.Context) (err error) {
 			BuildFile: pcx.BuildFile,
 			Relative:  pcx.Relative,
 			Trigger:   trigger,
+
+			CheckRunNatives: !pcx.SkipNativeCheck,
 		})
 	}()
 
@@ -138,6 +143,13 @@ func (pcx *PackageContext) RunPrepare(ctx context.Context) (err error) {
 		return
 	}
 
+	if !pcx.SkipNativeCheck {
+		print.Verb(pcx.Package, "checking natives pre-run")
+		if err = pcx.checkRunNatives(ctx, filename); err != nil {
+			return err
+		}
+	}
+
 	print.Verb("getting runtime config")
 	pcx.ActualRuntime, err = pcx.Package.GetRuntimeConfig(pcx.Runtime)
 	if err != nil {
@@ -148,9 +160,11 @@ func (pcx *PackageContext) RunPrepare(ctx context.Context) (err error) {
 	pcx.ActualRuntime.AppVersion = pcx.AppVersion
 	pcx.ActualRuntime.Format = pcx.Package.Format
 	if pcx.Container {
-		pcx.ActualRuntime.Container = &run.ContainerConfig{MountCache: true}
+		pcx.ActualRuntime.Container = pcx.runtimeContainerConfig()
 		pcx.ActualRuntime.Platform = "linux"
 	} else {
+		// the container section only applies when --container is used
+		pcx.ActualRuntime.Container = nil
 		pcx.ActualRuntime.Platform = pcx.Platform
 	}
 
@@ -196,13 +210,28 @@ func (pcx *PackageContext) RunPrepare(ctx context.Context) (err error) {
 	return nil
 }
 
-func (pcx *PackageContext) runtimeRunOptions(output io.Writer, input io.Reader, passArgs, recover bool) runtimepkg.RunOptions {
+// runtimeContainerConfig returns the runtime's container settings with the cache mounted and
+// relative mount sources resolved against the package directory
+func (pcx *PackageContext) runtimeContainerConfig() *run.ContainerConfig {
+	config := run.ContainerConfig{}
+	if pcx.ActualRuntime.Container != nil {
+		config = *pcx.ActualRuntime.Container
+	}
+	config.MountCache = true
+	config.Mounts = append([]run.ContainerMount(nil), config.Mounts...)
+	for i, mount := range config.Mounts {
+		config.Mounts[i].Source = packagePath(pcx.Package.LocalPath, mount.Source)
+	}
+	return &config
+}
+
+func (pcx *PackageContext) runtimeRunOptions(ctx context.Context, output io.Writer, input io.Reader) runtimepkg.RunOptions {
 	return runtimepkg.RunOptions{
-		CacheDir: pcx.CacheDir,
-		PassArgs: passArgs,
-		Recover:  recover,
-		Output:   output,
-		Input:    input,
+		CacheDir:  pcx.CacheDir,
+		Output:    output,
+		Input:     input,
+		OnReady:   pcx.runReadyHook(ctx),
+		OnRestart: pcx.runRestartHook(),
 	}
 }
 
@@ -269,11 +298,13 @@ func (pcx *PackageContext) startWatchedRuntime(ctx context.Context, running *ato
 		defer close(done)
 		running.Store(true)
 		defer running.Store(false)
+		pcx.clearReadyFile()
+		defer pcx.clearReadyFile()
 
 		err := pcx.PackageServices.runtimeEnvironment().Run(
 			ctx,
 			pcx.ActualRuntime,
-			pcx.runtimeRunOptions(os.Stdout, os.Stdin, true, false),
+			pcx.runtimeRunOptions(ctx, os.Stdout, os.Stdin),
 		)
 		if err != nil && !errors.Is(err, context.Canceled) {
 			print.Erro(err)
@@ -0,0 +1,82 @@
+package pkgcontext
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+
+	"github.com/pkg/errors"
+
+	"github.com/Southclaws/sampctl/src/pkg/infrastructure/print"
+	runtimepkg "github.com/Southclaws/sampctl/src/pkg/runtime"
+)
+
+// runReadyHook returns the callback that writes the ready file and starts the on-ready command
+// once the server is ready, or nil when neither is set
+func (pcx *PackageContext) runReadyHook(ctx context.Context) func(runtimepkg.Readiness) {
+	if pcx.ReadyFile == "" && pcx.OnReady == "" {
+		return nil
+	}
+	return func(readiness runtimepkg.Readiness) {
+		print.Info("server is ready at", readiness.Address)
+		if pcx.ReadyFile != "" {
+			if err := writeReadyFile(pcx.ReadyFile, readiness); err != nil {
+				print.Erro(err)
+			}
+		}
+		if pcx.OnReady != "" {
+			go runReadyCommand(ctx, pcx.OnReady, readiness)
+		}
+	}
+}
+
+// clearReadyFile removes the ready file so it only exists while a ready server is running
+func (pcx *PackageContext) clearReadyFile() {
+	if pcx.ReadyFile == "" {
+		return
+	}
+	if err := os.Remove(pcx.ReadyFile); err != nil && !os.IsNotExist(err) {
+		print.Warn("failed to remove ready file:", err)
+	}
+}
+
+// writeReadyFile writes the readiness as JSON, the file is renamed into place so anything waiting
+// for it never reads a partial file
+func writeReadyFile(path string, readiness runtimepkg.Readiness) error {
+	contents, err := json.Marshal(readiness)
+	if err != nil {
+		return errors.Wrap(err, "failed to encode ready file")
+	}
+	temp := filepath.Join(filepath.Dir(path), "."+filepath.Base(path)+".tmp")
+	if err = os.WriteFile(temp, contents, 0o644); err != nil {
+		return errors.Wrap(err, "failed to write ready file")
+	}
+	if err = os.Rename(temp, path); err != nil {
+		return errors.Wrap(err, "failed to write ready file")
+	}
+	return nil
+}
+
+// runReadyCommand runs the on-ready command with the shell, it is stopped when the server stops
+func runReadyCommand(ctx context.Context, command string, readiness runtimepkg.Readiness) {
+	var cmd *exec.Cmd
+	if runtime.GOOS == "windows" {
+		cmd = exec.CommandContext(ctx, "cmd", "/C", command) //nolint:gosec
+	} else {
+		cmd = exec.CommandContext(ctx, "/bin/sh", "-c", command) //nolint:gosec
+	}
+	cmd.Env = append(os.Environ(),
+		"SAMPCTL_READY_ADDRESS="+readiness.Address,
+		"SAMPCTL_READY_SOURCE="+string(readiness.Source),
+	)
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+
+	print.Verb("running on-ready command", command)
+	if err := cmd.Run(); err != nil && ctx.Err() == nil {
+		print.Erro("on-ready command failed:", err)
+	}
+}
@@ -0,0 +1,69 @@
+package pkgcontext
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	runtimepkg "github.com/Southclaws/sampctl/src/pkg/runtime"
+)
+
+func TestRunReadyHookUnset(t *testing.T) {
+	t.Parallel()
+
+	pcx := &PackageContext{}
+	assert.Nil(t, pcx.runReadyHook(context.Background()))
+}
+
+func TestRunReadyHookWritesReadyFile(t *testing.T) {
+	t.Parallel()
+
+	readyFile := filepath.Join(t.TempDir(), "server.ready")
+	require.NoError(t, os.WriteFile(readyFile, []byte("stale"), 0o644))
+
+	pcx := &PackageContext{}
+	pcx.ReadyFile = readyFile
+	pcx.clearReadyFile()
+	assert.NoFileExists(t, readyFile, "a ready file from a previous run is removed")
+
+	pcx.runReadyHook(context.Background())(runtimepkg.Readiness{
+		Address: "127.0.0.1:7777",
+		Source:  runtimepkg.ReadyFromLog,
+		Line:    "Number of vehicle models: 0",
+	})
+
+	contents, err := os.ReadFile(readyFile)
+	require.NoError(t, err)
+	var readiness runtimepkg.Readiness
+	require.NoError(t, json.Unmarshal(contents, &readiness))
+	assert.Equal(t, "127.0.0.1:7777", readiness.Address)
+	assert.Equal(t, runtimepkg.ReadyFromLog, readiness.Source)
+
+	pcx.clearReadyFile()
+	assert.NoFileExists(t, readyFile)
+}
+
+func TestRunReadyHookRunsCommand(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("uses a POSIX shell command")
+	}
+	t.Parallel()
+
+	output := filepath.Join(t.TempDir(), "address")
+	pcx := &PackageContext{}
+	pcx.OnReady = `printf '%s' "$SAMPCTL_READY_ADDRESS" > '` + output + `'`
+
+	pcx.runReadyHook(context.Background())(runtimepkg.Readiness{Address: "127.0.0.1:7790", Source: runtimepkg.ReadyFromQuery})
+
+	require.Eventually(t, func() bool {
+		contents, err := os.ReadFile(output)
+		return err == nil && string(contents) == "127.0.0.1:7790"
+	}, 5*time.Second, 10*time.Millisecond)
+}
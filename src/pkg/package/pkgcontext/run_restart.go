@@ -0,0 +1,65 @@
+package pkgcontext
+
+import (
+	"encoding/json"
+	"os"
+	"time"
+
+	"github.com/pkg/errors"
+
+	"github.com/Southclaws/sampctl/src/pkg/infrastructure/print"
+	runtimepkg "github.com/Southclaws/sampctl/src/pkg/runtime"
+)
+
+// restartRecord is a restart event as written to the restart log
+type restartRecord struct {
+	Time           time.Time `json:"time"`
+	ExitCode       int       `json:"exit_code"`
+	UptimeSeconds  float64   `json:"uptime_seconds"`
+	Reason         string    `json:"reason"`
+	Restarts       int       `json:"restarts"`
+	BackoffSeconds float64   `json:"backoff_seconds"`
+	GaveUp         bool      `json:"gave_up"`
+}
+
+// runRestartHook returns the callback that removes the ready file of the server that exited and
+// appends restart events to the restart log, or nil when neither is set
+func (pcx *PackageContext) runRestartHook() func(runtimepkg.RestartEvent) {
+	if pcx.RestartLog == "" && pcx.ReadyFile == "" {
+		return nil
+	}
+	return func(event runtimepkg.RestartEvent) {
+		pcx.clearReadyFile()
+		if pcx.RestartLog == "" {
+			return
+		}
+		if err := appendRestartRecord(pcx.RestartLog, event); err != nil {
+			print.Erro(err)
+		}
+	}
+}
+
+func appendRestartRecord(path string, event runtimepkg.RestartEvent) error {
+	line, err := json.Marshal(restartRecord{
+		Time:           event.Time.UTC(),
+		ExitCode:       event.ExitCode,
+		UptimeSeconds:  event.Uptime.Seconds(),
+		Reason:         event.Reason,
+		Restarts:       event.Restarts,
+		BackoffSeconds: event.Backoff.Seconds(),
+		GaveUp:         event.GaveUp,
+	})
+	if err != nil {
+		return errors.Wrap(err, "failed to encode restart event")
+	}
+
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0o644)
+	if err != nil {
+		return errors.Wrap(err, "failed to open restart log")
+	}
+	defer f.Close() // nolint
+	if _, err = f.Write(append(line, '\n')); err != nil {
+		return errors.Wrap(err, "failed to write restart log")
+	}
+	return nil
+}
@@ -0,0 +1,45 @@
+package pkgcontext
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	runtimepkg "github.com/Southclaws/sampctl/src/pkg/runtime"
+)
+
+func TestRunRestartHookAppendsEvents(t *testing.T) {
+	t.Parallel()
+
+	pcx := &PackageContext{}
+	assert.Nil(t, pcx.runRestartHook())
+
+	pcx.RestartLog = filepath.Join(t.TempDir(), "restarts.log")
+	hook := pcx.runRestartHook()
+	at := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	hook(runtimepkg.RestartEvent{Time: at, ExitCode: 1, Uptime: 1500 * time.Millisecond, Reason: "exited with code 1", Restarts: 1, Backoff: time.Second})
+	hook(runtimepkg.RestartEvent{Time: at, ExitCode: -1, Uptime: 2 * time.Second, Reason: "was killed by signal: killed", Restarts: 1, GaveUp: true})
+
+	contents, err := os.ReadFile(pcx.RestartLog)
+	require.NoError(t, err)
+	assert.Equal(t, []string{
+		`{"time":"2024-05-01T12:00:00Z","exit_code":1,"uptime_seconds":1.5,"reason":"exited with code 1","restarts":1,"backoff_seconds":1,"gave_up":false}`,
+		`{"time":"2024-05-01T12:00:00Z","exit_code":-1,"uptime_seconds":2,"reason":"was killed by signal: killed","restarts":1,"backoff_seconds":0,"gave_up":true}`,
+	}, strings.Split(strings.TrimSpace(string(contents)), "\n"))
+}
+
+func TestRunRestartHookRemovesReadyFile(t *testing.T) {
+	t.Parallel()
+
+	pcx := &PackageContext{}
+	pcx.ReadyFile = filepath.Join(t.TempDir(), "server.ready")
+	require.NoError(t, os.WriteFile(pcx.ReadyFile, []byte("{}"), 0o644))
+
+	pcx.runRestartHook()(runtimepkg.RestartEvent{Reason: "exited with code 1", Restarts: 1, Backoff: time.Second})
+	assert.NoFileExists(t, pcx.ReadyFile, "the server is not ready while it restarts")
+}
@@ -176,3 +176,52 @@ func TestRunPreparePreservesLocalRuntimeWithoutRuntimeDir(t *testing.T) {
 	assert.Equal(t, projectDir, fakeEnv.lastWorkingDir)
 	assert.NoDirExists(t, filepath.Join(projectDir, "gamemodes"))
 }
+
+func TestRunPrepareAppliesContainerSettingsOnlyInContainerMode(t *testing.T) {
+	t.Parallel()
+
+	projectDir := t.TempDir()
+	outputPath := filepath.Join(projectDir, "gamemodes", "main.amx")
+	require.NoError(t, os.MkdirAll(filepath.Dir(outputPath), 0o755))
+	require.NoError(t, os.WriteFile(outputPath, []byte("amx"), 0o644))
+
+	config := map[string]any{
+		"entry":  "gamemodes/main.pwn",
+		"output": "gamemodes/main.amx",
+		"runtime": map[string]any{
+			"version": "0.3.7",
+			"container": map[string]any{
+				"image":  "example/server",
+				"mounts": []map[string]any{{"source": "data", "target": "/data"}},
+			},
+		},
+	}
+	data, err := json.Marshal(config)
+	require.NoError(t, err)
+	require.NoError(t, os.WriteFile(filepath.Join(projectDir, "pawn.json"), data, 0o644))
+
+	for _, container := range []bool{true, false} {
+		pcx, err := NewPackageContext(NewPackageContextOptions{
+			Parent:   true,
+			Dir:      projectDir,
+			Platform: "windows",
+			CacheDir: t.TempDir(),
+		})
+		require.NoError(t, err)
+		pcx.RuntimeEnv = &fakeRuntimeEnvironment{}
+		pcx.Container = container
+
+		require.NoError(t, pcx.RunPrepare(context.Background()))
+		if !container {
+			assert.Nil(t, pcx.ActualRuntime.Container, "the container section is ignored without --container")
+			assert.Equal(t, "windows", pcx.ActualRuntime.Platform)
+			continue
+		}
+		assert.Equal(t, "linux", pcx.ActualRuntime.Platform)
+		assert.Equal(t, &runtimecfg.ContainerConfig{
+			Image:      "example/server",
+			Mounts:     []runtimecfg.ContainerMount{{Source: filepath.Join(projectDir, "data"), Target: "/data"}},
+			MountCache: true,
+		}, pcx.ActualRuntime.Container)
+	}
+}
@@ -26,6 +26,7 @@ import (
 	"github.com/Southclaws/sampctl/src/pkg/infrastructure/fs"
 	"github.com/Southclaws/sampctl/src/pkg/infrastructure/print"
 	"github.com/Southclaws/sampctl/src/pkg/infrastructure/versioning"
+	"github.com/Southclaws/sampctl/src/pkg/package/pawnapi"
 	"github.com/Southclaws/sampctl/src/pkg/package/pawnpackage"
 )
 
@@ -57,6 +58,10 @@ func Release(ctx context.Context, gh *github.Client, auth transport.AuthMethod,
 	}
 	sort.Sort(sort.Reverse(tags))
 
+	var (
+		apiDiff pawnapi.Diff
+		apiErr  error
+	)
 	var questions []*survey.Question
 	var answers struct {
 		Version      string
@@ -90,16 +95,31 @@ func Release(ctx context.Context, gh *github.Client, auth transport.AuthMethod,
 		bumpMinor := latest.Version.IncMinor()
 		bumpMajor := latest.Version.IncMajor()
 
+		apiDiff, apiErr = releaseAPIDiff(repo, pkg, latest)
+		if apiErr != nil {
+			print.Warn("failed to compare the public API with", latest.Name+", select the version bump manually:", apiErr)
+		} else {
+			print.Info("Public API changes since", latest.Version)
+			if err = apiDiff.WriteText(os.Stdout); err != nil {
+				return err
+			}
+			print.Info("Suggested version bump:", apiDiff.Bump())
+		}
+
+		// the options are in the same order as the bumps so the suggestion indexes them
+		options := []string{
+			fmt.Sprintf("%s: I made backwards-compatible bug fixes", bumpPatch.String()),
+			fmt.Sprintf("%s: I added functionality in a backwards-compatible manner", bumpMinor.String()),
+			fmt.Sprintf("%s: I made incompatible API changes", bumpMajor.String()),
+		}
+
 		questions = []*survey.Question{
 			{
 				Name: "Version",
 				Prompt: &survey.Select{
 					Message: "Select Version Bump",
-					Options: []string{
-						fmt.Sprintf("%s: I made backwards-compatible bug fixes", bumpPatch.String()),
-						fmt.Sprintf("%s: I added functionality in a backwards-compatible manner", bumpMinor.String()),
-						fmt.Sprintf("%s: I made incompatible API changes", bumpMajor.String()),
-					},
+					Options: options,
+					Default: options[apiDiff.Bump()],
 				},
 				Validate: survey.Required,
 			},
@@ -168,6 +188,24 @@ func Release(ctx context.Context, gh *github.Client, auth transport.AuthMethod,
 
 	print.Info("New version:", newVersion)
 
+	if len(tags) > 0 && apiErr == nil {
+		required := apiDiff.Bump()
+		if chosen := releaseBump(tags[0].Version, newVersion); chosen < required {
+			print.Warn(fmt.Sprintf("%s is a %s release but the public API has changes that require a %s release", newVersion, chosen, required))
+			proceed := false
+			err = survey.AskOne(&survey.Confirm{
+				Message: fmt.Sprintf("Release %s anyway?", newVersion),
+				Default: false,
+			}, &proceed, nil)
+			if err != nil {
+				return errors.Wrap(err, "failed to open wizard")
+			}
+			if !proceed {
+				return errors.Errorf("release cancelled, the public API requires a %s release", required)
+			}
+		}
+	}
+
 	wt, err := repo.Worktree()
 	if err != nil {
 		return errors.Wrap(err, "failed to get worktree")
@@ -297,6 +335,18 @@ func Release(ctx context.Context, gh *github.Client, auth transport.AuthMethod,
 	return nil
 }
 
+// releaseBump returns which part of the latest version was incremented to get the next version
+func releaseBump(latest, next *semver.Version) pawnapi.Bump {
+	switch {
+	case next.Major() > latest.Major():
+		return pawnapi.BumpMajor
+	case next.Major() == latest.Major() && next.Minor() > latest.Minor():
+		return pawnapi.BumpMinor
+	default:
+		return pawnapi.BumpPatch
+	}
+}
+
 func generateVersionInc(pkg pawnpackage.Package, version *semver.Version) (filename string, err error) {
 	filename = packageSlug(pkg.Repo) + "_version.inc"
 	err = os.WriteFile(
@@ -0,0 +1,92 @@
+package rook
+
+import (
+	"io"
+	"path"
+	"strings"
+
+	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/go-git/go-git/v5/plumbing/object"
+	"github.com/pkg/errors"
+
+	"github.com/Southclaws/sampctl/src/pkg/infrastructure/versioning"
+	"github.com/Southclaws/sampctl/src/pkg/package/pawnapi"
+	"github.com/Southclaws/sampctl/src/pkg/package/pawnpackage"
+)
+
+// releaseAPIDiff compares the public API declared by the package's include files at the latest
+// release tag with the API at HEAD
+func releaseAPIDiff(repo *git.Repository, pkg pawnpackage.Package, latest versioning.VersionedTag) (pawnapi.Diff, error) {
+	previousCommit, err := tagCommit(repo, latest.Ref.Hash())
+	if err != nil {
+		return pawnapi.Diff{}, errors.Wrapf(err, "failed to read commit of %s", latest.Name)
+	}
+	head, err := repo.Head()
+	if err != nil {
+		return pawnapi.Diff{}, errors.Wrap(err, "failed to get HEAD reference")
+	}
+	currentCommit, err := repo.CommitObject(head.Hash())
+	if err != nil {
+		return pawnapi.Diff{}, errors.Wrap(err, "failed to read HEAD commit")
+	}
+
+	previous, err := commitAPI(previousCommit, pkg)
+	if err != nil {
+		return pawnapi.Diff{}, errors.Wrapf(err, "failed to read public API at %s", latest.Name)
+	}
+	current, err := commitAPI(currentCommit, pkg)
+	if err != nil {
+		return pawnapi.Diff{}, errors.Wrap(err, "failed to read public API at HEAD")
+	}
+	return pawnapi.Compare(previous, current), nil
+}
+
+// tagCommit returns the commit a tag points to, resolving annotated tags
+func tagCommit(repo *git.Repository, hash plumbing.Hash) (*object.Commit, error) {
+	tag, err := repo.TagObject(hash)
+	if err == nil {
+		return tag.Commit()
+	}
+	return repo.CommitObject(hash)
+}
+
+// commitAPI parses the include files of a package as they were at a commit. Only files under the
+// package's include path are read, dependencies, hidden directories and the version include
+// generated by releases are not part of the API.
+func commitAPI(commit *object.Commit, pkg pawnpackage.Package) (pawnapi.API, error) {
+	api := pawnapi.New()
+	tree, err := commit.Tree()
+	if err != nil {
+		return api, err
+	}
+
+	includePath := strings.Trim(path.Clean(strings.ReplaceAll(pkg.IncludePath, "\\", "/")), "/")
+	if includePath == "." {
+		includePath = ""
+	}
+	versionInc := packageSlug(pkg.Repo) + "_version.inc"
+
+	err = tree.Files().ForEach(func(file *object.File) error {
+		name := file.Name
+		if !pawnapi.IsIncludeFile(name) || path.Base(name) == versionInc {
+			return nil
+		}
+		if includePath != "" && !strings.HasPrefix(name, includePath+"/") {
+			return nil
+		}
+
+		reader, err := file.Reader()
+		if err != nil {
+			return err
+		}
+		defer reader.Close() // nolint
+		source, err := io.ReadAll(reader)
+		if err != nil {
+			return err
+		}
+		api.Add(pawnapi.Parse(name, string(source))...)
+		return nil
+	})
+	return api, err
+}
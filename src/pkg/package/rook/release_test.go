@@ -23,6 +23,7 @@ import (
 	"github.com/stretchr/testify/require"
 
 	"github.com/Southclaws/sampctl/src/pkg/infrastructure/versioning"
+	"github.com/Southclaws/sampctl/src/pkg/package/pawnapi"
 	"github.com/Southclaws/sampctl/src/pkg/package/pawnpackage"
 )
 
@@ -882,3 +883,62 @@ func TestChangelogExcludesReleaseCommits(t *testing.T) {
 	assert.NotContains(t, changelog, "sampctl release")
 	assert.NotContains(t, changelog, "Merge pull request")
 }
+
+func TestReleaseAPIDiff(t *testing.T) {
+	dir := t.TempDir()
+	repo, err := git.PlainInit(dir, false)
+	require.NoError(t, err)
+	wt, err := repo.Worktree()
+	require.NoError(t, err)
+
+	commit := func(files map[string]string) plumbing.Hash {
+		for name, contents := range files {
+			require.NoError(t, os.MkdirAll(filepath.Dir(filepath.Join(dir, name)), 0o755))
+			require.NoError(t, os.WriteFile(filepath.Join(dir, name), []byte(contents), 0o644))
+			_, err = wt.Add(name)
+			require.NoError(t, err)
+		}
+		hash, err := wt.Commit("commit", &git.CommitOptions{
+			Author: &object.Signature{Name: "Test Author", Email: "test@example.com", When: time.Now()},
+		})
+		require.NoError(t, err)
+		return hash
+	}
+
+	hash := commit(map[string]string{
+		"things.inc":                   "native Thing_Create(name[]);\nnative Thing_Destroy(thingid);\n",
+		"things_version.inc":           "#define THINGS_VERSION_MAJOR (1)\n",
+		"dependencies/other/other.inc": "native Other();\n",
+		"test.pwn":                     "main() {}\n",
+	})
+	tag, err := repo.CreateTag("1.0.0", hash, &git.CreateTagOptions{
+		Tagger:  &object.Signature{Name: "Test Author", Email: "test@example.com", When: time.Now()},
+		Message: "1.0.0",
+	})
+	require.NoError(t, err)
+	latest := versioning.VersionedTag{Ref: tag, Name: "1.0.0", Version: semver.MustParse("1.0.0")}
+	pkg := pawnpackage.Package{LocalPath: dir, Repo: "things"}
+
+	commit(map[string]string{
+		"things.inc":                   "native Thing_Create(name[], flags = 0);\nnative Thing_Destroy(thingid);\n",
+		"things_version.inc":           "#define THINGS_VERSION_MAJOR (2)\n",
+		"dependencies/other/other.inc": "",
+	})
+	diff, err := releaseAPIDiff(repo, pkg, latest)
+	require.NoError(t, err)
+	assert.Equal(t, pawnapi.BumpMinor, diff.Bump())
+	require.Len(t, diff.Changes, 1)
+	assert.Equal(t, "Thing_Create", diff.Changes[0].Current.Name)
+
+	commit(map[string]string{"things.inc": "native Thing_Create(name[], flags = 0);\n"})
+	diff, err = releaseAPIDiff(repo, pkg, latest)
+	require.NoError(t, err)
+	assert.Equal(t, pawnapi.BumpMajor, diff.Bump())
+}
+
+func TestReleaseBump(t *testing.T) {
+	latest := semver.MustParse("1.2.3")
+	assert.Equal(t, pawnapi.BumpPatch, releaseBump(latest, semver.MustParse("1.2.4")))
+	assert.Equal(t, pawnapi.BumpMinor, releaseBump(latest, semver.MustParse("1.3.0")))
+	assert.Equal(t, pawnapi.BumpMajor, releaseBump(latest, semver.MustParse("2.0.0")))
+}
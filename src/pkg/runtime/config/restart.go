@@ -0,0 +1,94 @@
+package run
+
+import (
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// RestartPolicy decides whether a server that exited is started again
+type RestartPolicy string
+
+const (
+	// RestartNever leaves the server stopped, this is the default
+	RestartNever RestartPolicy = "never"
+	// RestartOnFailure starts the server again when it crashed or exited with an error
+	RestartOnFailure RestartPolicy = "on-failure"
+	// RestartAlways starts the server again whenever it exited, including `exit` from RCON
+	RestartAlways RestartPolicy = "always"
+)
+
+// RestartConfig controls restarting a server that exited in server mode. Durations are written
+// like `30s` or `10m`.
+type RestartConfig struct {
+	Policy         RestartPolicy `json:"policy,omitempty" yaml:"policy,omitempty"`                   // never, on-failure or always
+	MaxRestarts    *int          `json:"max_restarts,omitempty" yaml:"max_restarts,omitempty"`       // restarts allowed within the window before giving up, 5 by default, -1 for no limit
+	Window         string        `json:"window,omitempty" yaml:"window,omitempty"`                   // period restarts are counted over, 10m by default
+	InitialBackoff string        `json:"initial_backoff,omitempty" yaml:"initial_backoff,omitempty"` // wait before the first restart, 1s by default
+	MaxBackoff     string        `json:"max_backoff,omitempty" yaml:"max_backoff,omitempty"`         // the wait doubles after each restart up to this, 1m by default
+	StopTimeout    string        `json:"stop_timeout,omitempty" yaml:"stop_timeout,omitempty"`       // time to exit after being asked to stop before the server is killed, 10s by default
+}
+
+// RestartSettings is a restart section with its durations parsed and the defaults applied
+type RestartSettings struct {
+	Policy         RestartPolicy
+	MaxRestarts    int
+	Window         time.Duration
+	InitialBackoff time.Duration
+	MaxBackoff     time.Duration
+	StopTimeout    time.Duration
+}
+
+// Settings parses the restart section, a missing section never restarts the server
+func (cfg *RestartConfig) Settings() (RestartSettings, error) {
+	settings := RestartSettings{
+		Policy:         RestartNever,
+		MaxRestarts:    5,
+		Window:         10 * time.Minute,
+		InitialBackoff: time.Second,
+		MaxBackoff:     time.Minute,
+		StopTimeout:    10 * time.Second,
+	}
+	if cfg == nil {
+		return settings, nil
+	}
+
+	switch cfg.Policy {
+	case "":
+	case RestartNever, RestartOnFailure, RestartAlways:
+		settings.Policy = cfg.Policy
+	default:
+		return settings, errors.Errorf("unknown restart policy %q, must be one of never, on-failure or always", cfg.Policy)
+	}
+	if cfg.MaxRestarts != nil {
+		settings.MaxRestarts = *cfg.MaxRestarts
+	}
+
+	for _, duration := range []struct {
+		name  string
+		value string
+		into  *time.Duration
+	}{
+		{"window", cfg.Window, &settings.Window},
+		{"initial_backoff", cfg.InitialBackoff, &settings.InitialBackoff},
+		{"max_backoff", cfg.MaxBackoff, &settings.MaxBackoff},
+		{"stop_timeout", cfg.StopTimeout, &settings.StopTimeout},
+	} {
+		if duration.value == "" {
+			continue
+		}
+		parsed, err := time.ParseDuration(duration.value)
+		if err != nil {
+			return settings, errors.Wrapf(err, "invalid restart %s", duration.name)
+		}
+		if parsed < 0 {
+			return settings, errors.Errorf("restart %s must not be negative", duration.name)
+		}
+		*duration.into = parsed
+	}
+	if settings.MaxBackoff < settings.InitialBackoff {
+		settings.MaxBackoff = settings.InitialBackoff
+	}
+
+	return settings, nil
+}
@@ -2,9 +2,11 @@ package run
 
 import (
 	"encoding/json"
+	"net"
 	"os"
 	"path/filepath"
 	"runtime"
+	"strconv"
 	"strings"
 
 	"github.com/pkg/errors"
@@ -21,7 +23,6 @@ type Runtime struct {
 	// Only used internally
 	WorkingDir string                      `ignore:"1" json:"-" yaml:"-"` // local directory that configuration points to
 	Platform   string                      `ignore:"1" json:"-" yaml:"-"` // the target platform for the runtime
-	Container  *ContainerConfig            `ignore:"1" json:"-" yaml:"-"` // configuration for container runtime
 	AppVersion string                      `ignore:"1" json:"-" yaml:"-"` // app version for container runtime
 	PluginDeps []versioning.DependencyMeta `ignore:"1" json:"-" yaml:"-"` // an internal list of remote plugins to download
 	Format     string                      `ignore:"1" json:"-" yaml:"-"` // format stores the original format of the package definition file, either `json` or `yaml`
@@ -34,6 +35,9 @@ type Runtime struct {
 
 	Echo *string `ignore:"1" json:"echo,omitempty" yaml:"echo,omitempty"`
 
+	Container *ContainerConfig `ignore:"1" json:"container,omitempty" yaml:"container,omitempty"` // image and limits used with --container
+	Restart   *RestartConfig   `ignore:"1" json:"restart,omitempty"   yaml:"restart,omitempty"`   // when a server that exited is started again
+
 	// Core properties
 	Gamemodes     []string `cfg:"gamemode" numbered:"1"          json:"gamemodes,omitempty"     yaml:"gamemodes,omitempty"`     //
 	Filterscripts []string `                        required:"0" json:"filterscripts,omitempty" yaml:"filterscripts,omitempty"` //
@@ -105,7 +109,21 @@ type Runtime struct {
 
 // ContainerConfig is used if the runtime is specified to run inside a container
 type ContainerConfig struct {
-	MountCache bool // whether or not to mount the local cache directory inside the container
+	Image        string            `json:"image,omitempty" yaml:"image,omitempty"`               // image to run the server in, southclaws/sampctl by default
+	Tag          string            `json:"tag,omitempty" yaml:"tag,omitempty"`                   // image tag, the version of sampctl by default
+	Mounts       []ContainerMount  `json:"mounts,omitempty" yaml:"mounts,omitempty"`             // extra bind mounts
+	Env          map[string]string `json:"env,omitempty" yaml:"env,omitempty"`                   // environment variables set in the container
+	Memory       string            `json:"memory,omitempty" yaml:"memory,omitempty"`             // memory limit, such as 512m or 1g
+	CPUs         float64           `json:"cpus,omitempty" yaml:"cpus,omitempty"`                 // number of CPUs the container may use, such as 1.5
+	Unprivileged bool              `json:"unprivileged,omitempty" yaml:"unprivileged,omitempty"` // run without --privileged and with the default seccomp profile
+	MountCache   bool              `json:"-" yaml:"-"`                                           // whether or not to mount the local cache directory inside the container
+}
+
+// ContainerMount is a directory or file of the host bound into the container
+type ContainerMount struct {
+	Source   string `json:"source" yaml:"source"`                           // host path, relative to the package
+	Target   string `json:"target" yaml:"target"`                           // absolute path inside the container
+	ReadOnly bool   `json:"read_only,omitempty" yaml:"read_only,omitempty"` // mount without write access
 }
 
 // RunMode represents a method of running the server
@@ -189,6 +207,58 @@ func (cfg Runtime) IsOpenMP() bool {
 	return cfg.GetEffectiveRuntimeType() == RuntimeTypeOpenMP
 }
 
+// RemoteConsole returns the RCON password and whether remote RCON is enabled for a server started
+// from this runtime, open.mp `rcon_config` takes precedence over `rcon` and `rcon_password`
+func (cfg Runtime) RemoteConsole() (password string, enabled bool) {
+	if cfg.RCONPassword != nil {
+		password = *cfg.RCONPassword
+	}
+	if cfg.RCON != nil {
+		enabled = *cfg.RCON
+	}
+
+	if cfg.IsOpenMP() {
+		if value, ok := cfg.RCONConfig["password"].(string); ok {
+			password = value
+		}
+		if value, ok := cfg.RCONConfig["enable"].(bool); ok {
+			enabled = value
+		}
+	}
+	return password, enabled
+}
+
+// LocalAddress returns the address a server started from this runtime listens on as seen from the
+// same machine, the loopback address is used unless the server is bound to a specific address.
+// open.mp `network.port` and `network.bind` take precedence over `port` and `bind`.
+func (cfg Runtime) LocalAddress() string {
+	host, port := "127.0.0.1", GetRuntimeDefault().Port
+	if cfg.Port != nil {
+		port = cfg.Port
+	}
+	if cfg.Bind != nil && *cfg.Bind != "" {
+		host = *cfg.Bind
+	}
+
+	if cfg.IsOpenMP() {
+		switch value := cfg.Network["port"].(type) {
+		case int:
+			port = &value
+		case float64:
+			converted := int(value)
+			port = &converted
+		}
+		if bind, ok := cfg.Network["bind"].(string); ok && bind != "" {
+			host = bind
+		}
+	}
+
+	if host == "0.0.0.0" {
+		host = "127.0.0.1"
+	}
+	return net.JoinHostPort(host, strconv.Itoa(*port))
+}
+
 // RuntimeFromDir creates a config from a directory by searching for a JSON or YAML file to
 // read settings from. If both exist, the JSON file takes precedence.
 func RuntimeFromDir(dir string) (cfg Runtime, err error) {
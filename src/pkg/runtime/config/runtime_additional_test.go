@@ -5,6 +5,7 @@ import (
 	"path/filepath"
 	"runtime"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
@@ -211,3 +212,80 @@ func TestCloneWithoutDefaults(t *testing.T) {
 
 	assert.Nil(t, CloneWithoutDefaults(nil))
 }
+
+func TestRuntimeLocalAddress(t *testing.T) {
+	port := 7778
+	bind := "0.0.0.0"
+	assert.Equal(t, "127.0.0.1:7777", Runtime{}.LocalAddress())
+	assert.Equal(t, "127.0.0.1:7778", Runtime{Port: &port, Bind: &bind}.LocalAddress())
+
+	bind = "10.0.0.5"
+	assert.Equal(t, "10.0.0.5:7778", Runtime{Port: &port, Bind: &bind}.LocalAddress())
+
+	openmp := Runtime{Version: "openmp", Port: &port, Network: map[string]any{"port": float64(7780), "bind": "::1"}}
+	assert.Equal(t, "[::1]:7780", openmp.LocalAddress())
+}
+
+func TestRuntimeRemoteConsole(t *testing.T) {
+	password := "secret"
+	enabled := true
+
+	got, on := Runtime{}.RemoteConsole()
+	assert.Equal(t, "", got)
+	assert.False(t, on)
+
+	got, on = Runtime{RCONPassword: &password, RCON: &enabled}.RemoteConsole()
+	assert.Equal(t, "secret", got)
+	assert.True(t, on)
+
+	openmp := Runtime{Version: "openmp", RCONPassword: &password, RCONConfig: map[string]any{"password": "other", "enable": false}}
+	got, on = openmp.RemoteConsole()
+	assert.Equal(t, "other", got)
+	assert.False(t, on)
+}
+
+func TestRestartSettings(t *testing.T) {
+	settings, err := (*RestartConfig)(nil).Settings()
+	require.NoError(t, err)
+	assert.Equal(t, RestartSettings{
+		Policy:         RestartNever,
+		MaxRestarts:    5,
+		Window:         10 * time.Minute,
+		InitialBackoff: time.Second,
+		MaxBackoff:     time.Minute,
+		StopTimeout:    10 * time.Second,
+	}, settings)
+
+	unlimited := -1
+	settings, err = (&RestartConfig{
+		Policy:         RestartOnFailure,
+		MaxRestarts:    &unlimited,
+		Window:         "1h",
+		InitialBackoff: "5s",
+		MaxBackoff:     "2s",
+		StopTimeout:    "0s",
+	}).Settings()
+	require.NoError(t, err)
+	assert.Equal(t, RestartSettings{
+		Policy:         RestartOnFailure,
+		MaxRestarts:    -1,
+		Window:         time.Hour,
+		InitialBackoff: 5 * time.Second,
+		MaxBackoff:     5 * time.Second,
+		StopTimeout:    0,
+	}, settings, "the maximum backoff is at least the initial backoff")
+
+	none := 0
+	settings, err = (&RestartConfig{MaxRestarts: &none}).Settings()
+	require.NoError(t, err)
+	assert.Equal(t, 0, settings.MaxRestarts, "zero is kept rather than replaced by the default")
+
+	_, err = (&RestartConfig{Policy: "sometimes"}).Settings()
+	assert.EqualError(t, err, `unknown restart policy "sometimes", must be one of never, on-failure or always`)
+
+	_, err = (&RestartConfig{Window: "ten minutes"}).Settings()
+	assert.ErrorContains(t, err, "invalid restart window")
+
+	_, err = (&RestartConfig{StopTimeout: "-1s"}).Settings()
+	assert.EqualError(t, err, "restart stop_timeout must not be negative")
+}
@@ -2,6 +2,7 @@ package runtime
 
 import (
 	"context"
+	"io"
 	iofs "io/fs"
 	"os"
 	"path/filepath"
@@ -50,6 +51,7 @@ type EnsureVersionedPluginRequest struct {
 	Includes       bool
 	NoCache        bool
 	IgnorePatterns []string
+	Output         io.Writer // receives messages, the usual output when nil
 }
 
 // EnsureVersionedPluginCachedRequest describes a cache lookup for a versioned plugin asset.
@@ -61,6 +63,7 @@ type EnsureVersionedPluginCachedRequest struct {
 	CacheDir string
 	NoCache  bool
 	GitHub   *github.Client
+	Output   io.Writer // receives messages, the usual output when nil
 }
 
 // PluginFetchRequest describes a plugin asset fetch from the network.
@@ -71,6 +74,7 @@ type PluginFetchRequest struct {
 	Platform string
 	Version  string
 	CacheDir string
+	Output   io.Writer // receives messages, the usual output when nil
 }
 
 // EnsurePlugins validates and downloads plugin binary files.
@@ -167,15 +171,16 @@ func EnsureVersionedPlugin(request EnsureVersionedPluginRequest) (files []run.Pl
 		CacheDir: request.CacheDir,
 		NoCache:  request.NoCache,
 		GitHub:   request.GitHub,
+		Output:   request.Output,
 	})
 	if err != nil {
 		return
 	}
 
-	print.Verb(request.Meta, "retrieved package to file:", filename)
+	print.Fverb(request.Output, request.Meta, "retrieved package to file:", filename)
 
 	if resource.Archive {
-		print.Verb(request.Meta, "plugin resource is an archive")
+		print.Fverb(request.Output, request.Meta, "plugin resource is an archive")
 		ext := filepath.Ext(filename)
 		if ext == "" {
 			ext = detectArchiveExt(filename)
@@ -190,7 +195,7 @@ func EnsureVersionedPlugin(request EnsureVersionedPluginRequest) (files []run.Pl
 			}
 			for _, plugin := range resource.Plugins {
 				pluginDir := request.PluginDestDir + "/"
-				print.Verb(request.Meta, "marking plugin path", plugin, "for extraction to ./"+pluginDir)
+				print.Fverb(request.Output, request.Meta, "marking plugin path", plugin, "for extraction to ./"+pluginDir)
 				paths[plugin] = pluginDir
 			}
 		}
@@ -198,7 +203,7 @@ func EnsureVersionedPlugin(request EnsureVersionedPluginRequest) (files []run.Pl
 		// get include directories
 		if request.Includes {
 			for _, include := range resource.Includes {
-				print.Verb(request.Meta, "marking include path", include, "for extraction")
+				print.Fverb(request.Output, request.Meta, "marking include path", include, "for extraction")
 				paths[include] = ""
 			}
 		}
@@ -209,12 +214,12 @@ func EnsureVersionedPlugin(request EnsureVersionedPluginRequest) (files []run.Pl
 				// Don't override plugin/include destinations.
 				continue
 			}
-			print.Verb(request.Meta, "marking misc file path", src, "for extraction to", dest)
+			print.Fverb(request.Output, request.Meta, "marking misc file path", src, "for extraction to", dest)
 			paths[src] = dest
 		}
 
 		if len(request.IgnorePatterns) > 0 {
-			print.Verb(request.Meta, "using", len(request.IgnorePatterns), "ignore pattern(s) for extraction")
+			print.Fverb(request.Output, request.Meta, "using", len(request.IgnorePatterns), "ignore pattern(s) for extraction")
 		}
 
 		var extractedFiles map[string]string
@@ -236,18 +241,18 @@ func EnsureVersionedPlugin(request EnsureVersionedPluginRequest) (files []run.Pl
 			err = errors.Errorf("no files extracted from plugin %s: check the package definition of this dependency against the release assets", request.Meta)
 			return
 		}
-		print.Verb(request.Meta, "extracted", len(extractedFiles), "plugin files to", request.Dir)
+		print.Fverb(request.Output, request.Meta, "extracted", len(extractedFiles), "plugin files to", request.Dir)
 
 		for source, target := range extractedFiles {
 			for _, plugin := range resource.Plugins {
-				print.Verb(request.Meta, "checking resource source", source, "against plugin", plugin)
+				print.Fverb(request.Output, request.Meta, "checking resource source", source, "against plugin", plugin)
 				if source == plugin {
 					files = append(files, run.Plugin(filepath.Base(target)))
 				}
 			}
 		}
 	} else {
-		print.Verb(request.Meta, "plugin resource is a single file")
+		print.Fverb(request.Output, request.Meta, "plugin resource is a single file")
 		base := filepath.Base(filename)
 		if request.PluginDestDir == "" {
 			return nil, errors.New("pluginDestDir is required when plugins=true")
@@ -312,7 +317,7 @@ func EnsureVersionedPluginCached(request EnsureVersionedPluginCachedRequest) (
 	if !hit {
 		if !hasExplicitDependencyReference(request.Meta) {
 			//nolint:lll
-			print.Info("Downloading newest plugin because no version is specified. Consider specifying a version for this dependency.")
+			print.Finfo(request.Output, "Downloading newest plugin because no version is specified. Consider specifying a version for this dependency.")
 		}
 
 		filename, resource, err = PluginFromNet(PluginFetchRequest{
@@ -322,6 +327,7 @@ func EnsureVersionedPluginCached(request EnsureVersionedPluginCachedRequest) (
 			Platform: request.Platform,
 			Version:  request.Version,
 			CacheDir: request.CacheDir,
+			Output:   request.Output,
 		})
 		if err != nil {
 			err = errors.Wrapf(err, "failed to get plugin %s from net", request.Meta)
@@ -418,7 +424,7 @@ func cachedPackageResourceAsset(cachePath string, matcher *regexp.Regexp) (strin
 
 // PluginFromNet downloads a plugin from the given metadata to the cache directory
 func PluginFromNet(request PluginFetchRequest) (filename string, resource *pkgresource.Resource, err error) {
-	print.Info(request.Meta, "downloading plugin resource for", request.Platform)
+	print.Finfo(request.Output, request.Meta, "downloading plugin resource for", request.Platform)
 
 	pkg, err := pawnpackage.GetRemotePackage(request.Context, request.GitHub, request.Meta)
 	if err != nil {
@@ -458,7 +464,7 @@ func PluginFromNet(request PluginFetchRequest) (filename string, resource *pkgre
 		return
 	}
 
-	print.Verb(request.Meta, "downloaded", filename, "to cache")
+	print.Fverb(request.Output, request.Meta, "downloaded", filename, "to cache")
 
 	return filename, resource, nil
 }
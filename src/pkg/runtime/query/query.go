@@ -0,0 +1,311 @@
+// Package query implements the SA:MP query protocol, the UDP protocol SA:MP and open.mp servers
+// answer on their game port that the server browser uses to list servers and their players.
+package query
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/binary"
+	"net"
+	"strconv"
+	"time"
+
+	"github.com/pkg/errors"
+	"golang.org/x/text/encoding/charmap"
+)
+
+const (
+	// DefaultTimeout is how long a request waits for a response before it is sent again
+	DefaultTimeout = 2 * time.Second
+
+	// DefaultAttempts is how many times a request is sent before giving up, packets are not
+	// acknowledged so a lost request or response is only noticed by the timeout
+	DefaultAttempts = 2
+
+	headerSize = 11
+)
+
+// Opcodes of the query protocol, sent as the last byte of the request header
+const (
+	OpcodeInfo     byte = 'i'
+	OpcodeRules    byte = 'r'
+	OpcodeClients  byte = 'c'
+	OpcodeDetailed byte = 'd'
+	OpcodePing     byte = 'p'
+)
+
+// Info is the summary a server reports to the server browser
+type Info struct {
+	Password   bool   `json:"password"`
+	Players    int    `json:"players"`
+	MaxPlayers int    `json:"max_players"`
+	Hostname   string `json:"hostname"`
+	Gamemode   string `json:"gamemode"`
+	Language   string `json:"language"`
+}
+
+// Rule is a server setting reported to the server browser, such as `version` or `weburl`
+type Rule struct {
+	Name  string `json:"name"`
+	Value string `json:"value"`
+}
+
+// Player is a connected player, the ID and ping are only reported by detailed player queries
+type Player struct {
+	ID    int    `json:"id"`
+	Name  string `json:"name"`
+	Score int    `json:"score"`
+	Ping  int    `json:"ping"`
+}
+
+// Client sends queries to a single server
+type Client struct {
+	Timeout  time.Duration
+	Attempts int
+
+	address string
+	conn    net.Conn
+	header  []byte
+}
+
+// Dial creates a client for a server address in the form `host:port`
+func Dial(address string) (*Client, error) {
+	addr, err := net.ResolveUDPAddr("udp", address)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to resolve %s", address)
+	}
+	conn, err := net.DialUDP("udp", nil, addr)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to connect to %s", address)
+	}
+
+	// the header carries the server's IPv4 address and port, servers only echo them back
+	header := make([]byte, 0, headerSize)
+	header = append(header, "SAMP"...)
+	if ip := addr.IP.To4(); ip != nil {
+		header = append(header, ip...)
+	} else {
+		header = append(header, 0, 0, 0, 0)
+	}
+	header = binary.LittleEndian.AppendUint16(header, uint16(addr.Port))
+
+	return &Client{
+		Timeout:  DefaultTimeout,
+		Attempts: DefaultAttempts,
+		address:  address,
+		conn:     conn,
+		header:   header,
+	}, nil
+}
+
+// Close closes the client's socket
+func (c *Client) Close() error {
+	return c.conn.Close()
+}
+
+// Info requests the server's hostname, gamemode, language and player count
+func (c *Client) Info(ctx context.Context) (Info, error) {
+	payload, err := c.Request(ctx, OpcodeInfo, nil)
+	if err != nil {
+		return Info{}, err
+	}
+
+	r := reader{data: payload}
+	info := Info{
+		Password:   r.uint8() != 0,
+		Players:    int(r.uint16()),
+		MaxPlayers: int(r.uint16()),
+		Hostname:   r.string32(),
+		Gamemode:   r.string32(),
+		Language:   r.string32(),
+	}
+	if r.err != nil {
+		return Info{}, errors.Wrap(r.err, "malformed info response")
+	}
+	return info, nil
+}
+
+// Rules requests the server's rules in the order the server lists them
+func (c *Client) Rules(ctx context.Context) ([]Rule, error) {
+	payload, err := c.Request(ctx, OpcodeRules, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	r := reader{data: payload}
+	rules := make([]Rule, r.uint16())
+	for i := range rules {
+		rules[i] = Rule{Name: r.string8(), Value: r.string8()}
+	}
+	if r.err != nil {
+		return nil, errors.Wrap(r.err, "malformed rules response")
+	}
+	return rules, nil
+}
+
+// Clients requests the names and scores of connected players, servers with more than 100
+// players do not answer
+func (c *Client) Clients(ctx context.Context) ([]Player, error) {
+	payload, err := c.Request(ctx, OpcodeClients, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	r := reader{data: payload}
+	players := make([]Player, r.uint16())
+	for i := range players {
+		players[i] = Player{ID: -1, Name: r.string8(), Score: int(int32(r.uint32()))}
+	}
+	if r.err != nil {
+		return nil, errors.Wrap(r.err, "malformed clients response")
+	}
+	return players, nil
+}
+
+// Players requests the IDs, names, scores and pings of connected players, servers with more than
+// 100 players do not answer
+func (c *Client) Players(ctx context.Context) ([]Player, error) {
+	payload, err := c.Request(ctx, OpcodeDetailed, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	r := reader{data: payload}
+	players := make([]Player, r.uint16())
+	for i := range players {
+		players[i] = Player{
+			ID:    int(r.uint8()),
+			Name:  r.string8(),
+			Score: int(int32(r.uint32())),
+			Ping:  int(r.uint32()),
+		}
+	}
+	if r.err != nil {
+		return nil, errors.Wrap(r.err, "malformed players response")
+	}
+	return players, nil
+}
+
+// Ping measures how long the server takes to answer a ping request
+func (c *Client) Ping(ctx context.Context) (time.Duration, error) {
+	challenge := make([]byte, 4)
+	if _, err := rand.Read(challenge); err != nil {
+		return 0, errors.Wrap(err, "failed to generate ping challenge")
+	}
+
+	start := time.Now()
+	payload, err := c.Request(ctx, OpcodePing, challenge)
+	if err != nil {
+		return 0, err
+	}
+	if string(payload) != string(challenge) {
+		return 0, errors.New("ping response does not match the request")
+	}
+	return time.Since(start), nil
+}
+
+// Request sends a query and returns the payload of the response that follows the header
+func (c *Client) Request(ctx context.Context, opcode byte, payload []byte) ([]byte, error) {
+	packet := append(append(append([]byte(nil), c.header...), opcode), payload...)
+	buffer := make([]byte, 65536)
+
+	attempts := c.Attempts
+	if attempts < 1 {
+		attempts = 1
+	}
+	for attempt := 0; attempt < attempts; attempt++ {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		if err := c.conn.SetDeadline(c.deadline(ctx)); err != nil {
+			return nil, errors.Wrap(err, "failed to set query deadline")
+		}
+		if _, err := c.conn.Write(packet); err != nil {
+			return nil, errors.Wrapf(err, "failed to send query to %s", c.address)
+		}
+
+		for {
+			n, err := c.conn.Read(buffer)
+			if err != nil {
+				if netErr, ok := err.(net.Error); ok && netErr.Timeout() {
+					break
+				}
+				return nil, errors.Wrapf(err, "failed to read response from %s", c.address)
+			}
+			// responses to earlier attempts or other queries are skipped
+			if n >= headerSize && string(buffer[:4]) == "SAMP" && buffer[10] == opcode {
+				return append([]byte(nil), buffer[headerSize:n]...), nil
+			}
+		}
+	}
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	return nil, errors.Errorf("no response from %s to %s query", c.address, strconv.QuoteRune(rune(opcode)))
+}
+
+func (c *Client) deadline(ctx context.Context) time.Time {
+	timeout := c.Timeout
+	if timeout <= 0 {
+		timeout = DefaultTimeout
+	}
+	deadline := time.Now().Add(timeout)
+	if ctxDeadline, ok := ctx.Deadline(); ok && ctxDeadline.Before(deadline) {
+		deadline = ctxDeadline
+	}
+	return deadline
+}
+
+// reader reads the little-endian fields of a response, the first error is kept and later reads
+// return zero values
+type reader struct {
+	data []byte
+	err  error
+}
+
+func (r *reader) next(n int) []byte {
+	if r.err != nil {
+		return make([]byte, n)
+	}
+	if len(r.data) < n {
+		r.err = errors.New("response is truncated")
+		return make([]byte, n)
+	}
+	b := r.data[:n]
+	r.data = r.data[n:]
+	return b
+}
+
+func (r *reader) uint8() uint8 {
+	return r.next(1)[0]
+}
+
+func (r *reader) uint16() uint16 {
+	return binary.LittleEndian.Uint16(r.next(2))
+}
+
+func (r *reader) uint32() uint32 {
+	return binary.LittleEndian.Uint32(r.next(4))
+}
+
+func (r *reader) string8() string {
+	return decodeString(r.next(int(r.uint8())))
+}
+
+func (r *reader) string32() string {
+	length := r.uint32()
+	if r.err == nil && int(length) > len(r.data) {
+		r.err = errors.New("response is truncated")
+		return ""
+	}
+	return decodeString(r.next(int(length)))
+}
+
+// decodeString converts text from the Windows-1252 code page servers use to UTF-8
+func decodeString(b []byte) string {
+	decoded, err := charmap.Windows1252.NewDecoder().Bytes(b)
+	if err != nil {
+		return string(b)
+	}
+	return string(decoded)
+}
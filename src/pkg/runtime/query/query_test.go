@@ -0,0 +1,140 @@
+package query
+
+import (
+	"context"
+	"encoding/binary"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeServer answers queries with fixed payloads, opcodes without a payload are not answered
+func fakeServer(t *testing.T, responses map[byte][]byte) string {
+	t.Helper()
+
+	conn, err := net.ListenPacket("udp", "127.0.0.1:0")
+	require.NoError(t, err)
+	t.Cleanup(func() { conn.Close() }) // nolint
+
+	go func() {
+		buffer := make([]byte, 2048)
+		for {
+			n, addr, err := conn.ReadFrom(buffer)
+			if err != nil {
+				return
+			}
+			if n < headerSize || string(buffer[:4]) != "SAMP" {
+				continue
+			}
+			opcode := buffer[10]
+			response := append([]byte(nil), buffer[:headerSize]...)
+			if opcode == OpcodePing {
+				response = append(response, buffer[headerSize:n]...)
+			} else if payload, ok := responses[opcode]; ok {
+				response = append(response, payload...)
+			} else {
+				continue
+			}
+			conn.WriteTo(response, addr) // nolint
+		}
+	}()
+
+	return conn.LocalAddr().String()
+}
+
+type payload []byte
+
+func (p payload) u8(v uint8) payload   { return append(p, v) }
+func (p payload) u16(v uint16) payload { return binary.LittleEndian.AppendUint16(p, v) }
+func (p payload) u32(v uint32) payload { return binary.LittleEndian.AppendUint32(p, v) }
+func (p payload) s8(v string) payload  { return append(p.u8(uint8(len(v))), v...) }
+func (p payload) s32(v string) payload { return append(p.u32(uint32(len(v))), v...) }
+
+func testClient(t *testing.T, responses map[byte][]byte) *Client {
+	t.Helper()
+
+	client, err := Dial(fakeServer(t, responses))
+	require.NoError(t, err)
+	t.Cleanup(func() { client.Close() }) // nolint
+	client.Timeout = 200 * time.Millisecond
+	return client
+}
+
+func TestInfo(t *testing.T) {
+	t.Parallel()
+
+	client := testClient(t, map[byte][]byte{
+		// "Caf\xe9" is Café in Windows-1252
+		OpcodeInfo: payload{}.u8(1).u16(3).u16(50).s32("Caf\xe9 Freeroam").s32("Freeroam").s32("English"),
+	})
+
+	info, err := client.Info(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, Info{
+		Password:   true,
+		Players:    3,
+		MaxPlayers: 50,
+		Hostname:   "Café Freeroam",
+		Gamemode:   "Freeroam",
+		Language:   "English",
+	}, info)
+}
+
+func TestRulesAndPlayers(t *testing.T) {
+	t.Parallel()
+
+	client := testClient(t, map[byte][]byte{
+		OpcodeRules:    payload{}.u16(2).s8("version").s8("0.3.7-R2").s8("weburl").s8("open.mp"),
+		OpcodeClients:  payload{}.u16(1).s8("Southclaws").u32(10),
+		OpcodeDetailed: payload{}.u16(2).u8(0).s8("Southclaws").u32(10).u32(35).u8(4).s8("Y_Less").u32(0xFFFFFFFF).u32(120),
+	})
+	ctx := context.Background()
+
+	rules, err := client.Rules(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, []Rule{{Name: "version", Value: "0.3.7-R2"}, {Name: "weburl", Value: "open.mp"}}, rules)
+
+	clients, err := client.Clients(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, []Player{{ID: -1, Name: "Southclaws", Score: 10}}, clients)
+
+	players, err := client.Players(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, []Player{
+		{ID: 0, Name: "Southclaws", Score: 10, Ping: 35},
+		{ID: 4, Name: "Y_Less", Score: -1, Ping: 120},
+	}, players)
+}
+
+func TestPing(t *testing.T) {
+	t.Parallel()
+
+	client := testClient(t, nil)
+	elapsed, err := client.Ping(context.Background())
+	require.NoError(t, err)
+	assert.Positive(t, elapsed)
+}
+
+func TestRequestTimesOutWithoutResponse(t *testing.T) {
+	t.Parallel()
+
+	client := testClient(t, nil)
+	client.Timeout = 50 * time.Millisecond
+
+	_, err := client.Rules(context.Background())
+	assert.ErrorContains(t, err, "to 'r' query")
+}
+
+func TestMalformedResponse(t *testing.T) {
+	t.Parallel()
+
+	client := testClient(t, map[byte][]byte{
+		OpcodeInfo: payload{}.u8(0).u16(3).u16(50).u32(1000).s8("short"),
+	})
+
+	_, err := client.Info(context.Background())
+	assert.EqualError(t, err, "malformed info response: response is truncated")
+}
@@ -0,0 +1,87 @@
+package query
+
+import (
+	"context"
+	"encoding/binary"
+	"net"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+	"golang.org/x/text/encoding/charmap"
+)
+
+const (
+	// OpcodeRCON sends a remote console command, the server answers with one packet per line
+	OpcodeRCON byte = 'x'
+
+	// RCONQuietPeriod is how long to wait for more output after the last line of a response
+	RCONQuietPeriod = 300 * time.Millisecond
+)
+
+// ErrInvalidPassword is returned when the server rejects the RCON password
+var ErrInvalidPassword = errors.New("invalid RCON password")
+
+// RCON runs a remote console command and returns the lines the server printed in response. The end
+// of a response is not marked and commands such as `gmx` print nothing, so the response ends when
+// the server stays quiet. Commands are never sent twice since they are not safe to repeat.
+func (c *Client) RCON(ctx context.Context, password, command string) ([]string, error) {
+	packet := append(append([]byte(nil), c.header...), OpcodeRCON)
+	packet = appendString16(packet, password)
+	packet = appendString16(packet, command)
+
+	if err := c.conn.SetDeadline(c.deadline(ctx)); err != nil {
+		return nil, errors.Wrap(err, "failed to set RCON deadline")
+	}
+	if _, err := c.conn.Write(packet); err != nil {
+		return nil, errors.Wrapf(err, "failed to send RCON command to %s", c.address)
+	}
+
+	var lines []string
+	buffer := make([]byte, 65536)
+	for {
+		n, err := c.conn.Read(buffer)
+		if err != nil {
+			if netErr, ok := err.(net.Error); ok && netErr.Timeout() {
+				break
+			}
+			return lines, errors.Wrapf(err, "failed to read RCON response from %s", c.address)
+		}
+		if n < headerSize || string(buffer[:4]) != "SAMP" || buffer[10] != OpcodeRCON {
+			continue
+		}
+
+		r := reader{data: buffer[headerSize:n]}
+		line := r.string16()
+		if r.err != nil {
+			return lines, errors.Wrap(r.err, "malformed RCON response")
+		}
+		if strings.HasPrefix(strings.ToLower(line), "invalid rcon password") {
+			return nil, ErrInvalidPassword
+		}
+		lines = append(lines, line)
+
+		deadline := time.Now().Add(RCONQuietPeriod)
+		if ctxDeadline, ok := ctx.Deadline(); ok && ctxDeadline.Before(deadline) {
+			deadline = ctxDeadline
+		}
+		if err = c.conn.SetDeadline(deadline); err != nil {
+			return lines, errors.Wrap(err, "failed to set RCON deadline")
+		}
+	}
+	return lines, ctx.Err()
+}
+
+func (r *reader) string16() string {
+	return decodeString(r.next(int(r.uint16())))
+}
+
+// appendString16 appends text in the Windows-1252 code page prefixed with its length
+func appendString16(b []byte, text string) []byte {
+	encoded, err := charmap.Windows1252.NewEncoder().Bytes([]byte(text))
+	if err != nil {
+		encoded = []byte(text)
+	}
+	b = binary.LittleEndian.AppendUint16(b, uint16(len(encoded)))
+	return append(b, encoded...)
+}
@@ -0,0 +1,82 @@
+package query
+
+import (
+	"context"
+	"encoding/binary"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeRCONServer answers RCON commands with the lines of output returns, one packet per line
+func fakeRCONServer(t *testing.T, password string, output map[string][]string) *Client {
+	t.Helper()
+
+	conn, err := net.ListenPacket("udp", "127.0.0.1:0")
+	require.NoError(t, err)
+	t.Cleanup(func() { conn.Close() }) // nolint
+
+	go func() {
+		buffer := make([]byte, 2048)
+		for {
+			n, addr, err := conn.ReadFrom(buffer)
+			if err != nil {
+				return
+			}
+			if n < headerSize || buffer[10] != OpcodeRCON {
+				continue
+			}
+			r := reader{data: buffer[headerSize:n]}
+			gotPassword, command := r.string16(), r.string16()
+
+			lines := output[command]
+			if gotPassword != password {
+				lines = []string{"Invalid RCON password."}
+			}
+			for _, line := range lines {
+				response := append(append([]byte(nil), buffer[:headerSize]...), binary.LittleEndian.AppendUint16(nil, uint16(len(line)))...)
+				conn.WriteTo(append(response, line...), addr) // nolint
+			}
+		}
+	}()
+
+	client, err := Dial(conn.LocalAddr().String())
+	require.NoError(t, err)
+	t.Cleanup(func() { client.Close() }) // nolint
+	client.Timeout = 200 * time.Millisecond
+	return client
+}
+
+func TestRCONReturnsEveryLine(t *testing.T) {
+	t.Parallel()
+
+	client := fakeRCONServer(t, "secret", map[string][]string{
+		"players": {"ID\tName\tPing\tIP", "0\tSouthclaws\t35\t127.0.0.1"},
+	})
+
+	lines, err := client.RCON(context.Background(), "secret", "players")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"ID\tName\tPing\tIP", "0\tSouthclaws\t35\t127.0.0.1"}, lines)
+}
+
+func TestRCONCommandWithoutOutput(t *testing.T) {
+	t.Parallel()
+
+	client := fakeRCONServer(t, "secret", nil)
+
+	lines, err := client.RCON(context.Background(), "secret", "gmx")
+	require.NoError(t, err)
+	assert.Empty(t, lines)
+}
+
+func TestRCONInvalidPassword(t *testing.T) {
+	t.Parallel()
+
+	client := fakeRCONServer(t, "secret", nil)
+
+	_, err := client.RCON(context.Background(), "wrong", "gmx")
+	assert.ErrorIs(t, err, ErrInvalidPassword)
+}
@@ -0,0 +1,141 @@
+package runtime
+
+import (
+	"context"
+	"regexp"
+	"sync"
+	"time"
+
+	"github.com/Southclaws/sampctl/src/pkg/infrastructure/print"
+	"github.com/Southclaws/sampctl/src/pkg/runtime/query"
+)
+
+// ReadySource is what showed that a server is ready
+type ReadySource string
+
+const (
+	// ReadyFromLog means the server printed a line it only prints once it has started
+	ReadyFromLog ReadySource = "log"
+	// ReadyFromQuery means the server answered a query protocol ping
+	ReadyFromQuery ReadySource = "query"
+)
+
+// readyPollInterval is how often the server is pinged until it is ready
+var readyPollInterval = 500 * time.Millisecond
+
+// matchReady matches the lines SA:MP prints once the gamemode is loaded and both SA:MP and
+// open.mp print once the server is listening
+var matchReady = regexp.MustCompile(`Started server on port: \d+|Number of vehicle models: \d+`)
+
+// Readiness describes a server that is accepting players
+type Readiness struct {
+	Address string        `json:"address"`        // the address the server listens on
+	Source  ReadySource   `json:"source"`         // what showed the server is ready
+	Line    string        `json:"line,omitempty"` // the output line, when the source is the log
+	After   time.Duration `json:"-"`              // how long the server took to become ready
+}
+
+// readinessWatcher reports a server as ready the first time it prints a startup line or answers
+// a ping, whichever happens first, and again after each restart. A nil watcher does nothing.
+type readinessWatcher struct {
+	address string
+	onReady func(Readiness)
+
+	mu      sync.Mutex
+	started time.Time
+	ready   chan struct{} // closed once the current server is ready
+}
+
+func newReadinessWatcher(address string, onReady func(Readiness)) *readinessWatcher {
+	if onReady == nil {
+		return nil
+	}
+	return &readinessWatcher{
+		address: address,
+		onReady: onReady,
+		started: time.Now(),
+		ready:   make(chan struct{}),
+	}
+}
+
+// line checks a line of server output for a startup line
+func (w *readinessWatcher) line(line string) {
+	if w == nil || !matchReady.MatchString(line) {
+		return
+	}
+	w.mark(Readiness{Source: ReadyFromLog, Line: line})
+}
+
+// poll pings the server in the background until it answers or ctx is done
+func (w *readinessWatcher) poll(ctx context.Context) {
+	if w == nil {
+		return
+	}
+	w.mu.Lock()
+	ready := w.ready
+	w.mu.Unlock()
+	go func() {
+		ticker := time.NewTicker(readyPollInterval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ready:
+				return
+			case <-ticker.C:
+			}
+			if pingServer(ctx, w.address) {
+				w.mark(Readiness{Source: ReadyFromQuery})
+			}
+		}
+	}()
+}
+
+// rearm waits for a restarted server to become ready again, the poller of the previous server is
+// stopped and a new one started
+func (w *readinessWatcher) rearm(ctx context.Context) {
+	if w == nil {
+		return
+	}
+	w.mu.Lock()
+	select {
+	case <-w.ready:
+	default:
+		close(w.ready)
+	}
+	w.started = time.Now()
+	w.ready = make(chan struct{})
+	w.mu.Unlock()
+	w.poll(ctx)
+}
+
+func (w *readinessWatcher) mark(readiness Readiness) {
+	w.mu.Lock()
+	select {
+	case <-w.ready:
+		w.mu.Unlock()
+		return
+	default:
+	}
+	close(w.ready)
+	readiness.Address = w.address
+	readiness.After = time.Since(w.started)
+	w.mu.Unlock()
+
+	print.Verb("server ready at", readiness.Address, "after", readiness.After, "detected from", readiness.Source)
+	w.onReady(readiness)
+}
+
+func pingServer(ctx context.Context, address string) bool {
+	client, err := query.Dial(address)
+	if err != nil {
+		return false
+	}
+	defer client.Close() // nolint
+	client.Timeout = readyPollInterval
+	client.Attempts = 1
+
+	_, err = client.Ping(ctx)
+	return err == nil
+}
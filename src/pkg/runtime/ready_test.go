@@ -0,0 +1,99 @@
+package runtime
+
+import (
+	"context"
+	"io"
+	"net"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	run "github.com/Southclaws/sampctl/src/pkg/runtime/config"
+)
+
+func TestReadinessWatcherLine(t *testing.T) {
+	t.Parallel()
+
+	var calls []Readiness
+	watcher := newReadinessWatcher("127.0.0.1:7777", func(r Readiness) { calls = append(calls, r) })
+
+	watcher.line("Loaded 0 filterscripts.")
+	assert.Empty(t, calls)
+
+	watcher.line("[Info] Started server on port: 7777, with maxplayers: 50 lanmode is OFF.")
+	watcher.line("Number of vehicle models: 0")
+	require.Len(t, calls, 1, "readiness is only reported once")
+	assert.Equal(t, "127.0.0.1:7777", calls[0].Address)
+	assert.Equal(t, ReadyFromLog, calls[0].Source)
+	assert.Equal(t, "[Info] Started server on port: 7777, with maxplayers: 50 lanmode is OFF.", calls[0].Line)
+}
+
+func TestReadinessWatcherNil(t *testing.T) {
+	t.Parallel()
+
+	watcher := newReadinessWatcher("127.0.0.1:7777", nil)
+	assert.Nil(t, watcher)
+	watcher.line("Number of vehicle models: 0")
+	watcher.poll(context.Background())
+}
+
+func TestReadinessWatcherPoll(t *testing.T) {
+	t.Parallel()
+
+	conn, err := net.ListenPacket("udp", "127.0.0.1:0")
+	require.NoError(t, err)
+	defer conn.Close() // nolint
+	go func() {
+		// a ping response repeats the request
+		buffer := make([]byte, 64)
+		for {
+			n, addr, err := conn.ReadFrom(buffer)
+			if err != nil {
+				return
+			}
+			conn.WriteTo(buffer[:n], addr) // nolint
+		}
+	}()
+
+	ready := make(chan Readiness, 1)
+	watcher := newReadinessWatcher(conn.LocalAddr().String(), func(r Readiness) { ready <- r })
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	watcher.poll(ctx)
+
+	select {
+	case r := <-ready:
+		assert.Equal(t, ReadyFromQuery, r.Source)
+		assert.Equal(t, conn.LocalAddr().String(), r.Address)
+	case <-time.After(5 * time.Second):
+		t.Fatal("server was not detected as ready")
+	}
+}
+
+func TestExecuteRuntimeReportsReadiness(t *testing.T) {
+	t.Parallel()
+
+	scriptPath := filepath.Join(t.TempDir(), "runtime-ready.sh")
+	require.NoError(t, os.WriteFile(scriptPath, []byte("#!/bin/sh\nprintf 'Loaded 0 filterscripts.\\nNumber of vehicle models: 0\\n'\n"), 0o755))
+
+	ready := make(chan Readiness, 1)
+	err := executeRuntime(context.Background(), runtimeExecution{
+		binary:  scriptPath,
+		runType: run.Server,
+		output:  io.Discard,
+		ready:   newReadinessWatcher("127.0.0.1:0", func(r Readiness) { ready <- r }),
+	})
+	require.NoError(t, err)
+
+	select {
+	case r := <-ready:
+		assert.Equal(t, "Number of vehicle models: 0", r.Line)
+	default:
+		t.Fatal("readiness was not reported")
+	}
+}
@@ -0,0 +1,142 @@
+package runtime
+
+import (
+	"fmt"
+	"os/exec"
+	"time"
+
+	"github.com/pkg/errors"
+
+	"github.com/Southclaws/sampctl/src/pkg/infrastructure/print"
+	run "github.com/Southclaws/sampctl/src/pkg/runtime/config"
+)
+
+// RestartEvent describes a server that exited while its restart policy applied, either before it
+// is started again or when it is given up on
+type RestartEvent struct {
+	Time     time.Time
+	ExitCode int           // -1 when the server was killed by a signal or could not be started
+	Uptime   time.Duration // how long the server ran before it exited
+	Reason   string        // how the server exited
+	Restarts int           // restarts within the window, including the one this event announces
+	Backoff  time.Duration // wait before the server is started again
+	GaveUp   bool          // the restart limit was reached so the server is not started again
+}
+
+// restartTracker applies a restart policy to the exits of a server, a nil tracker never restarts
+type restartTracker struct {
+	settings  run.RestartSettings
+	onRestart func(RestartEvent)
+	backoff   time.Duration
+	restarts  []time.Time
+}
+
+func newRestartTracker(settings run.RestartSettings, onRestart func(RestartEvent)) *restartTracker {
+	return &restartTracker{
+		settings:  settings,
+		onRestart: onRestart,
+		backoff:   settings.InitialBackoff,
+	}
+}
+
+// next decides what happens after the server exited, either the run terminates or the server is
+// started again after the returned wait
+func (tracker *restartTracker) next(runType run.RunMode, uptime time.Duration, runErr error, now time.Time) (termination, time.Duration, bool) {
+	if tracker == nil || runType != run.Server || !tracker.shouldRestart(runErr) {
+		if runErr != nil {
+			return termination{err: errors.Wrap(runErr, "failed to start server")}, 0, false
+		}
+		return termination{}, 0, false
+	}
+
+	// a server that stayed up for a whole window was healthy, so the backoff starts over
+	if uptime >= tracker.settings.Window {
+		tracker.backoff = tracker.settings.InitialBackoff
+	}
+	recent := tracker.restarts[:0]
+	for _, restart := range tracker.restarts {
+		if now.Sub(restart) < tracker.settings.Window {
+			recent = append(recent, restart)
+		}
+	}
+	tracker.restarts = recent
+
+	event := RestartEvent{
+		Time:     now,
+		ExitCode: exitCode(runErr),
+		Uptime:   uptime,
+		Reason:   exitReason(runErr),
+		Restarts: len(tracker.restarts),
+	}
+	if tracker.settings.MaxRestarts >= 0 && len(tracker.restarts) >= tracker.settings.MaxRestarts {
+		event.GaveUp = true
+		tracker.emit(event)
+		return termination{
+			err: errors.Errorf("server restarted %d times within %s, giving up after it %s", len(tracker.restarts), tracker.settings.Window, event.Reason),
+		}, 0, false
+	}
+
+	tracker.restarts = append(tracker.restarts, now)
+	event.Restarts = len(tracker.restarts)
+	event.Backoff = tracker.backoff
+	tracker.emit(event)
+
+	print.Warn(fmt.Sprintf("server %s after %s, restarting in %s (%d within %s)",
+		event.Reason, uptime.Round(time.Millisecond), event.Backoff, event.Restarts, tracker.settings.Window))
+
+	tracker.backoff *= 2
+	if tracker.backoff > tracker.settings.MaxBackoff {
+		tracker.backoff = tracker.settings.MaxBackoff
+	}
+	return termination{}, event.Backoff, true
+}
+
+func (tracker *restartTracker) shouldRestart(runErr error) bool {
+	switch tracker.settings.Policy {
+	case run.RestartAlways:
+		return true
+	case run.RestartOnFailure:
+		return runErr != nil
+	default:
+		return false
+	}
+}
+
+func (tracker *restartTracker) emit(event RestartEvent) {
+	if tracker.onRestart != nil {
+		tracker.onRestart(event)
+	}
+}
+
+func exitCode(runErr error) int {
+	if runErr == nil {
+		return 0
+	}
+	var exitErr *exec.ExitError
+	if errors.As(runErr, &exitErr) {
+		return exitErr.ExitCode()
+	}
+	var containerErr *containerExitError
+	if errors.As(runErr, &containerErr) {
+		return containerErr.ExitCode()
+	}
+	return -1
+}
+
+func exitReason(runErr error) string {
+	if runErr == nil {
+		return "exited"
+	}
+	var exitErr *exec.ExitError
+	if errors.As(runErr, &exitErr) {
+		if exitErr.ExitCode() < 0 {
+			return "was killed by " + exitErr.String()
+		}
+		return fmt.Sprintf("exited with code %d", exitErr.ExitCode())
+	}
+	var containerErr *containerExitError
+	if errors.As(runErr, &containerErr) {
+		return fmt.Sprintf("exited with code %d", containerErr.ExitCode())
+	}
+	return "failed: " + runErr.Error()
+}
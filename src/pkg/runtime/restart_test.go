@@ -0,0 +1,230 @@
+package runtime
+
+import (
+	"context"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	run "github.com/Southclaws/sampctl/src/pkg/runtime/config"
+)
+
+func maxRestarts(n int) *int {
+	return &n
+}
+
+func testRestartSettings(policy run.RestartPolicy) run.RestartSettings {
+	settings, _ := (&run.RestartConfig{Policy: policy, MaxRestarts: maxRestarts(3)}).Settings()
+	return settings
+}
+
+func exitError(t *testing.T, code string) error {
+	t.Helper()
+	err := exec.Command("/bin/sh", "-c", "exit "+code).Run()
+	require.Error(t, err)
+	return err
+}
+
+func TestRestartTrackerNever(t *testing.T) {
+	t.Parallel()
+
+	var events []RestartEvent
+	tracker := newRestartTracker(testRestartSettings(run.RestartNever), func(e RestartEvent) { events = append(events, e) })
+
+	term, _, retry := tracker.next(run.Server, time.Second, errors.New("boom"), time.Now())
+	require.Error(t, term.err)
+	assert.Contains(t, term.err.Error(), "failed to start server")
+	assert.False(t, retry)
+
+	term, _, retry = tracker.next(run.Server, time.Second, nil, time.Now())
+	assert.NoError(t, term.err)
+	assert.False(t, retry)
+	assert.Empty(t, events)
+}
+
+func TestRestartTrackerOnFailure(t *testing.T) {
+	t.Parallel()
+
+	var events []RestartEvent
+	tracker := newRestartTracker(testRestartSettings(run.RestartOnFailure), func(e RestartEvent) { events = append(events, e) })
+
+	term, backoff, retry := tracker.next(run.Server, 5*time.Second, exitError(t, "3"), time.Now())
+	assert.NoError(t, term.err)
+	assert.True(t, retry)
+	assert.Equal(t, time.Second, backoff)
+	require.Len(t, events, 1)
+	assert.Equal(t, 3, events[0].ExitCode)
+	assert.Equal(t, 5*time.Second, events[0].Uptime)
+	assert.Equal(t, "exited with code 3", events[0].Reason)
+	assert.Equal(t, 1, events[0].Restarts)
+	assert.False(t, events[0].GaveUp)
+
+	term, _, retry = tracker.next(run.Server, time.Second, nil, time.Now())
+	assert.NoError(t, term.err)
+	assert.False(t, retry, "a clean exit is not restarted")
+	assert.Len(t, events, 1)
+
+	_, _, retry = tracker.next(run.MainOnly, time.Second, errors.New("boom"), time.Now())
+	assert.False(t, retry, "only server mode is restarted")
+}
+
+func TestRestartTrackerBacksOffAndGivesUp(t *testing.T) {
+	t.Parallel()
+
+	var events []RestartEvent
+	tracker := newRestartTracker(testRestartSettings(run.RestartAlways), func(e RestartEvent) { events = append(events, e) })
+	now := time.Now()
+
+	var backoffs []time.Duration
+	for i := 0; i < 3; i++ {
+		term, backoff, retry := tracker.next(run.Server, time.Second, nil, now)
+		require.NoError(t, term.err)
+		require.True(t, retry)
+		backoffs = append(backoffs, backoff)
+	}
+	assert.Equal(t, []time.Duration{time.Second, 2 * time.Second, 4 * time.Second}, backoffs)
+
+	term, _, retry := tracker.next(run.Server, time.Second, exitError(t, "1"), now)
+	require.Error(t, term.err)
+	assert.Contains(t, term.err.Error(), "server restarted 3 times within 10m0s")
+	assert.False(t, retry)
+	require.Len(t, events, 4)
+	assert.True(t, events[3].GaveUp)
+	assert.Equal(t, 3, events[3].Restarts)
+}
+
+func TestRestartTrackerZeroRestarts(t *testing.T) {
+	t.Parallel()
+
+	settings, err := (&run.RestartConfig{Policy: run.RestartAlways, MaxRestarts: maxRestarts(0)}).Settings()
+	require.NoError(t, err)
+	var events []RestartEvent
+	tracker := newRestartTracker(settings, func(e RestartEvent) { events = append(events, e) })
+
+	term, _, retry := tracker.next(run.Server, time.Second, nil, time.Now())
+	require.Error(t, term.err)
+	assert.False(t, retry, "zero restarts gives up on the first exit")
+	require.Len(t, events, 1)
+	assert.True(t, events[0].GaveUp)
+}
+
+func TestRestartTrackerWindow(t *testing.T) {
+	t.Parallel()
+
+	tracker := newRestartTracker(testRestartSettings(run.RestartAlways), nil)
+	now := time.Now()
+	for i := 0; i < 3; i++ {
+		_, _, retry := tracker.next(run.Server, time.Second, nil, now)
+		require.True(t, retry)
+	}
+
+	// restarts older than the window no longer count and a long run resets the backoff
+	term, backoff, retry := tracker.next(run.Server, 11*time.Minute, nil, now.Add(11*time.Minute))
+	require.NoError(t, term.err)
+	assert.True(t, retry)
+	assert.Equal(t, time.Second, backoff)
+}
+
+func TestRestartTrackerMaxBackoff(t *testing.T) {
+	t.Parallel()
+
+	settings, err := (&run.RestartConfig{Policy: run.RestartAlways, MaxRestarts: maxRestarts(-1), MaxBackoff: "3s"}).Settings()
+	require.NoError(t, err)
+	tracker := newRestartTracker(settings, nil)
+
+	var backoff time.Duration
+	for i := 0; i < 10; i++ {
+		var retry bool
+		_, backoff, retry = tracker.next(run.Server, time.Second, nil, time.Now())
+		require.True(t, retry, "-1 restarts without a limit")
+	}
+	assert.Equal(t, 3*time.Second, backoff)
+}
+
+func TestExecuteRuntimeRestartsServer(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	scriptPath := filepath.Join(dir, "runtime-crash.sh")
+	require.NoError(t, os.WriteFile(scriptPath, []byte("#!/bin/sh\necho run >> runs\nexit 2\n"), 0o755))
+
+	settings, err := (&run.RestartConfig{Policy: run.RestartOnFailure, MaxRestarts: maxRestarts(2), InitialBackoff: "1ms"}).Settings()
+	require.NoError(t, err)
+	var events []RestartEvent
+	err = executeRuntime(context.Background(), runtimeExecution{
+		binary:  scriptPath,
+		runType: run.Server,
+		restart: newRestartTracker(settings, func(e RestartEvent) { events = append(events, e) }),
+		output:  io.Discard,
+	})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "server restarted 2 times")
+
+	runs, err := os.ReadFile(filepath.Join(dir, "runs"))
+	require.NoError(t, err)
+	assert.Equal(t, "run\nrun\nrun\n", string(runs))
+	require.Len(t, events, 3)
+	assert.True(t, events[2].GaveUp)
+}
+
+func TestExecuteRuntimeStopsServerGracefully(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	scriptPath := filepath.Join(dir, "runtime-graceful.sh")
+	require.NoError(t, os.WriteFile(scriptPath, []byte("#!/bin/sh\ntrap 'echo stopped > stopped; exit 0' TERM\ntouch started\nwhile :; do sleep 0.1; done\n"), 0o755))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go func() {
+		for {
+			if _, err := os.Stat(filepath.Join(dir, "started")); err == nil {
+				cancel()
+				return
+			}
+			time.Sleep(10 * time.Millisecond)
+		}
+	}()
+
+	settings, err := (&run.RestartConfig{Policy: run.RestartAlways}).Settings()
+	require.NoError(t, err)
+	err = executeRuntime(ctx, runtimeExecution{
+		binary:      scriptPath,
+		runType:     run.Server,
+		restart:     newRestartTracker(settings, nil),
+		stopTimeout: 5 * time.Second,
+		output:      io.Discard,
+	})
+	assert.ErrorIs(t, err, context.Canceled)
+	assert.FileExists(t, filepath.Join(dir, "stopped"), "the server was asked to stop before it was killed")
+	_, err = os.Stat(filepath.Join(dir, "runs"))
+	assert.True(t, os.IsNotExist(err))
+}
+
+func TestExecuteRuntimeReportsReadinessAfterEachRestart(t *testing.T) {
+	t.Parallel()
+
+	scriptPath := filepath.Join(t.TempDir(), "runtime-ready-crash.sh")
+	require.NoError(t, os.WriteFile(scriptPath, []byte("#!/bin/sh\necho 'Number of vehicle models: 0'\nexit 2\n"), 0o755))
+
+	settings, err := (&run.RestartConfig{Policy: run.RestartOnFailure, MaxRestarts: maxRestarts(1), InitialBackoff: "1ms"}).Settings()
+	require.NoError(t, err)
+	ready := make(chan Readiness, 4)
+	err = executeRuntime(context.Background(), runtimeExecution{
+		binary:  scriptPath,
+		runType: run.Server,
+		restart: newRestartTracker(settings, nil),
+		output:  io.Discard,
+		ready:   newReadinessWatcher("127.0.0.1:0", func(r Readiness) { ready <- r }),
+	})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "server restarted 1 times")
+	assert.Len(t, ready, 2, "the restarted server is reported ready again")
+}
@@ -31,10 +31,15 @@ var (
 
 type RunOptions struct {
 	CacheDir string
-	PassArgs bool
-	Recover  bool
 	Output   io.Writer
 	Input    io.Reader
+	// OnReady is called the first time the server prints a startup line or answers a query on its
+	// local address, and again each time the restart policy started it again. It is called from
+	// the goroutine that noticed and should return quickly.
+	OnReady func(Readiness)
+	// OnRestart is called each time the server exited and the restart policy starts it again or
+	// gives up on it
+	OnRestart func(RestartEvent)
 }
 
 type testResults struct {
@@ -48,21 +53,24 @@ type termination struct {
 }
 
 type runtimeExecution struct {
-	binary  string
-	runType run.RunMode
-	recover bool
-	output  io.Writer
-	input   io.Reader
+	binary      string
+	runType     run.RunMode
+	restart     *restartTracker
+	stopTimeout time.Duration
+	output      io.Writer
+	input       io.Reader
+	ready       *readinessWatcher
 }
 
 type binaryRunConfig struct {
 	binary       string
 	runType      run.RunMode
-	recover      bool
+	restart      *restartTracker
 	outputWriter io.Writer
 	input        io.Reader
 	termCh       chan<- termination
 	onStart      func(*os.Process)
+	ready        *readinessWatcher
 }
 
 type runtimeTerminationRequest struct {
@@ -79,14 +87,7 @@ type outputReaderRequest struct {
 	OutputReader *io.PipeReader
 	TermCh       chan<- termination
 	StreamCh     chan<- string
-}
-
-type runResultRequest struct {
-	RunType run.RunMode
-	Recover bool
-	RunTime time.Duration
-	Backoff time.Duration
-	RunErr  error
+	Ready        *readinessWatcher
 }
 
 type outputModeState struct {
@@ -102,6 +103,10 @@ type commandTracker struct {
 // Run handles the actual running of the server process - it collects log output too.
 func Run(ctx context.Context, cfg run.Runtime, options RunOptions) error {
 	options = options.withDefaults()
+	restart, err := cfg.Restart.Settings()
+	if err != nil {
+		return errors.Wrap(err, "invalid restart configuration")
+	}
 	if cfg.Container != nil {
 		return RunContainer(ctx, cfg, options)
 	}
@@ -121,11 +126,13 @@ func Run(ctx context.Context, cfg run.Runtime, options RunOptions) error {
 	}
 
 	return executeRuntime(ctx, runtimeExecution{
-		binary:  fullPath,
-		runType: cfg.Mode,
-		recover: options.Recover,
-		output:  options.Output,
-		input:   options.Input,
+		binary:      fullPath,
+		runType:     cfg.Mode,
+		restart:     newRestartTracker(restart, options.OnRestart),
+		stopTimeout: restart.StopTimeout,
+		output:      options.Output,
+		input:       options.Input,
+		ready:       newReadinessWatcher(cfg.LocalAddress(), options.OnReady),
 	})
 }
 
@@ -140,6 +147,9 @@ func executeRuntime(ctx context.Context, execCfg runtimeExecution) error {
 	outputReader, outputWriter := io.Pipe()
 	runCtx, cancel := context.WithCancel(ctx)
 	defer cancel()
+	// stopping the runner first keeps a server that is being stopped from being restarted
+	runnerCtx, stopRunner := context.WithCancel(runCtx)
+	defer stopRunner()
 
 	termCh := make(chan termination, 1)
 	streamCh := make(chan string)
@@ -152,17 +162,21 @@ func executeRuntime(ctx context.Context, execCfg runtimeExecution) error {
 		OutputReader: outputReader,
 		TermCh:       termCh,
 		StreamCh:     streamCh,
+		Ready:        execCfg.ready,
 	})
-	runnerDone := startBinaryRunner(runCtx, binaryRunConfig{
+	runnerDone := startBinaryRunner(runnerCtx, binaryRunConfig{
 		binary:       execCfg.binary,
 		runType:      execCfg.runType,
-		recover:      execCfg.recover,
+		restart:      execCfg.restart,
 		outputWriter: outputWriter,
 		input:        execCfg.input,
 		termCh:       termCh,
 		onStart:      tracker.set,
+		ready:        execCfg.ready,
 	})
 
+	execCfg.ready.poll(runCtx)
+
 	signal.Notify(sigCh, syscall.SIGINT, syscall.SIGTERM)
 	defer signal.Stop(sigCh)
 
@@ -175,13 +189,20 @@ func executeRuntime(ctx context.Context, execCfg runtimeExecution) error {
 	})
 	print.Verb("finished server execution with:", term)
 
+	// keep writing output while the server stops, it may print until it exits
+	flushDone := make(chan error, 1)
+	go func() {
+		flushDone <- flushRuntimeOutput(execCfg.output, streamCh)
+	}()
+
+	stopRunner()
 	if shouldKillTrackedProcess(term) {
-		killTrackedProcess(tracker.current())
+		stopTrackedProcess(tracker.current(), execCfg.stopTimeout, runnerDone)
 	}
 
 	<-runnerDone
 	closeOutputPipe(outputWriter)
-	if flushErr := flushRuntimeOutput(execCfg.output, streamCh); flushErr != nil && term.err == nil {
+	if flushErr := <-flushDone; flushErr != nil && term.err == nil {
 		term.err = flushErr
 	}
 	<-readerDone
@@ -258,6 +279,9 @@ func readBinaryOutput(request outputReaderRequest) <-chan struct{} {
 	done := make(chan struct{})
 	go func() {
 		defer close(done)
+		// the server keeps writing until it exits, so the rest of its output is discarded rather
+		// than blocking it
+		defer io.Copy(io.Discard, request.OutputReader) // nolint
 		defer close(request.StreamCh)
 
 		state := outputModeState{
@@ -265,6 +289,7 @@ func readBinaryOutput(request outputReaderRequest) <-chan struct{} {
 		}
 		scanner := bufio.NewScanner(request.OutputReader)
 		for scanner.Scan() {
+			request.Ready.line(scanner.Text())
 			line, emit, term, stop := processOutputLine(request.RunType, &state, scanner.Text())
 			if emit && !sendOutputLine(request.Context, request.StreamCh, line) {
 				return
@@ -345,27 +370,24 @@ func shouldSkipPreambleLine(state *outputModeState, line string) bool {
 }
 
 func runBinary(ctx context.Context, cfg binaryRunConfig) {
-	backoff := time.Second
 	for {
-		cmd := exec.CommandContext(ctx, cfg.binary) //nolint:gosec
+		// the process is stopped by executeRuntime, which gives it time to exit before killing it
+		cmd := exec.Command(cfg.binary) //nolint:gosec
 		cmd.Dir = filepath.Dir(cfg.binary)
 
 		startedAt := time.Now()
-		runErr := platformRun(ctx, cmd, cfg.outputWriter, cfg.input, cfg.onStart)
+		runErr := platformRun(context.WithoutCancel(ctx), cmd, cfg.outputWriter, cfg.input, cfg.onStart)
 		logCommandResult(cmd, runErr)
+		if ctx.Err() != nil {
+			return
+		}
 
-		term, nextBackoff, retry := evaluateRunResult(runResultRequest{
-			RunType: cfg.runType,
-			Recover: cfg.recover,
-			RunTime: time.Since(startedAt),
-			Backoff: backoff,
-			RunErr:  runErr,
-		})
-		backoff = nextBackoff
+		term, backoff, retry := cfg.restart.next(cfg.runType, time.Since(startedAt), runErr, time.Now())
 		if retry {
 			if !sleepWithContext(ctx, backoff) {
 				return
 			}
+			cfg.ready.rearm(ctx)
 			continue
 		}
 
@@ -374,36 +396,6 @@ func runBinary(ctx context.Context, cfg binaryRunConfig) {
 	}
 }
 
-func evaluateRunResult(request runResultRequest) (termination, time.Duration, bool) {
-	if request.RunType != run.Server || !request.Recover {
-		if request.RunErr != nil {
-			return termination{err: errors.Wrap(request.RunErr, "failed to start server")}, request.Backoff, false
-		}
-		return termination{}, request.Backoff, false
-	}
-
-	nextBackoff := nextCrashLoopBackoff(request.RunTime, request.Backoff)
-	if nextBackoff < 15*time.Second {
-		print.Warn("crash loop backoff for", nextBackoff, "reason:", request.RunErr)
-		return termination{}, nextBackoff, true
-	}
-
-	return termination{
-		err: errors.Errorf("too many crashloops, last error: %v", request.RunErr),
-	}, nextBackoff, false
-}
-
-func nextCrashLoopBackoff(runTime, current time.Duration) time.Duration {
-	if runTime < time.Minute {
-		next := current * 2
-		print.Verb("doubling backoff time", next)
-		return next
-	}
-
-	print.Verb("initial backoff", time.Second)
-	return time.Second
-}
-
 func logCommandResult(cmd *exec.Cmd, runErr error) {
 	if cmd.Process != nil {
 		print.Verb("child exec thread finished, pid:", cmd.Process.Pid, "error:", runErr)
@@ -454,6 +446,27 @@ func (tracker *commandTracker) current() *os.Process {
 	return tracker.process
 }
 
+// stopTrackedProcess asks the server to exit and kills it when it is still running after the
+// timeout, exited is closed once it is no longer running
+func stopTrackedProcess(process *os.Process, timeout time.Duration, exited <-chan struct{}) {
+	if process != nil && timeout > 0 {
+		if err := interruptRuntimeProcess(process); err != nil {
+			print.Verb("failed to ask server to stop:", err)
+		} else {
+			timer := time.NewTimer(timeout)
+			defer timer.Stop()
+			select {
+			case <-exited:
+				print.Verb("server stopped")
+				return
+			case <-timer.C:
+				print.Warn("server did not stop within", timeout, "killing it")
+			}
+		}
+	}
+	killTrackedProcess(process)
+}
+
 func killTrackedProcess(process *os.Process) {
 	if process == nil {
 		print.Verb("not attempting to kill server: process is nil")
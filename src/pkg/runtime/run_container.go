@@ -5,8 +5,10 @@ import (
 	"context"
 	"fmt"
 	"io"
-	"os"
 	"os/signal"
+	"path"
+	"path/filepath"
+	"sort"
 	"syscall"
 	"time"
 
@@ -17,22 +19,51 @@ import (
 	"github.com/docker/docker/api/types/strslice"
 	"github.com/docker/docker/client"
 	"github.com/docker/go-connections/nat"
+	units "github.com/docker/go-units"
+	ocispec "github.com/opencontainers/image-spec/specs-go/v1"
 	"github.com/pkg/errors"
 
 	"github.com/Southclaws/sampctl/src/pkg/infrastructure/print"
 	run "github.com/Southclaws/sampctl/src/pkg/runtime/config"
 )
 
-// RunContainer does what Run does but inside a Linux container
-// nolint:gocyclo
+const (
+	// containerImage is the image the server runs in when the runtime does not name one
+	containerImage = "southclaws/sampctl"
+
+	// containerWorkingDir is where the runtime directory is mounted inside the container
+	containerWorkingDir = "/samp"
+)
+
+// containerClient is the part of the Docker API the container runtime uses, it is satisfied by
+// the Docker client and replaced with a fake daemon in tests
+type containerClient interface {
+	containerStopper
+	containerRemover
+	ImagePull(ctx context.Context, ref string, options types.ImagePullOptions) (io.ReadCloser, error)
+	ContainerCreate(
+		ctx context.Context,
+		config *container.Config,
+		hostConfig *container.HostConfig,
+		networkingConfig *network.NetworkingConfig,
+		platform *ocispec.Platform,
+		containerName string,
+	) (container.CreateResponse, error)
+	ContainerStart(ctx context.Context, containerID string, options container.StartOptions) error
+	ContainerLogs(ctx context.Context, containerID string, options container.LogsOptions) (io.ReadCloser, error)
+}
+
+// RunContainer does what Run does but inside a Linux container. The runtime directory has already
+// been ensured and configured for Linux, so it is mounted into the container and the server binary
+// in it is started directly.
 func RunContainer(
 	ctx context.Context,
 	cfg run.Runtime,
 	options RunOptions,
 ) (err error) {
-	cli, err := client.NewClientWithOpts()
+	cli, err := client.NewClientWithOpts(client.FromEnv, client.WithAPIVersionNegotiation())
 	if err != nil {
-		return
+		return errors.Wrap(err, "failed to create docker client")
 	}
 	defer func() {
 		if errClose := cli.Close(); errClose != nil {
@@ -44,104 +75,43 @@ func RunContainer(
 		}
 	}()
 
-	args := strslice.StrSlice{"sampctl", "server", "run"}
-	if options.PassArgs {
-		for i, arg := range os.Args {
-			// trim first 3 args and container specific flags
-			if arg == "--container" || arg == "--mountCache" || i < 3 {
-				continue
-			}
-			args = append(args, arg)
-		}
-	}
-
-	port := fmt.Sprint(*cfg.Port)
-	print.Verb("mounting package working directory at", cfg.WorkingDir, "into container at /samp")
-	mounts := []mount.Mount{
-		{
-			Type:   mount.TypeBind,
-			Source: cfg.WorkingDir,
-			Target: "/samp",
-		},
-	}
-
-	if cfg.Container.MountCache {
-		print.Verb("mounting cache at", options.CacheDir, "into container at /root/.samp")
-		mounts = append(mounts, mount.Mount{
-			Type:     mount.TypeBind,
-			Source:   options.CacheDir,
-			Target:   "/root/.samp",
-			ReadOnly: true,
-		})
-	}
-
-	hostConfig := &container.HostConfig{
-		Mounts: mounts,
-		PortBindings: nat.PortMap{
-			nat.Port(port): []nat.PortBinding{
-				{HostIP: "0.0.0.0", HostPort: port},
-			},
-		},
-		SecurityOpt: []string{"seccomp=unconfined"},
-		Privileged:  true,
-	}
+	return runContainer(ctx, cli, cfg, options)
+}
 
-	netConfig := &network.NetworkingConfig{
-		//
+// nolint:gocyclo
+func runContainer(
+	ctx context.Context,
+	cli containerClient,
+	cfg run.Runtime,
+	options RunOptions,
+) (err error) {
+	containerConfig, hostConfig, err := containerSpec(cfg, options)
+	if err != nil {
+		return err
 	}
-
-	ref := "southclaws/sampctl:" + cfg.AppVersion
-	containerConfig := &container.Config{
-		Image:        ref,
-		Entrypoint:   args,
-		Tty:          true,
-		AttachStdout: true,
-		AttachStderr: true,
-		AttachStdin:  true,
+	restart, err := cfg.Restart.Settings()
+	if err != nil {
+		return errors.Wrap(err, "invalid restart configuration")
 	}
-
+	ref := containerConfig.Image
 	containerName := fmt.Sprintf("sampctl-%d", time.Now().Unix())
 
 	ctxPrepare, cancel := context.WithTimeout(ctx, time.Minute*10)
 	defer cancel()
 
 	var cnt container.CreateResponse
-	cnt, err = cli.ContainerCreate(
-		ctxPrepare,
-		containerConfig,
-		hostConfig,
-		netConfig,
-		nil,
-		containerName)
+	cnt, err = cli.ContainerCreate(ctxPrepare, containerConfig, hostConfig, nil, nil, containerName)
 	if err != nil {
-		if client.IsErrNotFound(err) {
-			print.Info("Pulling image:", ref)
-			pullReader, errInner := cli.ImagePull(ctxPrepare, ref, types.ImagePullOptions{})
-			if errInner != nil {
-				return errors.Wrap(errInner, "failed to pull image")
-			}
-			defer func() {
-				errDefer := pullReader.Close()
-				if errDefer != nil {
-					print.Erro(errDefer)
-				}
-			}()
-			_, errInner = io.ReadAll(pullReader)
-			if errInner != nil {
-				return errors.Wrap(errInner, "failed to read pull output")
-			}
+		if !client.IsErrNotFound(err) {
+			return errors.Wrap(err, "failed to create container")
+		}
 
-			cnt, errInner = cli.ContainerCreate(
-				ctxPrepare,
-				containerConfig,
-				hostConfig,
-				netConfig,
-				nil,
-				containerName)
-			if errInner != nil {
-				return errors.Wrap(errInner, "failed to create container")
-			}
-		} else {
+		print.Info("Pulling image:", ref)
+		if err = pullImage(ctxPrepare, cli, ref); err != nil {
+			return err
+		}
+		cnt, err = cli.ContainerCreate(ctxPrepare, containerConfig, hostConfig, nil, nil, containerName)
+		if err != nil {
 			return errors.Wrap(err, "failed to create container")
 		}
 	}
@@ -157,73 +127,216 @@ func RunContainer(
 		print.Warn("failed to remove container:", removeErr)
 	}()
 
-	print.Info("Starting container...")
-	err = cli.ContainerStart(ctx, cnt.ID, container.StartOptions{})
-	if err != nil {
-		return errors.Wrap(err, "failed to start container")
-	}
-
 	runCtx, stopSignals := signal.NotifyContext(ctx, syscall.SIGINT, syscall.SIGTERM)
 	defer stopSignals()
 
-	// Get logs and wait for exit
+	// stopped stops the container after the run was cancelled or interrupted
+	stopped := func() error {
+		if stopErr := stopContainer(context.Background(), cli, cnt.ID, restart.StopTimeout); stopErr != nil {
+			return errors.Wrap(stopErr, "failed to stop container after cancellation")
+		}
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		return errors.New("received signal, stopped container")
+	}
+
+	tracker := newRestartTracker(restart, options.OnRestart)
+	ready := newReadinessWatcher(cfg.LocalAddress(), options.OnReady)
+	ready.poll(runCtx)
+
+	// the logs of a restarted container start with the output of its earlier runs
+	written := 0
+	for {
+		print.Info("Starting container...")
+		startedAt := time.Now()
+		err = cli.ContainerStart(ctx, cnt.ID, container.StartOptions{})
+		if err != nil {
+			return errors.Wrap(err, "failed to start container")
+		}
+
+		written, err = followContainerLogs(runCtx, cli, cnt.ID, written, ready, options.Output)
+		if err != nil {
+			if stopErr := stopContainer(context.Background(), cli, cnt.ID, restart.StopTimeout); stopErr != nil {
+				print.Warn("failed to stop container after output error:", stopErr)
+			}
+			return err
+		}
+		if runCtx.Err() != nil {
+			return stopped()
+		}
+
+		exitErr := waitForContainerExit(runCtx, cli, cnt.ID)
+		if runCtx.Err() != nil {
+			return stopped()
+		}
+
+		term, backoff, retry := tracker.next(cfg.Mode, time.Since(startedAt), exitErr, time.Now())
+		if !retry {
+			if exitErr != nil && (cfg.Mode != run.Server || restart.Policy == run.RestartNever) {
+				return errors.Wrap(exitErr, "container execution failed")
+			}
+			return term.err
+		}
+		if !sleepWithContext(runCtx, backoff) {
+			return stopped()
+		}
+		ready.rearm(runCtx)
+	}
+}
 
-	reader, err := cli.ContainerLogs(runCtx, cnt.ID, container.LogsOptions{
+// followContainerLogs writes the output of a container until it exits, skipping the lines of
+// earlier runs that were already written, and returns how many lines its logs hold
+func followContainerLogs(
+	ctx context.Context,
+	cli containerClient,
+	containerID string,
+	written int,
+	ready *readinessWatcher,
+	w io.Writer,
+) (int, error) {
+	reader, err := cli.ContainerLogs(ctx, containerID, container.LogsOptions{
 		ShowStdout: true,
 		ShowStderr: true,
 		Follow:     true,
 		Timestamps: false,
 	})
 	if err != nil {
-		return
+		return written, errors.Wrap(err, "failed to read container logs")
 	}
-	defer func() {
-		if errClose := reader.Close(); errClose != nil {
-			if err == nil {
-				err = errors.Wrap(errClose, "failed to close container log stream")
-				return
-			}
-			print.Warn("failed to close container log stream:", errClose)
-		}
-	}()
+	defer reader.Close() // nolint
 
+	lines := 0
 	scanner := bufio.NewScanner(reader)
-	writeFailed := false
 	for scanner.Scan() {
-		_, err = fmt.Fprintln(options.Output, scanner.Text())
-		if err != nil {
-			writeFailed = true
-			break
+		lines++
+		if lines <= written {
+			continue
+		}
+		ready.line(scanner.Text())
+		if _, err = fmt.Fprintln(w, scanner.Text()); err != nil {
+			return lines, errors.Wrap(err, "failed to write container output")
 		}
 	}
+	if err = scanner.Err(); err != nil && ctx.Err() == nil {
+		return lines, errors.Wrap(err, "failed to read container logs")
+	}
+	return lines, nil
+}
 
-	if scanErr := scanner.Err(); scanErr != nil && runCtx.Err() == nil {
-		return errors.Wrap(scanErr, "failed to read container logs")
+// containerSpec builds the container and host configuration that run the server of a runtime
+func containerSpec(cfg run.Runtime, options RunOptions) (*container.Config, *container.HostConfig, error) {
+	settings := run.ContainerConfig{}
+	if cfg.Container != nil {
+		settings = *cfg.Container
+	}
+	if cfg.Port == nil {
+		return nil, nil, errors.New("runtime has no port to publish")
 	}
 
-	if writeFailed {
-		stopErr := stopContainer(context.Background(), cli, cnt.ID)
-		if stopErr != nil {
-			print.Warn("failed to stop container after output error:", stopErr)
-		}
-		return errors.Wrap(err, "failed to write container output")
+	image := settings.Image
+	if image == "" {
+		image = containerImage
+	}
+	tag := settings.Tag
+	if tag == "" {
+		tag = cfg.AppVersion
+	}
+	if tag != "" {
+		image += ":" + tag
 	}
 
-	if runCtx.Err() != nil {
-		stopErr := stopContainer(context.Background(), cli, cnt.ID)
-		if stopErr != nil {
-			return errors.Wrap(stopErr, "failed to stop container after cancellation")
+	// SA:MP and open.mp serve players, queries and RCON on the same UDP port
+	port, err := nat.NewPort("udp", fmt.Sprint(*cfg.Port))
+	if err != nil {
+		return nil, nil, errors.Wrap(err, "invalid runtime port")
+	}
+
+	print.Verb("mounting package working directory at", cfg.WorkingDir, "into container at", containerWorkingDir)
+	mounts := []mount.Mount{
+		{
+			Type:   mount.TypeBind,
+			Source: cfg.WorkingDir,
+			Target: containerWorkingDir,
+		},
+	}
+	if settings.MountCache {
+		print.Verb("mounting cache at", options.CacheDir, "into container at /root/.samp")
+		mounts = append(mounts, mount.Mount{
+			Type:     mount.TypeBind,
+			Source:   options.CacheDir,
+			Target:   "/root/.samp",
+			ReadOnly: true,
+		})
+	}
+	for _, extra := range settings.Mounts {
+		if !filepath.IsAbs(extra.Source) || !path.IsAbs(extra.Target) {
+			return nil, nil, errors.Errorf("container mount %s:%s must use absolute paths", extra.Source, extra.Target)
 		}
-		if ctx.Err() != nil {
-			return ctx.Err()
+		mounts = append(mounts, mount.Mount{
+			Type:     mount.TypeBind,
+			Source:   extra.Source,
+			Target:   extra.Target,
+			ReadOnly: extra.ReadOnly,
+		})
+	}
+
+	hostConfig := &container.HostConfig{
+		Mounts: mounts,
+		PortBindings: nat.PortMap{
+			port: []nat.PortBinding{
+				{HostIP: "0.0.0.0", HostPort: port.Port()},
+			},
+		},
+	}
+	if settings.Unprivileged {
+		hostConfig.SecurityOpt = []string{"no-new-privileges"}
+	} else {
+		hostConfig.SecurityOpt = []string{"seccomp=unconfined"}
+		hostConfig.Privileged = true
+	}
+	if settings.Memory != "" {
+		memory, err := units.RAMInBytes(settings.Memory)
+		if err != nil {
+			return nil, nil, errors.Wrapf(err, "invalid container memory limit %q", settings.Memory)
 		}
-		return errors.New("received signal, stopped container")
+		hostConfig.Memory = memory
+	}
+	if settings.CPUs < 0 {
+		return nil, nil, errors.Errorf("invalid container CPU limit %v", settings.CPUs)
+	}
+	hostConfig.NanoCPUs = int64(settings.CPUs * 1e9)
+
+	env := make([]string, 0, len(settings.Env))
+	for name, value := range settings.Env {
+		env = append(env, name+"="+value)
 	}
+	sort.Strings(env)
 
-	if waitErr := waitForContainerExit(runCtx, cli, cnt.ID); waitErr != nil {
-		return errors.Wrap(waitErr, "container execution failed")
+	binary := getServerBinary(options.CacheDir, cfg.Version, "linux")
+	containerConfig := &container.Config{
+		Image:        image,
+		Entrypoint:   strslice.StrSlice{path.Join(containerWorkingDir, filepath.ToSlash(binary))},
+		WorkingDir:   containerWorkingDir,
+		Env:          env,
+		ExposedPorts: nat.PortSet{port: struct{}{}},
+		Tty:          true,
+		AttachStdout: true,
+		AttachStderr: true,
+		AttachStdin:  true,
 	}
+	return containerConfig, hostConfig, nil
+}
 
+func pullImage(ctx context.Context, cli containerClient, ref string) error {
+	pullReader, err := cli.ImagePull(ctx, ref, types.ImagePullOptions{})
+	if err != nil {
+		return errors.Wrap(err, "failed to pull image")
+	}
+	defer pullReader.Close() // nolint
+	if _, err = io.ReadAll(pullReader); err != nil {
+		return errors.Wrap(err, "failed to read pull output")
+	}
 	return nil
 }
 
@@ -240,8 +353,8 @@ type containerWaiter interface {
 	ContainerWait(ctx context.Context, containerID string, condition container.WaitCondition) (<-chan container.WaitResponse, <-chan error)
 }
 
-func stopContainer(ctx context.Context, cli containerStopper, containerID string) error {
-	stopCtx, cancel := context.WithTimeout(ctx, 10*time.Second)
+func stopContainer(ctx context.Context, cli containerStopper, containerID string, timeout time.Duration) error {
+	stopCtx, cancel := context.WithTimeout(ctx, timeout)
 	defer cancel()
 
 	if err := cli.ContainerKill(stopCtx, containerID, "SIGINT"); err != nil {
@@ -273,16 +386,35 @@ func removeContainer(ctx context.Context, cli containerRemover, containerID stri
 	return cli.ContainerRemove(removeCtx, containerID, container.RemoveOptions{Force: true})
 }
 
+// containerExitError is a container that exited with an error, like exec.ExitError for a process
+type containerExitError struct {
+	code    int
+	message string
+}
+
+func (e *containerExitError) Error() string {
+	if e.message != "" {
+		return fmt.Sprintf("container exited with status code %d: %s", e.code, e.message)
+	}
+	return fmt.Sprintf("container exited with status code %d", e.code)
+}
+
+// ExitCode returns the status code the container exited with
+func (e *containerExitError) ExitCode() int {
+	return e.code
+}
+
 func waitForContainerExit(ctx context.Context, cli containerWaiter, containerID string) error {
 	waitCh, errCh := cli.ContainerWait(ctx, containerID, container.WaitConditionNotRunning)
 
 	select {
 	case waitResp := <-waitCh:
-		if waitResp.Error != nil && waitResp.Error.Message != "" {
-			return errors.Errorf("container exited with status code %d: %s", waitResp.StatusCode, waitResp.Error.Message)
-		}
-		if waitResp.StatusCode != 0 {
-			return errors.Errorf("container exited with status code %d", waitResp.StatusCode)
+		if waitResp.StatusCode != 0 || (waitResp.Error != nil && waitResp.Error.Message != "") {
+			exitErr := &containerExitError{code: int(waitResp.StatusCode)}
+			if waitResp.Error != nil {
+				exitErr.message = waitResp.Error.Message
+			}
+			return exitErr
 		}
 		return nil
 	case err := <-errCh:
@@ -1,13 +1,26 @@
 package runtime
 
 import (
+	"bytes"
 	"context"
 	"errors"
+	"io"
+	"strings"
 	"testing"
 	"time"
 
+	"github.com/docker/docker/api/types"
 	"github.com/docker/docker/api/types/container"
+	"github.com/docker/docker/api/types/mount"
+	"github.com/docker/docker/api/types/network"
+	"github.com/docker/docker/api/types/strslice"
+	"github.com/docker/docker/errdefs"
+	"github.com/docker/go-connections/nat"
+	ocispec "github.com/opencontainers/image-spec/specs-go/v1"
+	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
+
+	run "github.com/Southclaws/sampctl/src/pkg/runtime/config"
 )
 
 type fakeContainerRemover struct {
@@ -125,7 +138,7 @@ func TestStopContainerSendsSIGINTAndWaits(t *testing.T) {
 	t.Parallel()
 
 	cli := &fakeContainerStopper{waitResponse: container.WaitResponse{StatusCode: 0}}
-	err := stopContainer(context.Background(), cli, "container-id")
+	err := stopContainer(context.Background(), cli, "container-id", 10*time.Second)
 	require.NoError(t, err)
 	require.True(t, cli.killCalled)
 	require.Equal(t, "SIGINT", cli.killSignal)
@@ -135,7 +148,7 @@ func TestStopContainerReturnsWaitError(t *testing.T) {
 	t.Parallel()
 
 	cli := &fakeContainerStopper{waitErr: errors.New("wait failed")}
-	err := stopContainer(context.Background(), cli, "container-id")
+	err := stopContainer(context.Background(), cli, "container-id", 10*time.Second)
 	require.EqualError(t, err, "wait failed")
 	require.True(t, cli.killCalled)
 }
@@ -147,7 +160,206 @@ func TestStopContainerReturnsContextError(t *testing.T) {
 	cancel()
 
 	cli := &fakeContainerStopper{waitDelay: time.Second}
-	err := stopContainer(ctx, cli, "container-id")
+	err := stopContainer(ctx, cli, "container-id", 10*time.Second)
 	require.ErrorIs(t, err, context.Canceled)
 	require.True(t, cli.killCalled)
 }
+
+// fakeDaemon records the calls the container runtime makes, the image is missing until pulled
+type fakeDaemon struct {
+	pulled     []string
+	created    []*container.Config
+	hostConfig *container.HostConfig
+	started    string
+	starts     int
+	removed    string
+	logs       string
+	exitCode   int64
+	exitCodes  []int64 // status of each run in turn, exitCode once they are used up
+}
+
+func (f *fakeDaemon) ImagePull(_ context.Context, ref string, _ types.ImagePullOptions) (io.ReadCloser, error) {
+	f.pulled = append(f.pulled, ref)
+	return io.NopCloser(strings.NewReader(`{"status":"Downloaded newer image"}`)), nil
+}
+
+func (f *fakeDaemon) ContainerCreate(
+	_ context.Context,
+	config *container.Config,
+	hostConfig *container.HostConfig,
+	_ *network.NetworkingConfig,
+	_ *ocispec.Platform,
+	_ string,
+) (container.CreateResponse, error) {
+	if len(f.pulled) == 0 {
+		return container.CreateResponse{}, errdefs.NotFound(errors.New("no such image"))
+	}
+	f.created = append(f.created, config)
+	f.hostConfig = hostConfig
+	return container.CreateResponse{ID: "container-id"}, nil
+}
+
+func (f *fakeDaemon) ContainerStart(_ context.Context, containerID string, _ container.StartOptions) error {
+	f.started = containerID
+	f.starts++
+	return nil
+}
+
+// ContainerLogs replays the output of every run so far, like the logs of a restarted container
+func (f *fakeDaemon) ContainerLogs(_ context.Context, _ string, _ container.LogsOptions) (io.ReadCloser, error) {
+	return io.NopCloser(strings.NewReader(strings.Repeat(f.logs, f.starts))), nil
+}
+
+func (f *fakeDaemon) ContainerKill(_ context.Context, _, _ string) error {
+	return nil
+}
+
+func (f *fakeDaemon) ContainerWait(_ context.Context, _ string, _ container.WaitCondition) (<-chan container.WaitResponse, <-chan error) {
+	responseCh := make(chan container.WaitResponse, 1)
+	exitCode := f.exitCode
+	if f.starts <= len(f.exitCodes) {
+		exitCode = f.exitCodes[f.starts-1]
+	}
+	responseCh <- container.WaitResponse{StatusCode: exitCode}
+	return responseCh, make(chan error)
+}
+
+func (f *fakeDaemon) ContainerRemove(_ context.Context, containerID string, _ container.RemoveOptions) error {
+	f.removed = containerID
+	return nil
+}
+
+func testContainerRuntime(settings *run.ContainerConfig) run.Runtime {
+	port := 7777
+	return run.Runtime{
+		WorkingDir: "/home/user/server",
+		Version:    "0.3.7",
+		AppVersion: "1.2.3",
+		Port:       &port,
+		Container:  settings,
+	}
+}
+
+func TestRunContainerPullsImageAndStreamsLogs(t *testing.T) {
+	daemon := &fakeDaemon{logs: "SA-MP Dedicated Server\nLoaded 0 filterscripts.\n"}
+	var output bytes.Buffer
+
+	err := runContainer(context.Background(), daemon, testContainerRuntime(nil), RunOptions{Output: &output, CacheDir: t.TempDir()})
+	require.NoError(t, err)
+
+	assert.Equal(t, []string{"southclaws/sampctl:1.2.3"}, daemon.pulled)
+	require.Len(t, daemon.created, 1)
+	assert.Equal(t, "container-id", daemon.started)
+	assert.Equal(t, "container-id", daemon.removed)
+	assert.Equal(t, "SA-MP Dedicated Server\nLoaded 0 filterscripts.\n", output.String())
+}
+
+func TestRunContainerReturnsExitStatus(t *testing.T) {
+	daemon := &fakeDaemon{pulled: []string{"cached"}, exitCode: 1}
+
+	err := runContainer(context.Background(), daemon, testContainerRuntime(nil), RunOptions{Output: io.Discard, CacheDir: t.TempDir()})
+	require.EqualError(t, err, "container execution failed: container exited with status code 1")
+	assert.Equal(t, "container-id", daemon.removed, "the container is removed after a failed run")
+}
+
+func TestRunContainerRestartsServer(t *testing.T) {
+	daemon := &fakeDaemon{pulled: []string{"cached"}, logs: "Number of vehicle models: 0\n", exitCodes: []int64{2, 0}}
+	cfg := testContainerRuntime(nil)
+	cfg.Mode = run.Server
+	cfg.Restart = &run.RestartConfig{Policy: run.RestartOnFailure, InitialBackoff: "1ms"}
+
+	var (
+		output bytes.Buffer
+		ready  []Readiness
+		events []RestartEvent
+	)
+	err := runContainer(context.Background(), daemon, cfg, RunOptions{
+		Output:    &output,
+		CacheDir:  t.TempDir(),
+		OnReady:   func(r Readiness) { ready = append(ready, r) },
+		OnRestart: func(e RestartEvent) { events = append(events, e) },
+	})
+	require.NoError(t, err)
+
+	assert.Equal(t, 2, daemon.starts)
+	assert.Equal(t, "Number of vehicle models: 0\nNumber of vehicle models: 0\n", output.String(), "earlier output is not written again")
+	assert.Len(t, ready, 2, "the restarted server is reported ready again")
+	require.Len(t, events, 1)
+	assert.Equal(t, 2, events[0].ExitCode)
+	assert.Equal(t, "exited with code 2", events[0].Reason)
+}
+
+func TestRunContainerGivesUpRestarting(t *testing.T) {
+	daemon := &fakeDaemon{pulled: []string{"cached"}, exitCode: 1}
+	cfg := testContainerRuntime(nil)
+	cfg.Mode = run.Server
+	cfg.Restart = &run.RestartConfig{Policy: run.RestartAlways, MaxRestarts: maxRestarts(1), InitialBackoff: "1ms"}
+
+	err := runContainer(context.Background(), daemon, cfg, RunOptions{Output: io.Discard, CacheDir: t.TempDir()})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "server restarted 1 times")
+	assert.Equal(t, 2, daemon.starts)
+	assert.Equal(t, "container-id", daemon.removed)
+}
+
+func TestContainerSpecDefaults(t *testing.T) {
+	t.Parallel()
+
+	config, host, err := containerSpec(testContainerRuntime(&run.ContainerConfig{MountCache: true}), RunOptions{CacheDir: "/home/user/.samp"})
+	require.NoError(t, err)
+
+	assert.Equal(t, "southclaws/sampctl:1.2.3", config.Image)
+	assert.Equal(t, strslice.StrSlice{"/samp/samp03svr"}, config.Entrypoint)
+	assert.Equal(t, "/samp", config.WorkingDir)
+	assert.Equal(t, nat.PortSet{"7777/udp": struct{}{}}, config.ExposedPorts)
+	assert.Equal(t, nat.PortMap{"7777/udp": []nat.PortBinding{{HostIP: "0.0.0.0", HostPort: "7777"}}}, host.PortBindings)
+	assert.Equal(t, []mount.Mount{
+		{Type: mount.TypeBind, Source: "/home/user/server", Target: "/samp"},
+		{Type: mount.TypeBind, Source: "/home/user/.samp", Target: "/root/.samp", ReadOnly: true},
+	}, host.Mounts)
+	assert.True(t, host.Privileged)
+	assert.Equal(t, []string{"seccomp=unconfined"}, host.SecurityOpt)
+	assert.Zero(t, host.Memory)
+	assert.Zero(t, host.NanoCPUs)
+}
+
+func TestContainerSpecSettings(t *testing.T) {
+	t.Parallel()
+
+	config, host, err := containerSpec(testContainerRuntime(&run.ContainerConfig{
+		Image:        "ghcr.io/example/server",
+		Tag:          "bookworm",
+		Mounts:       []run.ContainerMount{{Source: "/home/user/data", Target: "/data", ReadOnly: true}},
+		Env:          map[string]string{"TZ": "UTC", "MYSQL_HOST": "db"},
+		Memory:       "512m",
+		CPUs:         1.5,
+		Unprivileged: true,
+	}), RunOptions{CacheDir: t.TempDir()})
+	require.NoError(t, err)
+
+	assert.Equal(t, "ghcr.io/example/server:bookworm", config.Image)
+	assert.Equal(t, []string{"MYSQL_HOST=db", "TZ=UTC"}, config.Env)
+	assert.Contains(t, host.Mounts, mount.Mount{Type: mount.TypeBind, Source: "/home/user/data", Target: "/data", ReadOnly: true})
+	assert.Len(t, host.Mounts, 2, "the cache is only mounted when requested")
+	assert.Equal(t, int64(512*1024*1024), host.Memory)
+	assert.Equal(t, int64(1500000000), host.NanoCPUs)
+	assert.False(t, host.Privileged)
+	assert.Equal(t, []string{"no-new-privileges"}, host.SecurityOpt)
+}
+
+func TestContainerSpecRejectsInvalidSettings(t *testing.T) {
+	t.Parallel()
+
+	_, _, err := containerSpec(testContainerRuntime(&run.ContainerConfig{
+		Mounts: []run.ContainerMount{{Source: "data", Target: "/data"}},
+	}), RunOptions{})
+	assert.EqualError(t, err, "container mount data:/data must use absolute paths")
+
+	_, _, err = containerSpec(testContainerRuntime(&run.ContainerConfig{Memory: "lots"}), RunOptions{})
+	assert.ErrorContains(t, err, `invalid container memory limit "lots"`)
+
+	cfg := testContainerRuntime(nil)
+	cfg.Port = nil
+	_, _, err = containerSpec(cfg, RunOptions{})
+	assert.EqualError(t, err, "runtime has no port to publish")
+}
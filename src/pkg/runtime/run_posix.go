@@ -143,6 +143,15 @@ func getRuntimePtySysProcAttr() *syscall.SysProcAttr {
 	}
 }
 
+// interruptRuntimeProcess asks the server and the processes it started to exit
+func interruptRuntimeProcess(process *os.Process) error {
+	pgid, err := syscall.Getpgid(process.Pid)
+	if err == nil {
+		return syscall.Kill(-pgid, syscall.SIGTERM)
+	}
+	return process.Signal(syscall.SIGTERM)
+}
+
 func terminateRuntimeProcess(process *os.Process) error {
 	pgid, err := syscall.Getpgid(process.Pid)
 	if err == nil {
@@ -243,74 +243,6 @@ func TestProcessOutputLine(t *testing.T) {
 	})
 }
 
-func TestEvaluateRunResult(t *testing.T) {
-	t.Parallel()
-
-	t.Run("non recovering server wraps error", func(t *testing.T) {
-		t.Parallel()
-
-		term, next, retry := evaluateRunResult(runResultRequest{
-			RunType: run.Server,
-			Recover: false,
-			Backoff: time.Second,
-			RunErr:  errors.New("boom"),
-		})
-
-		require.Error(t, term.err)
-		assert.Contains(t, term.err.Error(), "failed to start server")
-		assert.Equal(t, time.Second, next)
-		assert.False(t, retry)
-	})
-
-	t.Run("recovering server retries after short crash", func(t *testing.T) {
-		t.Parallel()
-
-		term, next, retry := evaluateRunResult(runResultRequest{
-			RunType: run.Server,
-			Recover: true,
-			RunTime: 10 * time.Second,
-			Backoff: time.Second,
-			RunErr:  errors.New("boom"),
-		})
-
-		assert.NoError(t, term.err)
-		assert.Equal(t, 2*time.Second, next)
-		assert.True(t, retry)
-	})
-
-	t.Run("recovering server stops after repeated crashes", func(t *testing.T) {
-		t.Parallel()
-
-		term, next, retry := evaluateRunResult(runResultRequest{
-			RunType: run.Server,
-			Recover: true,
-			RunTime: 10 * time.Second,
-			Backoff: 8 * time.Second,
-			RunErr:  errors.New("boom"),
-		})
-
-		require.Error(t, term.err)
-		assert.Contains(t, term.err.Error(), "too many crashloops")
-		assert.Equal(t, 16*time.Second, next)
-		assert.False(t, retry)
-	})
-
-	t.Run("long runtime resets backoff", func(t *testing.T) {
-		t.Parallel()
-
-		_, next, retry := evaluateRunResult(runResultRequest{
-			RunType: run.Server,
-			Recover: true,
-			RunTime: 2 * time.Minute,
-			Backoff: 8 * time.Second,
-			RunErr:  errors.New("boom"),
-		})
-
-		assert.Equal(t, time.Second, next)
-		assert.True(t, retry)
-	})
-}
-
 func TestSleepAndChannelHelpers(t *testing.T) {
 	t.Parallel()
 
@@ -130,6 +130,12 @@ func getRuntimeSysProcAttr() *syscall.SysProcAttr {
 	}
 }
 
+// interruptRuntimeProcess kills the server, Windows has no signal that asks a console process
+// started without a console of its own to exit
+func interruptRuntimeProcess(process *os.Process) error {
+	return terminateRuntimeProcess(process)
+}
+
 func terminateRuntimeProcess(process *os.Process) error {
 	err := process.Kill()
 	if err != nil && err.Error() == "process already finished" {